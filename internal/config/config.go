@@ -0,0 +1,166 @@
+// config.go
+
+// Package config reads gpv's settings from the environment and the command
+// line.
+package config
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// pageSize is the most results the GitLab API returns per page, and the most
+// pipelines prefetched per project.
+const pageSize = 100
+
+// Config is how gpv is set up.
+type Config struct {
+	// GitLabURL is the instance, from GITLAB_URL, https://gitlab.com by
+	// default. Token is GITLAB_PERSONAL_TOKEN.
+	GitLabURL string
+	Token     string
+
+	// IncludeArchived, MemberOnly and Visibility filter the projects of
+	// the tree, from GPV_INCLUDE_ARCHIVED, GPV_MEMBER_ONLY and
+	// GPV_VISIBILITY.
+	IncludeArchived bool
+	MemberOnly      bool
+	Visibility      string
+
+	// RefreshInterval is GPV_REFRESH_INTERVAL, 0 when views don't refresh
+	// on their own.
+	RefreshInterval time.Duration
+	// ASCIIIcons is GPV_ASCII_ICONS and StripANSI is GPV_STRIP_ANSI.
+	ASCIIIcons bool
+	StripANSI  bool
+	// DownloadDir is where artifacts are saved, GPV_DOWNLOAD_DIR or the
+	// current directory.
+	DownloadDir string
+	// GraphQL is set by GPV_BACKEND=graphql.
+	GraphQL bool
+	// PrefetchDepth and PrefetchWorkers are GPV_PREFETCH_DEPTH and
+	// GPV_PREFETCH_WORKERS.
+	PrefetchDepth   int
+	PrefetchWorkers int
+
+	// The rest is set from the command line.
+	LogLevel string
+	NoCache  bool
+	Record   string
+	Replay   string
+	// Project, Ref and Pipeline open the TUI at a project, its pipelines
+	// of a ref, or a pipeline.
+	Project  string
+	Ref      string
+	Pipeline int
+	// Args are the arguments left after the flags: a command and its
+	// arguments, or a GitLab URL to open.
+	Args []string
+}
+
+// RegisterFlags adds the command line flags to fs.
+func (c *Config) RegisterFlags(fs *flag.FlagSet) {
+	fs.StringVar(&c.LogLevel, "log-level", "info", "log level: debug, info, warn or error")
+	fs.BoolVar(&c.NoCache, "no-cache", false, "don't read or write the on-disk cache")
+	fs.StringVar(&c.Record, "record", "", "save every API response as a fixture in this directory")
+	fs.StringVar(&c.Replay, "replay", "", "answer API requests from the fixtures in this directory instead of GitLab")
+	fs.StringVar(&c.Project, "p", "", "open the TUI at this project, e.g. group/project")
+	fs.StringVar(&c.Ref, "ref", "", "with -p, open the pipelines of this branch or tag")
+	fs.IntVar(&c.Pipeline, "pipeline", 0, "with -p, open this pipeline")
+}
+
+// Load reads the settings from the environment into c. The token is only
+// required when requests go to GitLab rather than recorded fixtures.
+func (c *Config) Load() error {
+	c.Token = os.Getenv("GITLAB_PERSONAL_TOKEN")
+	if c.Token == "" && c.Replay == "" {
+		return fmt.Errorf("please set GITLAB_PERSONAL_TOKEN environment variable")
+	}
+
+	c.GitLabURL = os.Getenv("GITLAB_URL")
+	if c.GitLabURL == "" {
+		c.GitLabURL = "https://gitlab.com"
+	}
+
+	for _, setting := range []struct {
+		name  string
+		value *bool
+	}{
+		{"GPV_INCLUDE_ARCHIVED", &c.IncludeArchived},
+		{"GPV_MEMBER_ONLY", &c.MemberOnly},
+		{"GPV_ASCII_ICONS", &c.ASCIIIcons},
+		{"GPV_STRIP_ANSI", &c.StripANSI},
+	} {
+		value := os.Getenv(setting.name)
+		if value == "" {
+			continue
+		}
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid %s %q: %w", setting.name, value, err)
+		}
+		*setting.value = parsed
+	}
+
+	switch c.Visibility = os.Getenv("GPV_VISIBILITY"); c.Visibility {
+	case "", "private", "internal", "public":
+	default:
+		return fmt.Errorf("invalid GPV_VISIBILITY %q: must be private, internal or public", c.Visibility)
+	}
+
+	if value := os.Getenv("GPV_REFRESH_INTERVAL"); value != "" {
+		interval, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("invalid GPV_REFRESH_INTERVAL %q: %w", value, err)
+		}
+		if interval < time.Second {
+			return fmt.Errorf("invalid GPV_REFRESH_INTERVAL %q: must be at least 1s", value)
+		}
+		c.RefreshInterval = interval
+	}
+
+	c.DownloadDir = os.Getenv("GPV_DOWNLOAD_DIR")
+	if c.DownloadDir == "" {
+		c.DownloadDir = "."
+	}
+
+	switch backend := os.Getenv("GPV_BACKEND"); backend {
+	case "", "rest":
+	case "graphql":
+		c.GraphQL = true
+	default:
+		return fmt.Errorf("invalid GPV_BACKEND %q: must be rest or graphql", backend)
+	}
+
+	c.PrefetchDepth, c.PrefetchWorkers = 10, 2
+	for _, setting := range []struct {
+		name  string
+		value *int
+	}{
+		{"GPV_PREFETCH_DEPTH", &c.PrefetchDepth},
+		{"GPV_PREFETCH_WORKERS", &c.PrefetchWorkers},
+	} {
+		value := os.Getenv(setting.name)
+		if value == "" {
+			continue
+		}
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 || parsed > pageSize {
+			return fmt.Errorf("invalid %s %q: must be a number from 0 to %d", setting.name, value, pageSize)
+		}
+		*setting.value = parsed
+	}
+	if c.PrefetchWorkers == 0 {
+		c.PrefetchDepth = 0
+	}
+
+	if c.Record != "" || c.Replay != "" {
+		// Cached data would make the fixtures depend on what was cached
+		// while recording.
+		c.NoCache = true
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+// dirs.go
+package config
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// StateDir returns $XDG_STATE_HOME/gpv, falling back to ~/.local/state/gpv.
+func StateDir() (string, error) {
+	return xdgDir("XDG_STATE_HOME", ".local", "state")
+}
+
+// CacheDir returns $XDG_CACHE_HOME/gpv, falling back to ~/.cache/gpv.
+func CacheDir() (string, error) {
+	return xdgDir("XDG_CACHE_HOME", ".cache")
+}
+
+// ConfigDir returns $XDG_CONFIG_HOME/gpv, falling back to ~/.config/gpv.
+func ConfigDir() (string, error) {
+	return xdgDir("XDG_CONFIG_HOME", ".config")
+}
+
+// xdgDir returns the gpv directory in the directory named by env, or if it
+// isn't set, in fallback under the home directory.
+func xdgDir(env string, fallback ...string) (string, error) {
+	if dir := os.Getenv(env); dir != "" {
+		return filepath.Join(dir, "gpv"), nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(append(append([]string{home}, fallback...), "gpv")...), nil
+}
@@ -0,0 +1,146 @@
+// etag.go
+package gitlab
+
+import (
+	"bytes"
+	"container/list"
+	"io"
+	"net/http"
+	"sync"
+
+	"main.go/internal/logging"
+)
+
+const (
+	// maxETagEntries is how many responses the ETag cache keeps, dropping
+	// the least recently used first.
+	maxETagEntries = 1000
+	// maxETagBody is the largest response body the ETag cache keeps, so job
+	// logs and artifacts don't fill it.
+	maxETagBody = 1 << 20
+)
+
+// etagEntry is a cached response and the validators to ask GitLab whether
+// it is still current.
+type etagEntry struct {
+	url          string
+	etag         string
+	lastModified string
+	header       http.Header
+	body         []byte
+}
+
+// etagTransport makes GET requests conditional on the ETag or Last-Modified
+// of the response it got last for the same URL. When GitLab answers 304 Not
+// Modified the cached response is returned instead, so refreshing a view
+// whose data hasn't changed costs almost nothing against the rate limit.
+type etagTransport struct {
+	next http.RoundTripper
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List // most recently used first
+}
+
+func newETagTransport(next http.RoundTripper) *etagTransport {
+	return &etagTransport{
+		next:    next,
+		entries: map[string]*list.Element{},
+		order:   list.New(),
+	}
+}
+
+func (t *etagTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// Partial job logs are fetched with Range, their validators don't
+	// describe what is cached.
+	if req.Method != http.MethodGet || req.Header.Get("Range") != "" {
+		return t.next.RoundTrip(req)
+	}
+
+	key := req.URL.String()
+	entry := t.lookup(key)
+	if entry != nil {
+		req = req.Clone(req.Context())
+		if entry.etag != "" {
+			req.Header.Set("If-None-Match", entry.etag)
+		}
+		if entry.lastModified != "" {
+			req.Header.Set("If-Modified-Since", entry.lastModified)
+		}
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+
+	if resp.StatusCode == http.StatusNotModified && entry != nil {
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		logging.Debug("api response not modified", "path", req.URL.Path)
+		return &http.Response{
+			Status:        "200 OK",
+			StatusCode:    http.StatusOK,
+			Proto:         resp.Proto,
+			ProtoMajor:    resp.ProtoMajor,
+			ProtoMinor:    resp.ProtoMinor,
+			Header:        entry.header.Clone(),
+			Body:          io.NopCloser(bytes.NewReader(entry.body)),
+			ContentLength: int64(len(entry.body)),
+			Request:       req,
+		}, nil
+	}
+
+	etag, lastModified := resp.Header.Get("ETag"), resp.Header.Get("Last-Modified")
+	if resp.StatusCode != http.StatusOK || etag == "" && lastModified == "" {
+		return resp, nil
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxETagBody+1))
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	if len(body) > maxETagBody {
+		// Too large to keep, hand it on with what was already read.
+		resp.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(body), resp.Body), resp.Body}
+		return resp, nil
+	}
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	t.store(&etagEntry{url: key, etag: etag, lastModified: lastModified, header: resp.Header.Clone(), body: body})
+	return resp, nil
+}
+
+func (t *etagTransport) lookup(key string) *etagEntry {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	element, ok := t.entries[key]
+	if !ok {
+		return nil
+	}
+	t.order.MoveToFront(element)
+	return element.Value.(*etagEntry)
+}
+
+func (t *etagTransport) store(entry *etagEntry) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if element, ok := t.entries[entry.url]; ok {
+		element.Value = entry
+		t.order.MoveToFront(element)
+		return
+	}
+	t.entries[entry.url] = t.order.PushFront(entry)
+	if t.order.Len() > maxETagEntries {
+		oldest := t.order.Back()
+		t.order.Remove(oldest)
+		delete(t.entries, oldest.Value.(*etagEntry).url)
+	}
+}
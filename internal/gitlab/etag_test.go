@@ -0,0 +1,100 @@
+package gitlab
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// etagServer answers like GitLab does: 304 when the request's validator
+// still matches, or else the current body with its ETag.
+type etagServer struct {
+	etag     string
+	body     string
+	requests []*http.Request
+}
+
+func (s *etagServer) RoundTrip(req *http.Request) (*http.Response, error) {
+	s.requests = append(s.requests, req)
+	resp := &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Request: req, Body: io.NopCloser(strings.NewReader(s.body))}
+	if s.etag != "" {
+		resp.Header.Set("ETag", s.etag)
+		if req.Header.Get("If-None-Match") == s.etag {
+			resp.StatusCode = http.StatusNotModified
+			resp.Body = io.NopCloser(strings.NewReader(""))
+		}
+	}
+	return resp, nil
+}
+
+func TestETagTransport(t *testing.T) {
+	tests := []struct {
+		name          string
+		method        string
+		rangeHeader   string
+		etag          string
+		change        bool
+		wantCondition bool
+		wantBody      string
+	}{
+		{name: "unchanged", method: http.MethodGet, etag: `"v1"`, wantCondition: true, wantBody: "first"},
+		{name: "changed", method: http.MethodGet, etag: `"v1"`, change: true, wantCondition: true, wantBody: "second"},
+		{name: "no validator", method: http.MethodGet, wantBody: "first"},
+		{name: "range", method: http.MethodGet, rangeHeader: "bytes=10-", etag: `"v1"`, wantBody: "first"},
+		{name: "post", method: http.MethodPost, etag: `"v1"`, wantBody: "first"},
+	}
+	for _, tt := range tests {
+		server := &etagServer{etag: tt.etag, body: "first"}
+		transport := newETagTransport(server)
+
+		get := func() string {
+			req, _ := http.NewRequest(tt.method, "https://gitlab.example.com/api/v4/projects/1", nil)
+			if tt.rangeHeader != "" {
+				req.Header.Set("Range", tt.rangeHeader)
+			}
+			resp, err := transport.RoundTrip(req)
+			if err != nil {
+				t.Fatalf("%s: %v", tt.name, err)
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				t.Errorf("%s: status %d, want 200", tt.name, resp.StatusCode)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			return string(body)
+		}
+
+		if got := get(); got != "first" {
+			t.Errorf("%s: first body %q, want first", tt.name, got)
+		}
+		if tt.change {
+			server.etag, server.body = `"v2"`, "second"
+		}
+		if got := get(); got != tt.wantBody {
+			t.Errorf("%s: second body %q, want %q", tt.name, got, tt.wantBody)
+		}
+		if got := server.requests[1].Header.Get("If-None-Match") != ""; got != tt.wantCondition {
+			t.Errorf("%s: second request conditional %v, want %v", tt.name, got, tt.wantCondition)
+		}
+	}
+}
+
+func TestETagTransportEvicts(t *testing.T) {
+	server := &etagServer{etag: `"v1"`, body: "x"}
+	transport := newETagTransport(server)
+	for i := 0; i <= maxETagEntries; i++ {
+		req, _ := http.NewRequest(http.MethodGet, "https://gitlab.example.com/api/v4/projects/"+strings.Repeat("a", i+1), nil)
+		resp, err := transport.RoundTrip(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	if len(transport.entries) != maxETagEntries || transport.order.Len() != maxETagEntries {
+		t.Errorf("%d entries, %d in order, want %d", len(transport.entries), transport.order.Len(), maxETagEntries)
+	}
+	if transport.lookup("https://gitlab.example.com/api/v4/projects/a") != nil {
+		t.Error("oldest entry still cached")
+	}
+}
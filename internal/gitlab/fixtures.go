@@ -0,0 +1,157 @@
+// fixtures.go
+package gitlab
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"main.go/internal/logging"
+)
+
+// fixture is an API response saved by a recordingTransport and served by a
+// replayTransport.
+type fixture struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Status int         `json:"status"`
+	Header http.Header `json:"header"`
+	Body   []byte      `json:"body"`
+}
+
+// fixturePath returns the file the response to req is kept in and restores
+// its body, which is read to tell GraphQL queries apart. The instance is
+// left out, so fixtures replay against any GITLAB_URL.
+func fixturePath(dir string, req *http.Request) (string, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return "", err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	sum := sha256.New()
+	fmt.Fprintf(sum, "%s %s?%s\n", req.Method, req.URL.Path, req.URL.Query().Encode())
+	sum.Write(body)
+	return filepath.Join(dir, hex.EncodeToString(sum.Sum(nil))[:16]+".json"), nil
+}
+
+// fixtureTransport returns the transport API requests finally go through:
+// GitLab itself, GitLab with every response saved as a fixture in record, or
+// the fixtures in replay alone.
+func fixtureTransport(record, replay string) (http.RoundTripper, error) {
+	switch {
+	case record != "" && replay != "":
+		return nil, fmt.Errorf("--record and --replay can't be used together")
+	case record != "":
+		if err := os.MkdirAll(record, 0o700); err != nil {
+			return nil, err
+		}
+		return &recordingTransport{dir: record, next: http.DefaultTransport}, nil
+	case replay != "":
+		if _, err := os.Stat(replay); err != nil {
+			return nil, err
+		}
+		return &replayTransport{dir: replay}, nil
+	default:
+		return http.DefaultTransport, nil
+	}
+}
+
+// recordingTransport saves each response it passes on as a fixture, a later
+// identical request replacing it.
+type recordingTransport struct {
+	dir  string
+	next http.RoundTripper
+}
+
+func (t *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	path, err := fixturePath(t.dir, req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	data, err := json.MarshalIndent(fixture{
+		Method: req.Method,
+		URL:    req.URL.RequestURI(),
+		Status: resp.StatusCode,
+		Header: resp.Header,
+		Body:   body,
+	}, "", "  ")
+	if err == nil {
+		err = os.WriteFile(path, data, 0o600)
+	}
+	if err != nil {
+		logging.Warn("recording fixture failed", "path", req.URL.Path, "error", err)
+	}
+	return resp, nil
+}
+
+// replayTransport answers requests from the fixtures in dir without going
+// to the network. Requests nothing was recorded for get a 404, which views
+// show like any other API error.
+type replayTransport struct {
+	dir string
+}
+
+func (t *replayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	path, err := fixturePath(t.dir, req)
+	if err != nil {
+		return nil, err
+	}
+
+	recorded := fixture{
+		Status: http.StatusNotFound,
+		Header: http.Header{"Content-Type": {"application/json"}},
+	}
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(data, &recorded); err != nil {
+			return nil, fmt.Errorf("reading fixture %s: %w", path, err)
+		}
+	case os.IsNotExist(err):
+		logging.Debug("no fixture recorded", "method", req.Method, "path", req.URL.Path, "query", req.URL.RawQuery)
+		recorded.Body, _ = json.Marshal(map[string]string{
+			"message": "no fixture recorded for " + req.Method + " " + req.URL.RequestURI(),
+		})
+	default:
+		return nil, err
+	}
+
+	return &http.Response{
+		Status:        strconv.Itoa(recorded.Status) + " " + http.StatusText(recorded.Status),
+		StatusCode:    recorded.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        recorded.Header,
+		Body:          io.NopCloser(bytes.NewReader(recorded.Body)),
+		ContentLength: int64(len(recorded.Body)),
+		Request:       req,
+	}, nil
+}
@@ -0,0 +1,61 @@
+// graphql.go
+package gitlab
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+type graphqlRequest struct {
+	Query     string                 `json:"query"`
+	Variables map[string]interface{} `json:"variables,omitempty"`
+}
+
+type graphqlResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+func (s restService) GraphQL(query string, variables map[string]interface{}, data interface{}) error {
+	body, err := json.Marshal(graphqlRequest{Query: query, Variables: variables})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, s.baseURL+"/api/graphql", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+s.token)
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("POST %s: %s", req.URL.Path, resp.Status)
+	}
+
+	var decoded graphqlResponse
+	if err := json.NewDecoder(resp.Body).Decode(&decoded); err != nil {
+		return fmt.Errorf("decoding GraphQL response: %w", err)
+	}
+	if len(decoded.Errors) > 0 {
+		messages := make([]string, len(decoded.Errors))
+		for i, e := range decoded.Errors {
+			messages[i] = e.Message
+		}
+		return errors.New("GraphQL: " + strings.Join(messages, "; "))
+	}
+
+	return json.Unmarshal(decoded.Data, data)
+}
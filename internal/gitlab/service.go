@@ -0,0 +1,506 @@
+// service.go
+
+// Package gitlab is gpv's access to the GitLab API: the REST and GraphQL
+// clients, the transports that cache, log, record and replay requests, and a
+// Mock for tests.
+package gitlab
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+
+	gogitlab "github.com/xanzy/go-gitlab"
+)
+
+// Service is everything gpv asks GitLab for. Views and commands go
+// through it rather than a *gogitlab.Client, so they can be tested against a
+// Mock and run offline against recorded fixtures.
+//
+// Each method returns the part of the API a go-gitlab service covers, limited
+// to the calls gpv makes; add a method to the matching interface below when
+// a new call is needed.
+type Service interface {
+	Branches() BranchesAPI
+	Commits() CommitsAPI
+	Deployments() DeploymentsAPI
+	Environments() EnvironmentsAPI
+	GroupVariables() GroupVariablesAPI
+	Groups() GroupsAPI
+	Jobs() JobsAPI
+	MergeRequestApprovals() MergeRequestApprovalsAPI
+	MergeRequests() MergeRequestsAPI
+	MergeTrains() MergeTrainsAPI
+	PipelineSchedules() PipelineSchedulesAPI
+	PipelineTriggers() PipelineTriggersAPI
+	Pipelines() PipelinesAPI
+	ProjectVariables() ProjectVariablesAPI
+	Projects() ProjectsAPI
+	Releases() ReleasesAPI
+	Repositories() RepositoriesAPI
+	RepositoryFiles() RepositoryFilesAPI
+	Runners() RunnersAPI
+	Search() SearchAPI
+	Tags() TagsAPI
+	Users() UsersAPI
+	Validate() ValidateAPI
+
+	// GraphQL runs query against the GraphQL API and decodes the "data"
+	// member of the response into data. Some things, such as job needs,
+	// are only exposed there.
+	GraphQL(query string, variables map[string]interface{}, data interface{}) error
+
+	// HTTPClient returns the client API requests go through, for downloads
+	// from URLs GitLab hands out.
+	HTTPClient() *http.Client
+
+	// Send makes a request go-gitlab has no method for, to path relative
+	// to the API root, decoding the response into v or copying it to v if
+	// it is an io.Writer.
+	Send(method, path string, opt, v interface{}) (*gogitlab.Response, error)
+}
+
+type BranchesAPI interface {
+	ListBranches(pid interface{}, opts *gogitlab.ListBranchesOptions, options ...gogitlab.RequestOptionFunc) ([]*gogitlab.Branch, *gogitlab.Response, error)
+}
+
+type CommitsAPI interface {
+	GetCommit(pid interface{}, sha string, options ...gogitlab.RequestOptionFunc) (*gogitlab.Commit, *gogitlab.Response, error)
+	ListCommits(pid interface{}, opt *gogitlab.ListCommitsOptions, options ...gogitlab.RequestOptionFunc) ([]*gogitlab.Commit, *gogitlab.Response, error)
+}
+
+type DeploymentsAPI interface {
+	ListProjectDeployments(pid interface{}, opts *gogitlab.ListProjectDeploymentsOptions, options ...gogitlab.RequestOptionFunc) ([]*gogitlab.Deployment, *gogitlab.Response, error)
+}
+
+type EnvironmentsAPI interface {
+	GetEnvironment(pid interface{}, environment int, options ...gogitlab.RequestOptionFunc) (*gogitlab.Environment, *gogitlab.Response, error)
+	ListEnvironments(pid interface{}, opts *gogitlab.ListEnvironmentsOptions, options ...gogitlab.RequestOptionFunc) ([]*gogitlab.Environment, *gogitlab.Response, error)
+}
+
+type GroupVariablesAPI interface {
+	CreateVariable(gid interface{}, opt *gogitlab.CreateGroupVariableOptions, options ...gogitlab.RequestOptionFunc) (*gogitlab.GroupVariable, *gogitlab.Response, error)
+	ListVariables(gid interface{}, opt *gogitlab.ListGroupVariablesOptions, options ...gogitlab.RequestOptionFunc) ([]*gogitlab.GroupVariable, *gogitlab.Response, error)
+}
+
+type GroupsAPI interface {
+	ListGroupProjects(gid interface{}, opt *gogitlab.ListGroupProjectsOptions, options ...gogitlab.RequestOptionFunc) ([]*gogitlab.Project, *gogitlab.Response, error)
+	ListGroups(opt *gogitlab.ListGroupsOptions, options ...gogitlab.RequestOptionFunc) ([]*gogitlab.Group, *gogitlab.Response, error)
+	ListSubGroups(gid interface{}, opt *gogitlab.ListSubGroupsOptions, options ...gogitlab.RequestOptionFunc) ([]*gogitlab.Group, *gogitlab.Response, error)
+}
+
+type JobsAPI interface {
+	CancelJob(pid interface{}, jobID int, options ...gogitlab.RequestOptionFunc) (*gogitlab.Job, *gogitlab.Response, error)
+	DeleteArtifacts(pid interface{}, jobID int, options ...gogitlab.RequestOptionFunc) (*gogitlab.Response, error)
+	DownloadSingleArtifactsFile(pid interface{}, jobID int, artifactPath string, options ...gogitlab.RequestOptionFunc) (*bytes.Reader, *gogitlab.Response, error)
+	EraseJob(pid interface{}, jobID int, options ...gogitlab.RequestOptionFunc) (*gogitlab.Job, *gogitlab.Response, error)
+	GetJob(pid interface{}, jobID int, options ...gogitlab.RequestOptionFunc) (*gogitlab.Job, *gogitlab.Response, error)
+	GetTraceFile(pid interface{}, jobID int, options ...gogitlab.RequestOptionFunc) (*bytes.Reader, *gogitlab.Response, error)
+	KeepArtifacts(pid interface{}, jobID int, options ...gogitlab.RequestOptionFunc) (*gogitlab.Job, *gogitlab.Response, error)
+	ListPipelineBridges(pid interface{}, pipelineID int, opts *gogitlab.ListJobsOptions, options ...gogitlab.RequestOptionFunc) ([]*gogitlab.Bridge, *gogitlab.Response, error)
+	ListPipelineJobs(pid interface{}, pipelineID int, opts *gogitlab.ListJobsOptions, options ...gogitlab.RequestOptionFunc) ([]*gogitlab.Job, *gogitlab.Response, error)
+	ListProjectJobs(pid interface{}, opts *gogitlab.ListJobsOptions, options ...gogitlab.RequestOptionFunc) ([]*gogitlab.Job, *gogitlab.Response, error)
+	PlayJob(pid interface{}, jobID int, opt *gogitlab.PlayJobOptions, options ...gogitlab.RequestOptionFunc) (*gogitlab.Job, *gogitlab.Response, error)
+	RetryJob(pid interface{}, jobID int, options ...gogitlab.RequestOptionFunc) (*gogitlab.Job, *gogitlab.Response, error)
+}
+
+type MergeRequestApprovalsAPI interface {
+	ApproveMergeRequest(pid interface{}, mr int, opt *gogitlab.ApproveMergeRequestOptions, options ...gogitlab.RequestOptionFunc) (*gogitlab.MergeRequestApprovals, *gogitlab.Response, error)
+}
+
+type MergeRequestsAPI interface {
+	AcceptMergeRequest(pid interface{}, mergeRequest int, opt *gogitlab.AcceptMergeRequestOptions, options ...gogitlab.RequestOptionFunc) (*gogitlab.MergeRequest, *gogitlab.Response, error)
+	CancelMergeWhenPipelineSucceeds(pid interface{}, mergeRequest int, options ...gogitlab.RequestOptionFunc) (*gogitlab.MergeRequest, *gogitlab.Response, error)
+	GetMergeRequest(pid interface{}, mergeRequest int, opt *gogitlab.GetMergeRequestsOptions, options ...gogitlab.RequestOptionFunc) (*gogitlab.MergeRequest, *gogitlab.Response, error)
+	ListMergeRequestPipelines(pid interface{}, mergeRequest int, options ...gogitlab.RequestOptionFunc) ([]*gogitlab.PipelineInfo, *gogitlab.Response, error)
+	ListProjectMergeRequests(pid interface{}, opt *gogitlab.ListProjectMergeRequestsOptions, options ...gogitlab.RequestOptionFunc) ([]*gogitlab.MergeRequest, *gogitlab.Response, error)
+}
+
+type MergeTrainsAPI interface {
+	ListMergeRequestInMergeTrain(pid interface{}, targetBranch string, opts *gogitlab.ListMergeTrainsOptions, options ...gogitlab.RequestOptionFunc) ([]*gogitlab.MergeTrain, *gogitlab.Response, error)
+}
+
+type PipelineSchedulesAPI interface {
+	CreatePipelineSchedule(pid interface{}, opt *gogitlab.CreatePipelineScheduleOptions, options ...gogitlab.RequestOptionFunc) (*gogitlab.PipelineSchedule, *gogitlab.Response, error)
+	CreatePipelineScheduleVariable(pid interface{}, schedule int, opt *gogitlab.CreatePipelineScheduleVariableOptions, options ...gogitlab.RequestOptionFunc) (*gogitlab.PipelineVariable, *gogitlab.Response, error)
+	DeletePipelineSchedule(pid interface{}, schedule int, options ...gogitlab.RequestOptionFunc) (*gogitlab.Response, error)
+	DeletePipelineScheduleVariable(pid interface{}, schedule int, key string, options ...gogitlab.RequestOptionFunc) (*gogitlab.PipelineVariable, *gogitlab.Response, error)
+	EditPipelineSchedule(pid interface{}, schedule int, opt *gogitlab.EditPipelineScheduleOptions, options ...gogitlab.RequestOptionFunc) (*gogitlab.PipelineSchedule, *gogitlab.Response, error)
+	EditPipelineScheduleVariable(pid interface{}, schedule int, key string, opt *gogitlab.EditPipelineScheduleVariableOptions, options ...gogitlab.RequestOptionFunc) (*gogitlab.PipelineVariable, *gogitlab.Response, error)
+	GetPipelineSchedule(pid interface{}, schedule int, options ...gogitlab.RequestOptionFunc) (*gogitlab.PipelineSchedule, *gogitlab.Response, error)
+	ListPipelineSchedules(pid interface{}, opt *gogitlab.ListPipelineSchedulesOptions, options ...gogitlab.RequestOptionFunc) ([]*gogitlab.PipelineSchedule, *gogitlab.Response, error)
+	RunPipelineSchedule(pid interface{}, schedule int, options ...gogitlab.RequestOptionFunc) (*gogitlab.Response, error)
+}
+
+type PipelineTriggersAPI interface {
+	AddPipelineTrigger(pid interface{}, opt *gogitlab.AddPipelineTriggerOptions, options ...gogitlab.RequestOptionFunc) (*gogitlab.PipelineTrigger, *gogitlab.Response, error)
+	DeletePipelineTrigger(pid interface{}, trigger int, options ...gogitlab.RequestOptionFunc) (*gogitlab.Response, error)
+	ListPipelineTriggers(pid interface{}, opt *gogitlab.ListPipelineTriggersOptions, options ...gogitlab.RequestOptionFunc) ([]*gogitlab.PipelineTrigger, *gogitlab.Response, error)
+	RunPipelineTrigger(pid interface{}, opt *gogitlab.RunPipelineTriggerOptions, options ...gogitlab.RequestOptionFunc) (*gogitlab.Pipeline, *gogitlab.Response, error)
+}
+
+type PipelinesAPI interface {
+	CancelPipelineBuild(pid interface{}, pipeline int, options ...gogitlab.RequestOptionFunc) (*gogitlab.Pipeline, *gogitlab.Response, error)
+	CreatePipeline(pid interface{}, opt *gogitlab.CreatePipelineOptions, options ...gogitlab.RequestOptionFunc) (*gogitlab.Pipeline, *gogitlab.Response, error)
+	DeletePipeline(pid interface{}, pipeline int, options ...gogitlab.RequestOptionFunc) (*gogitlab.Response, error)
+	GetLatestPipeline(pid interface{}, opt *gogitlab.GetLatestPipelineOptions, options ...gogitlab.RequestOptionFunc) (*gogitlab.Pipeline, *gogitlab.Response, error)
+	GetPipeline(pid interface{}, pipeline int, options ...gogitlab.RequestOptionFunc) (*gogitlab.Pipeline, *gogitlab.Response, error)
+	GetPipelineTestReport(pid interface{}, pipeline int, options ...gogitlab.RequestOptionFunc) (*gogitlab.PipelineTestReport, *gogitlab.Response, error)
+	GetPipelineVariables(pid interface{}, pipeline int, options ...gogitlab.RequestOptionFunc) ([]*gogitlab.PipelineVariable, *gogitlab.Response, error)
+	ListProjectPipelines(pid interface{}, opt *gogitlab.ListProjectPipelinesOptions, options ...gogitlab.RequestOptionFunc) ([]*gogitlab.PipelineInfo, *gogitlab.Response, error)
+	RetryPipelineBuild(pid interface{}, pipeline int, options ...gogitlab.RequestOptionFunc) (*gogitlab.Pipeline, *gogitlab.Response, error)
+}
+
+type ProjectVariablesAPI interface {
+	CreateVariable(pid interface{}, opt *gogitlab.CreateProjectVariableOptions, options ...gogitlab.RequestOptionFunc) (*gogitlab.ProjectVariable, *gogitlab.Response, error)
+	ListVariables(pid interface{}, opt *gogitlab.ListProjectVariablesOptions, options ...gogitlab.RequestOptionFunc) ([]*gogitlab.ProjectVariable, *gogitlab.Response, error)
+	RemoveVariable(pid interface{}, key string, opt *gogitlab.RemoveProjectVariableOptions, options ...gogitlab.RequestOptionFunc) (*gogitlab.Response, error)
+	UpdateVariable(pid interface{}, key string, opt *gogitlab.UpdateProjectVariableOptions, options ...gogitlab.RequestOptionFunc) (*gogitlab.ProjectVariable, *gogitlab.Response, error)
+}
+
+type ProjectsAPI interface {
+	GetProject(pid interface{}, opt *gogitlab.GetProjectOptions, options ...gogitlab.RequestOptionFunc) (*gogitlab.Project, *gogitlab.Response, error)
+	ListProjects(opt *gogitlab.ListProjectsOptions, options ...gogitlab.RequestOptionFunc) ([]*gogitlab.Project, *gogitlab.Response, error)
+}
+
+type ReleasesAPI interface {
+	ListReleases(pid interface{}, opt *gogitlab.ListReleasesOptions, options ...gogitlab.RequestOptionFunc) ([]*gogitlab.Release, *gogitlab.Response, error)
+}
+
+type RepositoriesAPI interface {
+	Compare(pid interface{}, opt *gogitlab.CompareOptions, options ...gogitlab.RequestOptionFunc) (*gogitlab.Compare, *gogitlab.Response, error)
+}
+
+type RepositoryFilesAPI interface {
+	GetFile(pid interface{}, fileName string, opt *gogitlab.GetFileOptions, options ...gogitlab.RequestOptionFunc) (*gogitlab.File, *gogitlab.Response, error)
+	GetRawFile(pid interface{}, fileName string, opt *gogitlab.GetRawFileOptions, options ...gogitlab.RequestOptionFunc) ([]byte, *gogitlab.Response, error)
+	UpdateFile(pid interface{}, fileName string, opt *gogitlab.UpdateFileOptions, options ...gogitlab.RequestOptionFunc) (*gogitlab.FileInfo, *gogitlab.Response, error)
+}
+
+type RunnersAPI interface {
+	GetRunnerDetails(rid interface{}, options ...gogitlab.RequestOptionFunc) (*gogitlab.RunnerDetails, *gogitlab.Response, error)
+	ListAllRunners(opt *gogitlab.ListRunnersOptions, options ...gogitlab.RequestOptionFunc) ([]*gogitlab.Runner, *gogitlab.Response, error)
+	ListGroupsRunners(gid interface{}, opt *gogitlab.ListGroupsRunnersOptions, options ...gogitlab.RequestOptionFunc) ([]*gogitlab.Runner, *gogitlab.Response, error)
+	ListProjectRunners(pid interface{}, opt *gogitlab.ListProjectRunnersOptions, options ...gogitlab.RequestOptionFunc) ([]*gogitlab.Runner, *gogitlab.Response, error)
+	ListRunnerJobs(rid interface{}, opt *gogitlab.ListRunnerJobsOptions, options ...gogitlab.RequestOptionFunc) ([]*gogitlab.Job, *gogitlab.Response, error)
+	ListRunners(opt *gogitlab.ListRunnersOptions, options ...gogitlab.RequestOptionFunc) ([]*gogitlab.Runner, *gogitlab.Response, error)
+	UpdateRunnerDetails(rid interface{}, opt *gogitlab.UpdateRunnerDetailsOptions, options ...gogitlab.RequestOptionFunc) (*gogitlab.RunnerDetails, *gogitlab.Response, error)
+}
+
+type SearchAPI interface {
+	Projects(query string, opt *gogitlab.SearchOptions, options ...gogitlab.RequestOptionFunc) ([]*gogitlab.Project, *gogitlab.Response, error)
+}
+
+type TagsAPI interface {
+	ListTags(pid interface{}, opt *gogitlab.ListTagsOptions, options ...gogitlab.RequestOptionFunc) ([]*gogitlab.Tag, *gogitlab.Response, error)
+}
+
+type UsersAPI interface {
+	CurrentUser(options ...gogitlab.RequestOptionFunc) (*gogitlab.User, *gogitlab.Response, error)
+}
+
+type ValidateAPI interface {
+	ProjectLint(pid interface{}, opt *gogitlab.ProjectLintOptions, options ...gogitlab.RequestOptionFunc) (*gogitlab.ProjectLintResult, *gogitlab.Response, error)
+	ProjectNamespaceLint(pid interface{}, opt *gogitlab.ProjectNamespaceLintOptions, options ...gogitlab.RequestOptionFunc) (*gogitlab.ProjectLintResult, *gogitlab.Response, error)
+}
+
+// restService is the Service backed by the REST API.
+type restService struct {
+	client     *gogitlab.Client
+	httpClient *http.Client
+	baseURL    string
+	token      string
+}
+
+func (s restService) Branches() BranchesAPI             { return s.client.Branches }
+func (s restService) Commits() CommitsAPI               { return s.client.Commits }
+func (s restService) Deployments() DeploymentsAPI       { return s.client.Deployments }
+func (s restService) Environments() EnvironmentsAPI     { return s.client.Environments }
+func (s restService) GroupVariables() GroupVariablesAPI { return s.client.GroupVariables }
+func (s restService) Groups() GroupsAPI                 { return s.client.Groups }
+func (s restService) Jobs() JobsAPI                     { return s.client.Jobs }
+func (s restService) MergeRequestApprovals() MergeRequestApprovalsAPI {
+	return s.client.MergeRequestApprovals
+}
+func (s restService) MergeRequests() MergeRequestsAPI         { return s.client.MergeRequests }
+func (s restService) MergeTrains() MergeTrainsAPI             { return s.client.MergeTrains }
+func (s restService) PipelineSchedules() PipelineSchedulesAPI { return s.client.PipelineSchedules }
+func (s restService) PipelineTriggers() PipelineTriggersAPI   { return s.client.PipelineTriggers }
+func (s restService) Pipelines() PipelinesAPI                 { return s.client.Pipelines }
+func (s restService) ProjectVariables() ProjectVariablesAPI   { return s.client.ProjectVariables }
+func (s restService) Projects() ProjectsAPI                   { return s.client.Projects }
+func (s restService) Releases() ReleasesAPI                   { return s.client.Releases }
+func (s restService) Repositories() RepositoriesAPI           { return s.client.Repositories }
+func (s restService) RepositoryFiles() RepositoryFilesAPI     { return s.client.RepositoryFiles }
+func (s restService) Runners() RunnersAPI                     { return s.client.Runners }
+func (s restService) Search() SearchAPI                       { return s.client.Search }
+func (s restService) Tags() TagsAPI                           { return s.client.Tags }
+func (s restService) Users() UsersAPI                         { return s.client.Users }
+func (s restService) Validate() ValidateAPI                   { return s.client.Validate }
+
+func (s restService) Send(method, path string, opt, v interface{}) (*gogitlab.Response, error) {
+	req, err := s.client.NewRequest(method, path, opt, nil)
+	if err != nil {
+		return nil, err
+	}
+	return s.client.Do(req, v)
+}
+
+func (s restService) HTTPClient() *http.Client { return s.httpClient }
+
+// Mock is a Service for tests. Its APIs are nil until set, so a
+// test fills in only those the code under test uses, usually with a struct
+// embedding the interface and overriding the methods called:
+//
+//	type pipelines struct{ PipelinesAPI }
+//
+//	func (pipelines) GetPipeline(pid interface{}, id int, _ ...gogitlab.RequestOptionFunc) (*gogitlab.Pipeline, *gogitlab.Response, error) {
+//		return &gogitlab.Pipeline{ID: id, Status: "success"}, nil, nil
+//	}
+//
+//	gitlabClient = &gitlab.Mock{PipelinesAPI: pipelines{}}
+//
+// Calls to anything left unset fail with ErrNotMocked, naming the request
+// that was missing.
+type Mock struct {
+	BranchesAPI              BranchesAPI
+	CommitsAPI               CommitsAPI
+	DeploymentsAPI           DeploymentsAPI
+	EnvironmentsAPI          EnvironmentsAPI
+	GroupVariablesAPI        GroupVariablesAPI
+	GroupsAPI                GroupsAPI
+	JobsAPI                  JobsAPI
+	MergeRequestApprovalsAPI MergeRequestApprovalsAPI
+	MergeRequestsAPI         MergeRequestsAPI
+	MergeTrainsAPI           MergeTrainsAPI
+	PipelineSchedulesAPI     PipelineSchedulesAPI
+	PipelineTriggersAPI      PipelineTriggersAPI
+	PipelinesAPI             PipelinesAPI
+	ProjectVariablesAPI      ProjectVariablesAPI
+	ProjectsAPI              ProjectsAPI
+	ReleasesAPI              ReleasesAPI
+	RepositoriesAPI          RepositoriesAPI
+	RepositoryFilesAPI       RepositoryFilesAPI
+	RunnersAPI               RunnersAPI
+	SearchAPI                SearchAPI
+	TagsAPI                  TagsAPI
+	UsersAPI                 UsersAPI
+	ValidateAPI              ValidateAPI
+
+	GraphQLFunc func(query string, variables map[string]interface{}, data interface{}) error
+	SendFunc    func(method, path string, opt, v interface{}) (*gogitlab.Response, error)
+	Client      *http.Client
+}
+
+func (m *Mock) Branches() BranchesAPI {
+	if m.BranchesAPI == nil {
+		return unmocked.Branches()
+	}
+	return m.BranchesAPI
+}
+
+func (m *Mock) Commits() CommitsAPI {
+	if m.CommitsAPI == nil {
+		return unmocked.Commits()
+	}
+	return m.CommitsAPI
+}
+
+func (m *Mock) Deployments() DeploymentsAPI {
+	if m.DeploymentsAPI == nil {
+		return unmocked.Deployments()
+	}
+	return m.DeploymentsAPI
+}
+
+func (m *Mock) Environments() EnvironmentsAPI {
+	if m.EnvironmentsAPI == nil {
+		return unmocked.Environments()
+	}
+	return m.EnvironmentsAPI
+}
+
+func (m *Mock) GroupVariables() GroupVariablesAPI {
+	if m.GroupVariablesAPI == nil {
+		return unmocked.GroupVariables()
+	}
+	return m.GroupVariablesAPI
+}
+
+func (m *Mock) Groups() GroupsAPI {
+	if m.GroupsAPI == nil {
+		return unmocked.Groups()
+	}
+	return m.GroupsAPI
+}
+
+func (m *Mock) Jobs() JobsAPI {
+	if m.JobsAPI == nil {
+		return unmocked.Jobs()
+	}
+	return m.JobsAPI
+}
+
+func (m *Mock) MergeRequestApprovals() MergeRequestApprovalsAPI {
+	if m.MergeRequestApprovalsAPI == nil {
+		return unmocked.MergeRequestApprovals()
+	}
+	return m.MergeRequestApprovalsAPI
+}
+
+func (m *Mock) MergeRequests() MergeRequestsAPI {
+	if m.MergeRequestsAPI == nil {
+		return unmocked.MergeRequests()
+	}
+	return m.MergeRequestsAPI
+}
+
+func (m *Mock) MergeTrains() MergeTrainsAPI {
+	if m.MergeTrainsAPI == nil {
+		return unmocked.MergeTrains()
+	}
+	return m.MergeTrainsAPI
+}
+
+func (m *Mock) PipelineSchedules() PipelineSchedulesAPI {
+	if m.PipelineSchedulesAPI == nil {
+		return unmocked.PipelineSchedules()
+	}
+	return m.PipelineSchedulesAPI
+}
+
+func (m *Mock) PipelineTriggers() PipelineTriggersAPI {
+	if m.PipelineTriggersAPI == nil {
+		return unmocked.PipelineTriggers()
+	}
+	return m.PipelineTriggersAPI
+}
+
+func (m *Mock) Pipelines() PipelinesAPI {
+	if m.PipelinesAPI == nil {
+		return unmocked.Pipelines()
+	}
+	return m.PipelinesAPI
+}
+
+func (m *Mock) ProjectVariables() ProjectVariablesAPI {
+	if m.ProjectVariablesAPI == nil {
+		return unmocked.ProjectVariables()
+	}
+	return m.ProjectVariablesAPI
+}
+
+func (m *Mock) Projects() ProjectsAPI {
+	if m.ProjectsAPI == nil {
+		return unmocked.Projects()
+	}
+	return m.ProjectsAPI
+}
+
+func (m *Mock) Releases() ReleasesAPI {
+	if m.ReleasesAPI == nil {
+		return unmocked.Releases()
+	}
+	return m.ReleasesAPI
+}
+
+func (m *Mock) Repositories() RepositoriesAPI {
+	if m.RepositoriesAPI == nil {
+		return unmocked.Repositories()
+	}
+	return m.RepositoriesAPI
+}
+
+func (m *Mock) RepositoryFiles() RepositoryFilesAPI {
+	if m.RepositoryFilesAPI == nil {
+		return unmocked.RepositoryFiles()
+	}
+	return m.RepositoryFilesAPI
+}
+
+func (m *Mock) Runners() RunnersAPI {
+	if m.RunnersAPI == nil {
+		return unmocked.Runners()
+	}
+	return m.RunnersAPI
+}
+
+func (m *Mock) Search() SearchAPI {
+	if m.SearchAPI == nil {
+		return unmocked.Search()
+	}
+	return m.SearchAPI
+}
+
+func (m *Mock) Tags() TagsAPI {
+	if m.TagsAPI == nil {
+		return unmocked.Tags()
+	}
+	return m.TagsAPI
+}
+
+func (m *Mock) Users() UsersAPI {
+	if m.UsersAPI == nil {
+		return unmocked.Users()
+	}
+	return m.UsersAPI
+}
+
+func (m *Mock) Validate() ValidateAPI {
+	if m.ValidateAPI == nil {
+		return unmocked.Validate()
+	}
+	return m.ValidateAPI
+}
+
+func (m *Mock) GraphQL(query string, variables map[string]interface{}, data interface{}) error {
+	if m.GraphQLFunc == nil {
+		return unmocked.GraphQL(query, variables, data)
+	}
+	return m.GraphQLFunc(query, variables, data)
+}
+
+func (m *Mock) HTTPClient() *http.Client {
+	if m.Client == nil {
+		return unmocked.httpClient
+	}
+	return m.Client
+}
+
+func (m *Mock) Send(method, path string, opt, v interface{}) (*gogitlab.Response, error) {
+	if m.SendFunc == nil {
+		return unmocked.Send(method, path, opt, v)
+	}
+	return m.SendFunc(method, path, opt, v)
+}
+
+// ErrNotMocked is the error a Mock answers with for anything left unset.
+var ErrNotMocked = errors.New("not mocked")
+
+// unmocked is the Service behind what a Mock leaves unset: go-gitlab's
+// client with a transport failing every request with ErrNotMocked, so a test
+// gets an error naming the request rather than a nil pointer panic.
+var unmocked = func() restService {
+	httpClient := &http.Client{Transport: unmockedTransport{}}
+	client, err := gogitlab.NewClient("",
+		gogitlab.WithBaseURL(unmockedURL+"/api/v4"),
+		gogitlab.WithHTTPClient(httpClient))
+	if err != nil {
+		panic(err)
+	}
+	return restService{client: client, httpClient: httpClient, baseURL: unmockedURL}
+}()
+
+const unmockedURL = "http://mock.invalid"
+
+type unmockedTransport struct{}
+
+func (unmockedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return nil, fmt.Errorf("%s %s: %w", req.Method, req.URL.Path, ErrNotMocked)
+}
+
+var (
+	_ Service = restService{}
+	_ Service = &Mock{}
+)
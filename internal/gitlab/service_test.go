@@ -0,0 +1,26 @@
+package gitlab
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestMockUnset(t *testing.T) {
+	mock := &Mock{}
+
+	_, _, err := mock.Pipelines().GetPipeline(7, 42)
+	if !errors.Is(err, ErrNotMocked) || !strings.Contains(err.Error(), "/projects/7/pipelines/42") {
+		t.Errorf("GetPipeline error = %v, want ErrNotMocked naming the request", err)
+	}
+	if err := mock.GraphQL("query { currentUser { username } }", nil, &struct{}{}); !errors.Is(err, ErrNotMocked) {
+		t.Errorf("GraphQL error = %v, want ErrNotMocked", err)
+	}
+	if _, err := mock.Send(http.MethodGet, "version", nil, nil); !errors.Is(err, ErrNotMocked) {
+		t.Errorf("Send error = %v, want ErrNotMocked", err)
+	}
+	if _, err := mock.HTTPClient().Get("https://gitlab.example.com/artifact"); !errors.Is(err, ErrNotMocked) {
+		t.Errorf("download error = %v, want ErrNotMocked", err)
+	}
+}
@@ -0,0 +1,106 @@
+// transport.go
+package gitlab
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	gogitlab "github.com/xanzy/go-gitlab"
+
+	"main.go/internal/logging"
+)
+
+// Options are how requests to GitLab are sent.
+type Options struct {
+	// Record saves every response as a fixture in this directory.
+	Record string
+	// Replay answers requests from the fixtures in this directory instead
+	// of GitLab.
+	Replay string
+	// Context returns the context reads are sent in, so they can be
+	// canceled once nobody waits for them anymore. Nil sends them in the
+	// context they were made with.
+	Context func() context.Context
+}
+
+// New returns the Service for the instance at baseURL, authenticating with
+// token. REST and GraphQL requests go through the same transports.
+func New(baseURL, token string, opts Options) (Service, error) {
+	transport, err := fixtureTransport(opts.Record, opts.Replay)
+	if err != nil {
+		return nil, fmt.Errorf("setting up fixtures: %w", err)
+	}
+	transport = &loggingTransport{next: transport}
+	// Conditional requests would make the fixtures depend on what was
+	// requested before while recording.
+	if opts.Record == "" && opts.Replay == "" {
+		transport = newETagTransport(transport)
+	}
+	if opts.Context != nil {
+		transport = &contextTransport{next: transport, context: opts.Context}
+	}
+
+	httpClient := &http.Client{Transport: transport}
+	client, err := gogitlab.NewClient(token,
+		gogitlab.WithBaseURL(baseURL+"/api/v4"),
+		gogitlab.WithHTTPClient(httpClient))
+	if err != nil {
+		return nil, fmt.Errorf("creating GitLab client: %w", err)
+	}
+	return restService{client: client, httpClient: httpClient, baseURL: baseURL, token: token}, nil
+}
+
+// loggingTransport logs every API request at debug level, with its status
+// and how long it took.
+type loggingTransport struct {
+	next http.RoundTripper
+}
+
+func (t *loggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	elapsed := time.Since(start).Round(time.Millisecond)
+
+	if err != nil {
+		logging.Debug("api request failed", "method", req.Method, "path", req.URL.Path, "query", req.URL.RawQuery, "duration", elapsed, "error", err)
+		return resp, err
+	}
+
+	logging.Debug("api request", "method", req.Method, "path", req.URL.Path, "query", req.URL.RawQuery, "status", resp.StatusCode, "duration", elapsed)
+	return resp, nil
+}
+
+type detachedKey struct{}
+
+// Detached returns ctx marked so reads made with it keep their own context
+// instead of the one from Options.Context, for background work like
+// prefetching that outlives the view it was started from.
+func Detached(ctx context.Context) context.Context {
+	return context.WithValue(ctx, detachedKey{}, true)
+}
+
+// contextTransport sends reads that weren't made with a context that can be
+// canceled in the one returned by context. Writes are left alone: a retry
+// or cancel the user asked for shouldn't be lost because they moved on.
+type contextTransport struct {
+	next    http.RoundTripper
+	context func() context.Context
+}
+
+func (t *contextTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	if isRead(req) && ctx.Done() == nil && ctx.Value(detachedKey{}) == nil {
+		req = req.WithContext(t.context())
+	}
+	return t.next.RoundTrip(req)
+}
+
+// isRead reports whether req only reads. GraphQL requests are POSTs, but
+// gpv only sends queries.
+func isRead(req *http.Request) bool {
+	return req.Method == http.MethodGet || req.Method == http.MethodHead ||
+		strings.HasSuffix(req.URL.Path, "/api/graphql")
+}
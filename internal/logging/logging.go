@@ -0,0 +1,102 @@
+// logging.go
+
+// Package logging writes gpv's log file, one logfmt line per event.
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Level is how important a log event is.
+type Level int
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+var levelNames = map[Level]string{
+	LevelDebug: "debug",
+	LevelInfo:  "info",
+	LevelWarn:  "warn",
+	LevelError: "error",
+}
+
+// logger writes to the log file once Open has been called; until then
+// everything is discarded so nothing ends up on the terminal under the TUI.
+var (
+	logger    = log.New(io.Discard, "", 0)
+	minLogLvl = LevelInfo
+)
+
+// ParseLevel returns the level called name.
+func ParseLevel(name string) (Level, error) {
+	for level, levelName := range levelNames {
+		if strings.EqualFold(name, levelName) {
+			return level, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid log level %q: must be debug, info, warn or error", name)
+}
+
+// Open appends log output at or above level to gpv.log in dir and returns
+// the file's path. The file stays open for the lifetime of the process.
+func Open(dir string, level Level) (string, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(dir, "gpv.log")
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return "", err
+	}
+
+	logger = log.New(file, "", 0)
+	minLogLvl = level
+	return path, nil
+}
+
+// logEvent writes a logfmt line with a timestamp, the level, the message and
+// any number of key/value pairs.
+func logEvent(level Level, msg string, keyvals ...interface{}) {
+	if level < minLogLvl {
+		return
+	}
+
+	var line strings.Builder
+	line.WriteString("time=" + time.Now().Format(time.RFC3339))
+	line.WriteString(" level=" + levelNames[level])
+	line.WriteString(" msg=" + logValue(msg))
+
+	for i := 0; i < len(keyvals); i += 2 {
+		key := fmt.Sprint(keyvals[i])
+		value := "MISSING"
+		if i+1 < len(keyvals) {
+			value = logValue(fmt.Sprint(keyvals[i+1]))
+		}
+		line.WriteString(" " + key + "=" + value)
+	}
+
+	logger.Println(line.String())
+}
+
+func logValue(value string) string {
+	if value == "" || strings.ContainsAny(value, " \"=\t\n") {
+		return fmt.Sprintf("%q", value)
+	}
+	return value
+}
+
+func Debug(msg string, keyvals ...interface{}) { logEvent(LevelDebug, msg, keyvals...) }
+func Info(msg string, keyvals ...interface{})  { logEvent(LevelInfo, msg, keyvals...) }
+func Warn(msg string, keyvals ...interface{})  { logEvent(LevelWarn, msg, keyvals...) }
+func Error(msg string, keyvals ...interface{}) { logEvent(LevelError, msg, keyvals...) }
@@ -0,0 +1,203 @@
+// allprojects.go
+package ui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/xanzy/go-gitlab"
+)
+
+// currentUser is the user the token belongs to, fetched once per session.
+var currentUser *gitlab.User
+
+// allProjectsReference is the reference of the all projects node, which the
+// tree only shows to administrators.
+type allProjectsReference struct{}
+
+func loadCurrentUser() (*gitlab.User, error) {
+	if currentUser != nil {
+		return currentUser, nil
+	}
+	user, _, err := gitlabClient.Users().CurrentUser()
+	if err != nil {
+		return nil, fmt.Errorf("fetching current user: %w", err)
+	}
+	currentUser = user
+	return user, nil
+}
+
+// addAdminNodes adds the nodes only administrators get to the instance node,
+// once the current user is known.
+func addAdminNodes(instanceNode *tview.TreeNode, user *gitlab.User) {
+	if !user.IsAdmin {
+		return
+	}
+	instanceNode.AddChild(tview.NewTreeNode(" All projects").
+		SetColor(tcell.ColorWhiteSmoke).
+		SetReference(allProjectsReference{}))
+}
+
+// listProjectsPage fetches one page of every project on the instance whose
+// path matches search, in path order. GitLab only lists projects outside
+// the user's membership this way for administrators.
+func listProjectsPage(search string, page int) ([]*gitlab.Project, *gitlab.Response, error) {
+	options := &gitlab.ListProjectsOptions{
+		ListOptions: gitlab.ListOptions{PerPage: pageSize, Page: page},
+		OrderBy:     gitlab.String("path"),
+		Sort:        gitlab.String("asc"),
+	}
+	if search != "" {
+		options.Search = gitlab.String(search)
+		options.SearchNamespaces = gitlab.Bool(true)
+	}
+	filters.applyToProjectsOptions(options)
+
+	projects, resp, err := gitlabClient.Projects().ListProjects(options)
+	if err != nil {
+		return nil, nil, fmt.Errorf("fetching projects: %w", err)
+	}
+	return projects, resp, nil
+}
+
+// showAllProjects lists every project on the instance a page at a time. ]
+// and [ move between pages, / searches by path and Enter opens the pipelines
+// of the selected project. back is called on Esc.
+func showAllProjects(app *tview.Application, back func()) {
+	cancelPendingLoads()
+
+	table := tview.NewTable().
+		SetSelectable(true, false).
+		SetFixed(1, 0)
+	table.SetBorder(true)
+	searchField := tview.NewInputField().
+		SetLabel("Search: ").
+		SetFieldBackgroundColor(tcell.ColorDarkGray).
+		SetFieldTextColor(tcell.ColorOrangeRed)
+	statusBar := newStatusBar()
+
+	var projects []*gitlab.Project
+	search := ""
+	page := 1
+	nextPage := 0
+
+	setTitle := func() {
+		title := fmt.Sprintf(" All projects on %s (page %d) ", gitlabURL, page)
+		if search != "" {
+			title = fmt.Sprintf(" Projects matching %s (page %d) ", search, page)
+		}
+		table.SetTitle(tview.Escape(title))
+	}
+
+	fillTable := func() {
+		table.Clear()
+
+		for column, title := range []string{"ID", "Path", "Visibility", "Last activity"} {
+			table.SetCell(0, column, tview.NewTableCell(title).
+				SetTextColor(tcell.ColorYellow).
+				SetAttributes(tcell.AttrBold).
+				SetSelectable(false))
+		}
+
+		for i, project := range projects {
+			path := tview.Escape(project.PathWithNamespace)
+			if project.Archived {
+				path += " [gray](archived)[-]"
+			}
+
+			table.SetCell(i+1, 0, tview.NewTableCell(strconv.Itoa(project.ID)).SetAlign(tview.AlignRight))
+			table.SetCell(i+1, 1, tview.NewTableCell(path).SetExpansion(1))
+			table.SetCell(i+1, 2, tview.NewTableCell(string(project.Visibility)))
+			table.SetCell(i+1, 3, tview.NewTableCell(formatTime(project.LastActivityAt)))
+		}
+
+		setTitle()
+		if len(projects) == 0 {
+			table.SetCell(1, 0, tview.NewTableCell("No projects match").
+				SetTextColor(tcell.ColorGray).
+				SetSelectable(false))
+			return
+		}
+		table.Select(1, 0).ScrollToBeginning()
+	}
+
+	loadPage := func(number int) func() (func(), error) {
+		return func() (func(), error) {
+			loaded, resp, err := listProjectsPage(search, number)
+			if err != nil {
+				return nil, err
+			}
+			return func() {
+				projects = loaded
+				page = number
+				nextPage = resp.NextPage
+				fillTable()
+			}, nil
+		}
+	}
+
+	table.SetSelectedFunc(func(row, column int) {
+		if row >= 1 && row <= len(projects) {
+			showPipelines(app, projects[row-1])
+		}
+	})
+
+	var flex *tview.Flex
+
+	table.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch {
+		case event.Key() == tcell.KeyEsc:
+			back()
+			return nil
+		case isRefreshKey(event):
+			fetchInBackground(app, statusBar, "Refreshing...", loadPage(page))
+			return nil
+		case event.Rune() == ']':
+			if nextPage == 0 {
+				showInfo(app, "This is the last page")
+				return nil
+			}
+			fetchInBackground(app, statusBar, "Loading projects...", loadPage(nextPage))
+			return nil
+		case event.Rune() == '[':
+			if page > 1 {
+				fetchInBackground(app, statusBar, "Loading projects...", loadPage(page-1))
+			}
+			return nil
+		case event.Rune() == '/':
+			flex.ResizeItem(searchField, 1, 0)
+			app.SetFocus(searchField)
+			return nil
+		}
+		return event
+	})
+
+	searchField.SetDoneFunc(func(key tcell.Key) {
+		switch key {
+		case tcell.KeyEnter:
+			search = strings.TrimSpace(searchField.GetText())
+			fetchInBackground(app, statusBar, "Searching projects...", loadPage(1))
+		case tcell.KeyEsc:
+			searchField.SetText(search)
+		}
+		if searchField.GetText() == "" {
+			flex.ResizeItem(searchField, 0, 0)
+		}
+		app.SetFocus(table)
+	})
+
+	flex = tview.NewFlex().
+		SetDirection(tview.FlexRow).
+		AddItem(searchField, 0, 0, false).
+		AddItem(table, 0, 1, true).
+		AddItem(statusBar, 1, 0, false).
+		AddItem(tview.NewButton("ENTER - Pipelines   ] - Next page   [ - Previous page   / - Search   ESC - Back").SetSelectedFunc(back), 1, 0, false)
+
+	setTitle()
+	routes.show("projects", flex, table)
+
+	fetchInBackground(app, statusBar, "Loading projects...", loadPage(1))
+}
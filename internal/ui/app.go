@@ -0,0 +1,183 @@
+// app.go
+
+// Package ui is the gpv terminal UI and the commands that run without it.
+package ui
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/rivo/tview"
+
+	"main.go/internal/config"
+	api "main.go/internal/gitlab"
+	"main.go/internal/logging"
+)
+
+var (
+	gitlabClient api.Service
+	token        string
+	gitlabURL    string
+
+	// cfg is the configuration gpv was started with.
+	cfg config.Config
+)
+
+// Main runs the command in c.Args, or the TUI if there is none, and returns
+// the exit code.
+func Main(c config.Config) int {
+	if len(c.Args) > 0 && offlineCommand(c.Args[0]) {
+		cfg = c
+		loadState()
+		return runCLI(c.Args)
+	}
+
+	if err := c.Load(); err != nil {
+		fmt.Println("Error:", err)
+		return 1
+	}
+	logPath, err := setup(c)
+	if err != nil {
+		fmt.Println("Error:", err)
+		return 1
+	}
+	if cliMode() {
+		return runCLI(cfg.Args)
+	}
+
+	if err := setupTUI(); err != nil {
+		fmt.Println("Error:", err)
+		return 1
+	}
+	fmt.Println("Connecting to Instance:", gitlabURL)
+	if logPath != "" {
+		fmt.Println("Logging to:", logPath)
+	}
+
+	app := tview.NewApplication()
+	if err := start(app); err != nil {
+		fmt.Println("Error:", err)
+		return 1
+	}
+
+	err = app.Run()
+	removeStatusFile()
+	if err != nil {
+		logError("application stopped", "error", err)
+		fmt.Println("Error:", err)
+		return 1
+	}
+	return 0
+}
+
+// Attach shows gpv on app, which the caller runs, for embedding gpv in
+// another terminal UI. c must have been loaded.
+func Attach(app *tview.Application, c config.Config) error {
+	if _, err := setup(c); err != nil {
+		return err
+	}
+	if err := setupTUI(); err != nil {
+		return err
+	}
+	return start(app)
+}
+
+// setup opens the log and connects to GitLab, as needed by commands and the
+// TUI alike. It returns the path of the log, empty if it couldn't be opened.
+func setup(c config.Config) (string, error) {
+	cfg = c
+
+	level, err := logging.ParseLevel(c.LogLevel)
+	if err != nil {
+		return "", err
+	}
+	var logPath string
+	if dir, err := config.StateDir(); err != nil {
+		fmt.Fprintln(os.Stderr, "Warning: logging disabled, could not open log file:", err)
+	} else if logPath, err = logging.Open(dir, level); err != nil {
+		fmt.Fprintln(os.Stderr, "Warning: logging disabled, could not open log file:", err)
+	}
+
+	loadState()
+
+	token, gitlabURL = c.Token, c.GitLabURL
+	gitlabClient, err = api.New(gitlabURL, token, api.Options{
+		Record:  c.Record,
+		Replay:  c.Replay,
+		Context: loadContext,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	filters = newProjectFilters(c)
+	refreshInterval = c.RefreshInterval
+	asciiIcons = c.ASCIIIcons
+	stripLogColors = c.StripANSI
+	useGraphQL = c.GraphQL
+	downloadDir = c.DownloadDir
+	eventHooks = loadEventHooks()
+
+	if !c.NoCache {
+		go pruneCache()
+	}
+
+	logInfo("starting", "instance", gitlabURL, "log_level", c.LogLevel)
+	return logPath, nil
+}
+
+// setupTUI starts what only the TUI needs. Commands write their results to
+// stdout and don't watch or listen for anything.
+func setupTUI() error {
+	statusFilePath = loadStatusFilePath()
+	plugins = discoverPlugins()
+	setupPrefetch(cfg.PrefetchDepth, cfg.PrefetchWorkers)
+
+	var err error
+	keybinds, err = loadKeybinds()
+	if err != nil {
+		return fmt.Errorf("reading key bindings: %w", err)
+	}
+
+	notifyWebhookURL, err = loadNotifyWebhookURL()
+	if err != nil {
+		return fmt.Errorf("reading notification webhook: %w", err)
+	}
+
+	if err := startWebhookListener(); err != nil {
+		return fmt.Errorf("starting webhook listener: %w", err)
+	}
+	return nil
+}
+
+// start shows the first view on app: what the command line points at, or a
+// choice between the whole group tree and a group search.
+func start(app *tview.Application) error {
+	target, err := resolveJumpTarget()
+	if err != nil {
+		return err
+	}
+
+	routes.app = app
+	setupNotifications(app)
+	startStatusAnimation(app)
+
+	if target != nil {
+		target.open(app)
+		return nil
+	}
+
+	modal := tview.NewModal().
+		SetText("Choose an Option").
+		AddButtons([]string{"List all groups", "Search group by name"}).
+		SetDoneFunc(func(buttonIndex int, buttonLabel string) {
+			switch buttonLabel {
+			case "List all groups":
+				showTree(app, "")
+			case "Search group by name":
+				showGroupSearchInput(app)
+			}
+		})
+	routes.overlay(modal)
+	return nil
+}
@@ -0,0 +1,295 @@
+// artifacts.go
+package ui
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/xanzy/go-gitlab"
+)
+
+// downloadDir is where artifacts are saved. Set with GPV_DOWNLOAD_DIR,
+// defaults to the current directory.
+var downloadDir string
+
+// progressWriter collects a download in memory and reports how much of total
+// has arrived.
+type progressWriter struct {
+	buf      bytes.Buffer
+	total    int64
+	progress func(string)
+}
+
+func (w *progressWriter) Write(p []byte) (int, error) {
+	n, err := w.buf.Write(p)
+	if w.total > 0 {
+		done := int64(w.buf.Len())
+		w.progress(fmt.Sprintf("%d%% (%s of %s)", done*100/w.total, formatSize(done), formatSize(w.total)))
+	} else {
+		w.progress(formatSize(int64(w.buf.Len())))
+	}
+	return n, err
+}
+
+// downloadArtifactsArchive downloads the artifacts archive of job, reporting
+// progress against the archive size GitLab lists for the job.
+func downloadArtifactsArchive(projectID string, job *gitlab.Job, progress func(string)) ([]byte, error) {
+	path := fmt.Sprintf("projects/%s/jobs/%d/artifacts", gitlab.PathEscape(projectID), job.ID)
+	writer := &progressWriter{total: int64(job.ArtifactsFile.Size), progress: progress}
+	if _, err := gitlabClient.Send(http.MethodGet, path, nil, writer); err != nil {
+		return nil, fmt.Errorf("downloading artifacts of job %d: %w", job.ID, err)
+	}
+	return writer.buf.Bytes(), nil
+}
+
+// saveDownload writes data to name in downloadDir and returns the full path.
+func saveDownload(name string, data []byte) (string, error) {
+	if err := os.MkdirAll(downloadDir, 0o755); err != nil {
+		return "", err
+	}
+	path := filepath.Join(downloadDir, name)
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// artifactsSize adds up the sizes of all artifacts of job, including reports.
+func artifactsSize(job *gitlab.Job) int64 {
+	var size int64
+	for _, artifact := range job.Artifacts {
+		size += int64(artifact.Size)
+	}
+	return size
+}
+
+func keepArtifacts(projectID string, jobID int) (*gitlab.Job, error) {
+	job, _, err := gitlabClient.Jobs().KeepArtifacts(projectID, jobID)
+	if err != nil {
+		return nil, fmt.Errorf("keeping artifacts of job %d: %w", jobID, err)
+	}
+	return job, nil
+}
+
+func deleteArtifacts(projectID string, jobID int) error {
+	_, err := gitlabClient.Jobs().DeleteArtifacts(projectID, jobID)
+	if err != nil {
+		return fmt.Errorf("deleting artifacts of job %d: %w", jobID, err)
+	}
+	return nil
+}
+
+func formatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
+
+// showJobArtifacts lists the artifacts of job. Enter on the archive browses
+// its files and Enter on a file saves it, s saves the whole archive, k keeps
+// the artifacts past their expiry and D deletes them. back is called on Esc.
+func showJobArtifacts(app *tview.Application, projectID string, job *gitlab.Job, back func()) {
+	cancelPendingLoads()
+
+	header := tview.NewTextView().
+		SetDynamicColors(true)
+	artifactList := tview.NewList().ShowSecondaryText(false)
+	fileList := tview.NewList().ShowSecondaryText(false)
+	statusBar := newStatusBar()
+
+	pages := tview.NewPages().
+		AddPage("artifacts", artifactList, true, true).
+		AddPage("files", fileList, true, false)
+	pages.SetBorder(true).
+		SetTitle(fmt.Sprintf(" Artifacts of %s ", tview.Escape(job.Name)))
+
+	renderHeader := func() {
+		expiry := "never"
+		if job.ArtifactsExpireAt != nil {
+			expiry = formatTime(job.ArtifactsExpireAt)
+		}
+		header.SetText(fmt.Sprintf("[yellow]Archive[-] %s   [yellow]Size[-] %s   [yellow]Total[-] %s   [yellow]Expires[-] %s",
+			tview.Escape(job.ArtifactsFile.Filename), formatSize(int64(job.ArtifactsFile.Size)), formatSize(artifactsSize(job)), expiry))
+	}
+	renderHeader()
+
+	var archive []byte
+	archiveName := fmt.Sprintf("%s-%d-artifacts.zip", strings.ReplaceAll(job.Name, "/", "_"), job.ID)
+
+	// withArchive runs use on the downloaded archive, downloading it first if
+	// it hasn't been yet.
+	withArchive := func(use func()) {
+		if archive != nil {
+			use()
+			return
+		}
+		fetchWithProgress(app, statusBar, "Downloading artifacts...", func(progress func(string)) (func(), error) {
+			data, err := downloadArtifactsArchive(projectID, job, progress)
+			if err != nil {
+				return nil, err
+			}
+			return func() {
+				archive = data
+				use()
+			}, nil
+		})
+	}
+
+	saveArchive := func() {
+		withArchive(func() {
+			path, err := saveDownload(archiveName, archive)
+			if err != nil {
+				showError(app, fmt.Errorf("saving artifacts: %w", err), nil)
+				return
+			}
+			showInfo(app, "Saved "+path)
+		})
+	}
+
+	browseArchive := func() {
+		withArchive(func() {
+			reader, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+			if err != nil {
+				showError(app, fmt.Errorf("reading artifacts archive: %w", err), nil)
+				return
+			}
+
+			fileList.Clear()
+			for _, file := range reader.File {
+				if file.FileInfo().IsDir() {
+					continue
+				}
+				file := file
+				fileList.AddItem(fmt.Sprintf("%-60s %10s", tview.Escape(file.Name), formatSize(int64(file.UncompressedSize64))), "", 0, func() {
+					path, err := saveArtifactFile(file)
+					if err != nil {
+						showError(app, err, nil)
+						return
+					}
+					showInfo(app, "Saved "+path)
+				})
+			}
+			if fileList.GetItemCount() == 0 {
+				fileList.AddItem("[gray]The archive is empty[-]", "", 0, nil)
+			}
+
+			pages.SwitchToPage("files")
+			app.SetFocus(fileList)
+		})
+	}
+
+	for _, artifact := range job.Artifacts {
+		artifact := artifact
+		label := fmt.Sprintf("%-12s %-40s %10s", artifact.FileType, tview.Escape(artifact.Filename), formatSize(int64(artifact.Size)))
+		artifactList.AddItem(label, "", 0, func() {
+			if artifact.FileType != "archive" {
+				showError(app, fmt.Errorf("only the archive can be browsed, %s is a %s report", artifact.Filename, artifact.FileType), nil)
+				return
+			}
+			browseArchive()
+		})
+	}
+	if len(job.Artifacts) == 0 {
+		artifactList.AddItem("[gray]This job has no artifacts[-]", "", 0, nil)
+	}
+
+	var flex *tview.Flex
+
+	artifactList.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch {
+		case event.Key() == tcell.KeyEsc:
+			back()
+			return nil
+		case event.Rune() == 's':
+			saveArchive()
+			return nil
+		case event.Rune() == 'k':
+			fetchInBackground(app, statusBar, "Keeping artifacts...", func() (func(), error) {
+				kept, err := keepArtifacts(projectID, job.ID)
+				if err != nil {
+					return nil, err
+				}
+				return func() {
+					job.ArtifactsExpireAt = kept.ArtifactsExpireAt
+					renderHeader()
+					showInfo(app, fmt.Sprintf("Artifacts of job %s will be kept", job.Name))
+				}, nil
+			})
+			return nil
+		case event.Rune() == 'D':
+			message := fmt.Sprintf("Delete %s of artifacts of job %s?\nThis can't be undone.", formatSize(artifactsSize(job)), job.Name)
+			confirmAction(app, message, "Delete", func() {
+				fetchInBackground(app, statusBar, "Deleting artifacts...", func() (func(), error) {
+					if err := deleteArtifacts(projectID, job.ID); err != nil {
+						return nil, err
+					}
+					return func() {
+						job.Artifacts = nil
+						showInfo(app, fmt.Sprintf("Deleted artifacts of job %s", job.Name))
+						back()
+					}, nil
+				})
+			})
+			return nil
+		}
+		return event
+	})
+
+	fileList.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch {
+		case event.Key() == tcell.KeyEsc:
+			pages.SwitchToPage("artifacts")
+			app.SetFocus(artifactList)
+			return nil
+		case event.Rune() == 's':
+			saveArchive()
+			return nil
+		}
+		return event
+	})
+
+	flex = tview.NewFlex().
+		SetDirection(tview.FlexRow).
+		AddItem(header, 1, 0, false).
+		AddItem(pages, 0, 1, true).
+		AddItem(statusBar, 1, 0, false).
+		AddItem(tview.NewButton("ENTER - Browse/Save file   s - Save archive   k - Keep   D - Delete   ESC - Back").SetSelectedFunc(back), 1, 0, false)
+
+	routes.show("artifacts/"+projectID+"/"+strconv.Itoa(job.ID), flex, artifactList)
+}
+
+// saveArtifactFile extracts one file of the artifacts archive into
+// downloadDir, flattening its path.
+func saveArtifactFile(file *zip.File) (string, error) {
+	reader, err := file.Open()
+	if err != nil {
+		return "", fmt.Errorf("extracting %s: %w", file.Name, err)
+	}
+	defer reader.Close()
+
+	var data bytes.Buffer
+	if _, err := data.ReadFrom(reader); err != nil {
+		return "", fmt.Errorf("extracting %s: %w", file.Name, err)
+	}
+
+	path, err := saveDownload(filepath.Base(file.Name), data.Bytes())
+	if err != nil {
+		return "", fmt.Errorf("saving %s: %w", file.Name, err)
+	}
+	return path, nil
+}
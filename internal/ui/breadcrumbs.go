@@ -0,0 +1,86 @@
+// breadcrumbs.go
+package ui
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/xanzy/go-gitlab"
+)
+
+// pipelineCrumb is a parent pipeline the user descended from into a child or
+// downstream pipeline through one of its trigger jobs.
+type pipelineCrumb struct {
+	projectID  string
+	pipelineID string
+	branch     string
+	label      string
+}
+
+// pipelineTrail holds the parents of the pipeline being shown, outermost
+// first. It is reset whenever a pipeline list is opened.
+var pipelineTrail []pipelineCrumb
+
+// enterDownstreamPipeline opens the pipeline triggered by bridge, remembering
+// the parent so going back returns to its job list.
+func enterDownstreamPipeline(app *tview.Application, projectID, branch string, bridge *gitlab.Bridge) {
+	downstream := bridge.DownstreamPipeline
+
+	pipelineTrail = append(pipelineTrail, pipelineCrumb{
+		projectID:  projectID,
+		pipelineID: strconv.Itoa(bridge.Pipeline.ID),
+		branch:     branch,
+		label:      pipelineLabel(bridge.Pipeline.WebURL, bridge.Pipeline.ID) + " › " + bridge.Name,
+	})
+
+	showPipelineDetail(app, strconv.Itoa(downstream.ProjectID), strconv.Itoa(downstream.ID), downstream.Ref)
+}
+
+// leavePipeline goes back from a pipeline to the job list of its parent, or
+// to the pipeline list of branch for a top-level pipeline.
+func leavePipeline(app *tview.Application, projectID, branch string) {
+	if len(pipelineTrail) == 0 {
+		fetchAndShowPipelines(app, projectID, branch)
+		return
+	}
+
+	parent := pipelineTrail[len(pipelineTrail)-1]
+	pipelineTrail = pipelineTrail[:len(pipelineTrail)-1]
+
+	showJobList(app, nil, nil, parent.projectID, parent.pipelineID, parent.branch)
+}
+
+// newBreadcrumbBar shows the trail of parent pipelines above a view of
+// pipelineID. It has no height outside of downstream pipelines.
+func newBreadcrumbBar(pipelineID string) (*tview.TextView, int) {
+	bar := tview.NewTextView().
+		SetDynamicColors(true).
+		SetTextColor(tcell.ColorGray)
+
+	if len(pipelineTrail) == 0 {
+		return bar, 0
+	}
+
+	labels := make([]string, 0, len(pipelineTrail)+1)
+	for _, crumb := range pipelineTrail {
+		labels = append(labels, tview.Escape(crumb.label))
+	}
+	labels = append(labels, "[white]#"+pipelineID+"[-]")
+
+	bar.SetText(strings.Join(labels, " › "))
+	return bar, 1
+}
+
+// pipelineLabel names a pipeline by the project path in its web URL, which
+// is all that's known about the project of a downstream pipeline.
+func pipelineLabel(webURL string, pipelineID int) string {
+	label := "#" + strconv.Itoa(pipelineID)
+
+	path := strings.TrimPrefix(webURL, gitlabURL+"/")
+	if i := strings.Index(path, "/-/"); i > 0 {
+		return path[:i] + " " + label
+	}
+	return label
+}
@@ -0,0 +1,45 @@
+// browser.go
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+
+	"github.com/rivo/tview"
+)
+
+// openInBrowser opens url in the default web browser without waiting for
+// the browser to exit.
+func openInBrowser(url string) error {
+	if url == "" {
+		return errors.New("no web URL to open")
+	}
+
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", url)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	default:
+		cmd = exec.Command("xdg-open", url)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("opening %s: %w", url, err)
+	}
+	go cmd.Wait()
+	return nil
+}
+
+// openWebURL opens url in the browser and reports failures as an error
+// toast. It must be called from the UI goroutine.
+func openWebURL(app *tview.Application, url string) {
+	if err := openInBrowser(url); err != nil {
+		showError(app, err, nil)
+		return
+	}
+	logDebug("opened in browser", "url", url)
+}
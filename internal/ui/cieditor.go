@@ -0,0 +1,242 @@
+// cieditor.go
+package ui
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/xanzy/go-gitlab"
+)
+
+// ciEdit is an edit of a project's CI config in progress.
+type ciEdit struct {
+	project  *gitlab.Project
+	path     string
+	branch   string
+	target   string
+	message  string
+	original string
+	content  string
+	// lastCommitID is the last commit that changed the file on branch, so
+	// GitLab refuses the commit if someone else changed it in the meantime.
+	lastCommitID string
+}
+
+// showEditCIConfig asks which branch to edit the project's CI config on and
+// which branch to commit the result to, then opens the config in $EDITOR.
+// back is called on Esc or Cancel, and once the change is committed.
+func showEditCIConfig(app *tview.Application, project *gitlab.Project, back func()) {
+	cancelPendingLoads()
+
+	path := ciConfigPath(project)
+	if externalCIConfig(path) {
+		showError(app, fmt.Errorf("the CI config of %s lives in %s and can't be edited here", project.PathWithNamespace, path), nil)
+		return
+	}
+
+	statusBar := newStatusBar()
+	form := tview.NewForm()
+	form.SetBorder(true).
+		SetTitle(fmt.Sprintf(" Edit %s of %s ", tview.Escape(path), tview.Escape(project.PathWithNamespace)))
+
+	form.AddInputField("Branch", project.DefaultBranch, 40, nil, nil).
+		AddInputField("Commit to", project.DefaultBranch, 40, nil, nil).
+		AddInputField("Commit message", "Update "+path, 60, nil, nil).
+		AddButton("Edit", func() {
+			field := func(label string) string {
+				return strings.TrimSpace(form.GetFormItemByLabel(label).(*tview.InputField).GetText())
+			}
+			edit := &ciEdit{
+				project: project,
+				path:    path,
+				branch:  field("Branch"),
+				target:  field("Commit to"),
+				message: field("Commit message"),
+			}
+			if edit.branch == "" || edit.target == "" || edit.message == "" {
+				showError(app, fmt.Errorf("a branch, a branch to commit to and a commit message are required"), nil)
+				return
+			}
+
+			fetchInBackground(app, statusBar, fmt.Sprintf("Fetching %s...", path), func() (func(), error) {
+				file, _, err := gitlabClient.RepositoryFiles().GetFile(project.ID, path, &gitlab.GetFileOptions{Ref: gitlab.String(edit.branch)})
+				if err != nil {
+					return nil, fmt.Errorf("fetching %s on %s: %w", path, edit.branch, err)
+				}
+				content, err := base64.StdEncoding.DecodeString(file.Content)
+				if err != nil {
+					return nil, fmt.Errorf("decoding %s: %w", path, err)
+				}
+				return func() {
+					edit.original = string(content)
+					edit.content = edit.original
+					edit.lastCommitID = file.LastCommitID
+					editCIConfig(app, edit, func() {
+						showEditCIConfig(app, project, back)
+					}, back)
+				}, nil
+			})
+		}).
+		AddButton("Cancel", back).
+		SetCancelFunc(back)
+
+	flex := tview.NewFlex().
+		SetDirection(tview.FlexRow).
+		AddItem(form, 0, 1, true).
+		AddItem(statusBar, 1, 0, false).
+		AddItem(tview.NewButton("Commit to a new branch to create it from Branch   ESC - Back").SetSelectedFunc(back), 1, 0, false)
+
+	routes.show("ci-edit/"+strconv.Itoa(project.ID), flex, form)
+}
+
+// editCIConfig opens the config of edit in $EDITOR, then lints the result
+// and asks whether to commit it. cancel is called if the user discards the
+// edit, done once it is committed.
+func editCIConfig(app *tview.Application, edit *ciEdit, cancel, done func()) {
+	var content string
+	var err error
+	app.Suspend(func() {
+		content, err = openInEditor(edit.content)
+	})
+	if err != nil {
+		showError(app, fmt.Errorf("editing %s: %w", edit.path, err), nil)
+		cancel()
+		return
+	}
+	if content == edit.original {
+		showInfo(app, fmt.Sprintf("%s is unchanged, nothing to commit", edit.path))
+		cancel()
+		return
+	}
+	edit.content = content
+
+	showCIEditReview(app, edit, cancel, done)
+}
+
+// showCIEditReview shows the lint result of the edited config. c commits it,
+// e edits it again and Esc discards it.
+func showCIEditReview(app *tview.Application, edit *ciEdit, cancel, done func()) {
+	cancelPendingLoads()
+
+	view := tview.NewTextView().
+		SetDynamicColors(true).
+		SetScrollable(true).
+		SetWordWrap(true)
+	view.SetBorder(true).
+		SetTitle(fmt.Sprintf(" Review %s ", tview.Escape(edit.path)))
+	statusBar := newStatusBar()
+
+	var flex *tview.Flex
+	valid := false
+
+	commit := func() {
+		options := &gitlab.UpdateFileOptions{
+			Branch:        gitlab.String(edit.target),
+			Content:       gitlab.String(edit.content),
+			CommitMessage: gitlab.String(edit.message),
+		}
+		if edit.target == edit.branch {
+			options.LastCommitID = gitlab.String(edit.lastCommitID)
+		} else {
+			options.StartBranch = gitlab.String(edit.branch)
+		}
+
+		fetchInBackground(app, statusBar, "Committing...", func() (func(), error) {
+			_, _, err := gitlabClient.RepositoryFiles().UpdateFile(edit.project.ID, edit.path, options)
+			if err != nil {
+				return nil, fmt.Errorf("committing %s to %s: %w", edit.path, edit.target, err)
+			}
+			return func() {
+				done()
+				showInfo(app, fmt.Sprintf("Committed %s to %s", edit.path, edit.target))
+			}, nil
+		})
+	}
+
+	// commitChecked asks for confirmation before committing a config that
+	// doesn't pass CI Lint.
+	commitChecked := func() {
+		if valid {
+			commit()
+			return
+		}
+		confirmAction(app, fmt.Sprintf("%s doesn't pass CI Lint.\nCommit it anyway?", edit.path), "Commit anyway", commit)
+	}
+
+	view.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch {
+		case event.Key() == tcell.KeyEsc:
+			confirmAction(app, fmt.Sprintf("Discard your changes to %s?", edit.path), "Discard", cancel)
+			return nil
+		case event.Rune() == 'e':
+			editCIConfig(app, edit, cancel, done)
+			return nil
+		case event.Rune() == 'c':
+			commitChecked()
+			return nil
+		}
+		return event
+	})
+
+	flex = tview.NewFlex().
+		SetDirection(tview.FlexRow).
+		AddItem(view, 0, 1, true).
+		AddItem(statusBar, 1, 0, false).
+		AddItem(tview.NewButton(fmt.Sprintf("c - Commit to %s   e - Edit again   ESC - Discard", edit.target)).SetSelectedFunc(commitChecked), 1, 0, false)
+
+	routes.show("ci-edit-review", flex, view)
+
+	fetchInBackground(app, statusBar, "Linting...", func() (func(), error) {
+		result, err := lintCIConfig(edit.project.ID, edit.branch, ciConfig{source: edit.path, content: edit.content})
+		if err != nil {
+			return nil, err
+		}
+		return func() {
+			valid = result.Valid
+			view.SetText(formatLintResult(result, edit.content)).ScrollToBeginning()
+		}, nil
+	})
+}
+
+// openInEditor lets the user edit text in $EDITOR, or vi if it isn't set,
+// and returns the result. The caller must suspend the UI around it.
+func openInEditor(text string) (string, error) {
+	editor := strings.Fields(os.Getenv("EDITOR"))
+	if len(editor) == 0 {
+		editor = []string{"vi"}
+	}
+
+	file, err := os.CreateTemp("", "gpv-*.gitlab-ci.yml")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(file.Name())
+
+	_, err = file.WriteString(text)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", err
+	}
+
+	cmd := exec.Command(editor[0], append(editor[1:], file.Name())...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", err
+	}
+
+	edited, err := os.ReadFile(file.Name())
+	if err != nil {
+		return "", err
+	}
+	return string(edited), nil
+}
@@ -0,0 +1,236 @@
+// cilint.go
+package ui
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/xanzy/go-gitlab"
+)
+
+// lintErrorLine finds the line an error of the CI Lint API is about, e.g.
+// "did not find expected key while parsing a block mapping at line 3 column 1".
+var lintErrorLine = regexp.MustCompile(`\bline (\d+)`)
+
+// lintContextLines is how many lines around the line of an error are shown.
+const lintContextLines = 2
+
+// ciConfig is a CI configuration to lint. content is empty if the project's
+// config lives outside of its repository, in which case GitLab reads it
+// itself.
+type ciConfig struct {
+	source  string
+	content string
+}
+
+// ciConfigPath is where project keeps its CI configuration.
+func ciConfigPath(project *gitlab.Project) string {
+	if project.CIConfigPath != "" {
+		return project.CIConfigPath
+	}
+	return ".gitlab-ci.yml"
+}
+
+// externalCIConfig reports whether path points to another project or a URL,
+// which the repository files API can't read.
+func externalCIConfig(path string) bool {
+	return strings.Contains(path, "@") || strings.Contains(path, "://")
+}
+
+// loadCIConfig reads the config at localPath, or the project's own config on
+// ref if localPath is empty.
+func loadCIConfig(project *gitlab.Project, ref, localPath string) (ciConfig, error) {
+	if localPath != "" {
+		content, err := os.ReadFile(localPath)
+		if err != nil {
+			return ciConfig{}, fmt.Errorf("reading CI config: %w", err)
+		}
+		return ciConfig{source: localPath, content: string(content)}, nil
+	}
+
+	path := ciConfigPath(project)
+	if externalCIConfig(path) {
+		return ciConfig{source: path}, nil
+	}
+	content, _, err := gitlabClient.RepositoryFiles().GetRawFile(project.ID, path, &gitlab.GetRawFileOptions{Ref: gitlab.String(ref)})
+	if err != nil {
+		return ciConfig{}, fmt.Errorf("fetching %s on %s: %w", path, ref, err)
+	}
+	return ciConfig{source: path + " on " + ref, content: string(content)}, nil
+}
+
+// lintCIConfig validates config with the project's CI Lint endpoint, which
+// resolves includes the way a pipeline for ref would.
+func lintCIConfig(projectID int, ref string, config ciConfig) (*gitlab.ProjectLintResult, error) {
+	var (
+		result *gitlab.ProjectLintResult
+		err    error
+	)
+	if config.content == "" {
+		result, _, err = gitlabClient.Validate().ProjectLint(projectID, &gitlab.ProjectLintOptions{Ref: gitlab.String(ref)})
+	} else {
+		result, _, err = gitlabClient.Validate().ProjectNamespaceLint(projectID, &gitlab.ProjectNamespaceLintOptions{
+			Content: gitlab.String(config.content),
+			Ref:     gitlab.String(ref),
+		})
+	}
+	if err != nil {
+		return nil, fmt.Errorf("linting %s: %w", config.source, err)
+	}
+	return result, nil
+}
+
+// showCILintForm asks which ref to lint the project's CI config for, or
+// which local file to lint instead, and shows the result.
+func showCILintForm(app *tview.Application, project *gitlab.Project, back func()) {
+	cancelPendingLoads()
+
+	form := tview.NewForm()
+	form.SetBorder(true).
+		SetTitle(fmt.Sprintf(" Lint CI config of %s ", tview.Escape(project.PathWithNamespace)))
+
+	// submit calls show with what was filled in, once there is a ref.
+	submit := func(show func(app *tview.Application, project *gitlab.Project, ref, localPath string, back func())) {
+		ref := strings.TrimSpace(form.GetFormItemByLabel("Ref").(*tview.InputField).GetText())
+		localPath := strings.TrimSpace(form.GetFormItemByLabel("Local file").(*tview.InputField).GetText())
+		if ref == "" {
+			showError(app, fmt.Errorf("a ref is required to lint the CI config"), nil)
+			return
+		}
+		show(app, project, ref, localPath, func() {
+			showCILintForm(app, project, back)
+		})
+	}
+
+	form.AddInputField("Ref", project.DefaultBranch, 40, nil, nil).
+		AddInputField("Local file", "", 60, nil, nil).
+		AddButton("Lint", func() { submit(showCILint) }).
+		AddButton("Merged config", func() { submit(showMergedCIConfig) }).
+		AddButton("Cancel", back).
+		SetCancelFunc(back)
+
+	flex := tview.NewFlex().
+		SetDirection(tview.FlexRow).
+		AddItem(form, 0, 1, true).
+		AddItem(tview.NewButton("Leave the local file empty to lint the project's own config   ESC - Back").SetSelectedFunc(back), 1, 0, false)
+
+	routes.show("ci-lint-form/"+strconv.Itoa(project.ID), flex, form)
+}
+
+// showCILint lints the CI config at localPath, or the project's own config on
+// ref, and shows its errors and warnings. r lints it again, rereading the
+// file, and m shows the merged config. back is called on Esc.
+func showCILint(app *tview.Application, project *gitlab.Project, ref, localPath string, back func()) {
+	cancelPendingLoads()
+
+	view := tview.NewTextView().
+		SetDynamicColors(true).
+		SetScrollable(true).
+		SetWordWrap(true)
+	view.SetBorder(true).
+		SetTitle(" CI Lint ")
+	statusBar := newStatusBar()
+
+	lint := func() (func(), error) {
+		config, err := loadCIConfig(project, ref, localPath)
+		if err != nil {
+			return nil, err
+		}
+		result, err := lintCIConfig(project.ID, ref, config)
+		if err != nil {
+			return nil, err
+		}
+		return func() {
+			view.SetTitle(fmt.Sprintf(" CI Lint: %s ", tview.Escape(config.source)))
+			view.SetText(formatLintResult(result, config.content)).ScrollToBeginning()
+		}, nil
+	}
+
+	view.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch {
+		case event.Key() == tcell.KeyEsc:
+			back()
+			return nil
+		case isRefreshKey(event):
+			fetchInBackground(app, statusBar, "Linting...", lint)
+			return nil
+		case event.Rune() == 'm':
+			showMergedCIConfig(app, project, ref, localPath, func() {
+				showCILint(app, project, ref, localPath, back)
+			})
+			return nil
+		}
+		return event
+	})
+
+	flex := tview.NewFlex().
+		SetDirection(tview.FlexRow).
+		AddItem(view, 0, 1, true).
+		AddItem(statusBar, 1, 0, false).
+		AddItem(tview.NewButton("r - Lint again   m - Merged config   ESC - Back").SetSelectedFunc(back), 1, 0, false)
+
+	routes.show("ci-lint/"+strconv.Itoa(project.ID)+"/"+ref, flex, view)
+
+	fetchInBackground(app, statusBar, "Linting...", lint)
+}
+
+// formatLintResult renders the errors and warnings of result. Messages that
+// name a line of content are followed by the lines around it.
+func formatLintResult(result *gitlab.ProjectLintResult, content string) string {
+	var text strings.Builder
+
+	if result.Valid {
+		text.WriteString(statusIcon("success") + " [green::b]Valid[-::-]\n")
+	} else {
+		text.WriteString(statusIcon("failed") + " [red::b]Invalid[-::-]\n")
+	}
+
+	lines := strings.Split(content, "\n")
+	messages := func(title, color string, messages []string) {
+		if len(messages) == 0 {
+			return
+		}
+		fmt.Fprintf(&text, "\n[yellow]%s[-]\n", title)
+		for _, message := range messages {
+			fmt.Fprintf(&text, "[%s]•[-] %s\n", color, tview.Escape(message))
+			if m := lintErrorLine.FindStringSubmatch(message); m != nil && content != "" {
+				line, _ := strconv.Atoi(m[1])
+				text.WriteString(lintContext(lines, line))
+			}
+		}
+	}
+	messages("Errors", "red", result.Errors)
+	messages("Warnings", "orange", result.Warnings)
+
+	return text.String()
+}
+
+// lintContext renders the lines around line, which counts from 1, with line
+// itself highlighted.
+func lintContext(lines []string, line int) string {
+	if line < 1 || line > len(lines) {
+		return ""
+	}
+
+	var text strings.Builder
+	first, last := line-lintContextLines, line+lintContextLines
+	if first < 1 {
+		first = 1
+	}
+	if last > len(lines) {
+		last = len(lines)
+	}
+	for n := first; n <= last; n++ {
+		if n == line {
+			fmt.Fprintf(&text, "  [red]%4d >[-] %s\n", n, tview.Escape(lines[n-1]))
+			continue
+		}
+		fmt.Fprintf(&text, "  [gray]%4d |[-] %s\n", n, tview.Escape(lines[n-1]))
+	}
+	return text.String() + "\n"
+}
@@ -0,0 +1,331 @@
+// cli.go
+package ui
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+// cliCommand is a subcommand that runs without the TUI, for scripts and
+// quick checks from the shell.
+type cliCommand struct {
+	usage   string
+	summary string
+	// setup defines the flags of the command on flags and returns the
+	// function that runs it with the arguments left after them.
+	setup func(flags *flag.FlagSet) func(args []string) error
+}
+
+// cliCommands are the subcommands, keyed by the words that name them. It is
+// filled in init because the commands print their usage from it.
+var cliCommands map[string]cliCommand
+
+func init() {
+	cliCommands = map[string]cliCommand{
+		"pipelines list": {
+			usage:   "pipelines list -p <project> [--ref <ref>] [--filter <terms>] [-n <count>] [-o <format>]",
+			summary: "list the latest pipelines of a project",
+			setup:   setupPipelinesList,
+		},
+		"jobs list": {
+			usage:   "jobs list -p <project> --pipeline <id> [-o <format>]",
+			summary: "list the jobs of a pipeline",
+			setup:   setupJobsList,
+		},
+		"job retry": {
+			usage:   "job retry -p <project> [-o <format>] <job id>",
+			summary: "retry a job",
+			setup:   setupJobRetry,
+		},
+		"tail": {
+			usage:   "tail <project> --job <id|name> [--ref <ref>]",
+			summary: "print the log of a job as it runs and exit with its status",
+			setup:   setupTail,
+		},
+		"wait": {
+			usage:   "wait -p <project> (--pipeline <id> | [--ref <ref>] --latest) [-o <format>]",
+			summary: "wait for a pipeline to finish and exit with its status",
+			setup:   setupWait,
+		},
+		"watch": {
+			usage:   "watch -p <project> [--ref <ref>] [-n <count>] [-o <format>]",
+			summary: "print a line whenever a pipeline starts or changes status",
+			setup:   setupWatchCommand,
+		},
+		"trigger": {
+			usage:   "trigger -p <project> --ref <ref> [-v KEY=VALUE]... [--file KEY=@path]... [--wait | --tail] [-o <format>]",
+			summary: "run a pipeline, optionally waiting for it or following its logs",
+			setup:   setupTrigger,
+		},
+		"exporter": {
+			usage:   "exporter [-p <project>]... [--ref <ref>]... [--listen <address>] [--interval <duration>] [--days <count>]",
+			summary: "serve pipeline and job metrics for Prometheus",
+			setup:   setupExporter,
+		},
+		"completion": {
+			usage:   "completion bash|zsh|fish",
+			summary: "print the shell completion script",
+			setup:   setupCompletion,
+		},
+	}
+}
+
+// exitCode is returned by commands that ran fine but make gpv exit with a
+// code other than 0, like tail for a job that failed.
+type exitCode int
+
+func (c exitCode) Error() string {
+	return fmt.Sprintf("exit status %d", int(c))
+}
+
+// errUsage is returned by commands called with wrong arguments, after the
+// problem was reported by the flag set.
+var errUsage = errors.New("usage")
+
+// runCLI runs the subcommand named by args and returns the exit code. The
+// second word of a command is optional when the first alone names one.
+func runCLI(args []string) int {
+	switch args[0] {
+	case "help":
+		printCLIUsage(os.Stdout)
+		return 0
+	case completeCommand:
+		printCompletions(args[1:])
+		return 0
+	}
+
+	name, rest := args[0], args[1:]
+	if len(rest) > 0 {
+		if _, ok := cliCommands[name+" "+rest[0]]; ok {
+			name, rest = name+" "+rest[0], rest[1:]
+		}
+	}
+	command, ok := cliCommands[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Unknown command %q\n\n", strings.Join(args, " "))
+		printCLIUsage(os.Stderr)
+		return 2
+	}
+
+	flags := newCommandFlags(name)
+	run := command.setup(flags)
+	args, err := parseCommandFlags(flags, rest)
+	if err != nil {
+		return 2
+	}
+
+	logInfo("running command", "command", name)
+	if err := run(args); err != nil {
+		if errors.Is(err, errUsage) {
+			return 2
+		}
+		var code exitCode
+		if errors.As(err, &code) {
+			return int(code)
+		}
+		logError("command failed", "command", name, "error", err)
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		return 1
+	}
+	return 0
+}
+
+func printCLIUsage(w io.Writer) {
+	names := make([]string, 0, len(cliCommands))
+	for name := range cliCommands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(w, "Usage: gpv [--log-level <level>] [<command> [<args>]]")
+	fmt.Fprintln(w, "\nWithout a command gpv starts the TUI. Commands:")
+	tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+	for _, name := range names {
+		fmt.Fprintf(tw, "  gpv %s\t%s\n", cliCommands[name].usage, cliCommands[name].summary)
+	}
+	tw.Flush()
+}
+
+// newCommandFlags returns the flag set of a command, which reports its own
+// errors along with the usage of the command.
+func newCommandFlags(name string) *flag.FlagSet {
+	flags := flag.NewFlagSet("gpv "+name, flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: gpv %s\n", cliCommands[name].usage)
+		flags.PrintDefaults()
+	}
+	return flags
+}
+
+// parseCommandFlags parses args with flags, allowing flags after positional
+// arguments, and returns the positional arguments.
+func parseCommandFlags(flags *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := flags.Parse(args); err != nil {
+			return nil, errUsage
+		}
+		if flags.NArg() == 0 {
+			return positional, nil
+		}
+		positional = append(positional, flags.Arg(0))
+		args = flags.Args()[1:]
+	}
+}
+
+// requireFlag reports a missing mandatory flag the way the flag set would.
+func requireFlag(flags *flag.FlagSet, name, value string) error {
+	if value != "" {
+		return nil
+	}
+	fmt.Fprintf(flags.Output(), "flag -%s is required\n", name)
+	flags.Usage()
+	return errUsage
+}
+
+// maxCount is the most items -n asks for, GitLab's largest page.
+const maxCount = 100
+
+// validateCount reports a usage error for a -n value outside 1 to maxCount.
+func validateCount(flags *flag.FlagSet, count int) error {
+	if count >= 1 && count <= maxCount {
+		return nil
+	}
+	fmt.Fprintf(flags.Output(), "invalid value %d for flag -n: must be between 1 and %d\n", count, maxCount)
+	flags.Usage()
+	return errUsage
+}
+
+func setupPipelinesList(flags *flag.FlagSet) func(args []string) error {
+	project := flags.String("p", "", "project path or ID, e.g. group/project")
+	ref := flags.String("ref", "", "only list pipelines of this branch or tag")
+	filterText := flags.String("filter", "", `pipeline filter, e.g. "status=failed source=push"`)
+	count := flags.Int("n", 20, "number of pipelines to list, at most 100")
+	output := addOutputFlag(flags)
+	return func(args []string) error {
+		if err := requireFlag(flags, "p", *project); err != nil {
+			return err
+		}
+		if err := validateOutputFormat(flags, *output); err != nil {
+			return err
+		}
+		if err := validateCount(flags, *count); err != nil {
+			return err
+		}
+
+		filter, err := parsePipelineFilter(*filterText)
+		if err != nil {
+			return err
+		}
+		opts := filter.listOptions(*ref)
+		if opts.Ref != nil && *opts.Ref == "" {
+			opts.Ref = nil
+		}
+		opts.ListOptions = gitlab.ListOptions{PerPage: *count}
+
+		infos, _, err := gitlabClient.Pipelines().ListProjectPipelines(*project, opts)
+		if err != nil {
+			return fmt.Errorf("fetching pipelines for project %s: %w", *project, err)
+		}
+		var matching []*gitlab.PipelineInfo
+		for _, info := range infos {
+			if filter.matchRef(info.Ref) {
+				matching = append(matching, info)
+			}
+		}
+		pipelines, err := listPipelineDetails(*project, matching)
+		if err != nil {
+			return fmt.Errorf("fetching pipelines for project %s: %w", *project, err)
+		}
+
+		records := make([]pipelineRecord, len(pipelines))
+		for i, pipeline := range pipelines {
+			records[i] = newPipelineRecord(pipeline)
+		}
+		return writeOutput(os.Stdout, *output, records, func(w io.Writer) error {
+			tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(tw, "ID\tSTATUS\tREF\tSHA\tSOURCE\tCREATED\tDURATION")
+			for _, pipeline := range pipelines {
+				fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%s\t%s\n", pipeline.ID, pipeline.Status, pipeline.Ref, shortSHA(pipeline.SHA),
+					pipeline.Source, formatTime(pipeline.CreatedAt), formatDuration(pipeline.Duration))
+			}
+			return tw.Flush()
+		})
+	}
+}
+
+func setupJobsList(flags *flag.FlagSet) func(args []string) error {
+	project := flags.String("p", "", "project path or ID, e.g. group/project")
+	pipelineID := flags.Int("pipeline", 0, "pipeline ID")
+	output := addOutputFlag(flags)
+	return func(args []string) error {
+		if err := requireFlag(flags, "p", *project); err != nil {
+			return err
+		}
+		if *pipelineID == 0 {
+			return requireFlag(flags, "pipeline", "")
+		}
+		if err := validateOutputFormat(flags, *output); err != nil {
+			return err
+		}
+
+		jobs, err := listPipelineJobs(*project, strconv.Itoa(*pipelineID))
+		if err != nil {
+			return fmt.Errorf("fetching jobs of pipeline #%d: %w", *pipelineID, err)
+		}
+
+		jobs = jobsOldestFirst(jobs)
+		records := make([]jobRecord, len(jobs))
+		for i, job := range jobs {
+			records[i] = newJobRecord(job)
+		}
+		return writeOutput(os.Stdout, *output, records, func(w io.Writer) error {
+			tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(tw, "ID\tSTATUS\tSTAGE\tNAME\tDURATION")
+			for _, job := range jobs {
+				fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\n", job.ID, job.Status, job.Stage, job.Name, formatDuration(int(job.Duration)))
+			}
+			return tw.Flush()
+		})
+	}
+}
+
+func setupJobRetry(flags *flag.FlagSet) func(positional []string) error {
+	project := flags.String("p", "", "project path or ID, e.g. group/project")
+	output := addOutputFlag(flags)
+	return func(positional []string) error {
+		if err := requireFlag(flags, "p", *project); err != nil {
+			return err
+		}
+		if err := validateOutputFormat(flags, *output); err != nil {
+			return err
+		}
+		if len(positional) != 1 {
+			flags.Usage()
+			return errUsage
+		}
+		jobID, err := strconv.Atoi(positional[0])
+		if err != nil {
+			return fmt.Errorf("invalid job ID %q", positional[0])
+		}
+
+		job, _, err := gitlabClient.Jobs().RetryJob(*project, jobID)
+		if err != nil {
+			return fmt.Errorf("retrying job %d: %w", jobID, err)
+		}
+		runEventHook("on_job_retried", jobHookEnv(*project, job, jobID))
+		return writeOutput(os.Stdout, *output, newJobRecord(job), func(w io.Writer) error {
+			_, err := fmt.Fprintf(w, "Job %d retried as job %d: %s\n", jobID, job.ID, job.WebURL)
+			return err
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package ui
+
+import (
+	"errors"
+	"flag"
+	"io"
+	"testing"
+)
+
+func TestValidateCount(t *testing.T) {
+	tests := []struct {
+		count   int
+		wantErr bool
+	}{
+		{0, true},
+		{-5, true},
+		{1, false},
+		{maxCount, false},
+		{maxCount + 1, true},
+	}
+	for _, tt := range tests {
+		flags := flag.NewFlagSet("list", flag.ContinueOnError)
+		flags.SetOutput(io.Discard)
+		err := validateCount(flags, tt.count)
+		if tt.wantErr != errors.Is(err, errUsage) || (!tt.wantErr && err != nil) {
+			t.Errorf("validateCount(%d) = %v, want error %v", tt.count, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+// clipboard.go
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/rivo/tview"
+	"github.com/xanzy/go-gitlab"
+)
+
+// yankTarget is something y can copy: what it is and its text.
+type yankTarget struct {
+	label string
+	value string
+}
+
+func pipelineYankTargets(pipeline *gitlab.Pipeline) []yankTarget {
+	return []yankTarget{
+		{"Pipeline URL", pipeline.WebURL},
+		{"Commit SHA", pipeline.SHA},
+		{"Pipeline ID", strconv.Itoa(pipeline.ID)},
+	}
+}
+
+func jobYankTargets(job *gitlab.Job) []yankTarget {
+	return []yankTarget{
+		{"Job URL", job.WebURL},
+		{"Job ID", strconv.Itoa(job.ID)},
+		{"Commit SHA", job.Pipeline.Sha},
+	}
+}
+
+// showYankMenu asks which of targets to copy to the clipboard in a modal,
+// then goes back to the current view.
+func showYankMenu(app *tview.Application, targets []yankTarget) {
+	labels := make([]string, 0, len(targets)+1)
+	for _, target := range targets {
+		labels = append(labels, target.label)
+	}
+	labels = append(labels, "Cancel")
+
+	modal := tview.NewModal().
+		SetText("Copy to clipboard").
+		AddButtons(labels).
+		SetDoneFunc(func(buttonIndex int, buttonLabel string) {
+			routes.dismiss()
+			if buttonIndex < 0 || buttonIndex >= len(targets) {
+				return
+			}
+			target := targets[buttonIndex]
+			if err := copyToClipboard(target.value); err != nil {
+				showError(app, err, nil)
+				return
+			}
+			showInfo(app, fmt.Sprintf("Copied %s: %s", strings.ToLower(target.label), target.value))
+		})
+
+	routes.overlay(modal)
+}
+
+// copyToClipboard puts text on the system clipboard with pbcopy on macOS,
+// clip on Windows and wl-copy, xclip or xsel elsewhere, whichever is
+// installed.
+func copyToClipboard(text string) error {
+	if text == "" {
+		return errors.New("nothing to copy")
+	}
+
+	var candidates [][]string
+	switch runtime.GOOS {
+	case "darwin":
+		candidates = [][]string{{"pbcopy"}}
+	case "windows":
+		candidates = [][]string{{"clip"}}
+	default:
+		if os.Getenv("WAYLAND_DISPLAY") != "" {
+			candidates = append(candidates, []string{"wl-copy"})
+		}
+		candidates = append(candidates,
+			[]string{"xclip", "-selection", "clipboard"},
+			[]string{"xsel", "--clipboard", "--input"})
+	}
+
+	for _, candidate := range candidates {
+		path, err := exec.LookPath(candidate[0])
+		if err != nil {
+			continue
+		}
+		cmd := exec.Command(path, candidate[1:]...)
+		cmd.Stdin = strings.NewReader(text)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("copying to clipboard with %s: %w: %s", candidate[0], err, strings.TrimSpace(string(output)))
+		}
+		return nil
+	}
+	return fmt.Errorf("copying to clipboard: none of %s is installed", clipboardCommandNames(candidates))
+}
+
+func clipboardCommandNames(candidates [][]string) string {
+	names := make([]string, len(candidates))
+	for i, candidate := range candidates {
+		names[i] = candidate[0]
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,228 @@
+// codequality.go
+package ui
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// qualityFinding is one entry of a code quality report, which follows the
+// Code Climate format.
+type qualityFinding struct {
+	Description string `json:"description"`
+	CheckName   string `json:"check_name"`
+	Severity    string `json:"severity"`
+	Location    struct {
+		Path  string `json:"path"`
+		Lines struct {
+			Begin int `json:"begin"`
+		} `json:"lines"`
+		Positions struct {
+			Begin struct {
+				Line int `json:"line"`
+			} `json:"begin"`
+		} `json:"positions"`
+	} `json:"location"`
+}
+
+// qualitySeverities are the severities of code quality findings, least
+// severe first.
+var qualitySeverities = []string{"info", "minor", "major", "critical", "blocker"}
+
+var qualitySeverityColors = map[string]tcell.Color{
+	"info":     tcell.ColorGray,
+	"minor":    tcell.ColorBlue,
+	"major":    tcell.ColorYellow,
+	"critical": tcell.ColorRed,
+	"blocker":  tcell.ColorFuchsia,
+}
+
+func qualitySeverityRank(severity string) int {
+	for i, s := range qualitySeverities {
+		if s == severity {
+			return i
+		}
+	}
+	return -1
+}
+
+func (f qualityFinding) line() int {
+	if f.Location.Lines.Begin > 0 {
+		return f.Location.Lines.Begin
+	}
+	return f.Location.Positions.Begin.Line
+}
+
+func (f qualityFinding) location() string {
+	if line := f.line(); line > 0 {
+		return fmt.Sprintf("%s:%d", f.Location.Path, line)
+	}
+	return f.Location.Path
+}
+
+type qualityColumn struct {
+	title string
+	less  func(a, b qualityFinding) bool
+}
+
+var qualityColumns = []qualityColumn{
+	{"Severity", func(a, b qualityFinding) bool {
+		return qualitySeverityRank(a.Severity) < qualitySeverityRank(b.Severity)
+	}},
+	{"Location", func(a, b qualityFinding) bool {
+		if a.Location.Path != b.Location.Path {
+			return a.Location.Path < b.Location.Path
+		}
+		return a.line() < b.line()
+	}},
+	{"Check", func(a, b qualityFinding) bool { return a.CheckName < b.CheckName }},
+	{"Description", func(a, b qualityFinding) bool { return a.Description < b.Description }},
+}
+
+// listQualityFindings downloads and merges the code quality reports of the
+// jobs of a pipeline.
+func listQualityFindings(projectID, pipelineID string) ([]qualityFinding, int, error) {
+	jobs, err := listPipelineJobs(projectID, pipelineID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("fetching jobs for pipeline %s: %w", pipelineID, err)
+	}
+
+	reports := findReports(jobs, "codequality")
+	var findings []qualityFinding
+	for _, report := range reports {
+		data, err := downloadReport(projectID, report)
+		if err != nil {
+			return nil, 0, err
+		}
+		var reportFindings []qualityFinding
+		if err := json.Unmarshal(data, &reportFindings); err != nil {
+			return nil, 0, fmt.Errorf("parsing %s of job %s: %w", report.filename, report.job.Name, err)
+		}
+		findings = append(findings, reportFindings...)
+	}
+	return findings, len(reports), nil
+}
+
+// showCodeQuality lists the code quality findings of a pipeline. The number
+// keys sort by a column and f cycles the lowest severity shown. back is
+// called on Esc.
+func showCodeQuality(app *tview.Application, projectID, pipelineID string, back func()) {
+	cancelPendingLoads()
+
+	table := tview.NewTable().
+		SetSelectable(true, false).
+		SetFixed(1, 0)
+	table.SetBorder(true)
+	statusBar := newStatusBar()
+
+	var (
+		findings    []qualityFinding
+		reports     int
+		minSeverity int
+	)
+	// The findings are sorted by the same column and direction rules as the
+	// pipeline table.
+	order := pipelineSort{column: 0, descending: true}
+
+	fillTable := func() {
+		var shown []qualityFinding
+		for _, finding := range findings {
+			if qualitySeverityRank(finding.Severity) >= minSeverity {
+				shown = append(shown, finding)
+			}
+		}
+		less := qualityColumns[order.column].less
+		sort.SliceStable(shown, func(i, j int) bool {
+			if order.descending {
+				return less(shown[j], shown[i])
+			}
+			return less(shown[i], shown[j])
+		})
+
+		title := fmt.Sprintf(" Code quality of pipeline #%s: %d findings ", pipelineID, len(shown))
+		if minSeverity > 0 {
+			title = fmt.Sprintf(" Code quality of pipeline #%s: %d findings of %s or worse ", pipelineID, len(shown), qualitySeverities[minSeverity])
+		}
+		table.SetTitle(title)
+
+		table.Clear()
+		for column, c := range qualityColumns {
+			title := fmt.Sprintf("%d %s", column+1, c.title)
+			if column == order.column {
+				title += " " + sortArrow(order.descending)
+			}
+			table.SetCell(0, column, tview.NewTableCell(title).
+				SetTextColor(tcell.ColorYellow).
+				SetAttributes(tcell.AttrBold).
+				SetSelectable(false))
+		}
+
+		for i, finding := range shown {
+			row := i + 1
+			table.SetCell(row, 0, tview.NewTableCell(finding.Severity).
+				SetTextColor(qualitySeverityColors[finding.Severity]))
+			table.SetCell(row, 1, tview.NewTableCell(tview.Escape(finding.location())))
+			table.SetCell(row, 2, tview.NewTableCell(tview.Escape(finding.CheckName)))
+			table.SetCell(row, 3, tview.NewTableCell(tview.Escape(finding.Description)).SetExpansion(1))
+		}
+
+		if len(shown) == 0 {
+			message := "No findings"
+			if reports == 0 {
+				message = "No job of this pipeline uploaded a code quality report"
+			}
+			table.SetCell(1, 0, tview.NewTableCell(message).
+				SetTextColor(tcell.ColorGray).
+				SetSelectable(false))
+		}
+	}
+
+	reloadFindings := func() (func(), error) {
+		loaded, count, err := listQualityFindings(projectID, pipelineID)
+		if err != nil {
+			return nil, err
+		}
+		return func() {
+			findings = loaded
+			reports = count
+			fillTable()
+		}, nil
+	}
+
+	table.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch {
+		case event.Key() == tcell.KeyEsc:
+			back()
+			return nil
+		case isRefreshKey(event):
+			fetchInBackground(app, statusBar, "Refreshing...", reloadFindings)
+			return nil
+		case event.Rune() == 'f':
+			minSeverity = (minSeverity + 1) % len(qualitySeverities)
+			fillTable()
+			return nil
+		case event.Key() == tcell.KeyRune:
+			if column := strings.IndexRune("1234", event.Rune()); column >= 0 {
+				order.toggle(column)
+				fillTable()
+				return nil
+			}
+		}
+		return event
+	})
+
+	flex := tview.NewFlex().
+		SetDirection(tview.FlexRow).
+		AddItem(table, 0, 1, true).
+		AddItem(statusBar, 1, 0, false).
+		AddItem(tview.NewButton("1-4 - Sort   f - Minimum severity   ESC - Back").SetSelectedFunc(back), 1, 0, false)
+
+	routes.show("code-quality/"+projectID+"/"+pipelineID, flex, table)
+
+	fetchInBackground(app, statusBar, "Loading code quality report...", reloadFindings)
+}
@@ -0,0 +1,145 @@
+// commits.go
+package ui
+
+import (
+	"fmt"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/xanzy/go-gitlab"
+)
+
+// commitHistorySize is how many of the latest commits of a branch are shown.
+const commitHistorySize = 50
+
+func listBranchCommits(projectID, branch string) ([]*gitlab.Commit, error) {
+	commits, _, err := gitlabClient.Commits().ListCommits(projectID, &gitlab.ListCommitsOptions{
+		ListOptions: gitlab.ListOptions{PerPage: commitHistorySize},
+		RefName:     gitlab.String(branch),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("fetching commits of %s: %w", branch, err)
+	}
+	return commits, nil
+}
+
+// listCommitPipelines returns the latest pipeline for ref of each commit,
+// keyed by SHA. It looks at as many pipelines as there are commits shown,
+// plus some for commits that ran several.
+func listCommitPipelines(projectID, ref string) (map[string]*gitlab.PipelineInfo, error) {
+	pipelines, _, err := gitlabClient.Pipelines().ListProjectPipelines(projectID, &gitlab.ListProjectPipelinesOptions{
+		ListOptions: gitlab.ListOptions{PerPage: 2 * commitHistorySize},
+		Ref:         gitlab.String(ref),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("fetching pipelines of %s: %w", ref, err)
+	}
+
+	latest := map[string]*gitlab.PipelineInfo{}
+	for _, pipeline := range pipelines {
+		if _, ok := latest[pipeline.SHA]; !ok {
+			latest[pipeline.SHA] = pipeline
+		}
+	}
+	return latest, nil
+}
+
+// showCommits lists the latest commits of branch with the status of their
+// latest pipeline. Enter shows the pipelines of the selected commit. back is
+// called on Esc.
+func showCommits(app *tview.Application, projectID, branch string, back func()) {
+	cancelPendingLoads()
+
+	table := tview.NewTable().
+		SetSelectable(true, false).
+		SetFixed(1, 0)
+	table.SetBorder(true).
+		SetTitle(fmt.Sprintf(" Commits on %s ", tview.Escape(branch)))
+	statusBar := newStatusBar()
+
+	var commits []*gitlab.Commit
+
+	fillTable := func(pipelines map[string]*gitlab.PipelineInfo) {
+		row, _ := table.GetSelection()
+		table.Clear()
+
+		for column, title := range []string{"Pipeline", "SHA", "Title", "Author", "Committed"} {
+			table.SetCell(0, column, tview.NewTableCell(title).
+				SetTextColor(tcell.ColorYellow).
+				SetAttributes(tcell.AttrBold).
+				SetSelectable(false))
+		}
+
+		for i, commit := range commits {
+			status := "[gray]none[-]"
+			if pipeline := pipelines[commit.ID]; pipeline != nil {
+				status = statusLabel(pipeline.Status)
+			}
+			table.SetCell(i+1, 0, tview.NewTableCell(status))
+			table.SetCell(i+1, 1, tview.NewTableCell(commit.ShortID))
+			table.SetCell(i+1, 2, tview.NewTableCell(tview.Escape(commit.Title)).SetExpansion(1))
+			table.SetCell(i+1, 3, tview.NewTableCell(tview.Escape(commit.AuthorName)))
+			table.SetCell(i+1, 4, tview.NewTableCell(formatTime(commit.CommittedDate)))
+		}
+
+		if len(commits) == 0 {
+			table.SetCell(1, 0, tview.NewTableCell("No commits on this branch").
+				SetTextColor(tcell.ColorGray).
+				SetSelectable(false))
+			return
+		}
+		if row < 1 || row > len(commits) {
+			row = 1
+		}
+		table.Select(row, 0)
+	}
+
+	reloadCommits := func() (func(), error) {
+		loaded, err := listBranchCommits(projectID, branch)
+		if err != nil {
+			return nil, err
+		}
+		pipelines, err := listCommitPipelines(projectID, branch)
+		if err != nil {
+			return nil, err
+		}
+		return func() {
+			commits = loaded
+			fillTable(pipelines)
+		}, nil
+	}
+
+	table.SetSelectedFunc(func(row, column int) {
+		if row < 1 || row > len(commits) {
+			return
+		}
+		filter, err := parsePipelineFilter("sha=" + commits[row-1].ID)
+		if err != nil {
+			showError(app, err, nil)
+			return
+		}
+		showFilteredPipelines(app, projectID, branch, filter)
+	})
+
+	table.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch {
+		case event.Key() == tcell.KeyEsc:
+			back()
+			return nil
+		case isRefreshKey(event):
+			fetchInBackground(app, statusBar, "Refreshing...", reloadCommits)
+			return nil
+		}
+		return event
+	})
+
+	flex := tview.NewFlex().
+		SetDirection(tview.FlexRow).
+		AddItem(table, 0, 1, true).
+		AddItem(statusBar, 1, 0, false).
+		AddItem(tview.NewButton("ENTER - Pipelines of commit   ESC - Back").SetSelectedFunc(back), 1, 0, false)
+
+	routes.show("commits/"+projectID+"/"+branch, flex, table)
+
+	fetchInBackground(app, statusBar, "Loading commits...", reloadCommits)
+}
@@ -0,0 +1,214 @@
+// compare.go
+package ui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/xanzy/go-gitlab"
+)
+
+// pipelineComparison is what changed between two pipelines: the commits
+// between them and every job of either one, by name.
+type pipelineComparison struct {
+	older   *gitlab.Pipeline
+	newer   *gitlab.Pipeline
+	commits []*gitlab.Commit
+	jobs    []jobComparison
+}
+
+// jobComparison pairs the latest attempts of a job in both pipelines. Either
+// is nil if the job only ran in the other pipeline.
+type jobComparison struct {
+	name  string
+	stage string
+	older *gitlab.Job
+	newer *gitlab.Job
+}
+
+// lastSuccessBefore returns the newest successful pipeline among pipelines
+// that is older than pipeline, or nil if there is none.
+func lastSuccessBefore(pipelines []*gitlab.Pipeline, pipeline *gitlab.Pipeline) *gitlab.Pipeline {
+	var found *gitlab.Pipeline
+	for _, candidate := range pipelines {
+		if candidate.Status != "success" || candidate.ID >= pipeline.ID {
+			continue
+		}
+		if found == nil || candidate.ID > found.ID {
+			found = candidate
+		}
+	}
+	return found
+}
+
+func comparePipelines(projectID string, a, b *gitlab.Pipeline) (*pipelineComparison, error) {
+	older, newer := a, b
+	if older.ID > newer.ID {
+		older, newer = newer, older
+	}
+	comparison := &pipelineComparison{older: older, newer: newer}
+
+	if older.SHA != newer.SHA {
+		compare, _, err := gitlabClient.Repositories().Compare(projectID, &gitlab.CompareOptions{
+			From: gitlab.String(older.SHA),
+			To:   gitlab.String(newer.SHA),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("comparing %s and %s: %w", shortSHA(older.SHA), shortSHA(newer.SHA), err)
+		}
+		comparison.commits = compare.Commits
+	}
+
+	olderJobs, err := listPipelineJobs(projectID, strconv.Itoa(older.ID))
+	if err != nil {
+		return nil, fmt.Errorf("fetching jobs of pipeline #%d: %w", older.ID, err)
+	}
+	newerJobs, err := listPipelineJobs(projectID, strconv.Itoa(newer.ID))
+	if err != nil {
+		return nil, fmt.Errorf("fetching jobs of pipeline #%d: %w", newer.ID, err)
+	}
+	comparison.jobs = pairJobs(olderJobs, newerJobs)
+	return comparison, nil
+}
+
+// pairJobs matches the jobs of two pipelines by name, in the order of the
+// newer pipeline followed by the jobs only the older one had. Jobs are
+// listed newest first, so the first of a name is its latest attempt.
+func pairJobs(olderJobs, newerJobs []*gitlab.Job) []jobComparison {
+	var pairs []jobComparison
+	index := map[string]int{}
+
+	for i := len(newerJobs) - 1; i >= 0; i-- {
+		job := newerJobs[i]
+		if _, ok := index[job.Name]; ok {
+			continue
+		}
+		index[job.Name] = len(pairs)
+		pairs = append(pairs, jobComparison{name: job.Name, stage: job.Stage})
+	}
+	for _, job := range newerJobs {
+		if pair := &pairs[index[job.Name]]; pair.newer == nil {
+			pair.newer = job
+		}
+	}
+
+	for i := len(olderJobs) - 1; i >= 0; i-- {
+		job := olderJobs[i]
+		if _, ok := index[job.Name]; ok {
+			continue
+		}
+		index[job.Name] = len(pairs)
+		pairs = append(pairs, jobComparison{name: job.Name, stage: job.Stage})
+	}
+	for _, job := range olderJobs {
+		if pair := &pairs[index[job.Name]]; pair.older == nil {
+			pair.older = job
+		}
+	}
+	return pairs
+}
+
+// showPipelineComparison shows what changed between pipelines a and b: the
+// commits in between, jobs added and removed, status changes and how much
+// longer or shorter each job took. back is called on Esc.
+func showPipelineComparison(app *tview.Application, projectID string, a, b *gitlab.Pipeline, back func()) {
+	cancelPendingLoads()
+
+	view := tview.NewTextView().
+		SetDynamicColors(true).
+		SetScrollable(true).
+		SetWordWrap(true)
+	view.SetBorder(true)
+	statusBar := newStatusBar()
+
+	reloadComparison := func() (func(), error) {
+		comparison, err := comparePipelines(projectID, a, b)
+		if err != nil {
+			return nil, err
+		}
+		return func() {
+			view.SetTitle(fmt.Sprintf(" Pipeline #%d → #%d ", comparison.older.ID, comparison.newer.ID))
+			view.SetText(formatPipelineComparison(comparison)).ScrollToBeginning()
+		}, nil
+	}
+
+	view.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch {
+		case event.Key() == tcell.KeyEsc:
+			back()
+			return nil
+		case isRefreshKey(event):
+			fetchInBackground(app, statusBar, "Refreshing...", reloadComparison)
+			return nil
+		}
+		return event
+	})
+
+	flex := tview.NewFlex().
+		SetDirection(tview.FlexRow).
+		AddItem(view, 0, 1, true).
+		AddItem(statusBar, 1, 0, false).
+		AddItem(tview.NewButton("r - Refresh   ESC - Back").SetSelectedFunc(back), 1, 0, false)
+
+	routes.show("compare/"+projectID+"/"+strconv.Itoa(a.ID)+"/"+strconv.Itoa(b.ID), flex, view)
+
+	fetchInBackground(app, statusBar, "Comparing pipelines...", reloadComparison)
+}
+
+func formatPipelineComparison(comparison *pipelineComparison) string {
+	var text strings.Builder
+
+	for _, pipeline := range []*gitlab.Pipeline{comparison.older, comparison.newer} {
+		fmt.Fprintf(&text, "[yellow]#%-10d[-] %s  %s on %s  %s  %s\n", pipeline.ID, statusLabel(pipeline.Status),
+			shortSHA(pipeline.SHA), tview.Escape(pipeline.Ref), formatTime(pipeline.CreatedAt), formatDuration(pipeline.Duration))
+	}
+
+	text.WriteString("\n[yellow::b]Commits[-::-]\n")
+	if comparison.older.SHA == comparison.newer.SHA {
+		text.WriteString("[gray]Both pipelines ran on the same commit.[-]\n")
+	} else if len(comparison.commits) == 0 {
+		text.WriteString("[gray]The newer commit isn't ahead of the older one.[-]\n")
+	}
+	for _, commit := range comparison.commits {
+		fmt.Fprintf(&text, "%s %s [gray]%s[-]\n", shortSHA(commit.ID), tview.Escape(commit.Title), tview.Escape(commit.AuthorName))
+	}
+
+	text.WriteString("\n[yellow::b]Jobs[-::-]\n")
+	for _, pair := range comparison.jobs {
+		name := fmt.Sprintf("%-30s %-12s", tview.Escape(pair.name), tview.Escape(pair.stage))
+		switch {
+		case pair.older == nil:
+			fmt.Fprintf(&text, "[green]+[-] %s %s  %s\n", name, statusLabel(pair.newer.Status), formatDuration(int(pair.newer.Duration)))
+		case pair.newer == nil:
+			fmt.Fprintf(&text, "[red]-[-] %s %s  %s\n", name, statusLabel(pair.older.Status), formatDuration(int(pair.older.Duration)))
+		default:
+			marker := " "
+			if pair.older.Status != pair.newer.Status {
+				marker = "[orange]~[-]"
+			}
+			fmt.Fprintf(&text, "%s %s %s → %s  %s\n", marker, name, statusLabel(pair.older.Status), statusLabel(pair.newer.Status),
+				formatDurationDelta(int(pair.older.Duration), int(pair.newer.Duration)))
+		}
+	}
+	return text.String()
+}
+
+// formatDurationDelta shows how the duration of a job changed, in red if it
+// got slower and green if it got faster.
+func formatDurationDelta(older, newer int) string {
+	if older == 0 || newer == 0 {
+		return formatDuration(newer)
+	}
+	delta := newer - older
+	switch {
+	case delta > 0:
+		return fmt.Sprintf("%s [red](+%s)[-]", formatDuration(newer), formatDuration(delta))
+	case delta < 0:
+		return fmt.Sprintf("%s [green](-%s)[-]", formatDuration(newer), formatDuration(-delta))
+	default:
+		return formatDuration(newer)
+	}
+}
@@ -0,0 +1,186 @@
+// completion.go
+package ui
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// completeCommand is the hidden command the completion scripts call with the
+// words typed so far to get the candidates for the last one.
+const completeCommand = "__complete"
+
+// offlineCommand reports whether the command named by the first argument
+// works without a GitLab token or client, so completion works anywhere.
+func offlineCommand(name string) bool {
+	return name == "completion" || name == completeCommand
+}
+
+var completionScripts = map[string]string{
+	"bash": `# bash completion for gpv
+_gpv() {
+    local IFS=$'\n'
+    COMPREPLY=($(compgen -W "$(gpv ` + completeCommand + ` "${COMP_WORDS[@]:1:COMP_CWORD}" 2>/dev/null)" -- "${COMP_WORDS[COMP_CWORD]}"))
+}
+complete -o default -F _gpv gpv
+`,
+	"zsh": `#compdef gpv
+# zsh completion for gpv
+_gpv() {
+    local -a candidates
+    candidates=("${(@f)$(gpv ` + completeCommand + ` "${(@)words[2,CURRENT]}" 2>/dev/null)}")
+    candidates=(${candidates:#})
+    compadd -a candidates
+}
+compdef _gpv gpv
+`,
+	"fish": `# fish completion for gpv
+complete -c gpv -f -a '(gpv ` + completeCommand + ` (commandline -opc)[2..-1] (commandline -ct) 2>/dev/null)'
+`,
+}
+
+// setupCompletion sets up the completion command, which prints the
+// completion script for a shell.
+func setupCompletion(flags *flag.FlagSet) func(positional []string) error {
+	return func(positional []string) error {
+		if len(positional) != 1 || completionScripts[positional[0]] == "" {
+			flags.Usage()
+			return errUsage
+		}
+		_, err := io.WriteString(os.Stdout, completionScripts[positional[0]])
+		return err
+	}
+}
+
+// completeWords returns the candidates for the last of words, the arguments
+// typed after gpv so far: command names, the flags of the command, output
+// formats, and the paths of recent and favorite projects for -p.
+func completeWords(words []string) []string {
+	if len(words) == 0 {
+		words = []string{""}
+	}
+	typed, current := words[:len(words)-1], words[len(words)-1]
+
+	name := ""
+	if len(typed) > 0 {
+		name = typed[0]
+		if len(typed) > 1 {
+			if _, ok := cliCommands[name+" "+typed[1]]; ok {
+				name += " " + typed[1]
+			}
+		}
+	}
+	command, ok := cliCommands[name]
+	if !ok {
+		return completeCommandNames(typed)
+	}
+
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	command.setup(flags)
+
+	if len(typed) > len(strings.Fields(name)) {
+		previous := strings.TrimLeft(typed[len(typed)-1], "-")
+		if f := flags.Lookup(previous); f != nil && strings.HasPrefix(typed[len(typed)-1], "-") && !isBoolFlag(f) {
+			return completeFlagValue(previous)
+		}
+	}
+
+	if strings.HasPrefix(current, "-") {
+		var names []string
+		flags.VisitAll(func(f *flag.Flag) {
+			if len(f.Name) == 1 {
+				names = append(names, "-"+f.Name)
+			} else {
+				names = append(names, "--"+f.Name)
+			}
+		})
+		return names
+	}
+	if name == "completion" {
+		return completionShells()
+	}
+	if name == "tail" {
+		return recentProjectPaths()
+	}
+	return nil
+}
+
+// completeCommandNames returns the first words of the commands, or the
+// second words of those starting with the one typed.
+func completeCommandNames(typed []string) []string {
+	seen := map[string]bool{}
+	var names []string
+	for name := range cliCommands {
+		words := strings.Fields(name)
+		var candidate string
+		switch {
+		case len(typed) == 0:
+			candidate = words[0]
+		case len(typed) == 1 && len(words) > 1 && words[0] == typed[0]:
+			candidate = words[1]
+		default:
+			continue
+		}
+		if !seen[candidate] {
+			seen[candidate] = true
+			names = append(names, candidate)
+		}
+	}
+	if len(typed) == 0 {
+		names = append(names, "help")
+	}
+	sort.Strings(names)
+	return names
+}
+
+func completeFlagValue(name string) []string {
+	switch name {
+	case "p":
+		return recentProjectPaths()
+	case "o":
+		return outputFormats
+	}
+	return nil
+}
+
+// recentProjectPaths returns the paths of the favorite and recently opened
+// projects, which are known without asking GitLab.
+func recentProjectPaths() []string {
+	seen := map[string]bool{}
+	var paths []string
+	for _, projects := range [][]stateProject{state.Favorites, state.Recent} {
+		for _, project := range projects {
+			if !seen[project.Path] {
+				seen[project.Path] = true
+				paths = append(paths, project.Path)
+			}
+		}
+	}
+	return paths
+}
+
+func completionShells() []string {
+	shells := make([]string, 0, len(completionScripts))
+	for shell := range completionScripts {
+		shells = append(shells, shell)
+	}
+	sort.Strings(shells)
+	return shells
+}
+
+func isBoolFlag(f *flag.Flag) bool {
+	b, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
+
+// printCompletions prints the candidates for words one per line, as the
+// completion scripts expect.
+func printCompletions(words []string) {
+	for _, candidate := range completeWords(words) {
+		fmt.Println(candidate)
+	}
+}
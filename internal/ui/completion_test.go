@@ -0,0 +1,37 @@
+package ui
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCompleteWords(t *testing.T) {
+	saved := state
+	defer func() { state = saved }()
+	state = &appState{
+		Recent:    []stateProject{{ID: 1, Path: "group/recent"}, {ID: 2, Path: "group/both"}},
+		Favorites: []stateProject{{ID: 2, Path: "group/both"}, {ID: 3, Path: "group/favorite"}},
+	}
+	projects := []string{"group/both", "group/favorite", "group/recent"}
+
+	tests := []struct {
+		words []string
+		want  []string
+	}{
+		{nil, []string{"completion", "exporter", "help", "job", "jobs", "pipelines", "tail", "trigger", "wait", "watch"}},
+		{[]string{"pipelines", ""}, []string{"list"}},
+		{[]string{"pipelines", "list", "-o", ""}, outputFormats},
+		{[]string{"pipelines", "list", "-p", ""}, projects},
+		{[]string{"tail", ""}, projects},
+		{[]string{"completion", ""}, []string{"bash", "fish", "zsh"}},
+		{[]string{"wait", "--latest", ""}, nil},
+		{[]string{"job", "retry", "-"}, []string{"-o", "-p"}},
+		{[]string{"bogus", "x", ""}, nil},
+	}
+	for _, tt := range tests {
+		got := completeWords(tt.words)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("completeWords(%q) = %q, want %q", tt.words, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,23 @@
+// confirm.go
+package ui
+
+import (
+	"github.com/rivo/tview"
+)
+
+// confirmAction asks the user to confirm message in a modal. Either way it
+// then goes back to the current view and calls action only if the user
+// pressed confirmLabel.
+func confirmAction(app *tview.Application, message, confirmLabel string, action func()) {
+	modal := tview.NewModal().
+		SetText(message).
+		AddButtons([]string{confirmLabel, "Cancel"}).
+		SetDoneFunc(func(buttonIndex int, buttonLabel string) {
+			routes.dismiss()
+			if buttonLabel == confirmLabel {
+				action()
+			}
+		})
+
+	routes.overlay(modal)
+}
@@ -0,0 +1,129 @@
+// coverage.go
+package ui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/xanzy/go-gitlab"
+)
+
+// coverageTrendPipelines is how many of the latest pipelines of a branch the
+// coverage trend covers.
+const coverageTrendPipelines = 30
+
+// coverageBarWidth is the width of a bar at 100% coverage.
+const coverageBarWidth = 50
+
+// pipelineCoverage parses the coverage GitLab reports for pipeline. It
+// reports false if the pipeline has none.
+func pipelineCoverage(pipeline *gitlab.Pipeline) (float64, bool) {
+	if pipeline.Coverage == "" {
+		return 0, false
+	}
+	coverage, err := strconv.ParseFloat(pipeline.Coverage, 64)
+	return coverage, err == nil
+}
+
+func formatCoverage(pipeline *gitlab.Pipeline) string {
+	coverage, ok := pipelineCoverage(pipeline)
+	if !ok {
+		return "-"
+	}
+	return fmt.Sprintf("%.2f%%", coverage)
+}
+
+// showCoverageTrend charts the coverage of the latest pipelines of branch,
+// oldest first, with the change from the pipeline before. back is called on
+// Esc.
+func showCoverageTrend(app *tview.Application, projectID, branch string, back func()) {
+	cancelPendingLoads()
+
+	view := tview.NewTextView().
+		SetDynamicColors(true).
+		SetScrollable(true)
+	view.SetBorder(true).
+		SetTitle(fmt.Sprintf(" Coverage of %s ", tview.Escape(branch)))
+	statusBar := newStatusBar()
+
+	reloadTrend := func() (func(), error) {
+		infos, _, err := gitlabClient.Pipelines().ListProjectPipelines(projectID, &gitlab.ListProjectPipelinesOptions{
+			ListOptions: gitlab.ListOptions{PerPage: coverageTrendPipelines},
+			Ref:         gitlab.String(branch),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("fetching pipelines for project %s and branch %s: %w", projectID, branch, err)
+		}
+		pipelines, err := listPipelineDetails(projectID, infos)
+		if err != nil {
+			return nil, fmt.Errorf("fetching pipelines for project %s and branch %s: %w", projectID, branch, err)
+		}
+		return func() {
+			view.SetText(formatCoverageTrend(pipelines)).ScrollToEnd()
+		}, nil
+	}
+
+	view.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch {
+		case event.Key() == tcell.KeyEsc:
+			back()
+			return nil
+		case isRefreshKey(event):
+			fetchInBackground(app, statusBar, "Refreshing...", reloadTrend)
+			return nil
+		}
+		return event
+	})
+
+	flex := tview.NewFlex().
+		SetDirection(tview.FlexRow).
+		AddItem(view, 0, 1, true).
+		AddItem(statusBar, 1, 0, false).
+		AddItem(tview.NewButton("r - Refresh   ESC - Back").SetSelectedFunc(back), 1, 0, false)
+
+	routes.show("coverage/"+projectID+"/"+branch, flex, view)
+
+	fetchInBackground(app, statusBar, "Loading coverage...", reloadTrend)
+}
+
+// formatCoverageTrend renders one bar per pipeline with coverage. pipelines
+// come newest first, as GitLab lists them.
+func formatCoverageTrend(pipelines []*gitlab.Pipeline) string {
+	var text strings.Builder
+	bar := "█"
+	if asciiIcons {
+		bar = "#"
+	}
+
+	previous, hasPrevious := 0.0, false
+	for i := len(pipelines) - 1; i >= 0; i-- {
+		pipeline := pipelines[i]
+		coverage, ok := pipelineCoverage(pipeline)
+		if !ok {
+			continue
+		}
+
+		change := ""
+		switch {
+		case !hasPrevious:
+		case coverage > previous:
+			change = fmt.Sprintf("[green]+%.2f[-]", coverage-previous)
+		case coverage < previous:
+			change = fmt.Sprintf("[red]%.2f[-]", coverage-previous)
+		}
+		previous, hasPrevious = coverage, true
+
+		width := int(coverage / 100 * coverageBarWidth)
+		fmt.Fprintf(&text, "#%-10d %s  %s %-*s %6.2f%% %s\n",
+			pipeline.ID, formatTime(pipeline.CreatedAt), statusIcon(pipeline.Status),
+			coverageBarWidth, strings.Repeat(bar, width), coverage, change)
+	}
+
+	if !hasPrevious {
+		return fmt.Sprintf("None of the last %d pipelines report coverage.", len(pipelines))
+	}
+	return text.String()
+}
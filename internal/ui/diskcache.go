@@ -0,0 +1,185 @@
+// diskcache.go
+package ui
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"main.go/internal/config"
+)
+
+// The kinds of data kept in the on-disk cache. Each kind has its own
+// directory so it can be dropped on its own, like the tree on refresh.
+const (
+	treeCache     = "tree"
+	branchCache   = "branches"
+	pipelineCache = "pipelines"
+)
+
+// cacheTTLs is how long an entry of each kind is used. Pipelines are also
+// checked against their updated_at, so they can be kept much longer.
+var cacheTTLs = map[string]time.Duration{
+	treeCache:     time.Hour,
+	branchCache:   2 * time.Minute,
+	pipelineCache: 7 * 24 * time.Hour,
+}
+
+// cacheEntry is the file an entry is stored in.
+type cacheEntry struct {
+	StoredAt time.Time       `json:"stored_at"`
+	Value    json.RawMessage `json:"value"`
+}
+
+// cacheDir returns the cache directory with a directory per instance and
+// token, so different accounts never share entries.
+func cacheDir() (string, error) {
+	dir, err := config.CacheDir()
+	if err != nil {
+		return "", err
+	}
+	account := sha256.Sum256([]byte(gitlabURL + "\x00" + token))
+	return filepath.Join(dir, hex.EncodeToString(account[:8])), nil
+}
+
+func cacheFilePath(kind, key string) (string, error) {
+	dir, err := cacheDir()
+	if err != nil {
+		return "", err
+	}
+	name := sha256.Sum256([]byte(key))
+	return filepath.Join(dir, kind, hex.EncodeToString(name[:12])+".json"), nil
+}
+
+// readCache decodes the entry of kind stored under key into value, and
+// reports whether there was one younger than the TTL of kind.
+func readCache(kind, key string, value interface{}) bool {
+	if cfg.NoCache {
+		return false
+	}
+	path, err := cacheFilePath(kind, key)
+	if err != nil {
+		return false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		logDebug("ignoring corrupt cache entry", "path", path, "error", err)
+		return false
+	}
+	if time.Since(entry.StoredAt) > cacheTTLs[kind] {
+		return false
+	}
+	if err := json.Unmarshal(entry.Value, value); err != nil {
+		logDebug("ignoring corrupt cache entry", "path", path, "error", err)
+		return false
+	}
+	return true
+}
+
+// writeCache stores value under key. The cache only saves requests, so
+// failing to write it is logged and otherwise ignored.
+func writeCache(kind, key string, value interface{}) {
+	if cfg.NoCache {
+		return
+	}
+	if err := writeCacheEntry(kind, key, value); err != nil {
+		logWarn("writing cache entry failed", "kind", kind, "error", err)
+	}
+}
+
+func writeCacheEntry(kind, key string, value interface{}) error {
+	path, err := cacheFilePath(kind, key)
+	if err != nil {
+		return err
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(cacheEntry{StoredAt: time.Now(), Value: encoded})
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+
+	// Entries are written concurrently by background fetches, so each goes
+	// to a temporary file renamed over the old one.
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".entry-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// cachedFetch returns the value of kind cached under key, or fetches and
+// caches it.
+func cachedFetch[T any](kind, key string, fetch func() (T, error)) (T, error) {
+	var value T
+	if readCache(kind, key, &value) {
+		return value, nil
+	}
+	value, err := fetch()
+	if err != nil {
+		return value, err
+	}
+	writeCache(kind, key, value)
+	return value, nil
+}
+
+// dropCache deletes every entry of kind, for when the user asks for fresh
+// data.
+func dropCache(kind string) {
+	dir, err := cacheDir()
+	if err != nil {
+		return
+	}
+	if err := os.RemoveAll(filepath.Join(dir, kind)); err != nil {
+		logWarn("dropping cache failed", "kind", kind, "error", err)
+	}
+}
+
+// pruneCache deletes the entries that are past their TTL, so the cache
+// doesn't grow with every pipeline ever opened. It is meant to be run in its
+// own goroutine.
+func pruneCache() {
+	dir, err := cacheDir()
+	if err != nil {
+		return
+	}
+	for kind, ttl := range cacheTTLs {
+		entries, err := os.ReadDir(filepath.Join(dir, kind))
+		if err != nil {
+			if !errors.Is(err, fs.ErrNotExist) {
+				logWarn("pruning cache failed", "kind", kind, "error", err)
+			}
+			continue
+		}
+		for _, entry := range entries {
+			info, err := entry.Info()
+			if err == nil && time.Since(info.ModTime()) > ttl {
+				os.Remove(filepath.Join(dir, kind, entry.Name()))
+			}
+		}
+	}
+}
@@ -0,0 +1,215 @@
+// durations.go
+package ui
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/xanzy/go-gitlab"
+)
+
+// durationWindows are the numbers of latest pipelines the duration chart can
+// cover, cycled with n.
+var durationWindows = []int{20, 50, 100}
+
+// durationBarWidth is the width of the bar of the slowest pipeline.
+const durationBarWidth = 50
+
+var sparklineLevels = []rune("▁▂▃▄▅▆▇█")
+
+// durationStats sums up the durations of a set of pipelines, in seconds.
+type durationStats struct {
+	average int
+	p95     int
+	slowest *gitlab.Pipeline
+}
+
+// listFinishedPipelines fetches the latest count pipelines of a project with
+// their details, dropping those that haven't finished and so have no
+// duration yet.
+func listFinishedPipelines(projectID string, count int) ([]*gitlab.Pipeline, error) {
+	infos, _, err := gitlabClient.Pipelines().ListProjectPipelines(projectID, &gitlab.ListProjectPipelinesOptions{
+		ListOptions: gitlab.ListOptions{PerPage: count},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("fetching pipelines for project %s: %w", projectID, err)
+	}
+	pipelines, err := listPipelineDetails(projectID, infos)
+	if err != nil {
+		return nil, fmt.Errorf("fetching pipelines for project %s: %w", projectID, err)
+	}
+
+	finished := pipelines[:0]
+	for _, pipeline := range pipelines {
+		if pipeline.Duration > 0 {
+			finished = append(finished, pipeline)
+		}
+	}
+	return finished, nil
+}
+
+func computeDurationStats(pipelines []*gitlab.Pipeline) durationStats {
+	var stats durationStats
+	if len(pipelines) == 0 {
+		return stats
+	}
+
+	durations := make([]int, len(pipelines))
+	total := 0
+	for i, pipeline := range pipelines {
+		durations[i] = pipeline.Duration
+		total += pipeline.Duration
+		if stats.slowest == nil || pipeline.Duration > stats.slowest.Duration {
+			stats.slowest = pipeline
+		}
+	}
+	sort.Ints(durations)
+
+	stats.average = total / len(durations)
+	stats.p95 = durations[int(math.Ceil(0.95*float64(len(durations))))-1]
+	return stats
+}
+
+// sparkline draws one block per value, scaled to the largest.
+func sparkline(values []int) string {
+	largest := 0
+	for _, value := range values {
+		if value > largest {
+			largest = value
+		}
+	}
+	if largest == 0 {
+		return ""
+	}
+
+	levels := sparklineLevels
+	if asciiIcons {
+		levels = []rune("_.-=^")
+	}
+	var line strings.Builder
+	for _, value := range values {
+		line.WriteRune(levels[value*(len(levels)-1)/largest])
+	}
+	return line.String()
+}
+
+// showDurations charts how long the latest pipelines of project took, with
+// their average, 95th percentile and the slowest one. n cycles through the
+// number of pipelines covered and Enter opens the slowest pipeline. back is
+// called on Esc.
+func showDurations(app *tview.Application, project *gitlab.Project, back func()) {
+	cancelPendingLoads()
+
+	projectID := strconv.Itoa(project.ID)
+	window := durationWindows[0]
+
+	view := tview.NewTextView().
+		SetDynamicColors(true).
+		SetScrollable(true)
+	view.SetBorder(true)
+	statusBar := newStatusBar()
+
+	setTitle := func() {
+		view.SetTitle(fmt.Sprintf(" Pipeline durations of %s (last %d) ", tview.Escape(project.PathWithNamespace), window))
+	}
+	setTitle()
+
+	var stats durationStats
+
+	reloadDurations := func() (func(), error) {
+		pipelines, err := listFinishedPipelines(projectID, window)
+		if err != nil {
+			return nil, err
+		}
+		return func() {
+			stats = computeDurationStats(pipelines)
+			view.SetText(formatDurations(pipelines, stats)).ScrollToBeginning()
+		}, nil
+	}
+
+	view.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch {
+		case event.Key() == tcell.KeyEsc:
+			back()
+			return nil
+		case isRefreshKey(event):
+			fetchInBackground(app, statusBar, "Refreshing...", reloadDurations)
+			return nil
+		case event.Key() == tcell.KeyEnter:
+			if stats.slowest != nil {
+				pipelineTrail = nil
+				showPipelineDetail(app, projectID, strconv.Itoa(stats.slowest.ID), stats.slowest.Ref)
+			}
+			return nil
+		case event.Rune() == 'n':
+			for i, size := range durationWindows {
+				if size == window {
+					window = durationWindows[(i+1)%len(durationWindows)]
+					break
+				}
+			}
+			setTitle()
+			fetchInBackground(app, statusBar, "Loading pipelines...", reloadDurations)
+			return nil
+		}
+		return event
+	})
+
+	flex := tview.NewFlex().
+		SetDirection(tview.FlexRow).
+		AddItem(view, 0, 1, true).
+		AddItem(statusBar, 1, 0, false).
+		AddItem(tview.NewButton("ENTER - Slowest pipeline   n - Number of pipelines   r - Refresh   ESC - Back").SetSelectedFunc(back), 1, 0, false)
+
+	routes.show("durations/"+strconv.Itoa(project.ID), flex, view)
+
+	fetchInBackground(app, statusBar, "Loading pipelines...", reloadDurations)
+}
+
+// formatDurations renders the stats followed by a sparkline and one bar per
+// pipeline, oldest first. pipelines come newest first, as GitLab lists them.
+func formatDurations(pipelines []*gitlab.Pipeline, stats durationStats) string {
+	if len(pipelines) == 0 {
+		return "None of these pipelines has finished yet."
+	}
+
+	var text strings.Builder
+	field := func(name, value string) {
+		fmt.Fprintf(&text, "[yellow]%-8s[-] %s\n", name, value)
+	}
+	field("Average", formatDuration(stats.average))
+	field("p95", formatDuration(stats.p95))
+	field("Slowest", fmt.Sprintf("%s  #%d on %s, %s", formatDuration(stats.slowest.Duration),
+		stats.slowest.ID, tview.Escape(stats.slowest.Ref), formatTime(stats.slowest.CreatedAt)))
+
+	durations := make([]int, len(pipelines))
+	for i, pipeline := range pipelines {
+		durations[len(pipelines)-1-i] = pipeline.Duration
+	}
+	fmt.Fprintf(&text, "\n%s\n\n", sparkline(durations))
+
+	bar := "█"
+	if asciiIcons {
+		bar = "#"
+	}
+	for i := len(pipelines) - 1; i >= 0; i-- {
+		pipeline := pipelines[i]
+		width := pipeline.Duration * durationBarWidth / stats.slowest.Duration
+		color := "white"
+		switch {
+		case pipeline == stats.slowest:
+			color = "red"
+		case pipeline.Duration > stats.p95:
+			color = "orange"
+		}
+		fmt.Fprintf(&text, "#%-10d %s  %s [%s]%-*s[-] %s\n",
+			pipeline.ID, formatTime(pipeline.CreatedAt), statusIcon(pipeline.Status),
+			color, durationBarWidth, strings.Repeat(bar, width), formatDuration(pipeline.Duration))
+	}
+	return text.String()
+}
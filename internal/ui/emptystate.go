@@ -0,0 +1,50 @@
+// emptystate.go
+package ui
+
+import (
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// newEmptyState returns a panel explaining why a view has nothing to show,
+// with buttons for the actions that make sense there. done receives the label
+// of the pressed button, or "" when the panel is dismissed with Esc.
+func newEmptyState(message string, buttons []string, done func(label string)) *tview.Modal {
+	emptyState := tview.NewModal().
+		SetText(message).
+		AddButtons(buttons).
+		SetBackgroundColor(tcell.ColorDefault).
+		SetDoneFunc(func(buttonIndex int, buttonLabel string) {
+			done(buttonLabel)
+		})
+
+	emptyState.SetBorderColor(tcell.ColorGray)
+	return emptyState
+}
+
+// newListPages puts list and its empty state on separate pages, showing the
+// list first.
+func newListPages(list, emptyState tview.Primitive) *tview.Pages {
+	return tview.NewPages().
+		AddPage("list", list, true, true).
+		AddPage("empty", emptyState, true, false)
+}
+
+// showEmptyState switches pages between the list and its empty state, moving
+// focus along if the user was on the page being hidden.
+func showEmptyState(app *tview.Application, pages *tview.Pages, list, emptyState tview.Primitive, empty bool) {
+	if empty {
+		focused := list.HasFocus()
+		pages.SwitchToPage("empty")
+		if focused {
+			app.SetFocus(emptyState)
+		}
+		return
+	}
+
+	focused := emptyState.HasFocus()
+	pages.SwitchToPage("list")
+	if focused {
+		app.SetFocus(list)
+	}
+}
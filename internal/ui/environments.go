@@ -0,0 +1,342 @@
+// environments.go
+package ui
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/xanzy/go-gitlab"
+)
+
+// deploymentHistorySize is how many deployments of an environment are shown,
+// newest first.
+const deploymentHistorySize = 50
+
+// listEnvironments fetches the environments of a project with their latest
+// deployment, which only the single environment endpoint includes.
+func listEnvironments(projectID int) ([]*gitlab.Environment, error) {
+	listed, err := listAllPages(func(listOptions gitlab.ListOptions) ([]*gitlab.Environment, *gitlab.Response, error) {
+		return gitlabClient.Environments().ListEnvironments(projectID, &gitlab.ListEnvironmentsOptions{ListOptions: listOptions})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("fetching environments: %w", err)
+	}
+
+	environments := make([]*gitlab.Environment, len(listed))
+	errs := make([]error, len(listed))
+
+	forEachParallel(len(listed), pipelineDetailWorkers, func(i int) {
+		environments[i], _, errs[i] = gitlabClient.Environments().GetEnvironment(projectID, listed[i].ID)
+	})
+
+	for i, err := range errs {
+		if err != nil {
+			return nil, fmt.Errorf("fetching environment %s: %w", listed[i].Name, err)
+		}
+	}
+	return environments, nil
+}
+
+func listDeployments(projectID int, environment string) ([]*gitlab.Deployment, error) {
+	deployments, _, err := gitlabClient.Deployments().ListProjectDeployments(projectID, &gitlab.ListProjectDeploymentsOptions{
+		ListOptions: gitlab.ListOptions{PerPage: deploymentHistorySize},
+		Environment: gitlab.String(environment),
+		OrderBy:     gitlab.String("id"),
+		Sort:        gitlab.String("desc"),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("fetching deployments to %s: %w", environment, err)
+	}
+	return deployments, nil
+}
+
+// lastSuccessfulDeployment returns the latest deployment to environment that
+// succeeded, or nil if none did.
+func lastSuccessfulDeployment(projectID int, environment string) (*gitlab.Deployment, error) {
+	deployments, _, err := gitlabClient.Deployments().ListProjectDeployments(projectID, &gitlab.ListProjectDeploymentsOptions{
+		ListOptions: gitlab.ListOptions{PerPage: 1},
+		Environment: gitlab.String(environment),
+		Status:      gitlab.String("success"),
+		OrderBy:     gitlab.String("id"),
+		Sort:        gitlab.String("desc"),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("fetching deployments to %s: %w", environment, err)
+	}
+	if len(deployments) == 0 {
+		return nil, nil
+	}
+	return deployments[0], nil
+}
+
+// redeploy runs the job that made deployment again, which deploys its commit
+// to the environment once more. For an older deployment that is a rollback.
+func redeploy(projectID int, deployment *gitlab.Deployment) error {
+	_, _, err := gitlabClient.Jobs().RetryJob(projectID, deployment.Deployable.ID)
+	if err != nil {
+		return fmt.Errorf("running deployment job %d again: %w", deployment.Deployable.ID, err)
+	}
+	return nil
+}
+
+// deploymentJob describes the job that runs when deployment is redeployed.
+func deploymentJob(deployment *gitlab.Deployment) string {
+	return fmt.Sprintf("job %s (#%d) on %s at %s", deployment.Deployable.Name, deployment.Deployable.ID,
+		deployment.Ref, shortSHA(deployment.SHA))
+}
+
+// showEnvironments lists the environments of project with their latest
+// deployment. Enter shows the deployment history of the selected one and R
+// runs its last successful deployment again. back is called on Esc.
+func showEnvironments(app *tview.Application, project *gitlab.Project, back func()) {
+	cancelPendingLoads()
+
+	table := tview.NewTable().
+		SetSelectable(true, false).
+		SetFixed(1, 0)
+	table.SetBorder(true).
+		SetTitle(fmt.Sprintf(" Environments of %s ", tview.Escape(project.PathWithNamespace)))
+	statusBar := newStatusBar()
+
+	var environments []*gitlab.Environment
+
+	fillTable := func() {
+		row, _ := table.GetSelection()
+		table.Clear()
+
+		for column, title := range []string{"Environment", "Tier", "State", "Ref", "SHA", "Deployer", "Deployed", "Status"} {
+			table.SetCell(0, column, tview.NewTableCell(title).
+				SetTextColor(tcell.ColorYellow).
+				SetAttributes(tcell.AttrBold).
+				SetSelectable(false))
+		}
+
+		for i, environment := range environments {
+			table.SetCell(i+1, 0, tview.NewTableCell(tview.Escape(environment.Name)).SetExpansion(1))
+			table.SetCell(i+1, 1, tview.NewTableCell(tview.Escape(environment.Tier)))
+			table.SetCell(i+1, 2, tview.NewTableCell(tview.Escape(environment.State)))
+
+			deployment := environment.LastDeployment
+			if deployment == nil {
+				table.SetCell(i+1, 3, tview.NewTableCell("never deployed").SetTextColor(tcell.ColorGray))
+				for column := 4; column < 8; column++ {
+					table.SetCell(i+1, column, tview.NewTableCell(""))
+				}
+				continue
+			}
+			table.SetCell(i+1, 3, tview.NewTableCell(tview.Escape(deployment.Ref)))
+			table.SetCell(i+1, 4, tview.NewTableCell(shortSHA(deployment.SHA)))
+			table.SetCell(i+1, 5, tview.NewTableCell(tview.Escape(deploymentUser(deployment))))
+			table.SetCell(i+1, 6, tview.NewTableCell(formatTime(deployment.CreatedAt)))
+			table.SetCell(i+1, 7, tview.NewTableCell(statusLabel(deployment.Status)))
+		}
+
+		if len(environments) == 0 {
+			table.SetCell(1, 0, tview.NewTableCell("This project has no environments").
+				SetTextColor(tcell.ColorGray).
+				SetSelectable(false))
+			return
+		}
+		if row < 1 || row > len(environments) {
+			row = 1
+		}
+		table.Select(row, 0)
+	}
+
+	reloadEnvironments := func() (func(), error) {
+		loaded, err := listEnvironments(project.ID)
+		if err != nil {
+			return nil, err
+		}
+		return func() {
+			environments = loaded
+			fillTable()
+		}, nil
+	}
+
+	table.SetSelectedFunc(func(row, column int) {
+		if row < 1 || row > len(environments) {
+			return
+		}
+		showDeployments(app, project, environments[row-1], func() {
+			showEnvironments(app, project, back)
+		})
+	})
+
+	var flex *tview.Flex
+
+	table.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch {
+		case event.Key() == tcell.KeyEsc:
+			back()
+			return nil
+		case isRefreshKey(event):
+			fetchInBackground(app, statusBar, "Refreshing...", reloadEnvironments)
+			return nil
+		case event.Rune() == 'R':
+			row, _ := table.GetSelection()
+			if row < 1 || row > len(environments) {
+				return nil
+			}
+			environment := environments[row-1]
+			fetchInBackground(app, statusBar, "Finding last successful deployment...", func() (func(), error) {
+				deployment, err := lastSuccessfulDeployment(project.ID, environment.Name)
+				if err != nil {
+					return nil, err
+				}
+				return func() {
+					if deployment == nil {
+						showError(app, fmt.Errorf("nothing was deployed to %s successfully yet", environment.Name), nil)
+						return
+					}
+					confirmAction(app, fmt.Sprintf("Re-deploy to %s?\nThis runs %s again.", environment.Name, deploymentJob(deployment)), "Re-deploy", func() {
+						runAction(app, statusBar, "Re-deploying...", fmt.Sprintf("Re-deploying to %s", environment.Name),
+							func() error { return redeploy(project.ID, deployment) }, reloadEnvironments)
+					})
+				}, nil
+			})
+			return nil
+		}
+		return event
+	})
+
+	flex = tview.NewFlex().
+		SetDirection(tview.FlexRow).
+		AddItem(table, 0, 1, true).
+		AddItem(statusBar, 1, 0, false).
+		AddItem(tview.NewButton("ENTER - Deployments   R - Re-deploy   ESC - Back").SetSelectedFunc(back), 1, 0, false)
+
+	routes.show("environments/"+strconv.Itoa(project.ID), flex, table)
+
+	fetchInBackground(app, statusBar, "Loading environments...", reloadEnvironments)
+}
+
+// showDeployments shows the latest deployments to environment, newest first.
+// R runs the job of the selected deployment again: a re-deploy for the latest
+// one, a rollback for older ones. back is called on Esc.
+func showDeployments(app *tview.Application, project *gitlab.Project, environment *gitlab.Environment, back func()) {
+	cancelPendingLoads()
+
+	table := tview.NewTable().
+		SetSelectable(true, false).
+		SetFixed(1, 0)
+	table.SetBorder(true).
+		SetTitle(fmt.Sprintf(" Deployments to %s ", tview.Escape(environment.Name)))
+	statusBar := newStatusBar()
+
+	var deployments []*gitlab.Deployment
+
+	fillTable := func() {
+		row, _ := table.GetSelection()
+		table.Clear()
+
+		for column, title := range []string{"#", "Ref", "SHA", "Job", "Pipeline", "Deployer", "Created", "Status"} {
+			table.SetCell(0, column, tview.NewTableCell(title).
+				SetTextColor(tcell.ColorYellow).
+				SetAttributes(tcell.AttrBold).
+				SetSelectable(false))
+		}
+
+		for i, deployment := range deployments {
+			table.SetCell(i+1, 0, tview.NewTableCell(fmt.Sprint(deployment.IID)))
+			table.SetCell(i+1, 1, tview.NewTableCell(tview.Escape(deployment.Ref)).SetExpansion(1))
+			table.SetCell(i+1, 2, tview.NewTableCell(shortSHA(deployment.SHA)))
+			table.SetCell(i+1, 3, tview.NewTableCell(tview.Escape(deployment.Deployable.Name)))
+			pipeline := "-"
+			if deployment.Deployable.Pipeline.ID != 0 {
+				pipeline = fmt.Sprintf("#%d", deployment.Deployable.Pipeline.ID)
+			}
+			table.SetCell(i+1, 4, tview.NewTableCell(pipeline))
+			table.SetCell(i+1, 5, tview.NewTableCell(tview.Escape(deploymentUser(deployment))))
+			table.SetCell(i+1, 6, tview.NewTableCell(formatTime(deployment.CreatedAt)))
+			table.SetCell(i+1, 7, tview.NewTableCell(statusLabel(deployment.Status)))
+		}
+
+		if len(deployments) == 0 {
+			table.SetCell(1, 0, tview.NewTableCell("Nothing was deployed to this environment yet").
+				SetTextColor(tcell.ColorGray).
+				SetSelectable(false))
+			return
+		}
+		if row < 1 || row > len(deployments) {
+			row = 1
+		}
+		table.Select(row, 0)
+	}
+
+	reloadDeployments := func() (func(), error) {
+		loaded, err := listDeployments(project.ID, environment.Name)
+		if err != nil {
+			return nil, err
+		}
+		return func() {
+			deployments = loaded
+			fillTable()
+		}, nil
+	}
+
+	var flex *tview.Flex
+
+	table.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch {
+		case event.Key() == tcell.KeyEsc:
+			back()
+			return nil
+		case isRefreshKey(event):
+			fetchInBackground(app, statusBar, "Refreshing...", reloadDeployments)
+			return nil
+		case event.Rune() == 'R':
+			row, _ := table.GetSelection()
+			if row < 1 || row > len(deployments) {
+				return nil
+			}
+			deployment := deployments[row-1]
+			if deployment.Deployable.ID == 0 {
+				showError(app, fmt.Errorf("deployment #%d wasn't made by a job and can't be run again", deployment.IID), nil)
+				return nil
+			}
+
+			label, message := "Re-deploy", fmt.Sprintf("Re-deploy to %s?", environment.Name)
+			if row > 1 {
+				label, message = "Roll back", fmt.Sprintf("Roll %s back to deployment #%d?", environment.Name, deployment.IID)
+			}
+			message += fmt.Sprintf("\nThis runs %s again.", deploymentJob(deployment))
+			if deployment.Status != "success" {
+				message += fmt.Sprintf("\nThis deployment's status is %s.", deployment.Status)
+			}
+			confirmAction(app, message, label, func() {
+				runAction(app, statusBar, "Deploying...", fmt.Sprintf("Deploying %s to %s", shortSHA(deployment.SHA), environment.Name),
+					func() error { return redeploy(project.ID, deployment) }, reloadDeployments)
+			})
+			return nil
+		}
+		return event
+	})
+
+	flex = tview.NewFlex().
+		SetDirection(tview.FlexRow).
+		AddItem(table, 0, 1, true).
+		AddItem(statusBar, 1, 0, false).
+		AddItem(tview.NewButton("R - Re-deploy/Roll back   ESC - Back").SetSelectedFunc(back), 1, 0, false)
+
+	routes.show("deployments/"+strconv.Itoa(project.ID)+"/"+strconv.Itoa(environment.ID), flex, table)
+
+	fetchInBackground(app, statusBar, "Loading deployments...", reloadDeployments)
+}
+
+func deploymentUser(deployment *gitlab.Deployment) string {
+	if deployment.User == nil {
+		return "-"
+	}
+	return deployment.User.Username
+}
+
+// shortSHA abbreviates a commit SHA the way GitLab shows it.
+func shortSHA(sha string) string {
+	if len(sha) > 8 {
+		return sha[:8]
+	}
+	return sha
+}
@@ -0,0 +1,294 @@
+// exporter.go
+package ui
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+// exporterStatuses are the pipeline and job statuses gpv_pipeline_status and
+// gpv_job_status have a series for, so a status that ended shows as 0 rather
+// than vanishing.
+var exporterStatuses = []string{
+	"created", "waiting_for_resource", "preparing", "pending", "running",
+	"success", "failed", "canceled", "skipped", "manual", "scheduled",
+}
+
+// repeatedFlag collects the values of a flag that may be repeated, such as
+// -p.
+type repeatedFlag []string
+
+func (f *repeatedFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *repeatedFlag) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
+
+// metric is a Prometheus gauge with its samples.
+type metric struct {
+	name    string
+	help    string
+	samples []sample
+}
+
+type sample struct {
+	labels []string // name, value, name, value...
+	value  float64
+}
+
+func (m *metric) add(value float64, labels ...string) {
+	m.samples = append(m.samples, sample{labels: labels, value: value})
+}
+
+// exporterMetrics are the metrics collected for the projects.
+type exporterMetrics struct {
+	pipelineStatus   *metric
+	pipelineDuration *metric
+	pipelines        *metric
+	successRate      *metric
+	jobStatus        *metric
+	jobDuration      *metric
+	up               *metric
+}
+
+// exporter serves the metrics collected last, so scrapes never wait for
+// GitLab and every scraper shares the same requests.
+type exporter struct {
+	projects []*gitlab.Project
+	// refs are the refs with a latest pipeline metric; each project's
+	// default branch if empty, which keeps the series per project bounded.
+	refs []string
+	days int
+
+	mu      sync.Mutex
+	metrics []*metric
+}
+
+// setupExporter sets up the exporter command, which serves the status,
+// durations and success rates of pipelines and jobs as Prometheus metrics.
+func setupExporter(flags *flag.FlagSet) func(args []string) error {
+	var projectPaths, refs repeatedFlag
+	flags.Var(&projectPaths, "p", "project path or ID to export, may be repeated (default: your favorite projects)")
+	flags.Var(&refs, "ref", "branch or tag to export the latest pipeline of, may be repeated (default: the default branch)")
+	listen := flags.String("listen", ":9813", "address to serve /metrics on")
+	interval := flags.Duration("interval", time.Minute, "how often the metrics are collected from GitLab")
+	days := flags.Int("days", 7, "number of days success rates and pipeline counts cover")
+	return func(args []string) error {
+		if len(projectPaths) == 0 {
+			for _, project := range state.Favorites {
+				projectPaths = append(projectPaths, project.Path)
+			}
+		}
+		if len(projectPaths) == 0 {
+			fmt.Fprintln(flags.Output(), "no projects to export, pass -p or add favorites in the TUI")
+			flags.Usage()
+			return errUsage
+		}
+		if *interval <= 0 || *days <= 0 {
+			fmt.Fprintln(flags.Output(), "-interval and -days must be positive")
+			flags.Usage()
+			return errUsage
+		}
+
+		e := &exporter{refs: refs, days: *days}
+		for _, path := range projectPaths {
+			project, _, err := gitlabClient.Projects().GetProject(path, nil)
+			if err != nil {
+				return fmt.Errorf("fetching project %s: %w", path, err)
+			}
+			e.projects = append(e.projects, project)
+		}
+
+		listener, err := net.Listen("tcp", *listen)
+		if err != nil {
+			return fmt.Errorf("listening for scrapes on %s: %w", *listen, err)
+		}
+		logInfo("serving metrics", "address", listener.Addr().String(), "projects", len(e.projects))
+		fmt.Printf("Serving metrics of %d projects on http://%s/metrics\n", len(e.projects), listener.Addr())
+
+		go func() {
+			for {
+				e.collect()
+				time.Sleep(*interval)
+			}
+		}()
+
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", e)
+		return http.Serve(listener, mux)
+	}
+}
+
+// collect fetches the metrics of every project and replaces those served.
+func (e *exporter) collect() {
+	started := time.Now()
+	m := &exporterMetrics{
+		pipelineStatus:   &metric{name: "gpv_pipeline_status", help: "Status of the latest pipeline of a ref, 1 for its current status."},
+		pipelineDuration: &metric{name: "gpv_pipeline_duration_seconds", help: "Duration of the latest pipeline of a ref so far."},
+		pipelines:        &metric{name: "gpv_pipelines", help: fmt.Sprintf("Pipelines updated in the last %d days by status.", e.days)},
+		successRate:      &metric{name: "gpv_pipeline_success_ratio", help: fmt.Sprintf("Share of the pipelines finished in the last %d days that succeeded.", e.days)},
+		jobStatus:        &metric{name: "gpv_job_status", help: "Status of the jobs of the latest pipeline of the default branch, 1 for their current status."},
+		jobDuration:      &metric{name: "gpv_job_duration_seconds", help: "Duration of the jobs of the latest pipeline of the default branch so far."},
+		up:               &metric{name: "gpv_project_up", help: "Whether the last collection of a project succeeded."},
+	}
+
+	for _, project := range e.projects {
+		if err := e.collectProject(project, m); err != nil {
+			logWarn("collecting metrics failed", "project", project.PathWithNamespace, "error", err)
+			m.up.add(0, "project", project.PathWithNamespace)
+			continue
+		}
+		m.up.add(1, "project", project.PathWithNamespace)
+	}
+
+	collectDuration := &metric{name: "gpv_exporter_collect_duration_seconds", help: "How long the last collection took."}
+	collectDuration.add(time.Since(started).Seconds())
+	lastCollect := &metric{name: "gpv_exporter_last_collect_timestamp_seconds", help: "When the last collection finished."}
+	lastCollect.add(float64(time.Now().Unix()))
+
+	e.mu.Lock()
+	e.metrics = []*metric{m.pipelineStatus, m.pipelineDuration, m.pipelines, m.successRate, m.jobStatus, m.jobDuration, m.up,
+		collectDuration, lastCollect}
+	e.mu.Unlock()
+	logDebug("metrics collected", "duration", time.Since(started))
+}
+
+// collectProject adds the samples of project to m. A project that fails
+// midway keeps the samples added so far.
+func (e *exporter) collectProject(project *gitlab.Project, m *exporterMetrics) error {
+	path := project.PathWithNamespace
+	projectID := strconv.Itoa(project.ID)
+
+	infos, err := listPipelinesSince(project.ID, e.days, false)
+	if err != nil {
+		return err
+	}
+
+	var tally pipelineTally
+	counts := map[string]int{}
+	latest := map[string]*gitlab.PipelineInfo{}
+	for _, info := range infos {
+		tally.add(info.Status)
+		counts[info.Status]++
+		// Pipelines are listed newest first.
+		if latest[info.Ref] == nil {
+			latest[info.Ref] = info
+		}
+	}
+
+	for _, status := range exporterStatuses {
+		m.pipelines.add(float64(counts[status]), "project", path, "status", status)
+	}
+	if rate, ok := tally.rate(); ok {
+		m.successRate.add(rate/100, "project", path)
+	}
+
+	refs := e.refs
+	if len(refs) == 0 {
+		refs = []string{project.DefaultBranch}
+	}
+	for _, ref := range refs {
+		if latest[ref] == nil {
+			continue
+		}
+		pipeline, err := getPipelineDetails(projectID, latest[ref])
+		if err != nil {
+			return err
+		}
+		addStatusSamples(m.pipelineStatus, pipeline.Status, "project", path, "ref", ref)
+		m.pipelineDuration.add(float64(pipelineDuration(pipeline)), "project", path, "ref", ref)
+	}
+
+	defaultBranch := latest[project.DefaultBranch]
+	if defaultBranch == nil {
+		return nil
+	}
+	jobs, err := listPipelineJobs(projectID, strconv.Itoa(defaultBranch.ID))
+	if err != nil {
+		return fmt.Errorf("fetching jobs of pipeline %d: %w", defaultBranch.ID, err)
+	}
+	for _, job := range jobs {
+		labels := []string{"project", path, "ref", project.DefaultBranch, "stage", job.Stage, "job", job.Name}
+		addStatusSamples(m.jobStatus, job.Status, labels...)
+		m.jobDuration.add(jobDurationSeconds(job), labels...)
+	}
+	return nil
+}
+
+// addStatusSamples adds a sample for every status to m, 1 for current and 0
+// for the others.
+func addStatusSamples(m *metric, current string, labels ...string) {
+	for _, status := range exporterStatuses {
+		value := 0.0
+		if status == current {
+			value = 1
+		}
+		m.add(value, append(append([]string{}, labels...), "status", status)...)
+	}
+}
+
+// jobDurationSeconds is how long job has run so far. GitLab only reports the
+// duration once a job has finished.
+func jobDurationSeconds(job *gitlab.Job) float64 {
+	if job.Duration == 0 && job.FinishedAt == nil && job.StartedAt != nil {
+		return time.Since(*job.StartedAt).Seconds()
+	}
+	return job.Duration
+}
+
+func (e *exporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	e.mu.Lock()
+	metrics := e.metrics
+	e.mu.Unlock()
+
+	if metrics == nil {
+		http.Error(w, "metrics are still being collected", http.StatusServiceUnavailable)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	if err := writeMetrics(w, metrics); err != nil {
+		logDebug("writing metrics failed", "error", err)
+	}
+}
+
+// writeMetrics writes metrics in the Prometheus text exposition format.
+func writeMetrics(w io.Writer, metrics []*metric) error {
+	var out strings.Builder
+	for _, m := range metrics {
+		fmt.Fprintf(&out, "# HELP %s %s\n# TYPE %s gauge\n", m.name, m.help, m.name)
+		for _, s := range m.samples {
+			out.WriteString(m.name)
+			if len(s.labels) > 0 {
+				out.WriteString("{")
+				for i := 0; i+1 < len(s.labels); i += 2 {
+					if i > 0 {
+						out.WriteString(",")
+					}
+					fmt.Fprintf(&out, "%s=\"%s\"", s.labels[i], escapeLabelValue(s.labels[i+1]))
+				}
+				out.WriteString("}")
+			}
+			fmt.Fprintf(&out, " %s\n", strconv.FormatFloat(s.value, 'g', -1, 64))
+		}
+	}
+	_, err := io.WriteString(w, out.String())
+	return err
+}
+
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabelValue(value string) string {
+	return labelValueEscaper.Replace(value)
+}
@@ -0,0 +1,67 @@
+// filters.go
+package ui
+
+import (
+	"fmt"
+
+	"github.com/xanzy/go-gitlab"
+
+	"main.go/internal/config"
+)
+
+// projectFilters narrows down the projects listed in the tree. The filters are
+// passed through to the API so filtering happens server-side.
+type projectFilters struct {
+	includeArchived bool
+	memberOnly      bool
+	visibility      *gitlab.VisibilityValue
+}
+
+var filters projectFilters
+
+// newProjectFilters returns the project filters of c.
+func newProjectFilters(c config.Config) projectFilters {
+	f := projectFilters{includeArchived: c.IncludeArchived, memberOnly: c.MemberOnly}
+	if c.Visibility != "" {
+		f.visibility = gitlab.Visibility(gitlab.VisibilityValue(c.Visibility))
+	}
+	return f
+}
+
+func (f projectFilters) archived() *bool {
+	if f.includeArchived {
+		return nil
+	}
+	return gitlab.Bool(false)
+}
+
+func (f projectFilters) groupProjectsOptions(listOptions gitlab.ListOptions) *gitlab.ListGroupProjectsOptions {
+	options := &gitlab.ListGroupProjectsOptions{
+		ListOptions: listOptions,
+		Archived:    f.archived(),
+		Visibility:  f.visibility,
+	}
+	if f.memberOnly {
+		// Group project listings have no membership flag; requiring at least
+		// guest access has the same effect.
+		options.MinAccessLevel = gitlab.AccessLevel(gitlab.GuestPermissions)
+	}
+	return options
+}
+
+// cacheKey tells apart the project lists cached under different filters.
+func (f projectFilters) cacheKey() string {
+	visibility := ""
+	if f.visibility != nil {
+		visibility = string(*f.visibility)
+	}
+	return fmt.Sprintf("archived=%t member=%t visibility=%s", f.includeArchived, f.memberOnly, visibility)
+}
+
+func (f projectFilters) applyToProjectsOptions(options *gitlab.ListProjectsOptions) {
+	options.Archived = f.archived()
+	options.Visibility = f.visibility
+	if f.memberOnly {
+		options.Membership = gitlab.Bool(true)
+	}
+}
@@ -0,0 +1,217 @@
+// finder.go
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/xanzy/go-gitlab"
+)
+
+// searchDebounce is how long typing has to pause before the search API is
+// asked, so a query typed quickly is searched once rather than per key.
+const searchDebounce = 250 * time.Millisecond
+
+// finderCandidates keeps every project the search API has returned during
+// this session, so fuzzy matching can run against more than the last page.
+var finderCandidates = map[int]*gitlab.Project{}
+
+func showProjectFinder(app *tview.Application) {
+	cancelPendingLoads()
+
+	inputField := tview.NewInputField().
+		SetLabel("Find project: ").
+		SetFieldBackgroundColor(tcell.ColorDarkGray).
+		SetFieldTextColor(tcell.ColorOrangeRed)
+
+	resultList := tview.NewList().ShowSecondaryText(false)
+
+	statusBar := newStatusBar()
+
+	var matches []*gitlab.Project
+	var lastQuery string
+	var searchTimer *time.Timer
+	generation := loadGeneration
+
+	showMatches := func(query string) {
+		matches = fuzzyMatchProjects(query)
+
+		resultList.Clear()
+		for _, project := range matches {
+			resultList.AddItem(project.PathWithNamespace, "", 0, nil)
+		}
+	}
+
+	search := func(query string) {
+		fetchInBackground(app, statusBar, "Searching...", func() (func(), error) {
+			projects, err := searchProjects(query)
+			if err != nil {
+				return nil, err
+			}
+			return func() {
+				for _, project := range projects {
+					finderCandidates[project.ID] = project
+				}
+				if query == lastQuery {
+					showMatches(query)
+				}
+			}, nil
+		})
+	}
+
+	// Matches from earlier searches are shown right away; the list is
+	// re-ranked once typing pauses and the search API has answered for the
+	// current query.
+	refresh := func(query string) {
+		lastQuery = query
+		showMatches(query)
+
+		if searchTimer != nil {
+			searchTimer.Stop()
+		}
+		searchTimer = time.AfterFunc(searchDebounce, func() {
+			app.QueueUpdateDraw(func() {
+				if query == lastQuery && generation == loadGeneration {
+					search(query)
+				}
+			})
+		})
+	}
+
+	openMatch := func(index int) {
+		if index < 0 || index >= len(matches) {
+			return
+		}
+		showPipelines(app, matches[index])
+	}
+
+	inputField.SetChangedFunc(refresh)
+
+	inputField.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyEsc:
+			showTree(app, lastSearchTerm)
+			return nil
+		case tcell.KeyEnter:
+			openMatch(resultList.GetCurrentItem())
+			return nil
+		case tcell.KeyDown, tcell.KeyUp, tcell.KeyPgDn, tcell.KeyPgUp:
+			resultList.InputHandler()(event, nil)
+			return nil
+		}
+		return event
+	})
+
+	resultList.SetSelectedFunc(func(index int, mainText string, secondaryText string, shortcut rune) {
+		openMatch(index)
+	})
+
+	flex := tview.NewFlex().
+		SetDirection(tview.FlexRow).
+		AddItem(inputField, 1, 0, true).
+		AddItem(resultList, 0, 1, false).
+		AddItem(statusBar, 1, 0, false).
+		AddItem(tview.NewButton("ESC - Back").SetSelectedFunc(func() {
+			showTree(app, lastSearchTerm)
+		}), 1, 0, false)
+
+	routes.show("finder", flex, inputField)
+}
+
+// searchProjects asks the search API for projects matching the last path
+// segment of the query. The API only does substring matching, so the fuzzy
+// ranking happens client-side afterwards.
+func searchProjects(query string) ([]*gitlab.Project, error) {
+	term := query
+	if i := strings.LastIndex(term, "/"); i >= 0 {
+		term = term[i+1:]
+	}
+	term = strings.TrimSpace(term)
+	if len(term) < 2 {
+		return nil, nil
+	}
+
+	return shareFetch("search/projects/"+term, func() ([]*gitlab.Project, error) {
+		projects, _, err := gitlabClient.Search().Projects(term, &gitlab.SearchOptions{
+			ListOptions: gitlab.ListOptions{
+				PerPage: pageSize,
+			},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("searching projects: %w", err)
+		}
+		return projects, nil
+	})
+}
+
+func fuzzyMatchProjects(query string) []*gitlab.Project {
+	type scoredProject struct {
+		project *gitlab.Project
+		score   int
+	}
+
+	var scored []scoredProject
+	for _, project := range finderCandidates {
+		if score, ok := fuzzyScore(query, project.PathWithNamespace); ok {
+			scored = append(scored, scoredProject{project, score})
+		}
+	}
+
+	sort.Slice(scored, func(i, j int) bool {
+		if scored[i].score != scored[j].score {
+			return scored[i].score > scored[j].score
+		}
+		return scored[i].project.PathWithNamespace < scored[j].project.PathWithNamespace
+	})
+
+	matches := make([]*gitlab.Project, 0, len(scored))
+	for _, s := range scored {
+		matches = append(matches, s.project)
+	}
+	return matches
+}
+
+// fuzzyScore reports whether every rune of pattern appears in text in order,
+// ignoring case. Consecutive matches and matches at the start of a path
+// segment score higher, and shorter paths win ties.
+func fuzzyScore(pattern, text string) (int, bool) {
+	pattern = strings.ToLower(strings.TrimSpace(pattern))
+	if pattern == "" {
+		return 0, true
+	}
+
+	patternRunes := []rune(pattern)
+	textRunes := []rune(strings.ToLower(text))
+
+	score := 0
+	p := 0
+	lastMatch := -1
+	for i, r := range textRunes {
+		if p == len(patternRunes) {
+			break
+		}
+		if r != patternRunes[p] {
+			continue
+		}
+
+		score++
+		if lastMatch == i-1 {
+			score += 2
+		}
+		if i == 0 || !unicode.IsLetter(textRunes[i-1]) && !unicode.IsDigit(textRunes[i-1]) {
+			score += 3
+		}
+		lastMatch = i
+		p++
+	}
+
+	if p < len(patternRunes) {
+		return 0, false
+	}
+	return score - len(textRunes)/10, true
+}
@@ -0,0 +1,218 @@
+// flaky.go
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/xanzy/go-gitlab"
+)
+
+// jobAnalysisPipelines is how many of the latest pipelines of a project the
+// job reports look at.
+const jobAnalysisPipelines = 100
+
+// flakyJob is a job that both failed and succeeded on the same commit.
+// runs counts the commits it ran on, flakes those it flaked on.
+type flakyJob struct {
+	name      string
+	stage     string
+	runs      int
+	flakes    int
+	lastFlake *gitlab.Job
+}
+
+func (j flakyJob) rate() float64 {
+	return float64(j.flakes) * 100 / float64(j.runs)
+}
+
+// listRecentJobs fetches every job of the latest count pipelines of a
+// project, retried attempts included.
+func listRecentJobs(projectID string, count int) ([]*gitlab.Job, error) {
+	pipelines, _, err := gitlabClient.Pipelines().ListProjectPipelines(projectID, &gitlab.ListProjectPipelinesOptions{
+		ListOptions: gitlab.ListOptions{PerPage: count},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("fetching pipelines for project %s: %w", projectID, err)
+	}
+
+	jobs := make([][]*gitlab.Job, len(pipelines))
+	errs := make([]error, len(pipelines))
+
+	forEachParallel(len(pipelines), pipelineDetailWorkers, func(i int) {
+		pipelineID := pipelines[i].ID
+		jobs[i], errs[i] = listAllPages(func(listOptions gitlab.ListOptions) ([]*gitlab.Job, *gitlab.Response, error) {
+			return gitlabClient.Jobs().ListPipelineJobs(projectID, pipelineID, &gitlab.ListJobsOptions{
+				ListOptions:    listOptions,
+				IncludeRetried: gitlab.Bool(true),
+			})
+		})
+	})
+
+	var all []*gitlab.Job
+	for i, err := range errs {
+		if err != nil {
+			return nil, fmt.Errorf("fetching jobs of pipeline #%d: %w", pipelines[i].ID, err)
+		}
+		all = append(all, jobs[i]...)
+	}
+	return all, nil
+}
+
+// findFlakyJobs looks for jobs that failed and succeeded on the same commit,
+// whether retried within a pipeline or in a new pipeline, so the failure
+// can't have been caused by a code change. They are ranked by flake rate.
+func findFlakyJobs(jobs []*gitlab.Job) []flakyJob {
+	type attempts struct {
+		failed    *gitlab.Job
+		succeeded bool
+	}
+
+	byName := map[string]map[string]*attempts{}
+	stages := map[string]string{}
+	for _, job := range jobs {
+		commits, ok := byName[job.Name]
+		if !ok {
+			commits = map[string]*attempts{}
+			byName[job.Name] = commits
+			stages[job.Name] = job.Stage
+		}
+		commit, ok := commits[job.Pipeline.Sha]
+		if !ok {
+			commit = &attempts{}
+			commits[job.Pipeline.Sha] = commit
+		}
+		switch job.Status {
+		case "success":
+			commit.succeeded = true
+		case "failed":
+			if commit.failed == nil || job.ID > commit.failed.ID {
+				commit.failed = job
+			}
+		}
+	}
+
+	var flaky []flakyJob
+	for name, commits := range byName {
+		job := flakyJob{name: name, stage: stages[name], runs: len(commits)}
+		for _, commit := range commits {
+			if commit.failed == nil || !commit.succeeded {
+				continue
+			}
+			job.flakes++
+			if job.lastFlake == nil || commit.failed.ID > job.lastFlake.ID {
+				job.lastFlake = commit.failed
+			}
+		}
+		if job.flakes > 0 {
+			flaky = append(flaky, job)
+		}
+	}
+
+	sort.Slice(flaky, func(i, j int) bool {
+		if flaky[i].rate() != flaky[j].rate() {
+			return flaky[i].rate() > flaky[j].rate()
+		}
+		if flaky[i].flakes != flaky[j].flakes {
+			return flaky[i].flakes > flaky[j].flakes
+		}
+		return flaky[i].name < flaky[j].name
+	})
+	return flaky
+}
+
+// showFlakyJobs reports the flaky jobs of the latest pipelines of project,
+// highest flake rate first. Enter opens the pipeline of the latest flaky
+// failure of the selected job. back is called on Esc.
+func showFlakyJobs(app *tview.Application, project *gitlab.Project, back func()) {
+	cancelPendingLoads()
+
+	projectID := strconv.Itoa(project.ID)
+
+	table := tview.NewTable().
+		SetSelectable(true, false).
+		SetFixed(1, 0)
+	table.SetBorder(true).
+		SetTitle(fmt.Sprintf(" Flaky jobs of %s (last %d pipelines) ", tview.Escape(project.PathWithNamespace), jobAnalysisPipelines))
+	statusBar := newStatusBar()
+
+	var flaky []flakyJob
+
+	fillTable := func() {
+		row, _ := table.GetSelection()
+		table.Clear()
+
+		for column, title := range []string{"Job", "Stage", "Flaked", "Commits", "Flake rate", "Last flake"} {
+			table.SetCell(0, column, tview.NewTableCell(title).
+				SetTextColor(tcell.ColorYellow).
+				SetAttributes(tcell.AttrBold).
+				SetSelectable(false))
+		}
+
+		for i, job := range flaky {
+			table.SetCell(i+1, 0, tview.NewTableCell(tview.Escape(job.name)).SetExpansion(1))
+			table.SetCell(i+1, 1, tview.NewTableCell(tview.Escape(job.stage)))
+			table.SetCell(i+1, 2, tview.NewTableCell(strconv.Itoa(job.flakes)).SetAlign(tview.AlignRight))
+			table.SetCell(i+1, 3, tview.NewTableCell(strconv.Itoa(job.runs)).SetAlign(tview.AlignRight))
+			table.SetCell(i+1, 4, tview.NewTableCell(fmt.Sprintf("%5.1f%%", job.rate())).SetAlign(tview.AlignRight))
+			table.SetCell(i+1, 5, tview.NewTableCell(fmt.Sprintf("%s in #%d on %s", formatTime(job.lastFlake.CreatedAt),
+				job.lastFlake.Pipeline.ID, tview.Escape(job.lastFlake.Ref))))
+		}
+
+		if len(flaky) == 0 {
+			table.SetCell(1, 0, tview.NewTableCell("No job failed and then passed on the same commit").
+				SetTextColor(tcell.ColorGray).
+				SetSelectable(false))
+			return
+		}
+		if row < 1 || row > len(flaky) {
+			row = 1
+		}
+		table.Select(row, 0)
+	}
+
+	reloadFlaky := func() (func(), error) {
+		jobs, err := listRecentJobs(projectID, jobAnalysisPipelines)
+		if err != nil {
+			return nil, err
+		}
+		return func() {
+			flaky = findFlakyJobs(jobs)
+			fillTable()
+		}, nil
+	}
+
+	table.SetSelectedFunc(func(row, column int) {
+		if row < 1 || row > len(flaky) {
+			return
+		}
+		failure := flaky[row-1].lastFlake
+		pipelineTrail = nil
+		showPipelineDetail(app, projectID, strconv.Itoa(failure.Pipeline.ID), failure.Ref)
+	})
+
+	table.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch {
+		case event.Key() == tcell.KeyEsc:
+			back()
+			return nil
+		case isRefreshKey(event):
+			fetchInBackground(app, statusBar, "Refreshing...", reloadFlaky)
+			return nil
+		}
+		return event
+	})
+
+	flex := tview.NewFlex().
+		SetDirection(tview.FlexRow).
+		AddItem(table, 0, 1, true).
+		AddItem(statusBar, 1, 0, false).
+		AddItem(tview.NewButton("ENTER - Pipeline of the last flake   ESC - Back").SetSelectedFunc(back), 1, 0, false)
+
+	routes.show("flaky-jobs/"+strconv.Itoa(project.ID), flex, table)
+
+	fetchInBackground(app, statusBar, "Analyzing jobs...", reloadFlaky)
+}
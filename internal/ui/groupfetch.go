@@ -0,0 +1,135 @@
+// groupfetch.go
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/rivo/tview"
+	"github.com/xanzy/go-gitlab"
+)
+
+// groupFetchWorkers is how many groups have their subgroups and projects
+// fetched at once.
+const groupFetchWorkers = 8
+
+// topLevelGroups returns the groups the tree shows at its top level. Without
+// a search term only groups whose parent isn't visible to us are shown; their
+// subgroups are loaded on expansion. This keeps groups reachable when the
+// user is a member of a subgroup only.
+func topLevelGroups(allGroups []*gitlab.Group, searchTerm string) []*gitlab.Group {
+	visibleGroups := make(map[int]bool, len(allGroups))
+	for _, group := range allGroups {
+		visibleGroups[group.ID] = true
+	}
+
+	var groups []*gitlab.Group
+	for _, group := range allGroups {
+		if searchTerm == "" && group.ParentID != 0 && visibleGroups[group.ParentID] {
+			continue
+		}
+		if searchTerm == "" || strings.Contains(strings.ToLower(group.Name), strings.ToLower(searchTerm)) {
+			groups = append(groups, group)
+		}
+	}
+	return groups
+}
+
+// fetchGroupsChildren fetches the subgroups and projects of groups through a
+// pool of groupFetchWorkers, so the groups on screen expand instantly.
+// Groups that failed are left out and their errors joined, they are fetched
+// again when expanded.
+func fetchGroupsChildren(groups []*gitlab.Group, options ...gitlab.RequestOptionFunc) (map[int]*groupChildren, error) {
+	children := make([]*groupChildren, len(groups))
+	errs := make([]error, len(groups))
+
+	forEachParallel(len(groups), groupFetchWorkers, func(i int) {
+		children[i], errs[i] = fetchGroupChildren(groups[i], options...)
+		if errs[i] != nil {
+			errs[i] = fmt.Errorf("group %s: %w", groups[i].FullPath, errs[i])
+		}
+	})
+
+	fetched := make(map[int]*groupChildren, len(groups))
+	for i, group := range groups {
+		if errs[i] == nil {
+			fetched[group.ID] = children[i]
+		}
+	}
+	return fetched, errors.Join(errs...)
+}
+
+// prefetchingGroups holds the groups whose children are being prefetched,
+// so moving around the tree doesn't fetch them twice. It is only touched from
+// the UI goroutine.
+var prefetchingGroups = map[int]bool{}
+
+// prefetchVisibleGroups fetches the children of the group nodes of tree on
+// screen in the background and adds them to the group cache, so expanding
+// one of them is instant. Groups scrolled out of sight are left to be
+// fetched when they are expanded, so large instances aren't walked up front.
+// The groups that failed are reported together. The fetch isn't canceled
+// when the user opens another view, its results are kept for when they come
+// back. It must be called from the UI goroutine.
+func prefetchVisibleGroups(app *tview.Application, tree *tview.TreeView) {
+	var groups []*gitlab.Group
+	for _, node := range visibleTreeNodes(tree) {
+		group, ok := node.GetReference().(*gitlab.Group)
+		if !ok || prefetchingGroups[group.ID] || len(node.GetChildren()) > 0 {
+			continue
+		}
+		if _, cached := groupChildrenCache[group.ID]; cached {
+			continue
+		}
+		prefetchingGroups[group.ID] = true
+		groups = append(groups, group)
+	}
+	if len(groups) == 0 {
+		return
+	}
+
+	go func() {
+		children, err := fetchGroupsChildren(groups, detached)
+		app.QueueUpdateDraw(func() {
+			for _, group := range groups {
+				delete(prefetchingGroups, group.ID)
+			}
+			for id, groupChildren := range children {
+				if _, ok := groupChildrenCache[id]; !ok {
+					groupChildrenCache[id] = groupChildren
+				}
+			}
+			if err != nil {
+				showError(app, fmt.Errorf("fetching the contents of groups: %w", err), nil)
+			}
+		})
+	}()
+}
+
+// visibleTreeNodes returns the nodes of tree on screen, in the order they are
+// shown.
+func visibleTreeNodes(tree *tview.TreeView) []*tview.TreeNode {
+	var nodes []*tview.TreeNode
+	tree.GetRoot().Walk(func(node, parent *tview.TreeNode) bool {
+		// The root is hidden by the tree's top level of 1.
+		if parent != nil {
+			nodes = append(nodes, node)
+		}
+		return node.IsExpanded()
+	})
+
+	offset := tree.GetScrollOffset()
+	_, _, _, height := tree.GetInnerRect()
+	if height <= 0 {
+		// Not drawn yet, assume a screenful.
+		height = 50
+	}
+	if offset > len(nodes) {
+		offset = len(nodes)
+	}
+	if offset+height > len(nodes) {
+		height = len(nodes) - offset
+	}
+	return nodes[offset : offset+height]
+}
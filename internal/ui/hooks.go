@@ -0,0 +1,106 @@
+// hooks.go
+package ui
+
+import (
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+// hookEvents are the events a command can be hooked to, each set with
+// GPV_HOOK_<EVENT>, e.g. GPV_HOOK_ON_PIPELINE_FAILED.
+var hookEvents = []string{"on_pipeline_failed", "on_job_retried", "on_watch_complete"}
+
+// eventHooks maps an event to the shell command run when it happens.
+var eventHooks map[string]string
+
+// hookedFailures holds the pipelines on_pipeline_failed ran for, as both a
+// watch and a webhook can report the same failure.
+var (
+	hookedFailuresMu sync.Mutex
+	hookedFailures   = map[int]bool{}
+)
+
+func loadEventHooks() map[string]string {
+	hooks := map[string]string{}
+	for _, event := range hookEvents {
+		if command := os.Getenv("GPV_HOOK_" + strings.ToUpper(event)); command != "" {
+			hooks[event] = command
+		}
+	}
+	return hooks
+}
+
+// runEventHook runs the command hooked to event, if any, with env added to
+// its environment, and waits for it. Failures are only logged, a broken
+// hook mustn't get in the way.
+func runEventHook(event string, env []string) {
+	command := eventHooks[event]
+	if command == "" {
+		return
+	}
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", command)
+	} else {
+		cmd = exec.Command("sh", "-c", command)
+	}
+	cmd.Env = append(append(cmd.Environ(), "GPV_EVENT="+event), env...)
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		logWarn("hook failed", "event", event, "error", err, "output", strings.TrimSpace(string(output)))
+		return
+	}
+	logInfo("hook ran", "event", event)
+}
+
+// runPipelineFailedHook runs on_pipeline_failed once per pipeline.
+func runPipelineFailedHook(env []string, pipelineID int) {
+	hookedFailuresMu.Lock()
+	seen := hookedFailures[pipelineID]
+	hookedFailures[pipelineID] = true
+	hookedFailuresMu.Unlock()
+
+	if !seen {
+		runEventHook("on_pipeline_failed", env)
+	}
+}
+
+// pipelineHookEnv describes pipeline to a hook.
+func pipelineHookEnv(pipeline *gitlab.Pipeline) []string {
+	return []string{
+		"GPV_PROJECT=" + pipelineProjectPath(pipeline),
+		"GPV_PROJECT_ID=" + strconv.Itoa(pipeline.ProjectID),
+		"GPV_PIPELINE_ID=" + strconv.Itoa(pipeline.ID),
+		"GPV_PIPELINE_STATUS=" + pipeline.Status,
+		"GPV_PIPELINE_URL=" + pipeline.WebURL,
+		"GPV_REF=" + pipeline.Ref,
+		"GPV_SHA=" + pipeline.SHA,
+	}
+}
+
+// jobHookEnv describes job to a hook. For a retry, job is the new job and
+// retriedID the one it replaces, otherwise retriedID is 0.
+func jobHookEnv(projectID string, job *gitlab.Job, retriedID int) []string {
+	env := []string{
+		"GPV_PROJECT=" + webURLProjectPath(job.WebURL, projectID),
+		"GPV_PIPELINE_ID=" + strconv.Itoa(job.Pipeline.ID),
+		"GPV_REF=" + job.Ref,
+		"GPV_SHA=" + job.Pipeline.Sha,
+		"GPV_JOB_ID=" + strconv.Itoa(job.ID),
+		"GPV_JOB_NAME=" + job.Name,
+		"GPV_JOB_STAGE=" + job.Stage,
+		"GPV_JOB_URL=" + job.WebURL,
+	}
+	if retriedID != 0 {
+		env = append(env, "GPV_RETRIED_JOB_ID="+strconv.Itoa(retriedID))
+	}
+	return env
+}
@@ -0,0 +1,137 @@
+// jobdetail.go
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rivo/tview"
+	"github.com/xanzy/go-gitlab"
+)
+
+// runnerTags holds the tags of runners by ID. A nil entry means the runner's
+// details are still being fetched or couldn't be read, which is usual for
+// shared runners without admin rights. It is only used on the UI goroutine.
+var runnerTags = map[int][]string{}
+
+// newJobDetailPanel returns the panel shown next to the job list.
+func newJobDetailPanel() *tview.TextView {
+	panel := tview.NewTextView().
+		SetDynamicColors(true).
+		SetWordWrap(true)
+	panel.SetBorder(true).
+		SetTitle(" Job ")
+	return panel
+}
+
+// showJobDetail fills panel with job, fetching the tags of its runner first
+// if they aren't known yet. selected reports whether job is still the one
+// highlighted once they arrive.
+func showJobDetail(app *tview.Application, statusBar *tview.TextView, panel *tview.TextView, job *gitlab.Job, selected func(job *gitlab.Job) bool) {
+	panel.SetText(formatJobDetail(job)).ScrollToBeginning()
+
+	runnerID := job.Runner.ID
+	if _, ok := runnerTags[runnerID]; ok || runnerID == 0 {
+		return
+	}
+
+	runnerTags[runnerID] = nil
+	fetchInBackground(app, statusBar, "Loading runner...", func() (func(), error) {
+		runner, _, err := gitlabClient.Runners().GetRunnerDetails(runnerID)
+		if err != nil {
+			logDebug("fetching runner details failed", "runner", runnerID, "error", err)
+			return func() {}, nil
+		}
+		// The tags are cached even if the user has moved on from this view.
+		app.QueueUpdate(func() {
+			runnerTags[runnerID] = runner.TagList
+		})
+		return func() {
+			if selected(job) {
+				panel.SetText(formatJobDetail(job))
+			}
+		}, nil
+	})
+}
+
+func formatJobDetail(job *gitlab.Job) string {
+	var text strings.Builder
+	field := func(name, value string) {
+		fmt.Fprintf(&text, "[yellow]%-13s[-] %s\n", name, value)
+	}
+
+	field("Name", tview.Escape(job.Name))
+	field("Status", statusLabel(job.Status))
+	field("Stage", tview.Escape(job.Stage))
+	if job.FailureReason != "" {
+		field("Failure", "[red]"+strings.ReplaceAll(job.FailureReason, "_", " ")+"[-]")
+	}
+	field("Allow failure", fmt.Sprintf("%t", job.AllowFailure))
+	field("Queued", formatDuration(int(job.QueuedDuration)))
+	field("Duration", formatDuration(int(job.Duration)))
+	field("Started", formatTime(job.StartedAt))
+	field("Finished", formatTime(job.FinishedAt))
+	if job.ErasedAt != nil {
+		field("Erased", formatTime(job.ErasedAt))
+	}
+	if job.Coverage > 0 {
+		field("Coverage", fmt.Sprintf("%.2f%%", job.Coverage))
+	} else {
+		field("Coverage", "-")
+	}
+
+	if job.Runner.ID == 0 {
+		field("Runner", "-")
+	} else {
+		runner := job.Runner.Description
+		if runner == "" {
+			runner = fmt.Sprintf("#%d", job.Runner.ID)
+		}
+		if job.Runner.IsShared {
+			runner += " (shared)"
+		}
+		field("Runner", tview.Escape(runner))
+		if tags := runnerTags[job.Runner.ID]; len(tags) > 0 {
+			field("Runner tags", tview.Escape(strings.Join(tags, ", ")))
+		}
+	}
+	if len(job.TagList) > 0 {
+		field("Job tags", tview.Escape(strings.Join(job.TagList, ", ")))
+	}
+
+	if len(job.Artifacts) == 0 {
+		field("Artifacts", "-")
+	} else {
+		expiry := "never expire"
+		if job.ArtifactsExpireAt != nil {
+			expiry = "expire " + formatTime(job.ArtifactsExpireAt)
+		}
+		field("Artifacts", fmt.Sprintf("%d files, %s, %s", len(job.Artifacts), formatSize(artifactsSize(job)), expiry))
+	}
+
+	return text.String()
+}
+
+func formatBridgeDetail(bridge *gitlab.Bridge) string {
+	var text strings.Builder
+	field := func(name, value string) {
+		fmt.Fprintf(&text, "[yellow]%-13s[-] %s\n", name, value)
+	}
+
+	field("Name", tview.Escape(bridge.Name))
+	field("Status", statusLabel(bridge.Status))
+	field("Stage", tview.Escape(bridge.Stage))
+	if bridge.FailureReason != "" {
+		field("Failure", "[red]"+strings.ReplaceAll(bridge.FailureReason, "_", " ")+"[-]")
+	}
+	field("Allow failure", fmt.Sprintf("%t", bridge.AllowFailure))
+	field("Duration", formatDuration(int(bridge.Duration)))
+	if bridge.DownstreamPipeline != nil {
+		field("Downstream", tview.Escape(pipelineLabel(bridge.DownstreamPipeline.WebURL, bridge.DownstreamPipeline.ID))+
+			" "+statusLabel(bridge.DownstreamPipeline.Status))
+	} else {
+		field("Downstream", "not created yet")
+	}
+
+	return text.String()
+}
@@ -0,0 +1,434 @@
+// joblog.go
+package ui
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/xanzy/go-gitlab"
+)
+
+// logTailInterval is how often the log of a running job is polled for new
+// output.
+const logTailInterval = 2 * time.Second
+
+// stripLogColors drops the ANSI colors of job logs instead of rendering them.
+// Set with GPV_STRIP_ANSI.
+var stripLogColors bool
+
+// ansiSequence matches an ANSI control sequence such as a color change.
+var ansiSequence = regexp.MustCompile(`\x1b\[[0-9;?]*[ -/]*[@-~]`)
+
+// logWriter writes job log output for a text view. Brackets in the log are
+// escaped so they aren't taken for color tags, and ANSI colors are either
+// translated into color tags or stripped.
+type logWriter struct {
+	out io.Writer
+}
+
+func newLogWriter(out io.Writer) logWriter {
+	if stripLogColors {
+		return logWriter{out: out}
+	}
+	return logWriter{out: tview.ANSIWriter(out)}
+}
+
+func (w logWriter) Write(p []byte) (int, error) {
+	text := string(p)
+	if stripLogColors {
+		text = ansiSequence.ReplaceAllString(text, "")
+	}
+	if _, err := io.WriteString(w.out, tview.Escape(text)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func fetchAndDisplayJobLogs(app *tview.Application, projectID, jobID string, returnToModal func()) {
+	cancelPendingLoads()
+
+	logView := tview.NewTextView().
+		SetScrollable(true).
+		SetDynamicColors(true).
+		SetRegions(true).
+		SetWordWrap(true)
+
+	statusBar := newStatusBar()
+	logBar := newStatusBar()
+
+	// following keeps the view scrolled to the end while new output arrives.
+	// Scrolling up pauses it, f toggles it.
+	following := true
+	tailing := false
+	stopTail := func() {}
+
+	searchField := tview.NewInputField().
+		SetLabel("/").
+		SetFieldBackgroundColor(tcell.ColorDarkGray).
+		SetFieldTextColor(tcell.ColorOrangeRed)
+
+	// search is the pattern searched for with /, currentMatch the match n and
+	// N move from.
+	var search *regexp.Regexp
+	matches, currentMatch := 0, 0
+
+	renderLogBar := func() {
+		var parts []string
+		switch {
+		case !tailing:
+		case following:
+			parts = append(parts, statusIcon("running")+" following output (f to pause)")
+		default:
+			parts = append(parts, "[yellow]⏸ paused[-] (f to follow)")
+		}
+		switch {
+		case search == nil:
+		case matches == 0:
+			parts = append(parts, "no matches for "+tview.Escape(searchField.GetText()))
+		default:
+			parts = append(parts, fmt.Sprintf("match %d of %d (n/N)", currentMatch+1, matches))
+		}
+		logBar.SetText(strings.Join(parts, "   "))
+	}
+
+	// raw is the log as fetched so far. It is parsed into sections again
+	// whenever it grows, keeping the sections the user expanded or collapsed.
+	raw := ""
+	collapsed := map[string]bool{}
+	timestamps := noTimestamps
+	var sections []*logSection
+
+	showLog := func() {
+		row, column := logView.GetScrollOffset()
+		var text string
+		text, sections, matches = renderJobLog(parseJobLog(raw), logRender{
+			collapsed:  collapsed,
+			search:     search,
+			timestamps: timestamps,
+		})
+		logView.SetText(text)
+		if currentMatch >= matches {
+			currentMatch = 0
+		}
+		renderLogBar()
+		if following && tailing {
+			logView.ScrollToEnd()
+		} else {
+			logView.ScrollTo(row, column)
+		}
+	}
+
+	// selectSection highlights the section step places after the highlighted
+	// one, wrapping around.
+	selectSection := func(step int) {
+		if len(sections) == 0 {
+			return
+		}
+		next := 0
+		if step < 0 {
+			next = len(sections) - 1
+		}
+		if highlights := logView.GetHighlights(); len(highlights) > 0 {
+			for i, section := range sections {
+				if section.id == highlights[0] {
+					next = (i + step + len(sections)) % len(sections)
+					break
+				}
+			}
+		}
+		following = false
+		renderLogBar()
+		logView.Highlight(sections[next].id).ScrollToHighlight()
+	}
+
+	// selectMatch highlights the match step places after the current one,
+	// wrapping around.
+	selectMatch := func(step int) {
+		if matches == 0 {
+			return
+		}
+		currentMatch = (currentMatch + step + matches) % matches
+		following = false
+		renderLogBar()
+		logView.Highlight(fmt.Sprintf("match-%d", currentMatch)).ScrollToHighlight()
+	}
+
+	var flex *tview.Flex
+
+	searchField.SetDoneFunc(func(key tcell.Key) {
+		switch key {
+		case tcell.KeyEnter:
+			if searchField.GetText() == "" {
+				search = nil
+			} else {
+				search = compileLogSearch(searchField.GetText())
+			}
+			currentMatch = 0
+			showLog()
+			selectMatch(0)
+		case tcell.KeyEsc:
+			searchField.SetText("")
+			search = nil
+			logView.Highlight()
+			showLog()
+		}
+		flex.ResizeItem(searchField, 0, 0)
+		app.SetFocus(logView)
+	})
+
+	var startTail func(job *gitlab.Job, offset int)
+
+	reloadLogs := func() (func(), error) {
+		job, _, err := gitlabClient.Jobs().GetJob(projectID, toInt(jobID))
+		if err != nil {
+			return nil, fmt.Errorf("fetching job %s: %w", jobID, err)
+		}
+		logs, err := fetchJobTrace(projectID, jobID)
+		if err != nil {
+			return nil, fmt.Errorf("fetching logs for job %s: %w", jobID, err)
+		}
+		return func() {
+			stopTail()
+			raw = logs
+			if cancelableStatuses[job.Status] {
+				startTail(job, len(logs))
+			}
+			showLog()
+		}, nil
+	}
+
+	// startTail polls for output of job past offset until it finishes or the
+	// user leaves the view. It must be called from the UI goroutine.
+	startTail = func(job *gitlab.Job, offset int) {
+		generation := loadGeneration
+		done := make(chan struct{})
+		stopped := false
+		stopTail = func() {
+			if !stopped {
+				stopped = true
+				close(done)
+				tailing = false
+				renderLogBar()
+			}
+		}
+		tailing = true
+		renderLogBar()
+
+		go func() {
+			ticker := time.NewTicker(logTailInterval)
+			defer ticker.Stop()
+			wait := func() bool {
+				select {
+				case <-done:
+					return false
+				case <-ticker.C:
+					return true
+				}
+			}
+			emit := func(output string) error {
+				offset += len(output)
+				app.QueueUpdateDraw(func() {
+					if generation != loadGeneration {
+						stopTail()
+						return
+					}
+					raw += output
+					showLog()
+				})
+				return nil
+			}
+
+			for wait() {
+				latest, err := pollJobLog(projectID, job, offset, wait, emit)
+				job = latest
+				if err != nil {
+					queueError(app, fmt.Errorf("following logs of job %s: %w", jobID, err), nil)
+					continue
+				}
+				if !cancelableStatuses[job.Status] {
+					app.QueueUpdateDraw(func() {
+						stopTail()
+						showInfo(app, fmt.Sprintf("Job %s finished: %s", jobID, job.Status))
+					})
+				}
+				return
+			}
+		}()
+	}
+
+	logView.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch {
+		case event.Key() == tcell.KeyEsc:
+			stopTail()
+			returnToModal()
+			return nil
+		case isRefreshKey(event):
+			fetchInBackground(app, statusBar, "Refreshing...", reloadLogs)
+			return nil
+		case event.Key() == tcell.KeyTab:
+			selectSection(1)
+			return nil
+		case event.Key() == tcell.KeyBacktab:
+			selectSection(-1)
+			return nil
+		case event.Key() == tcell.KeyEnter:
+			highlights := logView.GetHighlights()
+			if len(highlights) == 0 {
+				return nil
+			}
+			for _, section := range sections {
+				if section.id == highlights[0] {
+					collapsed[section.id] = !section.collapsed
+					break
+				}
+			}
+			following = false
+			renderLogBar()
+			showLog()
+			logView.ScrollToHighlight()
+			return nil
+		case event.Rune() == '/':
+			flex.ResizeItem(searchField, 1, 0)
+			app.SetFocus(searchField)
+			return nil
+		case event.Rune() == 'e':
+			failedIn, ok := markFirstFailure(parseJobLog(raw))
+			if !ok {
+				showInfo(app, "No failure found in the log")
+				return nil
+			}
+			for _, section := range failedIn {
+				collapsed[section.id] = false
+			}
+			following = false
+			showLog()
+			logView.Highlight("failure").ScrollToHighlight()
+			return nil
+		case event.Rune() == 's':
+			path, err := saveDownload(fmt.Sprintf("job-%s.log", jobID), []byte(raw))
+			if err != nil {
+				showError(app, fmt.Errorf("saving logs of job %s: %w", jobID, err), nil)
+				return nil
+			}
+			showInfo(app, "Saved "+path)
+			return nil
+		case event.Rune() == 'p':
+			var err error
+			app.Suspend(func() {
+				err = openInPager(raw)
+			})
+			if err != nil {
+				showError(app, fmt.Errorf("opening logs of job %s in pager: %w", jobID, err), nil)
+			}
+			return nil
+		case event.Rune() == 't':
+			timestamps = (timestamps + 1) % 3
+			showLog()
+			return nil
+		case event.Rune() == 'n':
+			selectMatch(1)
+			return nil
+		case event.Rune() == 'N':
+			selectMatch(-1)
+			return nil
+		case event.Rune() == 'f':
+			following = !following
+			if following {
+				logView.ScrollToEnd()
+			}
+			renderLogBar()
+			return nil
+		case event.Key() == tcell.KeyUp, event.Key() == tcell.KeyPgUp, event.Key() == tcell.KeyHome,
+			event.Rune() == 'k', event.Rune() == 'g':
+			if following && tailing {
+				following = false
+				renderLogBar()
+			}
+		case event.Key() == tcell.KeyEnd, event.Rune() == 'G':
+			if !following && tailing {
+				following = true
+				renderLogBar()
+			}
+		}
+		return event
+	})
+
+	flex = tview.NewFlex().
+		SetDirection(tview.FlexRow).
+		AddItem(logView, 0, 1, true).
+		AddItem(searchField, 0, 0, false).
+		AddItem(logBar, 1, 0, false).
+		AddItem(statusBar, 1, 0, false).
+		AddItem(tview.NewButton("TAB - Next section   ENTER - Expand/Collapse   / - Search   e - First failure   t - Timestamps   s - Save   p - Pager   f - Follow   ESC - Back").SetSelectedFunc(func() {
+			stopTail()
+			returnToModal()
+		}), 1, 0, false)
+
+	routes.show("job-log/"+projectID+"/"+jobID, flex, logView)
+
+	fetchInBackground(app, statusBar, "Loading logs...", reloadLogs)
+}
+
+// openInPager shows text in $PAGER, or less if it isn't set. The app must be
+// suspended while it runs.
+func openInPager(text string) error {
+	pager := strings.Fields(os.Getenv("PAGER"))
+	if len(pager) == 0 {
+		pager = []string{"less", "-R"}
+	}
+
+	cmd := exec.Command(pager[0], pager[1:]...)
+	cmd.Stdin = strings.NewReader(text)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+func fetchJobTrace(projectID, jobID string) (string, error) {
+	logsReader, _, err := gitlabClient.Jobs().GetTraceFile(projectID, toInt(jobID))
+	if err != nil {
+		return "", err
+	}
+
+	logs, err := io.ReadAll(logsReader)
+	if err != nil {
+		return "", fmt.Errorf("reading logs: %w", err)
+	}
+	return string(logs), nil
+}
+
+// fetchJobTraceFrom returns the log output past offset bytes. It asks for
+// just that range, but copes with servers that send the whole trace anyway.
+func fetchJobTraceFrom(projectID, jobID string, offset int) (string, error) {
+	logsReader, resp, err := gitlabClient.Jobs().GetTraceFile(projectID, toInt(jobID),
+		gitlab.WithHeader("Range", fmt.Sprintf("bytes=%d-", offset)))
+	if err != nil {
+		// A range starting at the end of the trace can't be satisfied
+		// while nothing new has been written.
+		if resp != nil && resp.StatusCode == http.StatusRequestedRangeNotSatisfiable {
+			return "", nil
+		}
+		return "", err
+	}
+
+	logs, err := io.ReadAll(logsReader)
+	if err != nil {
+		return "", fmt.Errorf("reading logs: %w", err)
+	}
+
+	if resp.StatusCode == http.StatusPartialContent {
+		return string(logs), nil
+	}
+	if offset > len(logs) {
+		return "", nil
+	}
+	return string(logs[offset:]), nil
+}
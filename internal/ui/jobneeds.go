@@ -0,0 +1,88 @@
+// jobneeds.go
+package ui
+
+import (
+	"fmt"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/xanzy/go-gitlab"
+)
+
+// finishedStatuses are the job statuses that let the jobs after them start.
+var finishedStatuses = map[string]bool{
+	"success": true,
+	"skipped": true,
+}
+
+// newJobNeedsList lists the jobs job waits for and the jobs waiting for it.
+// Jobs without needs wait for every earlier stage, so for those the
+// unfinished jobs of earlier stages are listed instead. Selecting a job
+// calls jump with its name; Esc calls close.
+func newJobNeedsList(job *gitlab.Job, jobs []*gitlab.Job, needs map[string][]string, jump func(name string), close func()) *tview.List {
+	list := tview.NewList().ShowSecondaryText(false)
+	list.SetBorder(true).
+		SetTitle(fmt.Sprintf(" Dependencies of %s ", tview.Escape(job.Name)))
+
+	byName := map[string]*gitlab.Job{}
+	for _, other := range jobs {
+		byName[other.Name] = other
+	}
+
+	addJob := func(relation string, name string) {
+		label := "[gray]" + relation + "[-]  "
+		if other, ok := byName[name]; ok {
+			label += statusIcon(other.Status) + " " + tview.Escape(name)
+			if relation != "needed by" && !finishedStatuses[other.Status] {
+				label += "  [yellow](blocking)[-]"
+			}
+		} else {
+			label += tview.Escape(name) + "  [gray](not in this pipeline)[-]"
+		}
+		list.AddItem(label, "", 0, func() {
+			if _, ok := byName[name]; ok {
+				jump(name)
+			}
+		})
+	}
+
+	if jobNeeds := needs[job.Name]; len(jobNeeds) > 0 {
+		for _, name := range jobNeeds {
+			addJob("needs    ", name)
+		}
+	} else {
+		stages, byStage := jobsByStage(jobs)
+		for _, stage := range stages {
+			if stage == job.Stage {
+				break
+			}
+			for _, earlier := range sortedByName(byStage[stage]) {
+				if !finishedStatuses[earlier.Status] {
+					addJob("stage    ", earlier.Name)
+				}
+			}
+		}
+	}
+
+	for _, other := range sortedByName(jobs) {
+		for _, name := range needs[other.Name] {
+			if name == job.Name {
+				addJob("needed by", other.Name)
+			}
+		}
+	}
+
+	if list.GetItemCount() == 0 {
+		list.AddItem("[gray]Nothing is blocking this job and no job needs it[-]", "", 0, nil)
+	}
+
+	list.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyEsc {
+			close()
+			return nil
+		}
+		return event
+	})
+
+	return list
+}
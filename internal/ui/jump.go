@@ -0,0 +1,194 @@
+// jump.go
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/rivo/tview"
+	"github.com/xanzy/go-gitlab"
+)
+
+// jumpSpec names the view the TUI opens at instead of the group tree, for
+// users who know where they're going: a project, with optionally the
+// pipelines of a ref, a pipeline, a job or a merge request.
+type jumpSpec struct {
+	project         string
+	ref             string
+	pipelineID      int
+	jobID           int
+	mergeRequestIID int
+}
+
+// jumpTarget is a jumpSpec with what it names fetched.
+type jumpTarget struct {
+	project      *gitlab.Project
+	ref          string
+	pipelineID   int
+	job          *gitlab.Job
+	mergeRequest *gitlab.MergeRequest
+}
+
+// isWebURL reports whether the first argument is a GitLab link rather than
+// a command.
+func isWebURL(arg string) bool {
+	return strings.HasPrefix(arg, "https://") || strings.HasPrefix(arg, "http://")
+}
+
+// cliMode reports whether gpv runs a command instead of the TUI.
+func cliMode() bool {
+	return len(cfg.Args) > 0 && !isWebURL(cfg.Args[0])
+}
+
+// resolveJumpTarget fetches what a GitLab link argument or the -p, --ref and
+// --pipeline flags point at. It returns nil if there is neither.
+func resolveJumpTarget() (*jumpTarget, error) {
+	if len(cfg.Args) > 0 {
+		if len(cfg.Args) > 1 {
+			return nil, errors.New("only one GitLab link can be opened")
+		}
+		spec, err := parseWebURL(cfg.Args[0])
+		if err != nil {
+			return nil, err
+		}
+		return spec.resolve()
+	}
+
+	if cfg.Project == "" {
+		if cfg.Ref != "" || cfg.Pipeline != 0 {
+			return nil, errors.New("--ref and --pipeline need a project, given with -p")
+		}
+		return nil, nil
+	}
+	return jumpSpec{project: cfg.Project, ref: cfg.Ref, pipelineID: cfg.Pipeline}.resolve()
+}
+
+// parseWebURL reads a link to a project on the configured instance, or to
+// one of its pipelines, jobs, merge requests, branches or tags, as copied
+// from the browser.
+func parseWebURL(raw string) (jumpSpec, error) {
+	link, err := url.Parse(raw)
+	if err != nil {
+		return jumpSpec{}, fmt.Errorf("invalid link %q: %w", raw, err)
+	}
+	instance, err := url.Parse(gitlabURL)
+	if err != nil {
+		return jumpSpec{}, fmt.Errorf("invalid GITLAB_URL %q: %w", gitlabURL, err)
+	}
+	root := strings.TrimSuffix(instance.Path, "/") + "/"
+	if link.Host != instance.Host || !strings.HasPrefix(link.Path, root) {
+		return jumpSpec{}, fmt.Errorf("%s is not a link to %s", raw, gitlabURL)
+	}
+
+	path := strings.Trim(strings.TrimPrefix(link.Path, root), "/")
+	project, rest, _ := strings.Cut(path, "/-/")
+	if project == "" || !strings.Contains(project, "/") {
+		return jumpSpec{}, fmt.Errorf("%s is not a link to a project", raw)
+	}
+	spec := jumpSpec{project: project, ref: link.Query().Get("ref")}
+
+	parts := strings.Split(rest, "/")
+	parseID := func() (int, error) {
+		if len(parts) < 2 {
+			return 0, fmt.Errorf("%s doesn't name a %s", raw, strings.TrimSuffix(parts[0], "s"))
+		}
+		id, err := strconv.Atoi(parts[1])
+		if err != nil {
+			return 0, fmt.Errorf("%s doesn't name a %s", raw, strings.TrimSuffix(parts[0], "s"))
+		}
+		return id, nil
+	}
+
+	switch parts[0] {
+	case "", "branches", "tags":
+	case "pipelines":
+		// Without an ID this is the pipeline list, filtered by ?ref=.
+		if len(parts) > 1 {
+			spec.pipelineID, err = parseID()
+		}
+	case "jobs":
+		spec.jobID, err = parseID()
+	case "merge_requests":
+		spec.mergeRequestIID, err = parseID()
+	case "tree", "commits":
+		spec.ref = strings.Join(parts[1:], "/")
+	default:
+		return jumpSpec{}, fmt.Errorf("gpv can't open %s: only projects, pipelines, jobs, merge requests and branches are supported", raw)
+	}
+	if err != nil {
+		return jumpSpec{}, err
+	}
+	return spec, nil
+}
+
+// resolve fetches the project of s and what else is needed to open its
+// view, such as the ref of a pipeline so going back from it lists the
+// pipelines of its ref.
+func (s jumpSpec) resolve() (*jumpTarget, error) {
+	project, _, err := gitlabClient.Projects().GetProject(s.project, nil)
+	if err != nil {
+		return nil, fmt.Errorf("fetching project %s: %w", s.project, err)
+	}
+	target := &jumpTarget{project: project, ref: s.ref, pipelineID: s.pipelineID}
+
+	switch {
+	case s.jobID != 0:
+		job, _, err := gitlabClient.Jobs().GetJob(project.ID, s.jobID)
+		if err != nil {
+			return nil, fmt.Errorf("fetching job %d: %w", s.jobID, err)
+		}
+		target.job = job
+		target.pipelineID = job.Pipeline.ID
+		target.ref = job.Ref
+	case s.mergeRequestIID != 0:
+		mergeRequest, _, err := gitlabClient.MergeRequests().GetMergeRequest(project.ID, s.mergeRequestIID, nil)
+		if err != nil {
+			return nil, fmt.Errorf("fetching merge request !%d: %w", s.mergeRequestIID, err)
+		}
+		target.mergeRequest = mergeRequest
+	case s.pipelineID != 0 && s.ref == "":
+		pipeline, _, err := gitlabClient.Pipelines().GetPipeline(project.ID, s.pipelineID)
+		if err != nil {
+			return nil, fmt.Errorf("fetching pipeline #%d: %w", s.pipelineID, err)
+		}
+		target.ref = pipeline.Ref
+	}
+	return target, nil
+}
+
+// open shows the view of target: the log of a job, the pipelines of a merge
+// request, a pipeline, the pipelines of a ref or the branches of a project.
+func (t *jumpTarget) open(app *tview.Application) {
+	projectID := strconv.Itoa(t.project.ID)
+	logInfo("opening", "project", t.project.PathWithNamespace, "ref", t.ref, "pipeline", t.pipelineID)
+
+	if t.pipelineID != 0 || t.ref != "" || t.mergeRequest != nil {
+		if err := recordRecentProject(t.project.ID, t.project.PathWithNamespace); err != nil {
+			showError(app, err, nil)
+		}
+	}
+
+	switch {
+	case t.job != nil:
+		pipelineID := strconv.Itoa(t.pipelineID)
+		fetchAndDisplayJobLogs(app, projectID, strconv.Itoa(t.job.ID), func() {
+			showJobList(app, nil, nil, projectID, pipelineID, t.ref)
+		})
+	case t.mergeRequest != nil:
+		showMergeRequestPipelines(app, t.project, t.mergeRequest, func() {
+			showMergeRequests(app, t.project, func() {
+				showTree(app, lastSearchTerm)
+			})
+		})
+	case t.pipelineID != 0:
+		pipelineTrail = nil
+		showPipelineDetail(app, projectID, strconv.Itoa(t.pipelineID), t.ref)
+	case t.ref != "":
+		fetchAndShowPipelines(app, projectID, t.ref)
+	default:
+		showPipelines(app, t.project)
+	}
+}
@@ -0,0 +1,53 @@
+package ui
+
+import "testing"
+
+func TestParseWebURL(t *testing.T) {
+	saved := gitlabURL
+	defer func() { gitlabURL = saved }()
+	gitlabURL = "https://gitlab.example.com/"
+
+	tests := []struct {
+		link    string
+		want    jumpSpec
+		wantErr bool
+	}{
+		{link: "https://gitlab.example.com/group/project", want: jumpSpec{project: "group/project"}},
+		{link: "https://gitlab.example.com/group/sub/project/-/pipelines/42", want: jumpSpec{project: "group/sub/project", pipelineID: 42}},
+		{link: "https://gitlab.example.com/group/project/-/pipelines?ref=main", want: jumpSpec{project: "group/project", ref: "main"}},
+		{link: "https://gitlab.example.com/group/project/-/jobs/7", want: jumpSpec{project: "group/project", jobID: 7}},
+		{link: "https://gitlab.example.com/group/project/-/merge_requests/3/diffs", want: jumpSpec{project: "group/project", mergeRequestIID: 3}},
+		{link: "https://gitlab.example.com/group/project/-/tree/feature/x", want: jumpSpec{project: "group/project", ref: "feature/x"}},
+		{link: "https://gitlab.example.com/group/project/-/jobs/latest", wantErr: true},
+		{link: "https://gitlab.example.com/group/project/-/issues/1", wantErr: true},
+		{link: "https://gitlab.example.com/group", wantErr: true},
+		{link: "https://gitlab.com/group/project", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseWebURL(tt.link)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseWebURL(%q): error %v, want error %v", tt.link, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseWebURL(%q) = %+v, want %+v", tt.link, got, tt.want)
+		}
+	}
+}
+
+func TestParseWebURLSubpath(t *testing.T) {
+	saved := gitlabURL
+	defer func() { gitlabURL = saved }()
+	gitlabURL = "https://example.com/gitlab"
+
+	got, err := parseWebURL("https://example.com/gitlab/group/project/-/pipelines/5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (jumpSpec{project: "group/project", pipelineID: 5}); got != want {
+		t.Errorf("parseWebURL() = %+v, want %+v", got, want)
+	}
+	if _, err := parseWebURL("https://example.com/other/group/project"); err == nil {
+		t.Error("parseWebURL() outside the instance path: no error")
+	}
+}
@@ -0,0 +1,172 @@
+// keybinds.go
+package ui
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/xanzy/go-gitlab"
+
+	"main.go/internal/config"
+)
+
+// keybinds maps a key to the shell command it runs, from the keybinds file.
+// A binding takes precedence over what the key does in gpv.
+var keybinds map[rune]string
+
+// keybindContext returns the environment variables describing what is
+// selected in the current view, for the command of a key binding. Views set
+// it with setKeybindContext, opening another view resets it.
+var keybindContext func() []string
+
+// keybindsFilePath returns GPV_KEYBINDS_FILE or, if it isn't set, keybinds
+// in the config directory.
+func keybindsFilePath() (string, error) {
+	if path := os.Getenv("GPV_KEYBINDS_FILE"); path != "" {
+		return path, nil
+	}
+	dir, err := config.ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "keybinds"), nil
+}
+
+// loadKeybinds reads the keybinds file. Each line binds a single character
+// to a shell command, e.g.
+//
+//	M glab mr view $GPV_MR_IID
+//
+// Empty lines and lines starting with # are ignored. A missing file binds
+// nothing.
+func loadKeybinds() (map[rune]string, error) {
+	path, err := keybindsFilePath()
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	bindings := map[rune]string{}
+	scanner := bufio.NewScanner(file)
+	for number := 1; scanner.Scan(); number++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, command, _ := strings.Cut(line, " ")
+		command = strings.TrimSpace(command)
+		if utf8.RuneCountInString(key) != 1 || command == "" {
+			return nil, fmt.Errorf("%s:%d: expected a single character and a command, got %q", path, number, line)
+		}
+		r, _ := utf8.DecodeRuneInString(key)
+		bindings[r] = command
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	logInfo("loaded key bindings", "path", path, "count", len(bindings))
+	return bindings, nil
+}
+
+// setKeybindContext makes context describe the selection of the current view
+// to key binding commands. It must be called from the UI goroutine.
+func setKeybindContext(context func() []string) {
+	keybindContext = context
+}
+
+// handleKeybind runs the command bound to the key of event, unless text is
+// being typed, and reports whether there was one.
+func handleKeybind(app *tview.Application, event *tcell.EventKey) bool {
+	if event.Key() != tcell.KeyRune || event.Modifiers()&(tcell.ModCtrl|tcell.ModAlt) != 0 {
+		return false
+	}
+	command, ok := keybinds[event.Rune()]
+	if !ok {
+		return false
+	}
+	switch app.GetFocus().(type) {
+	case *tview.InputField, *tview.TextArea:
+		return false
+	}
+
+	var env []string
+	if keybindContext != nil {
+		env = keybindContext()
+	}
+	var err error
+	app.Suspend(func() {
+		err = runKeybind(command, env)
+	})
+	if err != nil {
+		showError(app, fmt.Errorf("running key binding %c: %w", event.Rune(), err), nil)
+	}
+	return true
+}
+
+// runKeybind runs command in the terminal with env added to its environment,
+// then waits for Enter so its output can be read before gpv comes back.
+func runKeybind(command string, env []string) error {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", command)
+	} else {
+		cmd = exec.Command("sh", "-c", command)
+	}
+	cmd.Env = append(cmd.Environ(), env...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	logInfo("running key binding", "command", command)
+	err := cmd.Run()
+
+	fmt.Print("\nPress Enter to return to gpv")
+	bufio.NewReader(os.Stdin).ReadString('\n')
+	return err
+}
+
+// projectKeybindEnv describes project to a key binding command.
+func projectKeybindEnv(project *gitlab.Project) []string {
+	return []string{
+		"GPV_PROJECT=" + project.PathWithNamespace,
+		"GPV_PROJECT_ID=" + strconv.Itoa(project.ID),
+		"GPV_PROJECT_URL=" + project.WebURL,
+	}
+}
+
+// groupKeybindEnv describes group to a key binding command.
+func groupKeybindEnv(group *gitlab.Group) []string {
+	return []string{
+		"GPV_GROUP=" + group.FullPath,
+		"GPV_GROUP_ID=" + strconv.Itoa(group.ID),
+		"GPV_GROUP_URL=" + group.WebURL,
+	}
+}
+
+// mergeRequestKeybindEnv describes mergeRequest of project to a key binding
+// command.
+func mergeRequestKeybindEnv(project *gitlab.Project, mergeRequest *gitlab.MergeRequest) []string {
+	return append(projectKeybindEnv(project),
+		"GPV_MR_IID="+strconv.Itoa(mergeRequest.IID),
+		"GPV_MR_TITLE="+mergeRequest.Title,
+		"GPV_MR_URL="+mergeRequest.WebURL,
+		"GPV_MR_SOURCE_BRANCH="+mergeRequest.SourceBranch,
+		"GPV_MR_TARGET_BRANCH="+mergeRequest.TargetBranch,
+	)
+}
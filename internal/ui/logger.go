@@ -0,0 +1,9 @@
+// logger.go
+package ui
+
+import "main.go/internal/logging"
+
+func logDebug(msg string, keyvals ...interface{}) { logging.Debug(msg, keyvals...) }
+func logInfo(msg string, keyvals ...interface{})  { logging.Info(msg, keyvals...) }
+func logWarn(msg string, keyvals ...interface{})  { logging.Warn(msg, keyvals...) }
+func logError(msg string, keyvals ...interface{}) { logging.Error(msg, keyvals...) }
@@ -0,0 +1,350 @@
+// logsections.go
+package ui
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rivo/tview"
+)
+
+// sectionMarker matches the markers GitLab Runner writes around the steps of
+// a job, e.g. section_start:1700000000:restore_cache[collapsed=true]\r\e[0K.
+var sectionMarker = regexp.MustCompile(`section_(start|end):(\d+):([A-Za-z0-9_.\-]+)(?:\[([^\]]*)\])?\r\x1b\[0K`)
+
+// logSection is a part of a job log between a section_start and a
+// section_end marker. Sections can be nested.
+type logSection struct {
+	id        string
+	name      string
+	header    string
+	started   int64
+	ended     int64
+	collapsed bool
+	parent    *logSection
+	entries   []logEntry
+}
+
+// logEntry is either a line of a job log or a section nested in it. at is
+// when it was written, if the log tells.
+type logEntry struct {
+	line    string
+	section *logSection
+	failure bool
+	at      time.Time
+}
+
+// lineTimestamp matches the prefix GitLab Runner puts on every line of the
+// log when timestamps are enabled: the time, a stream number, O or E for
+// stdout or stderr and a + if the line continues the previous one.
+var lineTimestamp = regexp.MustCompile(`^(\d{4}-\d\d-\d\dT\d\d:\d\d:\d\d(?:\.\d+)?Z) [0-9a-f]{2}[OE](?:(\+)| )`)
+
+// logTimestamps is how the lines of a job log are prefixed with the time
+// they were written.
+type logTimestamps int
+
+const (
+	noTimestamps logTimestamps = iota
+	absoluteTimestamps
+	relativeTimestamps
+)
+
+// logRender is how renderJobLog shows a job log. collapsed holds the
+// sections the user expanded or collapsed, which take precedence over the
+// sections' own option. If search is set its matches are marked.
+type logRender struct {
+	collapsed  map[string]bool
+	search     *regexp.Regexp
+	timestamps logTimestamps
+}
+
+// failurePattern matches lines that show something went wrong in a job.
+var failurePattern = regexp.MustCompile(`\bERROR\b|\bFAILED\b|exit (code|status) [1-9]`)
+
+// title is the header GitLab shows for the section, or its name if it has
+// none.
+func (s *logSection) title() string {
+	if visibleLogText(s.header) {
+		return s.header
+	}
+	return s.name
+}
+
+// parseJobLog splits raw into its sections. The returned root section holds
+// the lines outside of any section and starts when the log does. A section
+// without an end marker yet runs to the end of the log. Lines are timed by
+// their timestamp prefix if they have one, or else by the last section marker
+// before them.
+func parseJobLog(raw string) *logSection {
+	root := &logSection{}
+	current := root
+	count := 0
+	var at time.Time
+
+	setTime := func(t time.Time) {
+		at = t
+		if root.started == 0 {
+			root.started = t.Unix()
+		}
+	}
+
+	for _, line := range strings.Split(strings.TrimSuffix(raw, "\n"), "\n") {
+		line = strings.TrimSuffix(line, "\r")
+
+		continued := false
+		if m := lineTimestamp.FindStringSubmatchIndex(line); m != nil {
+			if t, err := time.Parse(time.RFC3339Nano, line[m[2]:m[3]]); err == nil {
+				setTime(t)
+			}
+			continued = m[4] >= 0
+			line = line[m[1]:]
+		}
+
+		markers := sectionMarker.FindAllStringSubmatchIndex(line, -1)
+		if len(markers) == 0 {
+			if last := len(current.entries) - 1; continued && last >= 0 && current.entries[last].section == nil {
+				current.entries[last].line = lastOverwrite(current.entries[last].line + line)
+				continue
+			}
+			current.entries = append(current.entries, logEntry{line: lastOverwrite(line), at: at})
+			continue
+		}
+
+		if before := line[:markers[0][0]]; visibleLogText(before) {
+			current.entries = append(current.entries, logEntry{line: lastOverwrite(before), at: at})
+		}
+
+		for i, m := range markers {
+			kind := line[m[2]:m[3]]
+			timestamp, _ := strconv.ParseInt(line[m[4]:m[5]], 10, 64)
+			name := line[m[6]:m[7]]
+			options := ""
+			if m[8] >= 0 {
+				options = line[m[8]:m[9]]
+			}
+
+			next := len(line)
+			if i+1 < len(markers) {
+				next = markers[i+1][0]
+			}
+			rest := lastOverwrite(line[m[1]:next])
+			if at.IsZero() || at.Unix() < timestamp {
+				setTime(time.Unix(timestamp, 0))
+			}
+
+			switch kind {
+			case "start":
+				section := &logSection{
+					id:        "section-" + strconv.Itoa(count),
+					name:      name,
+					header:    rest,
+					started:   timestamp,
+					collapsed: strings.Contains(options, "collapsed=true"),
+					parent:    current,
+				}
+				count++
+				current.entries = append(current.entries, logEntry{section: section, at: at})
+				current = section
+			case "end":
+				for section := current; section != root; section = section.parent {
+					if section.name == name {
+						section.ended = timestamp
+						current = section.parent
+						break
+					}
+				}
+				if visibleLogText(rest) {
+					current.entries = append(current.entries, logEntry{line: rest, at: at})
+				}
+			}
+		}
+	}
+
+	return root
+}
+
+// renderJobLog turns root into text for a text view with dynamic colors and
+// regions. Every section header is a region named after the section's id and
+// is followed by the section's lines unless it is collapsed. Each match of
+// the search in the visible lines is a region named match-0, match-1 and so
+// on, and the first failure in the log is a region named failure. The visible
+// sections and the number of matches are returned along with the text.
+func renderJobLog(root *logSection, options logRender) (string, []*logSection, int) {
+	var text strings.Builder
+	var sections []*logSection
+	matches := 0
+	out := newLogWriter(&text)
+	markFirstFailure(root)
+
+	var render func(section *logSection)
+	render = func(section *logSection) {
+		for _, entry := range section.entries {
+			text.WriteString(options.timestamp(root, entry.at))
+			if entry.failure {
+				text.WriteString(`["failure"]`)
+				fmt.Fprint(out, entry.line)
+				text.WriteString(`[""]` + "\n")
+				continue
+			}
+			if entry.section == nil {
+				if options.search != nil && renderMatches(&text, entry.line, options.search, &matches) {
+					continue
+				}
+				fmt.Fprintln(out, entry.line)
+				continue
+			}
+
+			s := entry.section
+			if c, ok := options.collapsed[s.id]; ok {
+				s.collapsed = c
+			}
+			sections = append(sections, s)
+
+			fmt.Fprintf(&text, `["%s"]%s `, s.id, sectionArrow(s.collapsed))
+			fmt.Fprint(out, s.title())
+			text.WriteString("[-:-:-]")
+			if s.ended > 0 {
+				fmt.Fprintf(&text, "[gray] — %s[-]", time.Duration(s.ended-s.started)*time.Second)
+			}
+			text.WriteString(`[""]` + "\n")
+
+			if !s.collapsed {
+				render(s)
+			}
+		}
+	}
+	render(root)
+
+	return text.String(), sections, matches
+}
+
+// renderMatches writes line with every match of search marked as a region,
+// numbering them on from *matches. Lines with matches lose their ANSI colors
+// so the marks stand out. It reports whether line had any matches.
+func renderMatches(text *strings.Builder, line string, search *regexp.Regexp, matches *int) bool {
+	plain := ansiSequence.ReplaceAllString(line, "")
+
+	var found [][]int
+	for _, loc := range search.FindAllStringIndex(plain, -1) {
+		if loc[1] > loc[0] {
+			found = append(found, loc)
+		}
+	}
+	if len(found) == 0 {
+		return false
+	}
+
+	text.WriteString("[-:-:-]")
+	pos := 0
+	for _, loc := range found {
+		fmt.Fprintf(text, `%s["match-%d"][black:yellow]%s[-:-][""]`,
+			tview.Escape(plain[pos:loc[0]]), *matches, tview.Escape(plain[loc[0]:loc[1]]))
+		*matches++
+		pos = loc[1]
+	}
+	text.WriteString(tview.Escape(plain[pos:]) + "\n")
+	return true
+}
+
+// markFirstFailure marks the first line of root that shows a failure and
+// returns the sections it is in, outermost first. When that is GitLab's
+// closing "Job failed" line, the last script command before it is marked
+// instead, as that's the one that failed.
+func markFirstFailure(root *logSection) ([]*logSection, bool) {
+	var (
+		found     *logEntry
+		foundIn   []*logSection
+		command   *logEntry
+		commandIn []*logSection
+	)
+
+	var walk func(section *logSection, path []*logSection) bool
+	walk = func(section *logSection, path []*logSection) bool {
+		for i := range section.entries {
+			entry := &section.entries[i]
+			if entry.section != nil {
+				if walk(entry.section, append(path[:len(path):len(path)], entry.section)) {
+					return true
+				}
+				continue
+			}
+
+			line := strings.TrimSpace(ansiSequence.ReplaceAllString(entry.line, ""))
+			switch {
+			case strings.HasPrefix(line, "ERROR: Job failed") && command != nil:
+				found, foundIn = command, commandIn
+				return true
+			case failurePattern.MatchString(line):
+				found, foundIn = entry, path
+				return true
+			case strings.HasPrefix(line, "$ "):
+				command, commandIn = entry, path
+			}
+		}
+		return false
+	}
+
+	if !walk(root, nil) {
+		return nil, false
+	}
+	found.failure = true
+	return foundIn, true
+}
+
+// compileLogSearch turns what the user typed into a case-insensitive
+// regular expression, matching it literally if it isn't a valid one.
+func compileLogSearch(pattern string) *regexp.Regexp {
+	if search, err := regexp.Compile("(?i)" + pattern); err == nil {
+		return search
+	}
+	return regexp.MustCompile("(?i)" + regexp.QuoteMeta(pattern))
+}
+
+// timestamp is the prefix of a line written at, in a log that started with
+// root.
+func (o logRender) timestamp(root *logSection, at time.Time) string {
+	switch {
+	case o.timestamps == noTimestamps:
+		return ""
+	case at.IsZero() && o.timestamps == absoluteTimestamps:
+		return strings.Repeat(" ", len("15:04:05 "))
+	case at.IsZero():
+		return strings.Repeat(" ", len("+00:00:00 "))
+	case o.timestamps == absoluteTimestamps:
+		return "[gray]" + at.Local().Format("15:04:05") + "[-] "
+	default:
+		elapsed := int(at.Sub(time.Unix(root.started, 0)).Seconds())
+		return fmt.Sprintf("[gray]+%02d:%02d:%02d[-] ", elapsed/3600, elapsed/60%60, elapsed%60)
+	}
+}
+
+func sectionArrow(collapsed bool) string {
+	switch {
+	case asciiIcons && collapsed:
+		return "+"
+	case asciiIcons:
+		return "-"
+	case collapsed:
+		return "▸"
+	default:
+		return "▾"
+	}
+}
+
+// lastOverwrite returns what's left of line on a terminal after carriage
+// returns, which progress output uses to redraw a line in place.
+func lastOverwrite(line string) string {
+	if i := strings.LastIndex(line, "\r"); i >= 0 {
+		return line[i+1:]
+	}
+	return line
+}
+
+// visibleLogText reports whether text shows anything once its ANSI sequences
+// are removed.
+func visibleLogText(text string) bool {
+	return strings.TrimSpace(ansiSequence.ReplaceAllString(text, "")) != ""
+}
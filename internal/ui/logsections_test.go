@@ -0,0 +1,141 @@
+package ui
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// outline describes the lines and sections of s, with sections written as
+// name{...} and collapsed ones as name+{...}.
+func outline(s *logSection) string {
+	var parts []string
+	for _, entry := range s.entries {
+		switch {
+		case entry.section == nil:
+			parts = append(parts, entry.line)
+		case entry.section.collapsed:
+			parts = append(parts, entry.section.name+"+{"+outline(entry.section)+"}")
+		default:
+			parts = append(parts, entry.section.name+"{"+outline(entry.section)+"}")
+		}
+	}
+	return strings.Join(parts, "|")
+}
+
+func sectionStart(at, name, options string) string {
+	if options != "" {
+		name += "[" + options + "]"
+	}
+	return "section_start:" + at + ":" + name + "\r\x1b[0K"
+}
+
+func sectionEnd(at, name string) string {
+	return "section_end:" + at + ":" + name + "\r\x1b[0K"
+}
+
+func TestParseJobLog(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		want string
+	}{
+		{"plain", "one\ntwo\n", "one|two"},
+		{"carriage returns", "progress 10%\rprogress 100%\r\n", "progress 100%"},
+		{
+			"section",
+			"before\n" + sectionStart("100", "build", "") + "Building\nmake\n" + sectionEnd("105", "build") + "\nafter\n",
+			"before|build{make}|after",
+		},
+		{
+			"collapsed and nested",
+			sectionStart("100", "outer", "collapsed=true") + "Outer\n" + sectionStart("101", "inner", "") + "Inner\nx\n" +
+				sectionEnd("102", "inner") + sectionEnd("103", "outer") + "\ny\n",
+			"outer+{inner{x}}|y",
+		},
+		{
+			"unterminated",
+			sectionStart("100", "script", "") + "Script\n$ make\nstill running\n",
+			"script{$ make|still running}",
+		},
+		{
+			"timestamps",
+			"2024-01-01T10:00:00.000000Z 00O one\n2024-01-01T10:00:01.000000Z 00O+ more\n2024-01-01T10:00:02Z 01E two\n",
+			"one more|two",
+		},
+	}
+	for _, tt := range tests {
+		if got := outline(parseJobLog(tt.raw)); got != tt.want {
+			t.Errorf("%s: parseJobLog() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParseJobLogTimes(t *testing.T) {
+	root := parseJobLog(sectionStart("100", "build", "") + "Building\n" + sectionEnd("105", "build") + "\n")
+	if root.started != 100 {
+		t.Errorf("root started = %d, want 100", root.started)
+	}
+	build := root.entries[0].section
+	if build.header != "Building" || build.started != 100 || build.ended != 105 {
+		t.Errorf("section = %q %d-%d, want Building 100-105", build.header, build.started, build.ended)
+	}
+}
+
+func TestMarkFirstFailure(t *testing.T) {
+	tests := []struct {
+		name     string
+		raw      string
+		wantLine string
+		wantIn   []string
+	}{
+		{"none", "all good\n", "", nil},
+		{"error line", "ok\nERROR: something broke\nFAILED later\n", "ERROR: something broke", nil},
+		{
+			"job failed points at command",
+			sectionStart("100", "step_script", "") + "Executing\n$ make test\noutput\n" + sectionEnd("101", "step_script") +
+				"\nERROR: Job failed: exit code 2\n",
+			"$ make test",
+			[]string{"step_script"},
+		},
+		{
+			"nested",
+			sectionStart("100", "outer", "") + "O\n" + sectionStart("101", "inner", "") + "I\nprocess exit status 3\n" +
+				sectionEnd("102", "inner") + sectionEnd("103", "outer") + "\n",
+			"process exit status 3",
+			[]string{"outer", "inner"},
+		},
+	}
+	for _, tt := range tests {
+		root := parseJobLog(tt.raw)
+		in, found := markFirstFailure(root)
+		if found != (tt.wantLine != "") {
+			t.Errorf("%s: found = %v, want %v", tt.name, found, tt.wantLine != "")
+			continue
+		}
+		var names []string
+		for _, section := range in {
+			names = append(names, section.name)
+		}
+		if !reflect.DeepEqual(names, tt.wantIn) {
+			t.Errorf("%s: sections = %v, want %v", tt.name, names, tt.wantIn)
+		}
+		if got := failedLine(root); got != tt.wantLine {
+			t.Errorf("%s: marked %q, want %q", tt.name, got, tt.wantLine)
+		}
+	}
+}
+
+func failedLine(s *logSection) string {
+	for _, entry := range s.entries {
+		if entry.failure {
+			return entry.line
+		}
+		if entry.section != nil {
+			if line := failedLine(entry.section); line != "" {
+				return line
+			}
+		}
+	}
+	return ""
+}
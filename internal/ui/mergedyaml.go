@@ -0,0 +1,154 @@
+// mergedyaml.go
+package ui
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/xanzy/go-gitlab"
+)
+
+var (
+	// yamlKey matches the key of a mapping entry, after the indentation and
+	// any list dash in front of it.
+	yamlKey = regexp.MustCompile(`^(\s*(?:-\s+)*)([^\s#'"\-][^:#]*|"[^"]*"|'[^']*'):(\s|$)`)
+	// yamlListItem matches the dash of a list item without a key.
+	yamlListItem = regexp.MustCompile(`^(\s*)(-)(\s|$)`)
+	// yamlComment matches a comment, which starts a line or follows a space.
+	yamlComment = regexp.MustCompile(`(^|\s)#.*$`)
+)
+
+// showMergedCIConfig shows the CI config at localPath, or the project's own
+// config on ref, with its includes resolved and extends expanded, the way a
+// pipeline would run it. r fetches it again. back is called on Esc.
+func showMergedCIConfig(app *tview.Application, project *gitlab.Project, ref, localPath string, back func()) {
+	cancelPendingLoads()
+
+	view := tview.NewTextView().
+		SetDynamicColors(true).
+		SetScrollable(true).
+		SetWrap(false)
+	view.SetBorder(true).
+		SetTitle(" Merged CI config ")
+	statusBar := newStatusBar()
+
+	load := func() (func(), error) {
+		config, err := loadCIConfig(project, ref, localPath)
+		if err != nil {
+			return nil, err
+		}
+		result, err := lintCIConfig(project.ID, ref, config)
+		if err != nil {
+			return nil, err
+		}
+		return func() {
+			view.SetTitle(fmt.Sprintf(" Merged CI config: %s ", tview.Escape(config.source)))
+			if !result.Valid {
+				view.SetText(formatLintResult(result, config.content)).ScrollToBeginning()
+				return
+			}
+			view.SetText(highlightYAML(result.MergedYaml)).ScrollToBeginning()
+		}, nil
+	}
+
+	view.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch {
+		case event.Key() == tcell.KeyEsc:
+			back()
+			return nil
+		case isRefreshKey(event):
+			fetchInBackground(app, statusBar, "Refreshing...", load)
+			return nil
+		}
+		return event
+	})
+
+	flex := tview.NewFlex().
+		SetDirection(tview.FlexRow).
+		AddItem(view, 0, 1, true).
+		AddItem(statusBar, 1, 0, false).
+		AddItem(tview.NewButton("r - Refresh   ESC - Back").SetSelectedFunc(back), 1, 0, false)
+
+	routes.show("merged-ci/"+strconv.Itoa(project.ID)+"/"+ref, flex, view)
+
+	fetchInBackground(app, statusBar, "Merging CI config...", load)
+}
+
+// highlightYAML colors the keys, list items and comments of a YAML document
+// for a text view with dynamic colors, and numbers its lines. Top-level keys,
+// which are mostly job names, are bold.
+func highlightYAML(yaml string) string {
+	lines := strings.Split(strings.TrimSuffix(yaml, "\n"), "\n")
+	width := len(fmt.Sprint(len(lines)))
+
+	var text strings.Builder
+	for i, line := range lines {
+		fmt.Fprintf(&text, "[gray]%*d[-] ", width, i+1)
+
+		comment := ""
+		if loc := yamlComment.FindStringIndex(line); loc != nil && !insideQuotes(line, loc[0]) {
+			line, comment = line[:loc[0]], line[loc[0]:]
+		}
+
+		switch m := yamlKey.FindStringSubmatchIndex(line); {
+		case m != nil:
+			style := "[yellow]"
+			if m[3] == 0 {
+				style = "[yellow::b]"
+			}
+			text.WriteString(tview.Escape(line[:m[3]]) + style + tview.Escape(line[m[4]:m[5]]) + "[-::-]:" +
+				highlightYAMLValue(line[m[5]+1:]))
+		case yamlListItem.MatchString(line):
+			m := yamlListItem.FindStringSubmatchIndex(line)
+			text.WriteString(tview.Escape(line[:m[4]]) + "[blue]-[-]" + highlightYAMLValue(line[m[5]:]))
+		default:
+			text.WriteString(highlightYAMLValue(line))
+		}
+
+		if comment != "" {
+			text.WriteString("[gray]" + tview.Escape(comment) + "[-]")
+		}
+		text.WriteString("\n")
+	}
+	return text.String()
+}
+
+// highlightYAMLValue colors a scalar value: quoted strings green, and
+// booleans, nulls and numbers purple.
+func highlightYAMLValue(value string) string {
+	trimmed := strings.TrimSpace(value)
+	switch {
+	case trimmed == "":
+		return tview.Escape(value)
+	case strings.HasPrefix(trimmed, `"`) || strings.HasPrefix(trimmed, "'"):
+		return "[green]" + tview.Escape(value) + "[-]"
+	case trimmed == "true" || trimmed == "false" || trimmed == "null" || trimmed == "~" || isYAMLNumber(trimmed):
+		return "[purple]" + tview.Escape(value) + "[-]"
+	default:
+		return tview.Escape(value)
+	}
+}
+
+func isYAMLNumber(value string) bool {
+	_, err := fmt.Sscanf(value, "%g", new(float64))
+	return err == nil && strings.Trim(value, "0123456789.-+eE") == ""
+}
+
+// insideQuotes reports whether position pos of line is inside a quoted
+// string, so a # there doesn't start a comment.
+func insideQuotes(line string, pos int) bool {
+	var quote rune
+	for i, r := range line[:pos] {
+		switch {
+		case quote == 0 && (r == '"' || r == '\''):
+			quote = r
+		case r == quote && (r == '\'' || i == 0 || line[i-1] != '\\'):
+			quote = 0
+		}
+	}
+	return quote != 0
+}
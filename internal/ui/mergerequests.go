@@ -0,0 +1,453 @@
+// mergerequests.go
+package ui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/xanzy/go-gitlab"
+)
+
+// mergeRequestPageSize is how many of the most recently updated merge
+// requests are listed.
+const mergeRequestPageSize = 50
+
+// mergeRequestStates are the states the merge request list cycles through.
+var mergeRequestStates = []string{"opened", "merged", "closed", "all"}
+
+// mergeRequestFilter narrows the merge request list. Besides the state it is
+// written as space separated terms: author=<username>, target=<branch> and
+// anything else is searched for in titles and descriptions.
+type mergeRequestFilter struct {
+	state  string
+	text   string
+	author string
+	target string
+	search string
+}
+
+func parseMergeRequestFilter(state, text string) mergeRequestFilter {
+	filter := mergeRequestFilter{state: state, text: strings.TrimSpace(text)}
+
+	var search []string
+	for _, term := range strings.Fields(text) {
+		key, value, _ := strings.Cut(term, "=")
+		switch {
+		case key == "author" && value != "":
+			filter.author = value
+		case key == "target" && value != "":
+			filter.target = value
+		default:
+			search = append(search, term)
+		}
+	}
+	filter.search = strings.Join(search, " ")
+
+	return filter
+}
+
+func (f mergeRequestFilter) listOptions() *gitlab.ListProjectMergeRequestsOptions {
+	opts := &gitlab.ListProjectMergeRequestsOptions{
+		ListOptions: gitlab.ListOptions{PerPage: mergeRequestPageSize},
+		State:       gitlab.String(f.state),
+		OrderBy:     gitlab.String("updated_at"),
+	}
+	if f.author != "" {
+		opts.AuthorUsername = gitlab.String(f.author)
+	}
+	if f.target != "" {
+		opts.TargetBranch = gitlab.String(f.target)
+	}
+	if f.search != "" {
+		opts.Search = gitlab.String(f.search)
+	}
+	return opts
+}
+
+// listMergeRequests fetches the merge requests matching filter with their
+// head pipeline, which only the single merge request endpoint includes.
+func listMergeRequests(projectID int, filter mergeRequestFilter) ([]*gitlab.MergeRequest, error) {
+	listed, _, err := gitlabClient.MergeRequests().ListProjectMergeRequests(projectID, filter.listOptions())
+	if err != nil {
+		return nil, fmt.Errorf("fetching merge requests: %w", err)
+	}
+
+	mergeRequests := make([]*gitlab.MergeRequest, len(listed))
+	errs := make([]error, len(listed))
+
+	forEachParallel(len(listed), pipelineDetailWorkers, func(i int) {
+		mergeRequests[i], _, errs[i] = gitlabClient.MergeRequests().GetMergeRequest(projectID, listed[i].IID, nil)
+	})
+
+	for i, err := range errs {
+		if err != nil {
+			return nil, fmt.Errorf("fetching merge request !%d: %w", listed[i].IID, err)
+		}
+	}
+	return mergeRequests, nil
+}
+
+// showMergeRequests lists the merge requests of project with the status of
+// their head pipeline. Enter lists the pipelines of the selected one, s
+// cycles through open, merged, closed and all merge requests and / filters
+// them. A approves the selected merge request and m merges it. t shows the
+// merge train of its target branch. back is called on Esc.
+func showMergeRequests(app *tview.Application, project *gitlab.Project, back func()) {
+	cancelPendingLoads()
+
+	table := tview.NewTable().
+		SetSelectable(true, false).
+		SetFixed(1, 0)
+	table.SetBorder(true)
+	statusBar := newStatusBar()
+
+	filterField := tview.NewInputField().
+		SetLabel("/").
+		SetPlaceholder("author=alice target=main search words").
+		SetFieldBackgroundColor(tcell.ColorDarkGray).
+		SetFieldTextColor(tcell.ColorOrangeRed)
+
+	var mergeRequests []*gitlab.MergeRequest
+	filter := parseMergeRequestFilter(mergeRequestStates[0], "")
+
+	setKeybindContext(func() []string {
+		if row, _ := table.GetSelection(); row >= 1 && row <= len(mergeRequests) {
+			return mergeRequestKeybindEnv(project, mergeRequests[row-1])
+		}
+		return projectKeybindEnv(project)
+	})
+
+	setTitle := func() {
+		table.SetTitle(fmt.Sprintf(" Merge requests of %s (%s) ", tview.Escape(project.PathWithNamespace), filter.state))
+	}
+	setTitle()
+
+	fillTable := func() {
+		row, _ := table.GetSelection()
+		table.Clear()
+
+		for column, title := range []string{"!", "Title", "Author", "Branches", "Pipeline", "State", "Updated"} {
+			table.SetCell(0, column, tview.NewTableCell(title).
+				SetTextColor(tcell.ColorYellow).
+				SetAttributes(tcell.AttrBold).
+				SetSelectable(false))
+		}
+
+		for i, mergeRequest := range mergeRequests {
+			title := tview.Escape(mergeRequest.Title)
+			if mergeRequest.Draft {
+				title = "[gray]Draft:[-] " + title
+			}
+			author := "-"
+			if mergeRequest.Author != nil {
+				author = mergeRequest.Author.Username
+			}
+			pipeline := "[gray]none[-]"
+			if mergeRequest.HeadPipeline != nil {
+				pipeline = statusLabel(mergeRequest.HeadPipeline.Status)
+			}
+
+			table.SetCell(i+1, 0, tview.NewTableCell(fmt.Sprint(mergeRequest.IID)))
+			table.SetCell(i+1, 1, tview.NewTableCell(title).SetMaxWidth(60).SetExpansion(1))
+			table.SetCell(i+1, 2, tview.NewTableCell(tview.Escape(author)))
+			table.SetCell(i+1, 3, tview.NewTableCell(tview.Escape(mergeRequest.SourceBranch+" → "+mergeRequest.TargetBranch)))
+			table.SetCell(i+1, 4, tview.NewTableCell(pipeline))
+			table.SetCell(i+1, 5, tview.NewTableCell(mergeRequest.State))
+			table.SetCell(i+1, 6, tview.NewTableCell(formatTime(mergeRequest.UpdatedAt)))
+		}
+
+		if len(mergeRequests) == 0 {
+			table.SetCell(1, 0, tview.NewTableCell("No merge requests match").
+				SetTextColor(tcell.ColorGray).
+				SetSelectable(false))
+			return
+		}
+		if row < 1 || row > len(mergeRequests) {
+			row = 1
+		}
+		table.Select(row, 0)
+	}
+
+	reloadMergeRequests := func() (func(), error) {
+		loaded, err := listMergeRequests(project.ID, filter)
+		if err != nil {
+			return nil, err
+		}
+		return func() {
+			mergeRequests = loaded
+			fillTable()
+		}, nil
+	}
+
+	table.SetSelectedFunc(func(row, column int) {
+		if row < 1 || row > len(mergeRequests) {
+			return
+		}
+		showMergeRequestPipelines(app, project, mergeRequests[row-1], func() {
+			showMergeRequests(app, project, back)
+		})
+	})
+
+	var flex *tview.Flex
+
+	table.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch {
+		case event.Key() == tcell.KeyEsc:
+			back()
+			return nil
+		case isRefreshKey(event):
+			fetchInBackground(app, statusBar, "Refreshing...", reloadMergeRequests)
+			return nil
+		case event.Rune() == 's':
+			for i, state := range mergeRequestStates {
+				if state == filter.state {
+					filter.state = mergeRequestStates[(i+1)%len(mergeRequestStates)]
+					break
+				}
+			}
+			setTitle()
+			fetchInBackground(app, statusBar, "Loading merge requests...", reloadMergeRequests)
+			return nil
+		case event.Rune() == '/':
+			flex.ResizeItem(filterField, 1, 0)
+			app.SetFocus(filterField)
+			return nil
+		case event.Rune() == 'A':
+			if row, _ := table.GetSelection(); row >= 1 && row <= len(mergeRequests) {
+				mergeRequest := mergeRequests[row-1]
+				runAction(app, statusBar, "Approving...", fmt.Sprintf("Approved !%d", mergeRequest.IID),
+					func() error { return approveMergeRequest(project.ID, mergeRequest) }, reloadMergeRequests)
+			}
+			return nil
+		case event.Rune() == 'm':
+			if row, _ := table.GetSelection(); row >= 1 && row <= len(mergeRequests) {
+				confirmMerge(app, statusBar, project.ID, mergeRequests[row-1], reloadMergeRequests)
+			}
+			return nil
+		case event.Rune() == 'o':
+			if row, _ := table.GetSelection(); row >= 1 && row <= len(mergeRequests) {
+				openWebURL(app, mergeRequests[row-1].WebURL)
+			}
+			return nil
+		case event.Rune() == 't':
+			targetBranch := project.DefaultBranch
+			if row, _ := table.GetSelection(); row >= 1 && row <= len(mergeRequests) {
+				targetBranch = mergeRequests[row-1].TargetBranch
+			}
+			showMergeTrain(app, project, targetBranch, func() {
+				showMergeRequests(app, project, back)
+			})
+			return nil
+		}
+		return event
+	})
+
+	filterField.SetDoneFunc(func(key tcell.Key) {
+		switch key {
+		case tcell.KeyEnter:
+			filter = parseMergeRequestFilter(filter.state, filterField.GetText())
+			fetchInBackground(app, statusBar, "Filtering merge requests...", reloadMergeRequests)
+		case tcell.KeyEsc:
+			filterField.SetText(filter.text)
+		}
+		if filterField.GetText() == "" {
+			flex.ResizeItem(filterField, 0, 0)
+		}
+		app.SetFocus(table)
+	})
+
+	flex = tview.NewFlex().
+		SetDirection(tview.FlexRow).
+		AddItem(filterField, 0, 0, false).
+		AddItem(table, 0, 1, true).
+		AddItem(statusBar, 1, 0, false).
+		AddItem(tview.NewButton("ENTER - Pipelines   A - Approve   m - Merge   o - Open   t - Merge train   s - Open/Merged/Closed/All   / - Filter   ESC - Back").SetSelectedFunc(back), 1, 0, false)
+
+	routes.show("merge-requests/"+strconv.Itoa(project.ID), flex, table)
+
+	fetchInBackground(app, statusBar, "Loading merge requests...", reloadMergeRequests)
+}
+
+// approveMergeRequest approves mergeRequest at the commit shown, so an
+// approval never covers commits pushed since.
+func approveMergeRequest(projectID int, mergeRequest *gitlab.MergeRequest) error {
+	_, _, err := gitlabClient.MergeRequestApprovals().ApproveMergeRequest(projectID, mergeRequest.IID, &gitlab.ApproveMergeRequestOptions{
+		SHA: gitlab.String(mergeRequest.SHA),
+	})
+	if err != nil {
+		return fmt.Errorf("approving merge request !%d: %w", mergeRequest.IID, err)
+	}
+	return nil
+}
+
+// mergeMergeRequest merges mergeRequest at the commit shown, or sets it to
+// merge once its pipeline succeeds if whenPipelineSucceeds is set.
+func mergeMergeRequest(projectID int, mergeRequest *gitlab.MergeRequest, whenPipelineSucceeds bool) error {
+	_, _, err := gitlabClient.MergeRequests().AcceptMergeRequest(projectID, mergeRequest.IID, &gitlab.AcceptMergeRequestOptions{
+		MergeWhenPipelineSucceeds: gitlab.Bool(whenPipelineSucceeds),
+		SHA:                       gitlab.String(mergeRequest.SHA),
+	})
+	if err != nil {
+		return fmt.Errorf("merging merge request !%d: %w", mergeRequest.IID, err)
+	}
+	return nil
+}
+
+func listMergeRequestPipelines(projectID, iid int) ([]*gitlab.PipelineInfo, error) {
+	pipelines, _, err := gitlabClient.MergeRequests().ListMergeRequestPipelines(projectID, iid)
+	if err != nil {
+		return nil, fmt.Errorf("fetching pipelines of merge request !%d: %w", iid, err)
+	}
+	return pipelines, nil
+}
+
+// mergeRequestPipelineKind tells what kind of merge request pipeline ran for
+// ref: one for the merge request itself runs on refs/merge-requests/<iid>/head,
+// one for the merged result on .../merge and one for a merge train on
+// .../train. Anything else is a branch pipeline of the source branch.
+func mergeRequestPipelineKind(ref string) string {
+	if !strings.HasPrefix(ref, "refs/merge-requests/") {
+		return "branch"
+	}
+	switch {
+	case strings.HasSuffix(ref, "/head"):
+		return "detached"
+	case strings.HasSuffix(ref, "/merge"):
+		return "merged results"
+	case strings.HasSuffix(ref, "/train"):
+		return "merge train"
+	default:
+		return "merge request"
+	}
+}
+
+// showMergeRequestPipelines lists the pipelines of mergeRequest, including
+// detached and merged results pipelines. Enter opens the selected one like a
+// branch pipeline. back is called on Esc.
+func showMergeRequestPipelines(app *tview.Application, project *gitlab.Project, mergeRequest *gitlab.MergeRequest, back func()) {
+	cancelPendingLoads()
+
+	table := tview.NewTable().
+		SetSelectable(true, false).
+		SetFixed(1, 0)
+	table.SetBorder(true).
+		SetTitle(fmt.Sprintf(" Pipelines of !%d %s ", mergeRequest.IID, tview.Escape(mergeRequest.Title)))
+	statusBar := newStatusBar()
+	setKeybindContext(func() []string { return mergeRequestKeybindEnv(project, mergeRequest) })
+
+	var pipelines []*gitlab.PipelineInfo
+
+	fillTable := func() {
+		row, _ := table.GetSelection()
+		table.Clear()
+
+		for column, title := range []string{"Pipeline", "Kind", "Ref", "SHA", "Source", "Created", "Status"} {
+			table.SetCell(0, column, tview.NewTableCell(title).
+				SetTextColor(tcell.ColorYellow).
+				SetAttributes(tcell.AttrBold).
+				SetSelectable(false))
+		}
+
+		for i, pipeline := range pipelines {
+			table.SetCell(i+1, 0, tview.NewTableCell(fmt.Sprintf("#%d", pipeline.ID)))
+			table.SetCell(i+1, 1, tview.NewTableCell(mergeRequestPipelineKind(pipeline.Ref)))
+			table.SetCell(i+1, 2, tview.NewTableCell(tview.Escape(pipeline.Ref)).SetExpansion(1))
+			table.SetCell(i+1, 3, tview.NewTableCell(shortSHA(pipeline.SHA)))
+			table.SetCell(i+1, 4, tview.NewTableCell(tview.Escape(pipeline.Source)))
+			table.SetCell(i+1, 5, tview.NewTableCell(formatTime(pipeline.CreatedAt)))
+			table.SetCell(i+1, 6, tview.NewTableCell(statusLabel(pipeline.Status)))
+		}
+
+		if len(pipelines) == 0 {
+			table.SetCell(1, 0, tview.NewTableCell("No pipelines ran for this merge request").
+				SetTextColor(tcell.ColorGray).
+				SetSelectable(false))
+			return
+		}
+		if row < 1 || row > len(pipelines) {
+			row = 1
+		}
+		table.Select(row, 0)
+	}
+
+	reloadPipelines := func() (func(), error) {
+		loaded, err := listMergeRequestPipelines(project.ID, mergeRequest.IID)
+		if err != nil {
+			return nil, err
+		}
+		return func() {
+			pipelines = loaded
+			fillTable()
+		}, nil
+	}
+
+	table.SetSelectedFunc(func(row, column int) {
+		if row < 1 || row > len(pipelines) {
+			return
+		}
+		pipeline := pipelines[row-1]
+		pipelineTrail = nil
+		showPipelineDetail(app, strconv.Itoa(project.ID), strconv.Itoa(pipeline.ID), pipeline.Ref)
+	})
+
+	table.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch {
+		case event.Key() == tcell.KeyEsc:
+			back()
+			return nil
+		case isRefreshKey(event):
+			fetchInBackground(app, statusBar, "Refreshing...", reloadPipelines)
+			return nil
+		}
+		return event
+	})
+
+	flex := tview.NewFlex().
+		SetDirection(tview.FlexRow).
+		AddItem(table, 0, 1, true).
+		AddItem(statusBar, 1, 0, false).
+		AddItem(tview.NewButton("ENTER - Open pipeline   ESC - Back").SetSelectedFunc(back), 1, 0, false)
+
+	routes.show("merge-request-pipelines/"+strconv.Itoa(project.ID)+"/"+strconv.Itoa(mergeRequest.IID), flex, table)
+
+	fetchInBackground(app, statusBar, "Loading pipelines...", reloadPipelines)
+}
+
+// confirmMerge asks before merging mergeRequest, depending on its head
+// pipeline: one that succeeded merges right away, one still going merges
+// once it succeeds and one that didn't succeed doesn't merge at all.
+func confirmMerge(app *tview.Application, statusBar *tview.TextView, projectID int, mergeRequest *gitlab.MergeRequest, reload func() (func(), error)) {
+	if mergeRequest.State != "opened" {
+		showError(app, fmt.Errorf("merge request !%d is %s", mergeRequest.IID, mergeRequest.State), nil)
+		return
+	}
+
+	status := ""
+	if mergeRequest.HeadPipeline != nil {
+		status = mergeRequest.HeadPipeline.Status
+	}
+
+	whenPipelineSucceeds := false
+	message := fmt.Sprintf("Merge !%d %s into %s?", mergeRequest.IID, mergeRequest.Title, mergeRequest.TargetBranch)
+	switch {
+	case status == "success" || status == "":
+	case cancelableStatuses[status]:
+		whenPipelineSucceeds = true
+		message = fmt.Sprintf("The pipeline of !%d is %s.\nMerge it into %s when the pipeline succeeds?", mergeRequest.IID, status, mergeRequest.TargetBranch)
+	default:
+		showError(app, fmt.Errorf("the pipeline of merge request !%d is %s, it can't be merged until it succeeds", mergeRequest.IID, status), nil)
+		return
+	}
+
+	label, done := "Merge", fmt.Sprintf("Merged !%d", mergeRequest.IID)
+	if whenPipelineSucceeds {
+		label, done = "Merge when pipeline succeeds", fmt.Sprintf("!%d will merge when its pipeline succeeds", mergeRequest.IID)
+	}
+	confirmAction(app, message, label, func() {
+		runAction(app, statusBar, "Merging...", done,
+			func() error { return mergeMergeRequest(projectID, mergeRequest, whenPipelineSucceeds) }, reload)
+	})
+}
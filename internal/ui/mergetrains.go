@@ -0,0 +1,161 @@
+// mergetrains.go
+package ui
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/xanzy/go-gitlab"
+)
+
+// listMergeTrain fetches the merge requests queued on the merge train of
+// targetBranch, the next one to merge first.
+func listMergeTrain(projectID int, targetBranch string) ([]*gitlab.MergeTrain, error) {
+	cars, err := listAllPages(func(listOptions gitlab.ListOptions) ([]*gitlab.MergeTrain, *gitlab.Response, error) {
+		return gitlabClient.MergeTrains().ListMergeRequestInMergeTrain(projectID, targetBranch, &gitlab.ListMergeTrainsOptions{
+			ListOptions: listOptions,
+			Scope:       gitlab.String("active"),
+			Sort:        gitlab.String("asc"),
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("fetching merge train of %s: %w", targetBranch, err)
+	}
+	return cars, nil
+}
+
+// removeFromMergeTrain takes a merge request off its merge train. The API has
+// no call for that of its own; canceling the merge request's auto-merge does
+// it, as in the web UI.
+func removeFromMergeTrain(projectID, iid int) error {
+	_, _, err := gitlabClient.MergeRequests().CancelMergeWhenPipelineSucceeds(projectID, iid)
+	if err != nil {
+		return fmt.Errorf("removing merge request !%d from the merge train: %w", iid, err)
+	}
+	return nil
+}
+
+// showMergeTrain shows the merge train of targetBranch: the queued merge
+// requests in order and the status of their train pipeline. Enter opens the
+// pipeline of the selected one and D removes it from the train. back is
+// called on Esc.
+func showMergeTrain(app *tview.Application, project *gitlab.Project, targetBranch string, back func()) {
+	cancelPendingLoads()
+
+	table := tview.NewTable().
+		SetSelectable(true, false).
+		SetFixed(1, 0)
+	table.SetBorder(true).
+		SetTitle(fmt.Sprintf(" Merge train of %s ", tview.Escape(targetBranch)))
+	statusBar := newStatusBar()
+
+	var cars []*gitlab.MergeTrain
+
+	fillTable := func() {
+		row, _ := table.GetSelection()
+		table.Clear()
+
+		for column, title := range []string{"Position", "!", "Title", "Added by", "Added", "Pipeline", "Status"} {
+			table.SetCell(0, column, tview.NewTableCell(title).
+				SetTextColor(tcell.ColorYellow).
+				SetAttributes(tcell.AttrBold).
+				SetSelectable(false))
+		}
+
+		for i, car := range cars {
+			user := "-"
+			if car.User != nil {
+				user = car.User.Username
+			}
+			pipeline := "[gray]none[-]"
+			if car.Pipeline != nil {
+				pipeline = fmt.Sprintf("#%d %s", car.Pipeline.ID, statusLabel(car.Pipeline.Status))
+			}
+
+			table.SetCell(i+1, 0, tview.NewTableCell(strconv.Itoa(i+1)).SetAlign(tview.AlignRight))
+			table.SetCell(i+1, 1, tview.NewTableCell(strconv.Itoa(car.MergeRequest.IID)))
+			table.SetCell(i+1, 2, tview.NewTableCell(tview.Escape(car.MergeRequest.Title)).SetMaxWidth(60).SetExpansion(1))
+			table.SetCell(i+1, 3, tview.NewTableCell(tview.Escape(user)))
+			table.SetCell(i+1, 4, tview.NewTableCell(formatTime(car.CreatedAt)))
+			table.SetCell(i+1, 5, tview.NewTableCell(pipeline))
+			table.SetCell(i+1, 6, tview.NewTableCell(tview.Escape(car.Status)))
+		}
+
+		if len(cars) == 0 {
+			table.SetCell(1, 0, tview.NewTableCell("The merge train is empty").
+				SetTextColor(tcell.ColorGray).
+				SetSelectable(false))
+			return
+		}
+		if row < 1 || row > len(cars) {
+			row = 1
+		}
+		table.Select(row, 0)
+	}
+
+	reloadTrain := func() (func(), error) {
+		loaded, err := listMergeTrain(project.ID, targetBranch)
+		if err != nil {
+			return nil, err
+		}
+		return func() {
+			cars = loaded
+			fillTable()
+		}, nil
+	}
+
+	selected := func() *gitlab.MergeTrain {
+		row, _ := table.GetSelection()
+		if row < 1 || row > len(cars) {
+			return nil
+		}
+		return cars[row-1]
+	}
+
+	table.SetSelectedFunc(func(row, column int) {
+		car := selected()
+		if car == nil || car.Pipeline == nil {
+			return
+		}
+		pipelineTrail = nil
+		showPipelineDetail(app, strconv.Itoa(project.ID), strconv.Itoa(car.Pipeline.ID), car.Pipeline.Ref)
+	})
+
+	var flex *tview.Flex
+
+	table.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch {
+		case event.Key() == tcell.KeyEsc:
+			back()
+			return nil
+		case isRefreshKey(event):
+			fetchInBackground(app, statusBar, "Refreshing...", reloadTrain)
+			return nil
+		case event.Rune() == 'D':
+			car := selected()
+			if car == nil {
+				return nil
+			}
+			iid := car.MergeRequest.IID
+			confirmAction(app, fmt.Sprintf("Remove !%d %s from the merge train?\nThe merge requests behind it restart their pipelines.", iid, car.MergeRequest.Title), "Remove", func() {
+				runAction(app, statusBar, "Removing from merge train...", fmt.Sprintf("Removed !%d from the merge train", iid),
+					func() error { return removeFromMergeTrain(project.ID, iid) }, reloadTrain)
+			})
+			return nil
+		}
+		return event
+	})
+
+	flex = tview.NewFlex().
+		SetDirection(tview.FlexRow).
+		AddItem(table, 0, 1, true).
+		AddItem(statusBar, 1, 0, false).
+		AddItem(tview.NewButton("ENTER - Pipeline   D - Remove from train   ESC - Back").SetSelectedFunc(back), 1, 0, false)
+
+	routes.show("merge-train/"+strconv.Itoa(project.ID)+"/"+targetBranch, flex, table)
+
+	startAutoRefresh(app, table, reloadTrain)
+	fetchInBackground(app, statusBar, "Loading merge train...", reloadTrain)
+}
@@ -0,0 +1,117 @@
+// notificationcenter.go
+package ui
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// maxNotifications is how many notifications the notification center keeps.
+const maxNotifications = 100
+
+// notification is an event the notification center keeps after its toast
+// is gone: a watched pipeline that finished, an action that completed or an
+// error.
+type notification struct {
+	at      time.Time
+	message string
+	color   tcell.Color
+}
+
+// Like the toasts, the notification center is drawn on top of whatever view
+// is shown and its state is only touched from the UI goroutine.
+var (
+	notificationLog         []*notification
+	unreadNotifications     int
+	notificationCenterShown bool
+	notificationScroll      int
+)
+
+// recordNotification adds message to the notification center as unread.
+func recordNotification(message string, color tcell.Color) {
+	notificationLog = append(notificationLog, &notification{at: time.Now(), message: message, color: color})
+	if len(notificationLog) > maxNotifications {
+		notificationLog = notificationLog[len(notificationLog)-maxNotifications:]
+	}
+	if unreadNotifications < len(notificationLog) {
+		unreadNotifications++
+	}
+}
+
+// handleNotificationCenterKey handles event while the notification center is
+// shown: the arrow keys scroll it, c clears it and Esc or Ctrl-N closes it.
+func handleNotificationCenterKey(event *tcell.EventKey) {
+	switch {
+	case event.Key() == tcell.KeyEsc || event.Key() == tcell.KeyCtrlN:
+		notificationCenterShown = false
+	case event.Key() == tcell.KeyDown || event.Rune() == 'j':
+		if notificationScroll < len(notificationLog)-1 {
+			notificationScroll++
+		}
+	case event.Key() == tcell.KeyUp || event.Rune() == 'k':
+		if notificationScroll > 0 {
+			notificationScroll--
+		}
+	case event.Rune() == 'c':
+		notificationLog = nil
+		notificationScroll = 0
+	}
+}
+
+// openNotificationCenter shows the notification center, newest first, and
+// marks everything in it as read.
+func openNotificationCenter() {
+	notificationCenterShown = true
+	notificationScroll = 0
+	unreadNotifications = 0
+}
+
+// drawUnreadBadge is the draw function of the status bars. It shows the
+// number of unread notifications at their right end, keeping the rest of the
+// bar for its own text.
+func drawUnreadBadge(screen tcell.Screen, x, y, width, height int) (int, int, int, int) {
+	if unreadNotifications == 0 {
+		return x, y, width, height
+	}
+	badge := fmt.Sprintf(" %d unread (Ctrl-N) ", unreadNotifications)
+	badgeWidth := tview.TaggedStringWidth(badge)
+	if badgeWidth > width {
+		return x, y, width, height
+	}
+	tview.Print(screen, badge, x+width-badgeWidth, y, badgeWidth, tview.AlignRight, tcell.ColorYellow)
+	return x, y, width - badgeWidth, height
+}
+
+func drawNotificationCenter(screen tcell.Screen, width, height int) {
+	boxWidth := width * 3 / 4
+	boxHeight := height * 2 / 3
+
+	text := ""
+	if len(notificationLog) == 0 {
+		text = "[gray]Nothing happened yet[-]\n"
+	}
+	for i := len(notificationLog) - 1 - notificationScroll; i >= 0; i-- {
+		n := notificationLog[i]
+		color := "white"
+		switch n.color {
+		case tcell.ColorDarkRed:
+			color = "red"
+		case tcell.ColorDarkGreen:
+			color = "green"
+		}
+		text += fmt.Sprintf("[gray]%s[-] [%s]%s[-]\n", n.at.Format("15:04:05"), color, tview.Escape(n.message))
+	}
+
+	view := tview.NewTextView().
+		SetDynamicColors(true).
+		SetWordWrap(true).
+		SetText(text)
+	view.SetBorder(true).
+		SetTitle(fmt.Sprintf(" Notifications (%d)   ↑/↓ Scroll   c Clear   Esc Close ", len(notificationLog))).
+		SetBorderColor(tcell.ColorYellow)
+	view.SetRect((width-boxWidth)/2, (height-boxHeight)/2, boxWidth, boxHeight)
+	view.Draw(screen)
+}
@@ -0,0 +1,199 @@
+// notifications.go
+package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/xanzy/go-gitlab"
+)
+
+// toastDuration is how long a toast stays on screen.
+const toastDuration = 5 * time.Second
+
+// maxToasts is how many toasts are stacked on screen at once.
+const maxToasts = 3
+
+type toast struct {
+	message string
+	color   tcell.Color
+}
+
+// errorReport describes the last error shown to the user, with enough detail
+// to tell which request failed and a way to try it again.
+type errorReport struct {
+	err      error
+	endpoint string
+	status   int
+	retry    func()
+}
+
+// Toasts and the error detail overlay are drawn on top of whatever view is
+// shown, so they don't depend on how each view lays itself out. All of this
+// state is only touched from the UI goroutine.
+var (
+	toasts            []*toast
+	lastError         *errorReport
+	errorDetailsShown bool
+)
+
+// setupNotifications hooks the toast, error detail and notification center
+// overlays into app. It must be called once before the application runs.
+func setupNotifications(app *tview.Application) {
+	app.SetAfterDrawFunc(drawNotifications)
+
+	app.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if errorDetailsShown {
+			switch {
+			case event.Key() == tcell.KeyEsc:
+				errorDetailsShown = false
+			case event.Key() == tcell.KeyEnter && lastError.retry != nil:
+				errorDetailsShown = false
+				lastError.retry()
+			}
+			return nil
+		}
+
+		if notificationCenterShown {
+			handleNotificationCenterKey(event)
+			return nil
+		}
+
+		if event.Key() == tcell.KeyCtrlE && lastError != nil {
+			errorDetailsShown = true
+			return nil
+		}
+		if event.Key() == tcell.KeyCtrlN {
+			openNotificationCenter()
+			return nil
+		}
+		if handleKeybind(app, event) {
+			return nil
+		}
+		return event
+	})
+}
+
+// showToast displays message in the bottom-right corner for toastDuration.
+// It must be called from the UI goroutine.
+func showToast(app *tview.Application, message string, color tcell.Color) {
+	t := &toast{message: message, color: color}
+	toasts = append(toasts, t)
+	if len(toasts) > maxToasts {
+		toasts = toasts[len(toasts)-maxToasts:]
+	}
+
+	time.AfterFunc(toastDuration, func() {
+		app.QueueUpdateDraw(func() {
+			for i, other := range toasts {
+				if other == t {
+					toasts = append(toasts[:i], toasts[i+1:]...)
+					break
+				}
+			}
+		})
+	})
+}
+
+// showInfo reports a successful action as a toast.
+func showInfo(app *tview.Application, message string) {
+	logInfo(message)
+	recordNotification(message, tcell.ColorDarkGreen)
+	showToast(app, message, tcell.ColorDarkGreen)
+}
+
+// showError reports err as a toast and remembers it for the error detail
+// overlay, which offers to call retry if it is non-nil. Errors of reads
+// canceled because the user left their view aren't reported. It must be
+// called from the UI goroutine.
+func showError(app *tview.Application, err error, retry func()) {
+	if errors.Is(err, context.Canceled) {
+		logDebug("dropped error of a canceled load", "error", err)
+		return
+	}
+
+	report := &errorReport{err: err, retry: retry}
+
+	var errorResponse *gitlab.ErrorResponse
+	if errors.As(err, &errorResponse) && errorResponse.Response != nil {
+		report.status = errorResponse.Response.StatusCode
+		if request := errorResponse.Response.Request; request != nil {
+			report.endpoint = request.Method + " " + request.URL.Path
+		}
+	}
+
+	logError(err.Error(), "endpoint", report.endpoint, "status", report.status)
+
+	lastError = report
+	recordNotification("✖ "+err.Error(), tcell.ColorDarkRed)
+	showToast(app, "✖ "+err.Error()+" (Ctrl-E for details)", tcell.ColorDarkRed)
+}
+
+// queueError is showError for callers outside the UI goroutine.
+func queueError(app *tview.Application, err error, retry func()) {
+	app.QueueUpdateDraw(func() {
+		showError(app, err, retry)
+	})
+}
+
+func drawNotifications(screen tcell.Screen) {
+	width, height := screen.Size()
+
+	y := height - 1
+	for i := len(toasts) - 1; i >= 0; i-- {
+		t := toasts[i]
+
+		toastWidth := tview.TaggedStringWidth(tview.Escape(t.message)) + 2
+		if toastWidth > width/2 {
+			toastWidth = width / 2
+		}
+
+		view := tview.NewTextView().
+			SetText(" " + t.message).
+			SetTextColor(tcell.ColorWhite)
+		view.SetBackgroundColor(t.color)
+		view.SetRect(width-toastWidth, y-1, toastWidth, 1)
+		view.Draw(screen)
+
+		y -= 2
+	}
+
+	if notificationCenterShown {
+		drawNotificationCenter(screen, width, height)
+	}
+	if errorDetailsShown && lastError != nil {
+		drawErrorDetails(screen, width, height)
+	}
+}
+
+func drawErrorDetails(screen tcell.Screen, width, height int) {
+	text := "[red::b]" + tview.Escape(lastError.err.Error()) + "[-::-]\n\n"
+	if lastError.endpoint != "" {
+		text += "Endpoint:    " + tview.Escape(lastError.endpoint) + "\n"
+	}
+	if lastError.status != 0 {
+		text += fmt.Sprintf("Status code: %d\n", lastError.status)
+	}
+	text += "\n"
+	if lastError.retry != nil {
+		text += "[yellow]Enter[-] Retry   "
+	}
+	text += "[yellow]Esc[-] Close"
+
+	boxWidth := width * 2 / 3
+	boxHeight := 10
+
+	view := tview.NewTextView().
+		SetDynamicColors(true).
+		SetWordWrap(true).
+		SetText(text)
+	view.SetBorder(true).
+		SetTitle(" Error ").
+		SetBorderColor(tcell.ColorRed)
+	view.SetRect((width-boxWidth)/2, (height-boxHeight)/2, boxWidth, boxHeight)
+	view.Draw(screen)
+}
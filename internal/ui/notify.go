@@ -0,0 +1,85 @@
+// notify.go
+package ui
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+// notifyWebhookURL is where finished watched pipelines are posted to, empty
+// if they aren't. A Slack incoming webhook URL works as is.
+var notifyWebhookURL string
+
+var notifyHTTPClient = &http.Client{Timeout: 10 * time.Second}
+
+// loadNotifyWebhookURL reads GPV_NOTIFY_WEBHOOK_URL.
+func loadNotifyWebhookURL() (string, error) {
+	value := os.Getenv("GPV_NOTIFY_WEBHOOK_URL")
+	if value == "" {
+		return "", nil
+	}
+
+	u, err := url.Parse(value)
+	if err != nil {
+		return "", fmt.Errorf("invalid GPV_NOTIFY_WEBHOOK_URL: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+		return "", fmt.Errorf("invalid GPV_NOTIFY_WEBHOOK_URL %q: must be an http or https URL", value)
+	}
+	return value, nil
+}
+
+// pipelineNotification is the body posted to the notification webhook. text
+// is what Slack, Mattermost and most chat webhooks show; the other fields are
+// for webhooks that want to act on the pipeline.
+type pipelineNotification struct {
+	Text     string `json:"text"`
+	Project  string `json:"project"`
+	Pipeline int    `json:"pipeline"`
+	Ref      string `json:"ref"`
+	SHA      string `json:"sha"`
+	Status   string `json:"status"`
+	Duration int    `json:"duration"`
+	URL      string `json:"url"`
+}
+
+// postPipelineNotification posts that pipeline finished to the notification
+// webhook.
+func postPipelineNotification(pipeline *gitlab.Pipeline) error {
+	text := fmt.Sprintf("Pipeline <%s|#%d> of %s on %s %s after %s", pipeline.WebURL,
+		pipeline.ID, pipelineProjectPath(pipeline), pipeline.Ref, pipeline.Status, formatDuration(pipeline.Duration))
+
+	body, err := json.Marshal(pipelineNotification{
+		Text:     text,
+		Project:  pipelineProjectPath(pipeline),
+		Pipeline: pipeline.ID,
+		Ref:      pipeline.Ref,
+		SHA:      pipeline.SHA,
+		Status:   pipeline.Status,
+		Duration: pipeline.Duration,
+		URL:      pipeline.WebURL,
+	})
+	if err != nil {
+		return err
+	}
+
+	resp, err := notifyHTTPClient.Post(notifyWebhookURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("posting notification: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("posting notification: %s: %s", resp.Status, bytes.TrimSpace(message))
+	}
+	return nil
+}
@@ -0,0 +1,179 @@
+// output.go
+package ui
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+// outputFormats are the formats commands print their results in with -o.
+var outputFormats = []string{"table", "json", "yaml"}
+
+// addOutputFlag adds -o to the flags of a command.
+func addOutputFlag(flags *flag.FlagSet) *string {
+	return flags.String("o", "table", "output format: "+strings.Join(outputFormats, ", "))
+}
+
+// pipelineRecord is how commands output a pipeline. Its field names are part
+// of the command line interface and must not change.
+type pipelineRecord struct {
+	ID         int        `json:"id"`
+	IID        int        `json:"iid"`
+	ProjectID  int        `json:"project_id"`
+	Status     string     `json:"status"`
+	Ref        string     `json:"ref"`
+	SHA        string     `json:"sha"`
+	Source     string     `json:"source"`
+	CreatedAt  *time.Time `json:"created_at"`
+	FinishedAt *time.Time `json:"finished_at"`
+	Duration   int        `json:"duration"`
+	WebURL     string     `json:"web_url"`
+}
+
+func newPipelineRecord(pipeline *gitlab.Pipeline) pipelineRecord {
+	return pipelineRecord{
+		ID:         pipeline.ID,
+		IID:        pipeline.IID,
+		ProjectID:  pipeline.ProjectID,
+		Status:     pipeline.Status,
+		Ref:        pipeline.Ref,
+		SHA:        pipeline.SHA,
+		Source:     pipeline.Source,
+		CreatedAt:  pipeline.CreatedAt,
+		FinishedAt: pipeline.FinishedAt,
+		Duration:   pipeline.Duration,
+		WebURL:     pipeline.WebURL,
+	}
+}
+
+// jobRecord is how commands output a job. Its field names are part of the
+// command line interface and must not change.
+type jobRecord struct {
+	ID         int        `json:"id"`
+	Name       string     `json:"name"`
+	Stage      string     `json:"stage"`
+	Status     string     `json:"status"`
+	Ref        string     `json:"ref"`
+	PipelineID int        `json:"pipeline_id"`
+	SHA        string     `json:"sha"`
+	CreatedAt  *time.Time `json:"created_at"`
+	StartedAt  *time.Time `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at"`
+	Duration   float64    `json:"duration"`
+	WebURL     string     `json:"web_url"`
+}
+
+func newJobRecord(job *gitlab.Job) jobRecord {
+	return jobRecord{
+		ID:         job.ID,
+		Name:       job.Name,
+		Stage:      job.Stage,
+		Status:     job.Status,
+		Ref:        job.Ref,
+		PipelineID: job.Pipeline.ID,
+		SHA:        job.Pipeline.Sha,
+		CreatedAt:  job.CreatedAt,
+		StartedAt:  job.StartedAt,
+		FinishedAt: job.FinishedAt,
+		Duration:   job.Duration,
+		WebURL:     job.WebURL,
+	}
+}
+
+// validateOutputFormat reports an unknown -o value the way the flag set
+// would.
+func validateOutputFormat(flags *flag.FlagSet, format string) error {
+	for _, known := range outputFormats {
+		if format == known {
+			return nil
+		}
+	}
+	fmt.Fprintf(flags.Output(), "invalid value %q for flag -o: must be one of %s\n", format, strings.Join(outputFormats, ", "))
+	flags.Usage()
+	return errUsage
+}
+
+// writeOutput writes value to w in format, using table for the table format.
+func writeOutput(w io.Writer, format string, value interface{}, table func(w io.Writer) error) error {
+	switch format {
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(value)
+	case "yaml":
+		var out strings.Builder
+		writeYAML(&out, reflect.ValueOf(value), 0)
+		_, err := io.WriteString(w, out.String())
+		return err
+	default:
+		return table(w)
+	}
+}
+
+// writeYAML writes the records and lists of records commands output as YAML,
+// with the field names of their json tags in the order they are declared.
+// Strings are always quoted, so values like "no" or "1.0" keep their type.
+func writeYAML(out *strings.Builder, value reflect.Value, indent int) {
+	prefix := strings.Repeat("  ", indent)
+
+	switch value.Kind() {
+	case reflect.Slice:
+		if value.Len() == 0 {
+			out.WriteString(prefix + "[]\n")
+			return
+		}
+		for i := 0; i < value.Len(); i++ {
+			var item strings.Builder
+			writeYAML(&item, value.Index(i), indent+1)
+			// The dash takes the place of the first line's indentation.
+			out.WriteString(prefix + "- " + strings.TrimPrefix(item.String(), prefix+"  "))
+		}
+	case reflect.Struct:
+		kind := value.Type()
+		for i := 0; i < kind.NumField(); i++ {
+			name, _, _ := strings.Cut(kind.Field(i).Tag.Get("json"), ",")
+			field := value.Field(i)
+			if field.Kind() == reflect.Slice || field.Kind() == reflect.Struct && field.Type() != reflect.TypeOf(time.Time{}) {
+				out.WriteString(prefix + name + ":\n")
+				writeYAML(out, field, indent+1)
+				continue
+			}
+			out.WriteString(prefix + name + ": " + yamlScalar(field) + "\n")
+		}
+	default:
+		out.WriteString(prefix + yamlScalar(value) + "\n")
+	}
+}
+
+func yamlScalar(value reflect.Value) string {
+	if value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			return "null"
+		}
+		value = value.Elem()
+	}
+	if t, ok := value.Interface().(time.Time); ok {
+		return strconv.Quote(t.Format(time.RFC3339))
+	}
+
+	switch value.Kind() {
+	case reflect.String:
+		return strconv.Quote(value.String())
+	case reflect.Bool:
+		return strconv.FormatBool(value.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(value.Int(), 10)
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(value.Float(), 'f', -1, 64)
+	default:
+		return strconv.Quote(fmt.Sprint(value.Interface()))
+	}
+}
@@ -0,0 +1,95 @@
+package ui
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type yamlRecord struct {
+	Name     string     `json:"name"`
+	Count    int        `json:"count"`
+	Ratio    float64    `json:"ratio"`
+	OK       bool       `json:"ok"`
+	Started  *time.Time `json:"started"`
+	Finished *time.Time `json:"finished,omitempty"`
+	Tags     []string   `json:"tags"`
+}
+
+type yamlParent struct {
+	ID    int          `json:"id"`
+	Child yamlRecord   `json:"child"`
+	Items []yamlRecord `json:"items"`
+}
+
+func TestWriteYAML(t *testing.T) {
+	started := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		name  string
+		value interface{}
+		want  string
+	}{
+		{"string", "no", `"no"` + "\n"},
+		{"empty list", []yamlRecord{}, "[]\n"},
+		{
+			"record",
+			yamlRecord{Name: "1.0", Count: 3, Ratio: 0.5, OK: true, Started: &started, Tags: []string{"a"}},
+			`name: "1.0"
+count: 3
+ratio: 0.5
+ok: true
+started: "2024-01-02T03:04:05Z"
+finished: null
+tags:
+  - "a"
+`,
+		},
+		{
+			"list of records",
+			[]yamlRecord{{Name: "a"}, {Name: "b", Tags: []string{"x", "y"}}},
+			`- name: "a"
+  count: 0
+  ratio: 0
+  ok: false
+  started: null
+  finished: null
+  tags:
+    []
+- name: "b"
+  count: 0
+  ratio: 0
+  ok: false
+  started: null
+  finished: null
+  tags:
+    - "x"
+    - "y"
+`,
+		},
+		{
+			"nested",
+			yamlParent{ID: 1, Child: yamlRecord{Name: "c"}, Items: []yamlRecord{}},
+			`id: 1
+child:
+  name: "c"
+  count: 0
+  ratio: 0
+  ok: false
+  started: null
+  finished: null
+  tags:
+    []
+items:
+  []
+`,
+		},
+	}
+	for _, tt := range tests {
+		var out strings.Builder
+		writeYAML(&out, reflect.ValueOf(tt.value), 0)
+		if out.String() != tt.want {
+			t.Errorf("%s: writeYAML() =\n%s\nwant\n%s", tt.name, out.String(), tt.want)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+// pagination.go
+package ui
+
+import (
+	"github.com/xanzy/go-gitlab"
+)
+
+// pageSize is the number of items requested per page for every list call.
+const pageSize = 100
+
+// listAllPages calls fetch for successive pages until GitLab reports there is
+// no next page, and returns everything collected. NextPage is used instead of
+// TotalPages because GitLab omits the total headers for very large collections.
+func listAllPages[T any](fetch func(listOptions gitlab.ListOptions) ([]T, *gitlab.Response, error)) ([]T, error) {
+	var all []T
+	listOptions := gitlab.ListOptions{
+		PerPage: pageSize,
+		Page:    1,
+	}
+
+	for {
+		items, resp, err := fetch(listOptions)
+		if err != nil {
+			return nil, err
+		}
+
+		all = append(all, items...)
+
+		if resp == nil || resp.NextPage == 0 {
+			break
+		}
+		listOptions.Page = resp.NextPage
+	}
+
+	return all, nil
+}
@@ -0,0 +1,45 @@
+package ui
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+func TestListAllPages(t *testing.T) {
+	tests := []struct {
+		name    string
+		pages   map[int][]int
+		next    map[int]int
+		fail    int
+		want    []int
+		wantErr bool
+	}{
+		{name: "one page", pages: map[int][]int{1: {1, 2}}, want: []int{1, 2}},
+		{name: "empty", pages: map[int][]int{}, want: nil},
+		{name: "three pages", pages: map[int][]int{1: {1}, 2: {2}, 3: {3}}, next: map[int]int{1: 2, 2: 3}, want: []int{1, 2, 3}},
+		{name: "error", pages: map[int][]int{1: {1}}, next: map[int]int{1: 2}, fail: 2, wantErr: true},
+	}
+	for _, tt := range tests {
+		var requested []int
+		got, err := listAllPages(func(listOptions gitlab.ListOptions) ([]int, *gitlab.Response, error) {
+			if listOptions.PerPage != pageSize {
+				t.Errorf("%s: PerPage = %d, want %d", tt.name, listOptions.PerPage, pageSize)
+			}
+			requested = append(requested, listOptions.Page)
+			if listOptions.Page == tt.fail {
+				return nil, nil, errors.New("boom")
+			}
+			return tt.pages[listOptions.Page], &gitlab.Response{NextPage: tt.next[listOptions.Page]}, nil
+		})
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: error %v, want error %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %v, want %v (pages requested %v)", tt.name, got, tt.want, requested)
+		}
+	}
+}
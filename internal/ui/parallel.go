@@ -0,0 +1,26 @@
+// parallel.go
+package ui
+
+import "sync"
+
+// forEachParallel calls fn with every index below n, on at most workers
+// goroutines at once, and returns once all calls have returned.
+func forEachParallel(n, workers int, fn func(i int)) {
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers && w < n; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				fn(i)
+			}
+		}()
+	}
+
+	for i := 0; i < n; i++ {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+}
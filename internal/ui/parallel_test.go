@@ -0,0 +1,40 @@
+package ui
+
+import (
+	"sync/atomic"
+	"testing"
+)
+
+func TestForEachParallel(t *testing.T) {
+	tests := []struct {
+		n, workers int
+	}{
+		{0, 4},
+		{1, 4},
+		{10, 1},
+		{100, 8},
+	}
+	for _, tt := range tests {
+		calls := make([]int32, tt.n)
+		var running, peak atomic.Int32
+		forEachParallel(tt.n, tt.workers, func(i int) {
+			now := running.Add(1)
+			for {
+				old := peak.Load()
+				if now <= old || peak.CompareAndSwap(old, now) {
+					break
+				}
+			}
+			atomic.AddInt32(&calls[i], 1)
+			running.Add(-1)
+		})
+		for i, count := range calls {
+			if count != 1 {
+				t.Errorf("n=%d workers=%d: index %d called %d times, want 1", tt.n, tt.workers, i, count)
+			}
+		}
+		if peak.Load() > int32(tt.workers) {
+			t.Errorf("n=%d workers=%d: %d calls ran at once", tt.n, tt.workers, peak.Load())
+		}
+	}
+}
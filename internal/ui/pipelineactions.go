@@ -0,0 +1,69 @@
+// pipelineactions.go
+package ui
+
+import (
+	"fmt"
+)
+
+func retryPipeline(projectID string, pipelineID int) error {
+	_, _, err := gitlabClient.Pipelines().RetryPipelineBuild(projectID, pipelineID)
+	if err != nil {
+		return fmt.Errorf("retrying pipeline %d: %w", pipelineID, err)
+	}
+	return nil
+}
+
+// cancelableStatuses are the statuses in which a pipeline or job can still be
+// canceled.
+var cancelableStatuses = map[string]bool{
+	"created":              true,
+	"waiting_for_resource": true,
+	"preparing":            true,
+	"pending":              true,
+	"running":              true,
+	"scheduled":            true,
+}
+
+func cancelPipeline(projectID string, pipelineID int) error {
+	_, _, err := gitlabClient.Pipelines().CancelPipelineBuild(projectID, pipelineID)
+	if err != nil {
+		return fmt.Errorf("canceling pipeline %d: %w", pipelineID, err)
+	}
+	return nil
+}
+
+func cancelJob(projectID string, jobID int) error {
+	_, _, err := gitlabClient.Jobs().CancelJob(projectID, jobID)
+	if err != nil {
+		return fmt.Errorf("canceling job %d: %w", jobID, err)
+	}
+	return nil
+}
+
+func deletePipeline(projectID string, pipelineID int) error {
+	_, err := gitlabClient.Pipelines().DeletePipeline(projectID, pipelineID)
+	if err != nil {
+		return fmt.Errorf("deleting pipeline %d: %w", pipelineID, err)
+	}
+
+	pipelineDetailsCacheMu.Lock()
+	delete(pipelineDetailsCache, pipelineID)
+	pipelineDetailsCacheMu.Unlock()
+	return nil
+}
+
+// erasableStatuses are the statuses of finished jobs, whose log and
+// artifacts can be erased.
+var erasableStatuses = map[string]bool{
+	"success":  true,
+	"failed":   true,
+	"canceled": true,
+}
+
+func eraseJob(projectID string, jobID int) error {
+	_, _, err := gitlabClient.Jobs().EraseJob(projectID, jobID)
+	if err != nil {
+		return fmt.Errorf("erasing job %d: %w", jobID, err)
+	}
+	return nil
+}
@@ -0,0 +1,320 @@
+// pipelinedetail.go
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/xanzy/go-gitlab"
+)
+
+// pipelineDetail is everything shown on the pipeline detail panel.
+type pipelineDetail struct {
+	pipeline  *gitlab.Pipeline
+	commit    *gitlab.Commit
+	jobs      []*gitlab.Job
+	bridges   []*gitlab.Bridge
+	variables []*gitlab.PipelineVariable
+
+	// variablesErr is why the variables couldn't be fetched. Reading them
+	// takes more rights than reading the pipeline, so it doesn't fail the
+	// whole view.
+	variablesErr error
+	// commitErr is why the commit couldn't be fetched, e.g. because it was
+	// force-pushed away. commit is then empty.
+	commitErr error
+}
+
+// showPipelineDetail shows the metadata of one pipeline. Enter drills into its
+// jobs, g shows them as a graph, t, q and s show the test, code quality and
+// security reports, R retries and C cancels the pipeline, F retries its
+// failed jobs, w watches it until it finishes, Esc goes back to the pipeline
+// list of branch or, for a downstream pipeline, to the jobs of its parent.
+func showPipelineDetail(app *tview.Application, projectID, pipelineID, branch string) {
+	cancelPendingLoads()
+
+	view := tview.NewTextView().
+		SetDynamicColors(true).
+		SetWordWrap(true)
+	view.SetBorder(true).
+		SetTitle(fmt.Sprintf(" Pipeline #%s ", pipelineID))
+	statusBar := newStatusBar()
+	breadcrumbBar, breadcrumbHeight := newBreadcrumbBar(pipelineID)
+
+	var (
+		pipeline *gitlab.Pipeline
+		jobs     []*gitlab.Job
+		bridges  []*gitlab.Bridge
+	)
+
+	setKeybindContext(func() []string {
+		if pipeline != nil {
+			return pipelineHookEnv(pipeline)
+		}
+		return nil
+	})
+
+	reloadDetail := func() (func(), error) {
+		detail, err := fetchPipelineDetail(projectID, pipelineID)
+		if err != nil {
+			return nil, err
+		}
+		return func() {
+			pipeline = detail.pipeline
+			jobs = detail.jobs
+			bridges = detail.bridges
+			view.SetText(formatPipelineDetail(detail))
+		}, nil
+	}
+
+	showJobs := func() {
+		showJobList(app, jobs, bridges, projectID, pipelineID, branch)
+	}
+
+	var flex *tview.Flex
+
+	view.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch {
+		case event.Key() == tcell.KeyEsc:
+			leavePipeline(app, projectID, branch)
+			return nil
+		case event.Key() == tcell.KeyEnter:
+			showJobs()
+			return nil
+		case event.Rune() == 'g':
+			showPipelineGraph(app, projectID, pipelineID, branch)
+			return nil
+		case event.Rune() == 't':
+			showTestReport(app, projectID, pipelineID, func() {
+				showPipelineDetail(app, projectID, pipelineID, branch)
+			})
+			return nil
+		case event.Rune() == 'q':
+			showCodeQuality(app, projectID, pipelineID, func() {
+				showPipelineDetail(app, projectID, pipelineID, branch)
+			})
+			return nil
+		case event.Rune() == 's':
+			showSecurityReport(app, projectID, pipelineID, func() {
+				showPipelineDetail(app, projectID, pipelineID, branch)
+			})
+			return nil
+		case event.Rune() == 'R':
+			confirmAction(app, fmt.Sprintf("Retry pipeline #%s?", pipelineID), "Retry", func() {
+				runAction(app, statusBar, "Retrying pipeline...", fmt.Sprintf("Pipeline #%s retried", pipelineID),
+					func() error { return retryPipeline(projectID, toInt(pipelineID)) }, reloadDetail)
+			})
+			return nil
+		case event.Rune() == 'C':
+			if pipeline == nil {
+				return nil
+			}
+			if !cancelableStatuses[pipeline.Status] {
+				showError(app, fmt.Errorf("pipeline #%s is %s and can't be canceled", pipelineID, pipeline.Status), nil)
+				return nil
+			}
+			confirmAction(app, fmt.Sprintf("Cancel pipeline #%s?", pipelineID), "Cancel pipeline", func() {
+				runAction(app, statusBar, "Canceling pipeline...", fmt.Sprintf("Pipeline #%s canceled", pipelineID),
+					func() error { return cancelPipeline(projectID, toInt(pipelineID)) }, reloadDetail)
+			})
+			return nil
+		case event.Rune() == 'w':
+			if pipeline != nil {
+				toggleWatch(app, projectID, pipeline)
+			}
+			return nil
+		case event.Rune() == 'o':
+			if pipeline != nil {
+				openWebURL(app, pipeline.WebURL)
+			}
+			return nil
+		case event.Rune() == 'y':
+			if pipeline != nil {
+				showYankMenu(app, pipelineYankTargets(pipeline))
+			}
+			return nil
+		case event.Rune() == 'p':
+			if pipeline != nil {
+				showPluginMenu(app, statusBar, "pipeline", pipelineHookEnv(pipeline))
+			}
+			return nil
+		case event.Rune() == 'F':
+			confirmRetryFailedJobs(app, projectID, jobs, func() {
+				showPipelineDetail(app, projectID, pipelineID, branch)
+			})
+			return nil
+		case isRefreshKey(event):
+			fetchInBackground(app, statusBar, "Refreshing...", reloadDetail)
+			return nil
+		}
+		return event
+	})
+
+	startAutoRefresh(app, view, reloadDetail)
+
+	flex = tview.NewFlex().
+		SetDirection(tview.FlexRow).
+		AddItem(breadcrumbBar, breadcrumbHeight, 0, false).
+		AddItem(view, 0, 1, true).
+		AddItem(statusBar, 1, 0, false).
+		AddItem(tview.NewButton("ENTER - Jobs   g - Graph   t - Tests   q - Quality   s - Security   R - Retry   F - Retry failed   C - Cancel   w - Watch   o - Open   y - Copy   p - Plugins   ESC - Back").SetSelectedFunc(showJobs), 1, 0, false)
+
+	routes.show("pipeline/"+projectID+"/"+pipelineID, flex, view)
+
+	fetchInBackground(app, statusBar, "Loading pipeline...", reloadDetail)
+}
+
+func fetchPipelineDetail(projectID, pipelineID string) (*pipelineDetail, error) {
+	// The GraphQL query looks projects up by ID, pipelines opened from a
+	// project path go through REST.
+	if _, err := strconv.Atoi(projectID); useGraphQL && err == nil {
+		detail, err := fetchPipelineDetailGraphQL(projectID, pipelineID)
+		if err == nil {
+			return detail, nil
+		}
+		// Older instances lack some of the fields queried, REST still works
+		// there.
+		logWarn("graphql pipeline fetch failed, falling back to rest", "pipeline", pipelineID, "error", err)
+	}
+
+	pipeline, _, err := gitlabClient.Pipelines().GetPipeline(projectID, toInt(pipelineID))
+	if err != nil {
+		return nil, fmt.Errorf("fetching pipeline %s: %w", pipelineID, err)
+	}
+
+	commit, _, commitErr := gitlabClient.Commits().GetCommit(projectID, pipeline.SHA)
+	if commitErr != nil {
+		logWarn("fetching pipeline commit failed", "pipeline", pipelineID, "sha", pipeline.SHA, "error", commitErr)
+		commit = &gitlab.Commit{}
+	}
+
+	jobs, err := listPipelineJobs(projectID, pipelineID)
+	if err != nil {
+		return nil, fmt.Errorf("fetching jobs for pipeline %s: %w", pipelineID, err)
+	}
+
+	bridges, err := listPipelineBridges(projectID, pipelineID)
+	if err != nil {
+		return nil, fmt.Errorf("fetching trigger jobs for pipeline %s: %w", pipelineID, err)
+	}
+
+	variables, _, variablesErr := gitlabClient.Pipelines().GetPipelineVariables(projectID, pipeline.ID)
+
+	return &pipelineDetail{
+		pipeline:     pipeline,
+		commit:       commit,
+		jobs:         jobs,
+		bridges:      bridges,
+		variables:    variables,
+		variablesErr: variablesErr,
+		commitErr:    commitErr,
+	}, nil
+}
+
+func formatPipelineDetail(detail *pipelineDetail) string {
+	pipeline := detail.pipeline
+
+	var text strings.Builder
+	field := func(name, value string) {
+		fmt.Fprintf(&text, "[yellow]%-10s[-] %s\n", name, value)
+	}
+
+	field("Status", statusLabel(pipeline.Status))
+	field("Ref", tview.Escape(pipeline.Ref))
+	field("Source", pipeline.Source)
+	if detail.commitErr != nil {
+		field("Commit", "[gray]unavailable[-]")
+	} else {
+		field("Commit", tview.Escape(detail.commit.ShortID+" "+detail.commit.Title))
+	}
+	field("SHA", pipeline.SHA)
+	field("User", tview.Escape(pipelineUser(pipeline)))
+	field("Created", formatTime(pipeline.CreatedAt))
+	field("Started", formatTime(pipeline.StartedAt))
+	field("Finished", formatTime(pipeline.FinishedAt))
+	field("Duration", formatDuration(pipelineDuration(pipeline)))
+	field("Queued", formatDuration(pipeline.QueuedDuration))
+	if pipeline.Coverage != "" {
+		field("Coverage", pipeline.Coverage+"%")
+	} else {
+		field("Coverage", "-")
+	}
+	if pipeline.YamlErrors != "" {
+		field("YAML", "[red]"+tview.Escape(pipeline.YamlErrors)+"[-]")
+	}
+
+	text.WriteString("\n[yellow]Stages[-]\n")
+	stages := stageSummary(detail.jobs)
+	if len(stages) == 0 {
+		text.WriteString("  no jobs\n")
+	}
+	for _, stage := range stages {
+		text.WriteString("  " + stage + "\n")
+	}
+
+	if len(detail.bridges) > 0 {
+		text.WriteString("\n[yellow]Downstream[-]\n")
+		for _, bridge := range detail.bridges {
+			downstream := "not created yet"
+			if bridge.DownstreamPipeline != nil {
+				downstream = tview.Escape(pipelineLabel(bridge.DownstreamPipeline.WebURL, bridge.DownstreamPipeline.ID)) +
+					" " + statusLabel(bridge.DownstreamPipeline.Status)
+			}
+			fmt.Fprintf(&text, "  %-16s %s\n", tview.Escape(bridge.Name), downstream)
+		}
+	}
+
+	text.WriteString("\n[yellow]Variables[-]\n")
+	switch {
+	case detail.variablesErr != nil:
+		text.WriteString("  [red]" + tview.Escape(detail.variablesErr.Error()) + "[-]\n")
+	case len(detail.variables) == 0:
+		text.WriteString("  none\n")
+	}
+	for _, variable := range detail.variables {
+		value := variable.Value
+		if variable.VariableType == string(gitlab.FileVariableType) {
+			value = fmt.Sprintf("(file, %s)", formatSize(int64(len(value))))
+		}
+		fmt.Fprintf(&text, "  %-16s %s\n", tview.Escape(variable.Key), tview.Escape(value))
+	}
+
+	text.WriteString("\n[yellow]Links[-]\n")
+	text.WriteString("  Pipeline  " + pipeline.WebURL + "\n")
+	if detail.commit.WebURL != "" {
+		text.WriteString("  Commit    " + detail.commit.WebURL + "\n")
+	}
+
+	return text.String()
+}
+
+// stageSummary returns one line per stage, in pipeline order, counting the
+// stage's jobs by status.
+func stageSummary(jobs []*gitlab.Job) []string {
+	stages, byStage := jobsByStage(jobs)
+
+	lines := make([]string, 0, len(stages))
+	for _, stage := range stages {
+		counts := map[string]int{}
+		for _, job := range byStage[stage] {
+			counts[job.Status]++
+		}
+
+		statuses := make([]string, 0, len(counts))
+		for status := range counts {
+			statuses = append(statuses, status)
+		}
+		sort.Strings(statuses)
+
+		parts := make([]string, 0, len(statuses))
+		for _, status := range statuses {
+			parts = append(parts, statusLabel(status)+" "+strconv.Itoa(counts[status]))
+		}
+		lines = append(lines, fmt.Sprintf("%-16s %s", tview.Escape(stage), strings.Join(parts, "  ")))
+	}
+	return lines
+}
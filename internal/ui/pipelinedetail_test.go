@@ -0,0 +1,107 @@
+package ui
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/xanzy/go-gitlab"
+
+	api "main.go/internal/gitlab"
+)
+
+func TestFormatPipelineDetailCommit(t *testing.T) {
+	pipeline := &gitlab.Pipeline{ID: 1, Status: "success", Ref: "main", SHA: "abc123", WebURL: "https://gitlab.example.com/g/p/-/pipelines/1"}
+	tests := []struct {
+		name    string
+		detail  *pipelineDetail
+		want    string
+		notWant string
+	}{
+		{
+			"commit",
+			&pipelineDetail{pipeline: pipeline, commit: &gitlab.Commit{ShortID: "abc", Title: "Fix it", WebURL: "https://gitlab.example.com/g/p/-/commit/abc123"}},
+			"abc Fix it",
+			"unavailable",
+		},
+		{
+			"commit unavailable",
+			&pipelineDetail{pipeline: pipeline, commit: &gitlab.Commit{}, commitErr: errors.New("404 Commit Not Found")},
+			"unavailable",
+			"  Commit    \n",
+		},
+	}
+	for _, tt := range tests {
+		text := formatPipelineDetail(tt.detail)
+		if !strings.Contains(text, tt.want) {
+			t.Errorf("%s: %q missing from\n%s", tt.name, tt.want, text)
+		}
+		if strings.Contains(text, tt.notWant) {
+			t.Errorf("%s: %q in\n%s", tt.name, tt.notWant, text)
+		}
+	}
+}
+
+type restPipelinesStub struct {
+	pipelineVariablesStub
+}
+
+func (restPipelinesStub) GetPipeline(pid interface{}, id int, _ ...gitlab.RequestOptionFunc) (*gitlab.Pipeline, *gitlab.Response, error) {
+	return &gitlab.Pipeline{ID: id, Status: "failed", SHA: "abc"}, nil, nil
+}
+
+type restJobsStub struct {
+	api.JobsAPI
+}
+
+func (restJobsStub) ListPipelineJobs(pid interface{}, pipelineID int, _ *gitlab.ListJobsOptions, _ ...gitlab.RequestOptionFunc) ([]*gitlab.Job, *gitlab.Response, error) {
+	return []*gitlab.Job{{ID: 1, Name: "build"}, {ID: 2, Name: "test"}}, nil, nil
+}
+
+func (restJobsStub) ListPipelineBridges(pid interface{}, pipelineID int, _ *gitlab.ListJobsOptions, _ ...gitlab.RequestOptionFunc) ([]*gitlab.Bridge, *gitlab.Response, error) {
+	return nil, nil, nil
+}
+
+func TestFetchPipelineDetailREST(t *testing.T) {
+	savedClient, savedGraphQL := gitlabClient, useGraphQL
+	defer func() { gitlabClient, useGraphQL = savedClient, savedGraphQL }()
+
+	tests := []struct {
+		name       string
+		graphQL    bool
+		mock       *api.Mock
+		pipelineID string
+		err        string
+	}{
+		// GraphQL is left unset, the fetch falls back to REST. The
+		// commit is unset too and shows as unavailable.
+		{"rest", false, &api.Mock{PipelinesAPI: restPipelinesStub{}, JobsAPI: restJobsStub{}}, "101", ""},
+		{"graphql fallback", true, &api.Mock{PipelinesAPI: restPipelinesStub{}, JobsAPI: restJobsStub{}}, "102", ""},
+		{"jobs unset", false, &api.Mock{PipelinesAPI: restPipelinesStub{}}, "103", "fetching jobs for pipeline 103"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gitlabClient, useGraphQL = tt.mock, tt.graphQL
+
+			detail, err := fetchPipelineDetail("7", tt.pipelineID)
+			if tt.err != "" {
+				if !errors.Is(err, api.ErrNotMocked) || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("error = %v, want %s", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if detail.pipeline.Status != "failed" || len(detail.jobs) != 2 {
+				t.Errorf("pipeline %s with %d jobs, want failed with 2", detail.pipeline.Status, len(detail.jobs))
+			}
+			if !errors.Is(detail.commitErr, api.ErrNotMocked) {
+				t.Errorf("commit error = %v, want ErrNotMocked", detail.commitErr)
+			}
+			if text := formatPipelineDetail(detail); !strings.Contains(text, "unavailable") {
+				t.Errorf("commit not shown as unavailable:\n%s", text)
+			}
+		})
+	}
+}
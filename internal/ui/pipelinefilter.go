@@ -0,0 +1,126 @@
+// pipelinefilter.go
+package ui
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+// pipelineFilter narrows the pipeline list. It is written as space separated
+// key=value terms, e.g. "status=failed source=schedule ref=release/* from=2024-01-01".
+// Everything except ref globs is handled by the API.
+type pipelineFilter struct {
+	text   string
+	status *gitlab.BuildStateValue
+	source string
+	ref    string
+	sha    string
+	from   *time.Time
+	to     *time.Time
+}
+
+const pipelineFilterDateLayout = "2006-01-02"
+
+// pipelineStatuses are the statuses the API can filter pipelines by.
+var pipelineStatuses = map[string]bool{
+	"created":              true,
+	"waiting_for_resource": true,
+	"preparing":            true,
+	"pending":              true,
+	"running":              true,
+	"success":              true,
+	"failed":               true,
+	"canceled":             true,
+	"skipped":              true,
+	"manual":               true,
+	"scheduled":            true,
+}
+
+func parsePipelineFilter(text string) (pipelineFilter, error) {
+	filter := pipelineFilter{text: strings.TrimSpace(text)}
+
+	for _, term := range strings.Fields(text) {
+		key, value, ok := strings.Cut(term, "=")
+		if !ok || value == "" {
+			return pipelineFilter{}, fmt.Errorf("invalid filter term %q: expected key=value", term)
+		}
+
+		switch key {
+		case "status":
+			if !pipelineStatuses[value] {
+				return pipelineFilter{}, fmt.Errorf("invalid filter term %q: unknown status", term)
+			}
+			status := gitlab.BuildStateValue(value)
+			filter.status = &status
+		case "source":
+			filter.source = value
+		case "ref":
+			if _, err := path.Match(value, ""); err != nil {
+				return pipelineFilter{}, fmt.Errorf("invalid filter term %q: %w", term, err)
+			}
+			filter.ref = value
+		case "sha":
+			filter.sha = value
+		case "from", "to":
+			date, err := time.ParseInLocation(pipelineFilterDateLayout, value, time.Local)
+			if err != nil {
+				return pipelineFilter{}, fmt.Errorf("invalid filter term %q: dates are YYYY-MM-DD", term)
+			}
+			if key == "from" {
+				filter.from = &date
+			} else {
+				// The range includes the whole of the "to" day.
+				end := date.AddDate(0, 0, 1)
+				filter.to = &end
+			}
+		default:
+			return pipelineFilter{}, fmt.Errorf("invalid filter term %q: key must be status, source, ref, sha, from or to", term)
+		}
+	}
+
+	return filter, nil
+}
+
+// refGlob reports whether the ref filter has to be matched client-side,
+// since the API only accepts exact refs.
+func (f pipelineFilter) refGlob() bool {
+	return strings.ContainsAny(f.ref, "*?[")
+}
+
+// listOptions builds the API query for f. branch is used as the ref when the
+// filter doesn't name one.
+func (f pipelineFilter) listOptions(branch string) *gitlab.ListProjectPipelinesOptions {
+	opts := &gitlab.ListProjectPipelinesOptions{
+		Status:        f.status,
+		UpdatedAfter:  f.from,
+		UpdatedBefore: f.to,
+	}
+
+	switch {
+	case f.ref == "":
+		opts.Ref = &branch
+	case !f.refGlob():
+		opts.Ref = gitlab.String(f.ref)
+	}
+
+	if f.source != "" {
+		opts.Source = gitlab.String(f.source)
+	}
+	if f.sha != "" {
+		opts.SHA = gitlab.String(f.sha)
+	}
+	return opts
+}
+
+// matchRef applies a ref glob; refs always match when there is no glob.
+func (f pipelineFilter) matchRef(ref string) bool {
+	if !f.refGlob() {
+		return true
+	}
+	matched, _ := path.Match(f.ref, ref)
+	return matched
+}
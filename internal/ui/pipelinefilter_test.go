@@ -0,0 +1,66 @@
+package ui
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParsePipelineFilter(t *testing.T) {
+	tests := []struct {
+		text    string
+		wantErr bool
+		status  string
+		source  string
+		ref     string
+		sha     string
+		from    string
+		to      string
+	}{
+		{text: ""},
+		{text: "status=failed", status: "failed"},
+		{text: "source=schedule ref=release/* sha=abc", source: "schedule", ref: "release/*", sha: "abc"},
+		{text: "from=2024-01-01 to=2024-01-31", from: "2024-01-01", to: "2024-02-01"},
+		{text: "status=waiting_for_resource", status: "waiting_for_resource"},
+		{text: "status=bogus", wantErr: true},
+		{text: "status=online", wantErr: true},
+		{text: "status=paused", wantErr: true},
+		{text: "status=error", wantErr: true},
+		{text: "status", wantErr: true},
+		{text: "status=", wantErr: true},
+		{text: "ref=[", wantErr: true},
+		{text: "from=yesterday", wantErr: true},
+		{text: "owner=me", wantErr: true},
+	}
+	for _, tt := range tests {
+		filter, err := parsePipelineFilter(tt.text)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parsePipelineFilter(%q): error %v, want error %v", tt.text, err, tt.wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+
+		status := ""
+		if filter.status != nil {
+			status = string(*filter.status)
+		}
+		if status != tt.status || filter.source != tt.source || filter.ref != tt.ref || filter.sha != tt.sha {
+			t.Errorf("parsePipelineFilter(%q) = status %q source %q ref %q sha %q, want %q %q %q %q",
+				tt.text, status, filter.source, filter.ref, filter.sha, tt.status, tt.source, tt.ref, tt.sha)
+		}
+		if got := filterDate(filter.from); got != tt.from {
+			t.Errorf("parsePipelineFilter(%q) from = %q, want %q", tt.text, got, tt.from)
+		}
+		if got := filterDate(filter.to); got != tt.to {
+			t.Errorf("parsePipelineFilter(%q) to = %q, want %q", tt.text, got, tt.to)
+		}
+	}
+}
+
+func filterDate(date *time.Time) string {
+	if date == nil {
+		return ""
+	}
+	return date.Format(pipelineFilterDateLayout)
+}
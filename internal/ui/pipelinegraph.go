@@ -0,0 +1,575 @@
+// pipelinegraph.go
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/xanzy/go-gitlab"
+)
+
+const (
+	graphBoxHeight   = 3
+	graphColumnGap   = 4
+	graphMinBoxWidth = 14
+	graphMaxBoxWidth = 32
+)
+
+// pipelineGraph draws the jobs of a pipeline as boxes, one column per stage
+// or, in DAG mode, one column per level of needs, with lines from every job
+// to the jobs it needs.
+type pipelineGraph struct {
+	*tview.Box
+
+	jobs  []*gitlab.Job
+	needs map[string][]string
+	dag   bool
+
+	columns      [][]*gitlab.Job
+	columnTitles []string
+
+	selectedColumn int
+	selectedRow    int
+
+	// onSelect is called with the selected job when Enter is pressed.
+	onSelect func(job *gitlab.Job)
+}
+
+func newPipelineGraph() *pipelineGraph {
+	return &pipelineGraph{Box: tview.NewBox()}
+}
+
+// setJobs replaces the jobs shown, keeping the same job selected if it is
+// still there. needs maps job names to the names of the jobs they need.
+func (g *pipelineGraph) setJobs(jobs []*gitlab.Job, needs map[string][]string) {
+	selected := g.selectedJob()
+
+	g.jobs = jobs
+	g.needs = needs
+	g.layout()
+
+	if selected != nil {
+		g.selectJob(selected.Name)
+	}
+}
+
+// toggleMode switches between the stage and DAG layouts.
+func (g *pipelineGraph) toggleMode() {
+	selected := g.selectedJob()
+
+	g.dag = !g.dag
+	g.layout()
+
+	if selected != nil {
+		g.selectJob(selected.Name)
+	}
+}
+
+func (g *pipelineGraph) layout() {
+	g.columns = nil
+	g.columnTitles = nil
+
+	if g.dag {
+		levels := needsLevels(g.jobs, g.needs)
+		byLevel := map[int][]*gitlab.Job{}
+		maxLevel := 0
+		for _, job := range g.jobs {
+			level := levels[job.Name]
+			byLevel[level] = append(byLevel[level], job)
+			if level > maxLevel {
+				maxLevel = level
+			}
+		}
+		for level := 0; level <= maxLevel && len(g.jobs) > 0; level++ {
+			g.columns = append(g.columns, sortedByName(byLevel[level]))
+			g.columnTitles = append(g.columnTitles, fmt.Sprintf("Level %d", level+1))
+		}
+	} else {
+		stages, byStage := jobsByStage(g.jobs)
+		for _, stage := range stages {
+			g.columns = append(g.columns, sortedByName(byStage[stage]))
+			g.columnTitles = append(g.columnTitles, stage)
+		}
+	}
+
+	g.selectedColumn, g.selectedRow = 0, 0
+}
+
+func (g *pipelineGraph) selectedJob() *gitlab.Job {
+	if g.selectedColumn >= len(g.columns) || g.selectedRow >= len(g.columns[g.selectedColumn]) {
+		return nil
+	}
+	return g.columns[g.selectedColumn][g.selectedRow]
+}
+
+// selectJob moves the selection to the job called name, if it is shown.
+func (g *pipelineGraph) selectJob(name string) bool {
+	for column, jobs := range g.columns {
+		for row, job := range jobs {
+			if job.Name == name {
+				g.selectedColumn, g.selectedRow = column, row
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// position returns the column and row of the job called name.
+func (g *pipelineGraph) position(name string) (int, int, bool) {
+	for column, jobs := range g.columns {
+		for row, job := range jobs {
+			if job.Name == name {
+				return column, row, true
+			}
+		}
+	}
+	return 0, 0, false
+}
+
+func (g *pipelineGraph) boxWidth() int {
+	width := graphMinBoxWidth
+	for _, job := range g.jobs {
+		if w := tview.TaggedStringWidth(tview.Escape(job.Name)) + 6; w > width {
+			width = w
+		}
+	}
+	if width > graphMaxBoxWidth {
+		width = graphMaxBoxWidth
+	}
+	return width
+}
+
+func (g *pipelineGraph) Draw(screen tcell.Screen) {
+	g.DrawForSubclass(screen, g)
+
+	x, y, width, height := g.GetInnerRect()
+	if len(g.columns) == 0 {
+		tview.Print(screen, "No jobs", x, y, width, tview.AlignCenter, tcell.ColorGray)
+		return
+	}
+
+	boxWidth := g.boxWidth()
+	columnWidth := boxWidth + graphColumnGap
+
+	// Scroll so the selected job is always visible.
+	offsetX := 0
+	if right := (g.selectedColumn+1)*columnWidth - graphColumnGap; right > width {
+		offsetX = right - width
+	}
+	offsetY := 0
+	if bottom := 2 + (g.selectedRow+1)*graphBoxHeight; bottom > height {
+		offsetY = bottom - height
+	}
+
+	boxX := func(column int) int { return x + column*columnWidth - offsetX }
+	boxY := func(row int) int { return y + 2 + row*graphBoxHeight - offsetY }
+	inView := func(px, py int) bool { return px >= x && px < x+width && py >= y+2 && py < y+height }
+
+	selected := g.selectedJob()
+	related := map[string]bool{}
+
+	// Edges go first so the boxes are drawn over them. Edges of the selected
+	// job are drawn last so they stay visible where lines cross.
+	for pass := 0; pass < 2; pass++ {
+		for column, jobs := range g.columns {
+			for row, job := range jobs {
+				for _, need := range g.needs[job.Name] {
+					needColumn, needRow, ok := g.position(need)
+					if !ok || needColumn >= column {
+						continue
+					}
+
+					highlighted := selected != nil && (job.Name == selected.Name || need == selected.Name)
+					if highlighted != (pass == 1) {
+						continue
+					}
+
+					style := tcell.StyleDefault.Foreground(tcell.ColorGray)
+					if highlighted {
+						style = style.Foreground(tcell.ColorYellow)
+						related[job.Name] = true
+						related[need] = true
+					}
+
+					drawEdge(screen, boxX(needColumn)+boxWidth, boxY(needRow)+1, boxX(column)-1, boxY(row)+1, style, inView)
+				}
+			}
+		}
+	}
+
+	for column, jobs := range g.columns {
+		titleX := boxX(column)
+		if titleX+boxWidth > x && titleX < x+width {
+			tview.Print(screen, "[::b]"+tview.Escape(g.columnTitles[column]), titleX, y, boxWidth, tview.AlignCenter, tcell.ColorYellow)
+		}
+
+		for row, job := range jobs {
+			borderColor := tcell.ColorGray
+			switch {
+			case selected != nil && job.Name == selected.Name:
+				borderColor = tcell.ColorWhite
+			case related[job.Name]:
+				borderColor = tcell.ColorYellow
+			}
+			g.drawJobBox(screen, job, boxX(column), boxY(row), boxWidth, borderColor, inView)
+		}
+	}
+}
+
+// drawEdge draws a line from (fromX, fromY) to (toX, toY) that turns in the
+// gap just left of the target column.
+func drawEdge(screen tcell.Screen, fromX, fromY, toX, toY int, style tcell.Style, inView func(x, y int) bool) {
+	turnX := toX - graphColumnGap/2 + 1
+
+	put := func(x, y int, ch rune) {
+		if inView(x, y) {
+			tview.PrintJoinedSemigraphics(screen, x, y, ch, style)
+		}
+	}
+
+	for x := fromX; x < turnX; x++ {
+		put(x, fromY, tview.BoxDrawingsLightHorizontal)
+	}
+	for x := turnX + 1; x <= toX; x++ {
+		put(x, toY, tview.BoxDrawingsLightHorizontal)
+	}
+
+	switch {
+	case toY == fromY:
+		put(turnX, fromY, tview.BoxDrawingsLightHorizontal)
+	case toY > fromY:
+		put(turnX, fromY, tview.BoxDrawingsLightDownAndLeft)
+		for y := fromY + 1; y < toY; y++ {
+			put(turnX, y, tview.BoxDrawingsLightVertical)
+		}
+		put(turnX, toY, tview.BoxDrawingsLightUpAndRight)
+	default:
+		put(turnX, fromY, tview.BoxDrawingsLightUpAndLeft)
+		for y := toY + 1; y < fromY; y++ {
+			put(turnX, y, tview.BoxDrawingsLightVertical)
+		}
+		put(turnX, toY, tview.BoxDrawingsLightDownAndRight)
+	}
+}
+
+func (g *pipelineGraph) drawJobBox(screen tcell.Screen, job *gitlab.Job, left, top, width int, color tcell.Color, inView func(x, y int) bool) {
+	style := tcell.StyleDefault.Foreground(color)
+	right := left + width - 1
+	bottom := top + graphBoxHeight - 1
+
+	for px := left; px <= right; px++ {
+		for py := top; py <= bottom; py++ {
+			if !inView(px, py) {
+				continue
+			}
+			ch := ' '
+			switch {
+			case px == left && py == top:
+				ch = tview.BoxDrawingsLightDownAndRight
+			case px == right && py == top:
+				ch = tview.BoxDrawingsLightDownAndLeft
+			case px == left && py == bottom:
+				ch = tview.BoxDrawingsLightUpAndRight
+			case px == right && py == bottom:
+				ch = tview.BoxDrawingsLightUpAndLeft
+			case py == top || py == bottom:
+				ch = tview.BoxDrawingsLightHorizontal
+			case px == left || px == right:
+				ch = tview.BoxDrawingsLightVertical
+			}
+			screen.SetContent(px, py, ch, nil, style)
+		}
+	}
+
+	if inView(left+2, top+1) {
+		label := statusIcon(job.Status) + " " + tview.Escape(job.Name)
+		tview.Print(screen, label, left+2, top+1, width-4, tview.AlignLeft, tcell.ColorWhite)
+	}
+}
+
+func (g *pipelineGraph) InputHandler() func(event *tcell.EventKey, setFocus func(p tview.Primitive)) {
+	return g.WrapInputHandler(func(event *tcell.EventKey, setFocus func(p tview.Primitive)) {
+		if len(g.columns) == 0 {
+			return
+		}
+
+		switch event.Key() {
+		case tcell.KeyLeft:
+			if g.selectedColumn > 0 {
+				g.selectedColumn--
+			}
+		case tcell.KeyRight:
+			if g.selectedColumn < len(g.columns)-1 {
+				g.selectedColumn++
+			}
+		case tcell.KeyUp:
+			if g.selectedRow > 0 {
+				g.selectedRow--
+			}
+		case tcell.KeyDown:
+			g.selectedRow++
+		case tcell.KeyEnter:
+			if job := g.selectedJob(); job != nil && g.onSelect != nil {
+				g.onSelect(job)
+			}
+			return
+		}
+
+		if last := len(g.columns[g.selectedColumn]) - 1; g.selectedRow > last {
+			g.selectedRow = last
+		}
+	})
+}
+
+// jobsByStage groups jobs by stage and returns the stages in pipeline order,
+// which is the order in which their first jobs were created.
+func jobsByStage(jobs []*gitlab.Job) ([]string, map[string][]*gitlab.Job) {
+	byStage := map[string][]*gitlab.Job{}
+	firstJobID := map[string]int{}
+	var stages []string
+
+	for _, job := range jobs {
+		if _, ok := byStage[job.Stage]; !ok {
+			stages = append(stages, job.Stage)
+			firstJobID[job.Stage] = job.ID
+		}
+		byStage[job.Stage] = append(byStage[job.Stage], job)
+		if job.ID < firstJobID[job.Stage] {
+			firstJobID[job.Stage] = job.ID
+		}
+	}
+
+	sort.SliceStable(stages, func(i, j int) bool { return firstJobID[stages[i]] < firstJobID[stages[j]] })
+	return stages, byStage
+}
+
+// needsLevels places every job one level after the deepest job it needs, so
+// jobs without needs are at level 0.
+func needsLevels(jobs []*gitlab.Job, needs map[string][]string) map[string]int {
+	levels := map[string]int{}
+	visiting := map[string]bool{}
+
+	known := map[string]bool{}
+	for _, job := range jobs {
+		known[job.Name] = true
+	}
+
+	var level func(name string) int
+	level = func(name string) int {
+		if l, ok := levels[name]; ok {
+			return l
+		}
+		if visiting[name] {
+			return 0
+		}
+		visiting[name] = true
+
+		l := 0
+		for _, need := range needs[name] {
+			if known[need] {
+				if needLevel := level(need) + 1; needLevel > l {
+					l = needLevel
+				}
+			}
+		}
+
+		visiting[name] = false
+		levels[name] = l
+		return l
+	}
+
+	for _, job := range jobs {
+		level(job.Name)
+	}
+	return levels
+}
+
+func sortedByName(jobs []*gitlab.Job) []*gitlab.Job {
+	sorted := append([]*gitlab.Job(nil), jobs...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+	return sorted
+}
+
+// fetchJobNeeds returns the names of the jobs each job of the pipeline needs.
+// Needs aren't part of the REST API, so they come from GraphQL.
+func fetchJobNeeds(projectPath string, pipelineIID int) (map[string][]string, error) {
+	const query = `query($project: ID!, $iid: ID!, $after: String) {
+  project(fullPath: $project) {
+    pipeline(iid: $iid) {
+      jobs(after: $after) {
+        pageInfo { hasNextPage endCursor }
+        nodes { name needs { nodes { name } } }
+      }
+    }
+  }
+}`
+
+	var data struct {
+		Project *struct {
+			Pipeline *struct {
+				Jobs struct {
+					PageInfo struct {
+						HasNextPage bool   `json:"hasNextPage"`
+						EndCursor   string `json:"endCursor"`
+					} `json:"pageInfo"`
+					Nodes []struct {
+						Name  string `json:"name"`
+						Needs struct {
+							Nodes []struct {
+								Name string `json:"name"`
+							} `json:"nodes"`
+						} `json:"needs"`
+					} `json:"nodes"`
+				} `json:"jobs"`
+			} `json:"pipeline"`
+		} `json:"project"`
+	}
+
+	needs := map[string][]string{}
+	variables := map[string]interface{}{
+		"project": projectPath,
+		"iid":     strconv.Itoa(pipelineIID),
+	}
+
+	for {
+		if err := gitlabClient.GraphQL(query, variables, &data); err != nil {
+			return nil, fmt.Errorf("fetching job needs: %w", err)
+		}
+		if data.Project == nil || data.Project.Pipeline == nil {
+			return nil, fmt.Errorf("fetching job needs: pipeline %d of %s not found", pipelineIID, projectPath)
+		}
+
+		jobs := data.Project.Pipeline.Jobs
+		for _, job := range jobs.Nodes {
+			for _, need := range job.Needs.Nodes {
+				needs[job.Name] = append(needs[job.Name], need.Name)
+			}
+		}
+
+		if !jobs.PageInfo.HasNextPage {
+			return needs, nil
+		}
+		variables["after"] = jobs.PageInfo.EndCursor
+	}
+}
+
+// showPipelineGraph shows the jobs of a pipeline as a graph. Enter opens the
+// selected job's log, n lists its dependencies, d switches between the stage
+// and DAG layouts, Esc goes back to the pipeline detail.
+func showPipelineGraph(app *tview.Application, projectID, pipelineID, branch string) {
+	cancelPendingLoads()
+
+	graph := newPipelineGraph()
+	graph.SetBorder(true).
+		SetTitle(fmt.Sprintf(" Pipeline #%s - stages ", pipelineID))
+	statusBar := newStatusBar()
+
+	updateTitle := func() {
+		mode := "stages"
+		if graph.dag {
+			mode = "needs"
+		}
+		graph.SetTitle(fmt.Sprintf(" Pipeline #%s - %s ", pipelineID, mode))
+	}
+
+	reloadGraph := func() (func(), error) {
+		pipeline, _, err := gitlabClient.Pipelines().GetPipeline(projectID, toInt(pipelineID))
+		if err != nil {
+			return nil, fmt.Errorf("fetching pipeline %s: %w", pipelineID, err)
+		}
+		project, _, err := gitlabClient.Projects().GetProject(projectID, nil)
+		if err != nil {
+			return nil, fmt.Errorf("fetching project %s: %w", projectID, err)
+		}
+		jobs, err := listPipelineJobs(projectID, pipelineID)
+		if err != nil {
+			return nil, fmt.Errorf("fetching jobs for pipeline %s: %w", pipelineID, err)
+		}
+
+		// Without needs the stage layout still works, so a failure here is
+		// reported but doesn't stop the graph from being shown.
+		needs, needsErr := fetchJobNeeds(project.PathWithNamespace, pipeline.IID)
+
+		return func() {
+			graph.setJobs(jobs, needs)
+			if needsErr != nil {
+				showError(app, needsErr, nil)
+			}
+		}, nil
+	}
+
+	var returnToGraph func()
+
+	graph.onSelect = func(job *gitlab.Job) {
+		fetchAndDisplayJobLogs(app, projectID, strconv.Itoa(job.ID), returnToGraph)
+	}
+
+	pages := tview.NewPages().
+		AddPage("graph", graph, true, true)
+
+	showNeeds := func(job *gitlab.Job) {
+		closeNeeds := func() {
+			pages.RemovePage("needs")
+			app.SetFocus(graph)
+		}
+		list := newJobNeedsList(job, graph.jobs, graph.needs, func(name string) {
+			graph.selectJob(name)
+			closeNeeds()
+		}, closeNeeds)
+
+		overlay := tview.NewFlex().
+			AddItem(nil, 0, 1, false).
+			AddItem(tview.NewFlex().
+				SetDirection(tview.FlexRow).
+				AddItem(nil, 0, 1, false).
+				AddItem(list, list.GetItemCount()+2, 0, true).
+				AddItem(nil, 0, 1, false), 0, 2, true).
+			AddItem(nil, 0, 1, false)
+
+		pages.AddPage("needs", overlay, true, true)
+		app.SetFocus(list)
+	}
+
+	flex := tview.NewFlex().
+		SetDirection(tview.FlexRow).
+		AddItem(pages, 0, 1, true).
+		AddItem(statusBar, 1, 0, false).
+		AddItem(tview.NewButton("ENTER - Logs   n - Dependencies   d - Stages/Needs   ESC - Back").SetSelectedFunc(func() {
+			showPipelineDetail(app, projectID, pipelineID, branch)
+		}), 1, 0, false)
+
+	returnToGraph = func() {
+		cancelPendingLoads()
+		routes.show("pipeline-graph/"+projectID+"/"+pipelineID, flex, graph)
+		startAutoRefresh(app, graph, reloadGraph)
+	}
+
+	graph.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch {
+		case event.Key() == tcell.KeyEsc:
+			showPipelineDetail(app, projectID, pipelineID, branch)
+			return nil
+		case event.Rune() == 'd':
+			graph.toggleMode()
+			updateTitle()
+			return nil
+		case event.Rune() == 'n':
+			if job := graph.selectedJob(); job != nil {
+				showNeeds(job)
+			}
+			return nil
+		case isRefreshKey(event):
+			fetchInBackground(app, statusBar, "Refreshing...", reloadGraph)
+			return nil
+		}
+		return event
+	})
+
+	returnToGraph()
+
+	fetchInBackground(app, statusBar, "Loading pipeline graph...", reloadGraph)
+}
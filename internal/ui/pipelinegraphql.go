@@ -0,0 +1,329 @@
+// pipelinegraphql.go
+package ui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+// useGraphQL makes the pipeline detail fetch its pipeline, commit, jobs and
+// trigger jobs with one GraphQL query instead of a REST request each, which
+// is much faster over high-latency connections. It is set with
+// GPV_BACKEND=graphql.
+var useGraphQL bool
+
+const pipelineDetailQuery = `query($projects: [ID!], $pipeline: CiPipelineID!, $after: String) {
+  projects(ids: $projects) {
+    nodes {
+      pipeline(id: $pipeline) {
+        iid status ref sha source path
+        createdAt updatedAt startedAt finishedAt duration queuedDuration
+        coverage yamlErrorMessages
+        user { username }
+        commit { sha shortId title message authorName authorEmail authoredDate committedDate webUrl }
+        jobs(after: $after, retried: false) {
+          pageInfo { hasNextPage endCursor }
+          nodes {
+            id name kind status allowFailure tags webPath
+            duration queuedDuration createdAt startedAt finishedAt
+            stage { name }
+            runner { id }
+            downstreamPipeline { id status path project { id } }
+          }
+        }
+      }
+    }
+  }
+}`
+
+// pipelineListBatch is how many pipelines one pipeline list query asks for,
+// which keeps it under GitLab's query complexity limit.
+const pipelineListBatch = 20
+
+// pipelineListFields are the fields of every pipeline in a pipeline list
+// query, what the pipeline table shows. The jobs are left to the detail
+// query, the table doesn't show them.
+const pipelineListFields = `iid status ref sha source path
+      createdAt updatedAt startedAt finishedAt duration queuedDuration
+      coverage yamlErrorMessages
+      user { username }`
+
+type graphqlCommit struct {
+	SHA           string     `json:"sha"`
+	ShortID       string     `json:"shortId"`
+	Title         string     `json:"title"`
+	Message       string     `json:"message"`
+	AuthorName    string     `json:"authorName"`
+	AuthorEmail   string     `json:"authorEmail"`
+	AuthoredDate  *time.Time `json:"authoredDate"`
+	CommittedDate *time.Time `json:"committedDate"`
+	WebURL        string     `json:"webUrl"`
+}
+
+type graphqlJob struct {
+	ID             string     `json:"id"`
+	Name           string     `json:"name"`
+	Kind           string     `json:"kind"`
+	Status         string     `json:"status"`
+	AllowFailure   bool       `json:"allowFailure"`
+	Tags           []string   `json:"tags"`
+	WebPath        string     `json:"webPath"`
+	Duration       float64    `json:"duration"`
+	QueuedDuration float64    `json:"queuedDuration"`
+	CreatedAt      *time.Time `json:"createdAt"`
+	StartedAt      *time.Time `json:"startedAt"`
+	FinishedAt     *time.Time `json:"finishedAt"`
+	Stage          *struct {
+		Name string `json:"name"`
+	} `json:"stage"`
+	Runner *struct {
+		ID string `json:"id"`
+	} `json:"runner"`
+	DownstreamPipeline *struct {
+		ID      string `json:"id"`
+		Status  string `json:"status"`
+		Path    string `json:"path"`
+		Project *struct {
+			ID string `json:"id"`
+		} `json:"project"`
+	} `json:"downstreamPipeline"`
+}
+
+type graphqlPipeline struct {
+	IID               string     `json:"iid"`
+	Status            string     `json:"status"`
+	Ref               string     `json:"ref"`
+	SHA               string     `json:"sha"`
+	Source            string     `json:"source"`
+	Path              string     `json:"path"`
+	CreatedAt         *time.Time `json:"createdAt"`
+	UpdatedAt         *time.Time `json:"updatedAt"`
+	StartedAt         *time.Time `json:"startedAt"`
+	FinishedAt        *time.Time `json:"finishedAt"`
+	Duration          int        `json:"duration"`
+	QueuedDuration    float64    `json:"queuedDuration"`
+	Coverage          *float64   `json:"coverage"`
+	YamlErrorMessages string     `json:"yamlErrorMessages"`
+	User              *struct {
+		Username string `json:"username"`
+	} `json:"user"`
+	Commit *graphqlCommit `json:"commit"`
+	Jobs   struct {
+		PageInfo struct {
+			HasNextPage bool   `json:"hasNextPage"`
+			EndCursor   string `json:"endCursor"`
+		} `json:"pageInfo"`
+		Nodes []graphqlJob `json:"nodes"`
+	} `json:"jobs"`
+}
+
+// fetchPipelineDetailGraphQL is fetchPipelineDetail with one GraphQL query,
+// paged only for pipelines with more than a hundred jobs. The variables
+// aren't exposed there and are still fetched with REST.
+func fetchPipelineDetailGraphQL(projectID, pipelineID string) (*pipelineDetail, error) {
+	variables := map[string]interface{}{
+		"projects": []string{"gid://gitlab/Project/" + projectID},
+		"pipeline": "gid://gitlab/Ci::Pipeline/" + pipelineID,
+	}
+
+	var fetched *graphqlPipeline
+	var jobs []graphqlJob
+	for {
+		// A fresh value per page: decoding into the previous one would
+		// reuse the pointers already handed out for the earlier jobs.
+		var data struct {
+			Projects struct {
+				Nodes []struct {
+					Pipeline *graphqlPipeline `json:"pipeline"`
+				} `json:"nodes"`
+			} `json:"projects"`
+		}
+		if err := gitlabClient.GraphQL(pipelineDetailQuery, variables, &data); err != nil {
+			return nil, fmt.Errorf("fetching pipeline %s: %w", pipelineID, err)
+		}
+		if len(data.Projects.Nodes) == 0 || data.Projects.Nodes[0].Pipeline == nil {
+			return nil, fmt.Errorf("fetching pipeline %s: not found in project %s", pipelineID, projectID)
+		}
+		fetched = data.Projects.Nodes[0].Pipeline
+		jobs = append(jobs, fetched.Jobs.Nodes...)
+
+		if !fetched.Jobs.PageInfo.HasNextPage {
+			break
+		}
+		variables["after"] = fetched.Jobs.PageInfo.EndCursor
+	}
+
+	pipeline := fetched.toPipeline(toInt(projectID), toInt(pipelineID))
+	detail := &pipelineDetail{pipeline: pipeline, commit: &gitlab.Commit{}}
+	if fetched.Commit != nil {
+		detail.commit = fetched.Commit.toCommit()
+	}
+	for i := range jobs {
+		if jobs[i].Kind == "BRIDGE" {
+			detail.bridges = append(detail.bridges, jobs[i].toBridge(pipeline))
+		} else {
+			detail.jobs = append(detail.jobs, jobs[i].toJob(pipeline))
+		}
+	}
+
+	detail.variables, _, detail.variablesErr = gitlabClient.Pipelines().GetPipelineVariables(projectID, pipeline.ID)
+	return detail, nil
+}
+
+// listPipelinesGraphQL is listPipelineDetails with one GraphQL query per
+// pipelineListBatch pipelines instead of a REST request each.
+func listPipelinesGraphQL(projectID string, infos []*gitlab.PipelineInfo) ([]*gitlab.Pipeline, error) {
+	pipelines := make([]*gitlab.Pipeline, 0, len(infos))
+	for start := 0; start < len(infos); start += pipelineListBatch {
+		batch := infos[start:]
+		if len(batch) > pipelineListBatch {
+			batch = batch[:pipelineListBatch]
+		}
+
+		// Each pipeline is asked for under its own alias, p0, p1 and so on.
+		var query strings.Builder
+		query.WriteString("query($projects: [ID!]) {\n  projects(ids: $projects) {\n    nodes {\n")
+		for i, info := range batch {
+			fmt.Fprintf(&query, "      p%d: pipeline(id: \"gid://gitlab/Ci::Pipeline/%d\") {\n      %s\n      }\n", i, info.ID, pipelineListFields)
+		}
+		query.WriteString("    }\n  }\n}")
+
+		var data struct {
+			Projects struct {
+				Nodes []map[string]*graphqlPipeline `json:"nodes"`
+			} `json:"projects"`
+		}
+		variables := map[string]interface{}{"projects": []string{"gid://gitlab/Project/" + projectID}}
+		if err := gitlabClient.GraphQL(query.String(), variables, &data); err != nil {
+			return nil, fmt.Errorf("fetching pipelines of project %s: %w", projectID, err)
+		}
+		if len(data.Projects.Nodes) == 0 {
+			return nil, fmt.Errorf("fetching pipelines of project %s: project not found", projectID)
+		}
+		for i, info := range batch {
+			fetched := data.Projects.Nodes[0]["p"+strconv.Itoa(i)]
+			if fetched == nil {
+				return nil, fmt.Errorf("fetching pipeline %d: not found in project %s", info.ID, projectID)
+			}
+			pipelines = append(pipelines, fetched.toPipeline(toInt(projectID), info.ID))
+		}
+	}
+	return pipelines, nil
+}
+
+func (p *graphqlPipeline) toPipeline(projectID, id int) *gitlab.Pipeline {
+	pipeline := &gitlab.Pipeline{
+		ID:             id,
+		IID:            toInt(p.IID),
+		ProjectID:      projectID,
+		Status:         strings.ToLower(p.Status),
+		Source:         p.Source,
+		Ref:            p.Ref,
+		SHA:            p.SHA,
+		WebURL:         webURLFromPath(p.Path),
+		CreatedAt:      p.CreatedAt,
+		UpdatedAt:      p.UpdatedAt,
+		StartedAt:      p.StartedAt,
+		FinishedAt:     p.FinishedAt,
+		Duration:       p.Duration,
+		QueuedDuration: int(p.QueuedDuration),
+		YamlErrors:     p.YamlErrorMessages,
+	}
+	if p.Coverage != nil {
+		pipeline.Coverage = strconv.FormatFloat(*p.Coverage, 'f', -1, 64)
+	}
+	if p.User != nil {
+		pipeline.User = &gitlab.BasicUser{Username: p.User.Username}
+	}
+	return pipeline
+}
+
+func (c *graphqlCommit) toCommit() *gitlab.Commit {
+	return &gitlab.Commit{
+		ID:            c.SHA,
+		ShortID:       c.ShortID,
+		Title:         c.Title,
+		Message:       c.Message,
+		AuthorName:    c.AuthorName,
+		AuthorEmail:   c.AuthorEmail,
+		AuthoredDate:  c.AuthoredDate,
+		CommittedDate: c.CommittedDate,
+		WebURL:        c.WebURL,
+	}
+}
+
+func (j *graphqlJob) toJob(pipeline *gitlab.Pipeline) *gitlab.Job {
+	job := &gitlab.Job{
+		ID:             globalIDNumber(j.ID),
+		Name:           j.Name,
+		Status:         strings.ToLower(j.Status),
+		AllowFailure:   j.AllowFailure,
+		TagList:        j.Tags,
+		WebURL:         webURLFromPath(j.WebPath),
+		Duration:       j.Duration,
+		QueuedDuration: j.QueuedDuration,
+		CreatedAt:      j.CreatedAt,
+		StartedAt:      j.StartedAt,
+		FinishedAt:     j.FinishedAt,
+		Ref:            pipeline.Ref,
+	}
+	job.Pipeline.ID = pipeline.ID
+	job.Pipeline.ProjectID = pipeline.ProjectID
+	job.Pipeline.Ref = pipeline.Ref
+	job.Pipeline.Sha = pipeline.SHA
+	job.Pipeline.Status = pipeline.Status
+	if j.Stage != nil {
+		job.Stage = j.Stage.Name
+	}
+	if j.Runner != nil {
+		job.Runner.ID = globalIDNumber(j.Runner.ID)
+	}
+	return job
+}
+
+func (j *graphqlJob) toBridge(pipeline *gitlab.Pipeline) *gitlab.Bridge {
+	job := j.toJob(pipeline)
+	bridge := &gitlab.Bridge{
+		ID:           job.ID,
+		Name:         job.Name,
+		Stage:        job.Stage,
+		Status:       job.Status,
+		AllowFailure: job.AllowFailure,
+		WebURL:       job.WebURL,
+		Duration:     job.Duration,
+		CreatedAt:    job.CreatedAt,
+		StartedAt:    job.StartedAt,
+		FinishedAt:   job.FinishedAt,
+		Ref:          job.Ref,
+		Pipeline:     gitlab.PipelineInfo{ID: pipeline.ID, ProjectID: pipeline.ProjectID, Ref: pipeline.Ref, SHA: pipeline.SHA, Status: pipeline.Status},
+	}
+	if downstream := j.DownstreamPipeline; downstream != nil {
+		bridge.DownstreamPipeline = &gitlab.PipelineInfo{
+			ID:     globalIDNumber(downstream.ID),
+			Status: strings.ToLower(downstream.Status),
+			WebURL: webURLFromPath(downstream.Path),
+		}
+		if downstream.Project != nil {
+			bridge.DownstreamPipeline.ProjectID = globalIDNumber(downstream.Project.ID)
+		}
+	}
+	return bridge
+}
+
+// globalIDNumber returns the numeric ID at the end of a GraphQL global ID,
+// like 42 for gid://gitlab/Ci::Build/42.
+func globalIDNumber(gid string) int {
+	return toInt(gid[strings.LastIndex(gid, "/")+1:])
+}
+
+// webURLFromPath turns the web path GraphQL returns into a URL.
+func webURLFromPath(path string) string {
+	if path == "" {
+		return ""
+	}
+	return strings.TrimSuffix(gitlabURL, "/") + path
+}
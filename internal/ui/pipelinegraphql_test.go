@@ -0,0 +1,152 @@
+package ui
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xanzy/go-gitlab"
+
+	api "main.go/internal/gitlab"
+)
+
+type pipelineVariablesStub struct {
+	api.PipelinesAPI
+}
+
+func (pipelineVariablesStub) GetPipelineVariables(interface{}, int, ...gitlab.RequestOptionFunc) ([]*gitlab.PipelineVariable, *gitlab.Response, error) {
+	return nil, nil, nil
+}
+
+// replayGraphQL answers each GraphQL query with the next of pages.
+func replayGraphQL(t *testing.T, pages ...string) *api.Mock {
+	t.Helper()
+	return &api.Mock{
+		PipelinesAPI: pipelineVariablesStub{},
+		GraphQLFunc: func(query string, variables map[string]interface{}, data interface{}) error {
+			if len(pages) == 0 {
+				t.Fatal("unexpected GraphQL query")
+			}
+			page := pages[0]
+			pages = pages[1:]
+			return json.Unmarshal([]byte(page), data)
+		},
+	}
+}
+
+func TestFetchPipelineDetailGraphQLPages(t *testing.T) {
+	saved := gitlabClient
+	defer func() { gitlabClient = saved }()
+	gitlabClient = replayGraphQL(t,
+		`{"projects":{"nodes":[{"pipeline":{"iid":"3","status":"FAILED","ref":"main","sha":"abc",
+			"commit":{"sha":"abc","title":"first"},
+			"jobs":{"pageInfo":{"hasNextPage":true,"endCursor":"c1"},"nodes":[
+				{"id":"gid://gitlab/Ci::Build/1","name":"build","kind":"BUILD","status":"SUCCESS",
+				 "startedAt":"2026-01-01T10:00:00Z","stage":{"name":"build"},"runner":{"id":"gid://gitlab/Ci::Runner/5"}}]}}}]}}`,
+		`{"projects":{"nodes":[{"pipeline":{"iid":"3","status":"FAILED","ref":"main","sha":"abc",
+			"commit":{"sha":"abc","title":"first"},
+			"jobs":{"pageInfo":{"hasNextPage":false},"nodes":[
+				{"id":"gid://gitlab/Ci::Build/2","name":"test","kind":"BUILD","status":"FAILED",
+				 "startedAt":"2026-01-01T11:00:00Z","stage":{"name":"test"},"runner":{"id":"gid://gitlab/Ci::Runner/6"}},
+				{"id":"gid://gitlab/Ci::Bridge/3","name":"deploy","kind":"BRIDGE","status":"CREATED",
+				 "stage":{"name":"deploy"}}]}}}]}}`,
+	)
+
+	detail, err := fetchPipelineDetailGraphQL("7", "42")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if detail.pipeline.ID != 42 || detail.pipeline.ProjectID != 7 || detail.pipeline.Status != "failed" {
+		t.Errorf("pipeline = %d/%d %s, want 42/7 failed", detail.pipeline.ID, detail.pipeline.ProjectID, detail.pipeline.Status)
+	}
+	if detail.commit.Title != "first" {
+		t.Errorf("commit title = %q, want first", detail.commit.Title)
+	}
+
+	tests := []struct {
+		name    string
+		id      int
+		stage   string
+		runner  int
+		started string
+	}{
+		{"build", 1, "build", 5, "2026-01-01T10:00:00Z"},
+		{"test", 2, "test", 6, "2026-01-01T11:00:00Z"},
+	}
+	if len(detail.jobs) != len(tests) {
+		t.Fatalf("got %d jobs, want %d", len(detail.jobs), len(tests))
+	}
+	for i, tt := range tests {
+		job := detail.jobs[i]
+		started, _ := time.Parse(time.RFC3339, tt.started)
+		if job.Name != tt.name || job.ID != tt.id || job.Stage != tt.stage || job.Runner.ID != tt.runner ||
+			job.StartedAt == nil || !job.StartedAt.Equal(started) {
+			t.Errorf("job %d = %s/%d %s runner %d started %v, want %s/%d %s runner %d started %s",
+				i, job.Name, job.ID, job.Stage, job.Runner.ID, job.StartedAt, tt.name, tt.id, tt.stage, tt.runner, tt.started)
+		}
+	}
+	if len(detail.bridges) != 1 || detail.bridges[0].Name != "deploy" {
+		t.Errorf("bridges = %v, want deploy", detail.bridges)
+	}
+}
+
+func TestListPipelineDetailsGraphQLBatches(t *testing.T) {
+	savedClient, savedGraphQL := gitlabClient, useGraphQL
+	defer func() { gitlabClient, useGraphQL = savedClient, savedGraphQL }()
+	useGraphQL = true
+
+	var infos []*gitlab.PipelineInfo
+	for id := 9001; id <= 9000+pipelineListBatch+3; id++ {
+		infos = append(infos, &gitlab.PipelineInfo{ID: id})
+	}
+
+	var queries []string
+	gitlabClient = &api.Mock{
+		GraphQLFunc: func(query string, variables map[string]interface{}, data interface{}) error {
+			queries = append(queries, query)
+			// Answer every alias asked for with a pipeline whose ref
+			// tells which one it was.
+			nodes := map[string]interface{}{}
+			for i := 0; strings.Contains(query, fmt.Sprintf("p%d: pipeline", i)); i++ {
+				nodes[fmt.Sprintf("p%d", i)] = map[string]interface{}{"status": "SUCCESS", "ref": fmt.Sprintf("q%d-p%d", len(queries), i)}
+			}
+			encoded, _ := json.Marshal(map[string]interface{}{"projects": map[string]interface{}{"nodes": []interface{}{nodes}}})
+			return json.Unmarshal(encoded, data)
+		},
+	}
+
+	pipelines, err := listPipelineDetails("7", infos)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(queries) != 2 {
+		t.Fatalf("%d queries, want 2", len(queries))
+	}
+	tests := []struct {
+		index int
+		id    int
+		ref   string
+	}{
+		{0, 9001, "q1-p0"},
+		{pipelineListBatch - 1, 9000 + pipelineListBatch, fmt.Sprintf("q1-p%d", pipelineListBatch-1)},
+		{pipelineListBatch, 9001 + pipelineListBatch, "q2-p0"},
+		{len(infos) - 1, 9000 + len(infos), "q2-p2"},
+	}
+	for _, tt := range tests {
+		pipeline := pipelines[tt.index]
+		if pipeline.ID != tt.id || pipeline.Ref != tt.ref || pipeline.Status != "success" || pipeline.ProjectID != 7 {
+			t.Errorf("pipeline %d = #%d %s %s in %d, want #%d %s success in 7",
+				tt.index, pipeline.ID, pipeline.Ref, pipeline.Status, pipeline.ProjectID, tt.id, tt.ref)
+		}
+	}
+
+	// The details are cached now, listing again asks for nothing.
+	if _, err := listPipelineDetails("7", infos); err != nil {
+		t.Fatal(err)
+	}
+	if len(queries) != 2 {
+		t.Errorf("%d queries after listing again, want 2", len(queries))
+	}
+}
@@ -0,0 +1,897 @@
+// pipelines.go
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/xanzy/go-gitlab"
+)
+
+func showPipelines(app *tview.Application, project *gitlab.Project) {
+	cancelPendingLoads()
+	setKeybindContext(func() []string { return projectKeybindEnv(project) })
+
+	projectID := strconv.Itoa(project.ID)
+	if err := recordRecentProject(project.ID, project.PathWithNamespace); err != nil {
+		showError(app, err, nil)
+	}
+
+	var (
+		branches     []*gitlab.Branch
+		tags         []*gitlab.Tag
+		tagPipelines map[string]*gitlab.PipelineInfo
+		showingTags  bool
+	)
+
+	filterField := tview.NewInputField().
+		SetLabel("Filter branches: ").
+		SetFieldBackgroundColor(tcell.ColorDarkGray).
+		SetFieldTextColor(tcell.ColorOrangeRed)
+
+	branchList := tview.NewList().ShowSecondaryText(false)
+
+	var visibleRefs []string
+
+	applyFilter := func(filter string) {
+		visibleRefs = visibleRefs[:0]
+		branchList.Clear()
+
+		matches := func(name string) bool {
+			return filter == "" || strings.Contains(strings.ToLower(name), strings.ToLower(filter))
+		}
+
+		if showingTags {
+			for _, tag := range tags {
+				if matches(tag.Name) {
+					visibleRefs = append(visibleRefs, tag.Name)
+					branchList.AddItem(formatTag(tag, tagPipelines[tag.Name]), "", 0, nil)
+				}
+			}
+			return
+		}
+		for _, branch := range branches {
+			if matches(branch.Name) {
+				visibleRefs = append(visibleRefs, branch.Name)
+				branchList.AddItem(formatBranch(branch), "", 0, nil)
+			}
+		}
+	}
+
+	selectBranch := func(index int) {
+		if index < 0 || index >= len(visibleRefs) {
+			return
+		}
+		fetchAndShowPipelines(app, projectID, visibleRefs[index])
+	}
+
+	statusBar := newStatusBar()
+
+	// toggleTags switches between picking a branch and picking a tag, loading
+	// the tags and their latest pipelines the first time.
+	toggleTags := func() {
+		showingTags = !showingTags
+		if showingTags {
+			filterField.SetLabel("Filter tags: ")
+		} else {
+			filterField.SetLabel("Filter branches: ")
+		}
+		applyFilter(filterField.GetText())
+
+		if !showingTags || tags != nil {
+			return
+		}
+		fetchInBackground(app, statusBar, "Loading tags...", func() (func(), error) {
+			loaded, err := listAllTags(projectID)
+			if err != nil {
+				return nil, fmt.Errorf("fetching tags for project %s: %w", projectID, err)
+			}
+			pipelines, err := listTagPipelines(projectID)
+			if err != nil {
+				return nil, err
+			}
+			return func() {
+				tags, tagPipelines = loaded, pipelines
+				applyFilter(filterField.GetText())
+			}, nil
+		})
+	}
+
+	filterField.SetChangedFunc(applyFilter)
+
+	filterField.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyEsc:
+			showTree(app, lastSearchTerm)
+			return nil
+		case tcell.KeyEnter:
+			selectBranch(branchList.GetCurrentItem())
+			return nil
+		case tcell.KeyTab:
+			toggleTags()
+			return nil
+		case tcell.KeyDown, tcell.KeyUp, tcell.KeyPgDn, tcell.KeyPgUp:
+			branchList.InputHandler()(event, nil)
+			return nil
+		}
+		return event
+	})
+
+	branchList.SetSelectedFunc(func(index int, mainText string, secondaryText string, shortcut rune) {
+		selectBranch(index)
+	})
+
+	flex := tview.NewFlex().
+		SetDirection(tview.FlexRow).
+		AddItem(filterField, 1, 0, true).
+		AddItem(branchList, 0, 1, false).
+		AddItem(statusBar, 1, 0, false).
+		AddItem(tview.NewButton("TAB - Branches/Tags   ESC - Back").SetSelectedFunc(func() {
+			showTree(app, lastSearchTerm)
+		}), 1, 0, false)
+
+	routes.show("branches/"+strconv.Itoa(project.ID), flex, filterField)
+
+	if loaded, ok := prefetched[[]*gitlab.Branch](branchesPrefetchKey(projectID)); ok {
+		branches = loaded
+		applyFilter(filterField.GetText())
+		return
+	}
+	fetchInBackground(app, statusBar, "Loading branches...", func() (func(), error) {
+		loaded, err := listAllBranches(projectID)
+		if err != nil {
+			return nil, fmt.Errorf("fetching branches for project %s: %w", projectID, err)
+		}
+		return func() {
+			branches = loaded
+			applyFilter(filterField.GetText())
+		}, nil
+	})
+}
+
+// listAllBranches pages through every branch of the project and moves the
+// default branch to the front of the list.
+func listAllBranches(projectID string, options ...gitlab.RequestOptionFunc) ([]*gitlab.Branch, error) {
+	return shareFetch("branches/"+projectID, func() ([]*gitlab.Branch, error) {
+		allBranches, err := cachedFetch(branchCache, "branches/"+projectID, func() ([]*gitlab.Branch, error) {
+			return listAllPages(func(listOptions gitlab.ListOptions) ([]*gitlab.Branch, *gitlab.Response, error) {
+				return gitlabClient.Branches().ListBranches(projectID, &gitlab.ListBranchesOptions{ListOptions: listOptions}, options...)
+			})
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		sort.SliceStable(allBranches, func(i, j int) bool {
+			return allBranches[i].Default && !allBranches[j].Default
+		})
+
+		return allBranches, nil
+	})
+}
+
+func formatBranch(branch *gitlab.Branch) string {
+	name := branch.Name
+	if branch.Default {
+		name += " (default)"
+	}
+	if branch.Commit != nil && branch.Commit.CommittedDate != nil {
+		name += "  -  last commit " + branch.Commit.CommittedDate.Format("2006-01-02 15:04:05")
+	}
+	return name
+}
+
+// fetchAndShowPipelines shows the pipelines of branch, filtered as they were
+// when the user left them for one of their pipelines.
+func fetchAndShowPipelines(app *tview.Application, projectID, branch string) {
+	filter, err := parsePipelineFilter(routes.filter(pipelinesRouteKey(projectID, branch)))
+	if err != nil {
+		filter = pipelineFilter{}
+	}
+	showFilteredPipelines(app, projectID, branch, filter)
+}
+
+// pipelinesRouteKey is the router key of the pipelines of branch.
+func pipelinesRouteKey(projectID, branch string) string {
+	return "pipelines/" + projectID + "/" + branch
+}
+
+// showFilteredPipelines is fetchAndShowPipelines with the list already
+// narrowed by filter.
+func showFilteredPipelines(app *tview.Application, projectID, branch string, filter pipelineFilter) {
+	cancelPendingLoads()
+	pipelineTrail = nil
+
+	pipelineTable := tview.NewTable().
+		SetSelectable(true, false).
+		SetFixed(1, 0)
+	statusBar := newStatusBar()
+
+	var (
+		loadPipelines   func(progress func(string)) (func(), error)
+		reloadPipelines func() (func(), error)
+	)
+
+	runPipeline := func() {
+		run := func(ref string, variables []*gitlab.PipelineVariableOptions) (*gitlab.Pipeline, error) {
+			return createPipeline(projectID, ref, variables)
+		}
+		showRunPipelineForm(app, "Run pipeline", projectID, branch, run, func() {
+			fetchAndShowPipelines(app, projectID, branch)
+		})
+	}
+
+	emptyMessage := fmt.Sprintf("No pipelines for branch %s yet.\nTrigger one?", branch)
+	emptyState := newEmptyState(emptyMessage,
+		[]string{"Run pipeline", "Refresh", "Back"},
+		func(label string) {
+			switch label {
+			case "Run pipeline":
+				runPipeline()
+			case "Refresh":
+				fetchWithProgress(app, statusBar, "Refreshing...", loadPipelines)
+			default:
+				showTree(app, lastSearchTerm)
+			}
+		})
+
+	pages := newListPages(pipelineTable, emptyState)
+
+	var shownPipelines []*gitlab.Pipeline
+	order := pipelineSort{column: 0, descending: true}
+
+	// listed holds the pipelines listed so far. Only the rows on screen get
+	// their details, which the list endpoint leaves out, and more pages
+	// are listed when the selection reaches the last row. loadedPages is
+	// read by refreshes in the background. filling is set while the table
+	// is refilled, whose selection changes aren't the user's.
+	var (
+		listed      pipelinePage
+		loadingMore bool
+		filling     bool
+		loadedPages atomic.Int32
+	)
+
+	setKeybindContext(func() []string {
+		if pipeline := selectedPipeline(pipelineTable); pipeline != nil {
+			return pipelineHookEnv(pipeline)
+		}
+		return nil
+	})
+
+	filterField := tview.NewInputField().
+		SetLabel("/").
+		SetText(filter.text).
+		SetPlaceholder("status=failed source=push ref=release/* from=2024-01-01 to=2024-01-31").
+		SetFieldBackgroundColor(tcell.ColorDarkGray).
+		SetFieldTextColor(tcell.ColorOrangeRed)
+
+	fillPipelineList := func(pipelines []*gitlab.Pipeline) {
+		shownPipelines = pipelines
+		if filter.text != "" {
+			emptyState.SetText(fmt.Sprintf("No pipelines match %q.", filter.text))
+		} else {
+			emptyState.SetText(emptyMessage)
+		}
+		showEmptyState(app, pages, pipelineTable, emptyState, len(pipelines) == 0)
+		filling = true
+		fillPipelineTable(pipelineTable, order.apply(pipelines), order)
+		filling = false
+	}
+
+	showListed := func() {
+		fillPipelineList(pipelineRows(listed.pipelines))
+	}
+
+	// loadVisibleDetails fetches the details of the rows on screen that
+	// don't have up-to-date ones yet.
+	loadVisibleDetails := func() {
+		missing := visiblePipelinesWithoutDetails(pipelineTable, listed.pipelines)
+		if len(missing) == 0 {
+			return
+		}
+		fetchInBackground(app, statusBar, "Loading pipeline details...", func() (func(), error) {
+			pipelines, err := listPipelineDetails(projectID, missing)
+			if err != nil {
+				return nil, fmt.Errorf("fetching pipelines for project %s and branch %s: %w", projectID, branch, err)
+			}
+			return func() {
+				// A pipeline may have changed since it was listed, its
+				// details are only up to date for the listed state if
+				// the listing follows them.
+				for i, info := range missing {
+					info.Status = pipelines[i].Status
+					info.UpdatedAt = pipelines[i].UpdatedAt
+				}
+				showListed()
+			}, nil
+		})
+	}
+
+	var loadMore func()
+	loadMore = func() {
+		loadingMore = true
+		from := listed.nextPage
+		fetchWithProgress(app, statusBar, "Loading more pipelines...", func(progress func(string)) (func(), error) {
+			more, err := listPipelinePages(projectID, branch, filter, from, pipelinePagesPerLoad, pageSize, progress)
+			return func() {
+				loadingMore = false
+				if err != nil {
+					showError(app, fmt.Errorf("fetching pipelines for project %s and branch %s: %w", projectID, branch, err), loadMore)
+					return
+				}
+				listed.pipelines = append(listed.pipelines, more.pipelines...)
+				listed.nextPage = more.nextPage
+				loadedPages.Add(int32(more.read))
+				showListed()
+				loadVisibleDetails()
+			}, nil
+		})
+	}
+
+	pipelineTable.SetSelectionChangedFunc(func(row, column int) {
+		if filling {
+			return
+		}
+		loadVisibleDetails()
+		if row >= pipelineTable.GetRowCount()-1 && listed.nextPage != 0 && !loadingMore {
+			loadMore()
+		}
+	})
+
+	pipelineTable.SetSelectedFunc(func(row, column int) {
+		if pipeline := selectedPipeline(pipelineTable); pipeline != nil {
+			showPipelineDetail(app, projectID, strconv.Itoa(pipeline.ID), branch)
+		}
+	})
+
+	// loadPipelines lists the first pages again, as many as were loaded so
+	// far, or the first batch if none were.
+	loadPipelines = func(progress func(string)) (func(), error) {
+		maxPages, enough := int(loadedPages.Load()), 0
+		if maxPages == 0 {
+			maxPages, enough = pipelinePagesPerLoad, pageSize
+		}
+		page, err := listPipelinePages(projectID, branch, filter, 1, maxPages, enough, progress)
+		if err != nil {
+			return nil, fmt.Errorf("fetching pipelines for project %s and branch %s: %w", projectID, branch, err)
+		}
+		return func() {
+			listed = page
+			loadedPages.Store(int32(page.read))
+			showListed()
+			loadVisibleDetails()
+		}, nil
+	}
+	reloadPipelines = func() (func(), error) {
+		return loadPipelines(func(string) {})
+	}
+
+	startAutoRefresh(app, pipelineTable, reloadPipelines)
+	animateWhileFocused(pipelineTable, func() {
+		for _, pipeline := range shownPipelines {
+			if pipeline.Status == "running" {
+				fillPipelineList(shownPipelines)
+				return
+			}
+		}
+	})
+
+	var flex *tview.Flex
+
+	// marked is the pipeline picked with x to compare with another one.
+	var marked *gitlab.Pipeline
+
+	comparePipeline := func(a, b *gitlab.Pipeline) {
+		showPipelineComparison(app, projectID, a, b, func() {
+			showFilteredPipelines(app, projectID, branch, filter)
+		})
+	}
+
+	pipelineTable.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyEsc {
+			showTree(app, lastSearchTerm)
+			return nil
+		}
+		if event.Rune() == 'x' {
+			pipeline := selectedPipeline(pipelineTable)
+			switch {
+			case pipeline == nil:
+			case marked == nil:
+				marked = pipeline
+				showInfo(app, fmt.Sprintf("Pipeline #%d marked, press x on another pipeline to compare them", pipeline.ID))
+			case marked.ID == pipeline.ID:
+				marked = nil
+				showInfo(app, fmt.Sprintf("Pipeline #%d unmarked", pipeline.ID))
+			default:
+				comparePipeline(marked, pipeline)
+			}
+			return nil
+		}
+		if event.Rune() == 'w' {
+			if pipeline := selectedPipeline(pipelineTable); pipeline != nil {
+				toggleWatch(app, projectID, pipeline)
+			}
+			return nil
+		}
+		if event.Rune() == 'o' {
+			if pipeline := selectedPipeline(pipelineTable); pipeline != nil {
+				openWebURL(app, pipeline.WebURL)
+			}
+			return nil
+		}
+		if event.Rune() == 'y' {
+			if pipeline := selectedPipeline(pipelineTable); pipeline != nil {
+				showYankMenu(app, pipelineYankTargets(pipeline))
+			}
+			return nil
+		}
+		if event.Rune() == 'X' {
+			if pipeline := selectedPipeline(pipelineTable); pipeline != nil {
+				green := lastSuccessBefore(shownPipelines, pipeline)
+				if green == nil {
+					showError(app, fmt.Errorf("no successful pipeline before #%d in this list", pipeline.ID), nil)
+					return nil
+				}
+				comparePipeline(green, pipeline)
+			}
+			return nil
+		}
+		if isRefreshKey(event) {
+			fetchWithProgress(app, statusBar, "Refreshing...", loadPipelines)
+			return nil
+		}
+		if event.Rune() == 'R' {
+			if pipeline := selectedPipeline(pipelineTable); pipeline != nil {
+				confirmAction(app, fmt.Sprintf("Retry pipeline #%d?", pipeline.ID), "Retry", func() {
+					runAction(app, statusBar, "Retrying pipeline...", fmt.Sprintf("Pipeline #%d retried", pipeline.ID),
+						func() error { return retryPipeline(projectID, pipeline.ID) }, reloadPipelines)
+				})
+			}
+			return nil
+		}
+		if event.Rune() == 'C' {
+			if pipeline := selectedPipeline(pipelineTable); pipeline != nil {
+				if !cancelableStatuses[pipeline.Status] {
+					showError(app, fmt.Errorf("pipeline #%d is %s and can't be canceled", pipeline.ID, pipeline.Status), nil)
+					return nil
+				}
+				confirmAction(app, fmt.Sprintf("Cancel pipeline #%d?", pipeline.ID), "Cancel pipeline", func() {
+					runAction(app, statusBar, "Canceling pipeline...", fmt.Sprintf("Pipeline #%d canceled", pipeline.ID),
+						func() error { return cancelPipeline(projectID, pipeline.ID) }, reloadPipelines)
+				})
+			}
+			return nil
+		}
+		if event.Rune() == 'n' {
+			runPipeline()
+			return nil
+		}
+		if event.Rune() == 'c' {
+			showCoverageTrend(app, projectID, branch, func() {
+				fetchAndShowPipelines(app, projectID, branch)
+			})
+			return nil
+		}
+		if event.Rune() == 'h' {
+			showCommits(app, projectID, branch, func() {
+				fetchAndShowPipelines(app, projectID, branch)
+			})
+			return nil
+		}
+		if event.Rune() == 'D' {
+			if pipeline := selectedPipeline(pipelineTable); pipeline != nil {
+				confirmAction(app, fmt.Sprintf("Delete pipeline #%d?", pipeline.ID), "Delete", func() {
+					confirmAction(app, fmt.Sprintf("This permanently deletes pipeline #%d with its jobs, logs and artifacts.\nReally delete it?", pipeline.ID), "Delete permanently", func() {
+						runAction(app, statusBar, "Deleting pipeline...", fmt.Sprintf("Pipeline #%d deleted", pipeline.ID),
+							func() error { return deletePipeline(projectID, pipeline.ID) }, reloadPipelines)
+					})
+				})
+			}
+			return nil
+		}
+		if column, ok := pipelineSortKey(event); ok {
+			order.toggle(column)
+			fillPipelineList(shownPipelines)
+			return nil
+		}
+		if event.Rune() == '/' {
+			flex.ResizeItem(filterField, 1, 0)
+			app.SetFocus(filterField)
+			return nil
+		}
+		return event
+	})
+
+	filterField.SetDoneFunc(func(key tcell.Key) {
+		switch key {
+		case tcell.KeyEnter:
+			parsed, err := parsePipelineFilter(filterField.GetText())
+			if err != nil {
+				showError(app, err, nil)
+				return
+			}
+			filter = parsed
+			routes.setFilter(filter.text)
+			loadedPages.Store(0)
+			fetchWithProgress(app, statusBar, "Filtering pipelines...", loadPipelines)
+		case tcell.KeyEsc:
+			filterField.SetText(filter.text)
+		}
+		if filterField.GetText() == "" {
+			flex.ResizeItem(filterField, 0, 0)
+		}
+		app.SetFocus(pipelineTable)
+	})
+
+	flex = tview.NewFlex().
+		SetDirection(tview.FlexRow).
+		AddItem(filterField, 0, 0, false).
+		AddItem(pages, 0, 1, true).
+		AddItem(statusBar, 1, 0, false).
+		AddItem(tview.NewButton("n - Run pipeline   R - Retry   C - Cancel   D - Delete   c - Coverage   h - Commits   w - Watch   o - Open   y - Copy   x/X - Compare   / - Filter   1-8 - Sort   ESC - Back").SetSelectedFunc(func() {
+			showTree(app, "")
+		}), 1, 0, false)
+	if filter.text != "" {
+		flex.ResizeItem(filterField, 1, 0)
+	}
+
+	routes.show(pipelinesRouteKey(projectID, branch), flex, pipelineTable)
+	routes.setFilter(filter.text)
+
+	// The latest pipelines may have been prefetched while the project was
+	// highlighted; they are shown until the full list is loaded.
+	if pipelines, ok := prefetched[[]*gitlab.Pipeline](pipelinesPrefetchKey(projectID, branch)); ok && filter.text == "" {
+		fillPipelineList(pipelines)
+		fetchWithProgress(app, statusBar, "Refreshing...", loadPipelines)
+		return
+	}
+	fetchWithProgress(app, statusBar, "Loading pipelines...", loadPipelines)
+}
+
+func createPipeline(projectID, ref string, variables []*gitlab.PipelineVariableOptions) (*gitlab.Pipeline, error) {
+	opts := &gitlab.CreatePipelineOptions{Ref: &ref}
+	if len(variables) > 0 {
+		opts.Variables = &variables
+	}
+
+	pipeline, _, err := gitlabClient.Pipelines().CreatePipeline(projectID, opts)
+	if err != nil {
+		return nil, fmt.Errorf("creating pipeline for project %s and ref %s: %w", projectID, ref, err)
+	}
+	return pipeline, nil
+}
+
+// pipelinePagesPerLoad is how many pages of pipelines are listed at most
+// each time the pipeline table asks for more. A ref glob is matched here
+// rather than by GitLab, so pages can hold few matching pipelines.
+const pipelinePagesPerLoad = 10
+
+// pipelinePage is a run of pages of matching pipelines, newest first.
+// nextPage is the page to list more from, 0 once there are no more.
+type pipelinePage struct {
+	pipelines []*gitlab.PipelineInfo
+	read      int
+	nextPage  int
+}
+
+// listPipelinePages lists up to maxPages pages of pipelines starting at
+// page, stopping early once it has enough matching ones if enough isn't 0.
+// The page being read is passed to progress.
+func listPipelinePages(projectID, branch string, filter pipelineFilter, page, maxPages, enough int, progress func(string)) (pipelinePage, error) {
+	key := fmt.Sprintf("pipelines/%s/%s/%s/%d-%d-%d", projectID, branch, filter.text, page, maxPages, enough)
+	return shareFetch(key, func() (pipelinePage, error) {
+		listed := pipelinePage{nextPage: page}
+		for listed.read < maxPages && listed.nextPage != 0 {
+			if enough > 0 && len(listed.pipelines) >= enough {
+				break
+			}
+			progress(fmt.Sprintf("page %d", listed.nextPage))
+
+			opts := filter.listOptions(branch)
+			opts.ListOptions = gitlab.ListOptions{PerPage: pageSize, Page: listed.nextPage}
+			pipelines, resp, err := gitlabClient.Pipelines().ListProjectPipelines(projectID, opts)
+			if err != nil {
+				return pipelinePage{}, err
+			}
+			listed.read++
+
+			for _, pipeline := range pipelines {
+				if filter.matchRef(pipeline.Ref) {
+					listed.pipelines = append(listed.pipelines, pipeline)
+				}
+			}
+			listed.nextPage = 0
+			if resp != nil {
+				listed.nextPage = resp.NextPage
+			}
+		}
+		return listed, nil
+	})
+}
+
+func listPipelineJobs(projectID, pipelineID string) ([]*gitlab.Job, error) {
+	return shareFetch("jobs/"+projectID+"/"+pipelineID, func() ([]*gitlab.Job, error) {
+		return listAllPages(func(listOptions gitlab.ListOptions) ([]*gitlab.Job, *gitlab.Response, error) {
+			return gitlabClient.Jobs().ListPipelineJobs(projectID, toInt(pipelineID), &gitlab.ListJobsOptions{ListOptions: listOptions})
+		})
+	})
+}
+
+func listPipelineBridges(projectID, pipelineID string) ([]*gitlab.Bridge, error) {
+	return shareFetch("bridges/"+projectID+"/"+pipelineID, func() ([]*gitlab.Bridge, error) {
+		return listAllPages(func(listOptions gitlab.ListOptions) ([]*gitlab.Bridge, *gitlab.Response, error) {
+			return gitlabClient.Jobs().ListPipelineBridges(projectID, toInt(pipelineID), &gitlab.ListJobsOptions{ListOptions: listOptions})
+		})
+	})
+}
+
+// showJobList shows the job list from pipelineJobs and the trigger jobs in
+// pipelineBridges, or loads both in the background if pipelineJobs is nil.
+func showJobList(app *tview.Application, pipelineJobs []*gitlab.Job, pipelineBridges []*gitlab.Bridge, projectID, pipelineID, pipelineName string) {
+	cancelPendingLoads()
+
+	jobList := tview.NewList().ShowSecondaryText(false)
+	detailPanel := newJobDetailPanel()
+	statusBar := newStatusBar()
+	breadcrumbBar, breadcrumbHeight := newBreadcrumbBar(pipelineID)
+
+	var reloadJobs func() (func(), error)
+
+	setKeybindContext(func() []string {
+		if index := jobList.GetCurrentItem(); index >= 0 && index < len(pipelineJobs) {
+			return jobHookEnv(projectID, pipelineJobs[index], 0)
+		}
+		return nil
+	})
+
+	emptyState := newEmptyState(fmt.Sprintf("Pipeline %s has no jobs.", pipelineID),
+		[]string{"Refresh", "Back"},
+		func(label string) {
+			if label == "Refresh" {
+				fetchInBackground(app, statusBar, "Refreshing...", reloadJobs)
+				return
+			}
+			showPipelineDetail(app, projectID, pipelineID, pipelineName)
+		})
+
+	pages := newListPages(jobList, emptyState)
+
+	// showDetail fills the detail panel with the job or trigger job at index.
+	showDetail := func(index int) {
+		switch {
+		case index >= 0 && index < len(pipelineJobs):
+			showJobDetail(app, statusBar, detailPanel, pipelineJobs[index], func(job *gitlab.Job) bool {
+				current := jobList.GetCurrentItem()
+				return current < len(pipelineJobs) && pipelineJobs[current] == job
+			})
+		case index >= len(pipelineJobs) && index < len(pipelineJobs)+len(pipelineBridges):
+			detailPanel.SetText(formatBridgeDetail(pipelineBridges[index-len(pipelineJobs)]))
+		default:
+			detailPanel.SetText("")
+		}
+	}
+
+	jobList.SetChangedFunc(func(index int, mainText, secondaryText string, shortcut rune) {
+		showDetail(index)
+	})
+
+	fillJobList := func(jobs []*gitlab.Job, bridges []*gitlab.Bridge) {
+		showEmptyState(app, pages, jobList, emptyState, len(jobs) == 0 && len(bridges) == 0)
+
+		currentItem := jobList.GetCurrentItem()
+		jobList.Clear()
+
+		for _, job := range jobs {
+			jobInfo := fmt.Sprintf("Job ID: %d \nName: %s \nStatus: %s", job.ID, job.Name, statusLabel(job.Status))
+			jobList.AddItem(jobInfo, "", 0, nil)
+		}
+
+		for _, bridge := range bridges {
+			downstream := "not created yet"
+			if bridge.DownstreamPipeline != nil {
+				downstream = tview.Escape(pipelineLabel(bridge.DownstreamPipeline.WebURL, bridge.DownstreamPipeline.ID)) +
+					" " + statusLabel(bridge.DownstreamPipeline.Status)
+			}
+			bridgeInfo := fmt.Sprintf("Trigger Job ID: %d \nName: %s \nStatus: %s \nDownstream: %s",
+				bridge.ID, bridge.Name, statusLabel(bridge.Status), downstream)
+			jobList.AddItem(bridgeInfo, "", 0, nil)
+		}
+
+		jobList.SetCurrentItem(currentItem)
+		showDetail(jobList.GetCurrentItem())
+	}
+
+	reloadJobs = func() (func(), error) {
+		jobs, err := listPipelineJobs(projectID, pipelineID)
+		if err != nil {
+			return nil, fmt.Errorf("fetching jobs for project %s and pipeline %s: %w", projectID, pipelineID, err)
+		}
+		bridges, err := listPipelineBridges(projectID, pipelineID)
+		if err != nil {
+			return nil, fmt.Errorf("fetching trigger jobs for project %s and pipeline %s: %w", projectID, pipelineID, err)
+		}
+		return func() {
+			pipelineJobs = jobs
+			pipelineBridges = bridges
+			fillJobList(jobs, bridges)
+		}, nil
+	}
+
+	startAutoRefresh(app, jobList, reloadJobs)
+	animateWhileFocused(jobList, func() {
+		for _, job := range pipelineJobs {
+			if job.Status == "running" {
+				fillJobList(pipelineJobs, pipelineBridges)
+				return
+			}
+		}
+		for _, bridge := range pipelineBridges {
+			if bridge.Status == "running" {
+				fillJobList(pipelineJobs, pipelineBridges)
+				return
+			}
+		}
+	})
+
+	var flex *tview.Flex
+
+	jobList.SetSelectedFunc(func(index int, mainText string, secondaryText string, shortcut rune) {
+		if index >= len(pipelineJobs) {
+			bridge := pipelineBridges[index-len(pipelineJobs)]
+			if bridge.DownstreamPipeline == nil {
+				showError(app, fmt.Errorf("trigger job %s has not created a pipeline yet", bridge.Name), nil)
+				return
+			}
+			enterDownstreamPipeline(app, projectID, pipelineName, bridge)
+			return
+		}
+
+		selectedJob := pipelineJobs[index]
+
+		actions := []string{"Logs", "Retry"}
+		if len(selectedJob.Artifacts) > 0 {
+			actions = append(actions, "Artifacts")
+		}
+		if selectedJob.Status == "manual" {
+			actions = append(actions, "Play")
+		}
+		if cancelableStatuses[selectedJob.Status] {
+			actions = append(actions, "Cancel job")
+		}
+		if erasableStatuses[selectedJob.Status] && selectedJob.ErasedAt == nil {
+			actions = append(actions, "Erase")
+		}
+		for _, p := range plugins {
+			actions = append(actions, p.name)
+		}
+		actions = append(actions, "Close")
+
+		jobActionModal := tview.NewModal().
+			SetText(fmt.Sprintf("Select Action for Job %d", selectedJob.ID)).
+			AddButtons(actions)
+
+		returnToJobList := func() {
+			showJobList(app, pipelineJobs, pipelineBridges, projectID, pipelineID, pipelineName)
+		}
+
+		jobActionModal.SetDoneFunc(func(buttonIndex int, buttonLabel string) {
+			switch buttonLabel {
+			case "Logs":
+				fetchAndDisplayJobLogs(app, projectID, strconv.Itoa(selectedJob.ID), returnToJobList)
+			case "Retry":
+				go retryJob(app, projectID, strconv.Itoa(selectedJob.ID))
+				returnToJobList()
+			case "Artifacts":
+				showJobArtifacts(app, projectID, selectedJob, returnToJobList)
+			case "Play":
+				showPlayJobForm(app, projectID, selectedJob, func() {
+					showJobList(app, nil, nil, projectID, pipelineID, pipelineName)
+				}, returnToJobList)
+			case "Cancel job":
+				routes.dismiss()
+				runAction(app, statusBar, "Canceling job...", fmt.Sprintf("Job %d canceled", selectedJob.ID),
+					func() error { return cancelJob(projectID, selectedJob.ID) }, reloadJobs)
+			case "Erase":
+				routes.dismiss()
+				message := fmt.Sprintf("Erase job %s?\nIts log and artifacts will be deleted. This can't be undone.", selectedJob.Name)
+				confirmAction(app, message, "Erase", func() {
+					runAction(app, statusBar, "Erasing job...", fmt.Sprintf("Job %d erased", selectedJob.ID),
+						func() error { return eraseJob(projectID, selectedJob.ID) }, reloadJobs)
+				})
+			case "Close":
+				routes.dismiss()
+			default:
+				if p, ok := findPlugin(buttonLabel); ok {
+					routes.dismiss()
+					runPluginInBackground(app, statusBar, p, "job", jobHookEnv(projectID, selectedJob, 0))
+				}
+			}
+		})
+
+		routes.overlay(jobActionModal)
+	})
+
+	jobList.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyEsc {
+			showPipelineDetail(app, projectID, pipelineID, pipelineName)
+			return nil
+		}
+		if event.Rune() == 'F' {
+			confirmRetryFailedJobs(app, projectID, pipelineJobs, func() {
+				showJobList(app, nil, nil, projectID, pipelineID, pipelineName)
+			})
+			return nil
+		}
+		if event.Rune() == 'o' {
+			index := jobList.GetCurrentItem()
+			switch {
+			case index < len(pipelineJobs):
+				openWebURL(app, pipelineJobs[index].WebURL)
+			case index-len(pipelineJobs) < len(pipelineBridges):
+				openWebURL(app, pipelineBridges[index-len(pipelineJobs)].WebURL)
+			}
+			return nil
+		}
+		if event.Rune() == 'y' {
+			if index := jobList.GetCurrentItem(); index >= 0 && index < len(pipelineJobs) {
+				showYankMenu(app, jobYankTargets(pipelineJobs[index]))
+			}
+			return nil
+		}
+		if isRefreshKey(event) {
+			fetchInBackground(app, statusBar, "Refreshing...", reloadJobs)
+			return nil
+		}
+		return event
+	})
+
+	flex = tview.NewFlex().
+		SetDirection(tview.FlexRow).
+		AddItem(breadcrumbBar, breadcrumbHeight, 0, false).
+		AddItem(tview.NewFlex().
+			AddItem(pages, 0, 2, true).
+			AddItem(detailPanel, 0, 1, false), 0, 1, true).
+		AddItem(statusBar, 1, 0, false).
+		AddItem(tview.NewButton("F - Retry failed   o - Open   y - Copy   ESC - Back").SetSelectedFunc(func() {
+			showPipelineDetail(app, projectID, pipelineID, pipelineName)
+		}), 1, 0, false)
+
+	if pipelineJobs == nil {
+		fetchInBackground(app, statusBar, "Loading jobs...", reloadJobs)
+	} else {
+		fillJobList(pipelineJobs, pipelineBridges)
+	}
+
+	routes.show("jobs/"+projectID+"/"+pipelineID, flex, jobList)
+}
+
+func toInt(s string) int {
+	i, err := strconv.Atoi(s)
+	if err != nil {
+		return 0
+	}
+	return i
+}
+
+// retryJob retries the job and reports the outcome as a toast. It blocks, so
+// it is meant to be run in its own goroutine.
+func retryJob(app *tview.Application, projectID, jobID string) {
+	job, _, err := gitlabClient.Jobs().RetryJob(projectID, toInt(jobID))
+	if err != nil {
+		queueError(app, fmt.Errorf("retrying job %s: %w", jobID, err), func() {
+			go retryJob(app, projectID, jobID)
+		})
+		return
+	}
+	go runEventHook("on_job_retried", jobHookEnv(projectID, job, toInt(jobID)))
+
+	app.QueueUpdateDraw(func() {
+		showInfo(app, "Job "+jobID+" retried successfully")
+	})
+}
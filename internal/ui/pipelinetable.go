@@ -0,0 +1,398 @@
+// pipelinetable.go
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/xanzy/go-gitlab"
+)
+
+// pipelineDetailWorkers is how many pipelines are fetched at once when
+// filling in the details the list endpoint leaves out.
+const pipelineDetailWorkers = 8
+
+// maxPipelineDetails is how many full pipelines pipelineDetailsCache holds
+// before it drops the oldest.
+const maxPipelineDetails = 2000
+
+// pipelineDetailsCache holds full pipelines by ID. An entry is reused for as
+// long as the pipeline's updated_at hasn't changed. The on-disk pipeline cache
+// backs it across restarts. pipelineDetailsOrder holds the IDs in the order
+// they were added, oldest first.
+var (
+	pipelineDetailsCache   = map[int]*gitlab.Pipeline{}
+	pipelineDetailsOrder   []int
+	pipelineDetailsCacheMu sync.Mutex
+)
+
+type pipelineColumn struct {
+	title string
+	value func(pipeline *gitlab.Pipeline) string
+	less  func(a, b *gitlab.Pipeline) bool
+}
+
+var pipelineColumns = []pipelineColumn{
+	{
+		title: "ID",
+		value: func(p *gitlab.Pipeline) string { return fmt.Sprintf("%d", p.ID) },
+		less:  func(a, b *gitlab.Pipeline) bool { return a.ID < b.ID },
+	},
+	{
+		title: "Status",
+		value: func(p *gitlab.Pipeline) string { return statusLabel(p.Status) },
+		less:  func(a, b *gitlab.Pipeline) bool { return a.Status < b.Status },
+	},
+	{
+		title: "Ref",
+		value: func(p *gitlab.Pipeline) string { return tview.Escape(p.Ref) },
+		less:  func(a, b *gitlab.Pipeline) bool { return a.Ref < b.Ref },
+	},
+	{
+		title: "Source",
+		value: func(p *gitlab.Pipeline) string { return p.Source },
+		less:  func(a, b *gitlab.Pipeline) bool { return a.Source < b.Source },
+	},
+	{
+		title: "User",
+		value: func(p *gitlab.Pipeline) string { return tview.Escape(pipelineUser(p)) },
+		less:  func(a, b *gitlab.Pipeline) bool { return pipelineUser(a) < pipelineUser(b) },
+	},
+	{
+		title: "Duration",
+		value: func(p *gitlab.Pipeline) string { return formatDuration(pipelineDuration(p)) },
+		less:  func(a, b *gitlab.Pipeline) bool { return pipelineDuration(a) < pipelineDuration(b) },
+	},
+	{
+		title: "Coverage",
+		value: func(p *gitlab.Pipeline) string { return formatCoverage(p) },
+		less: func(a, b *gitlab.Pipeline) bool {
+			coverageA, _ := pipelineCoverage(a)
+			coverageB, _ := pipelineCoverage(b)
+			return coverageA < coverageB
+		},
+	},
+	{
+		title: "Finished at",
+		value: func(p *gitlab.Pipeline) string { return formatTime(p.FinishedAt) },
+		less: func(a, b *gitlab.Pipeline) bool {
+			if a.FinishedAt == nil || b.FinishedAt == nil {
+				return a.FinishedAt == nil && b.FinishedAt != nil
+			}
+			return a.FinishedAt.Before(*b.FinishedAt)
+		},
+	},
+}
+
+// pipelineSort is the column the pipeline table is sorted by. Pressing the
+// column's number key sorts by it, pressing it again flips the direction.
+type pipelineSort struct {
+	column     int
+	descending bool
+}
+
+func (s *pipelineSort) toggle(column int) {
+	if s.column == column {
+		s.descending = !s.descending
+		return
+	}
+	s.column = column
+	s.descending = false
+}
+
+func (s pipelineSort) apply(pipelines []*gitlab.Pipeline) []*gitlab.Pipeline {
+	sorted := append([]*gitlab.Pipeline(nil), pipelines...)
+	less := pipelineColumns[s.column].less
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if s.descending {
+			return less(sorted[j], sorted[i])
+		}
+		return less(sorted[i], sorted[j])
+	})
+	return sorted
+}
+
+// fillPipelineTable replaces the contents of table with a header row and one
+// row per pipeline, in the order given, keeping the selected pipeline
+// selected.
+func fillPipelineTable(table *tview.Table, pipelines []*gitlab.Pipeline, order pipelineSort) {
+	selectedID := 0
+	if selected := selectedPipeline(table); selected != nil {
+		selectedID = selected.ID
+	}
+
+	table.Clear()
+
+	for column, c := range pipelineColumns {
+		title := fmt.Sprintf("%d %s", column+1, c.title)
+		if column == order.column {
+			title += " " + sortArrow(order.descending)
+		}
+		table.SetCell(0, column, tview.NewTableCell(title).
+			SetTextColor(tcell.ColorYellow).
+			SetAttributes(tcell.AttrBold).
+			SetSelectable(false))
+	}
+
+	selectedRow := 1
+	for i, pipeline := range pipelines {
+		row := i + 1
+		for column, c := range pipelineColumns {
+			cell := tview.NewTableCell(c.value(pipeline)).SetExpansion(1)
+			if column == 0 {
+				cell.SetReference(pipeline)
+			}
+			table.SetCell(row, column, cell)
+		}
+		if pipeline.ID == selectedID {
+			selectedRow = row
+		}
+	}
+
+	table.Select(selectedRow, 0)
+}
+
+// selectedPipeline returns the pipeline on the selected row of table, or nil
+// if there is none.
+func selectedPipeline(table *tview.Table) *gitlab.Pipeline {
+	row, _ := table.GetSelection()
+	pipeline, _ := table.GetCell(row, 0).GetReference().(*gitlab.Pipeline)
+	return pipeline
+}
+
+func sortArrow(descending bool) string {
+	switch {
+	case asciiIcons && descending:
+		return "v"
+	case asciiIcons:
+		return "^"
+	case descending:
+		return "▼"
+	default:
+		return "▲"
+	}
+}
+
+// listPipelineDetails fetches the full pipeline for each of infos, which the
+// table needs for the user, duration and finish time. With the GraphQL
+// backend the ones not cached are fetched in batches; GraphQL requests take
+// no request options, so fetches given some always go through REST.
+func listPipelineDetails(projectID string, infos []*gitlab.PipelineInfo, options ...gitlab.RequestOptionFunc) ([]*gitlab.Pipeline, error) {
+	if _, err := strconv.Atoi(projectID); useGraphQL && err == nil && len(options) == 0 {
+		pipelines, err := listPipelineDetailsGraphQL(projectID, infos)
+		if err == nil {
+			return pipelines, nil
+		}
+		logWarn("graphql pipeline list failed, falling back to rest", "project", projectID, "error", err)
+	}
+
+	pipelines := make([]*gitlab.Pipeline, len(infos))
+	errs := make([]error, len(infos))
+
+	forEachParallel(len(infos), pipelineDetailWorkers, func(i int) {
+		pipelines[i], errs[i] = getPipelineDetails(projectID, infos[i], options...)
+	})
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return pipelines, nil
+}
+
+// listPipelineDetailsGraphQL is listPipelineDetails with the pipelines that
+// aren't cached fetched by listPipelinesGraphQL.
+func listPipelineDetailsGraphQL(projectID string, infos []*gitlab.PipelineInfo) ([]*gitlab.Pipeline, error) {
+	pipelines := make([]*gitlab.Pipeline, len(infos))
+	var missing []*gitlab.PipelineInfo
+	var missingAt []int
+	for i, info := range infos {
+		if cached, ok := cachedPipelineDetails(info); ok {
+			pipelines[i] = cached
+			continue
+		}
+		missing = append(missing, info)
+		missingAt = append(missingAt, i)
+	}
+	if len(missing) == 0 {
+		return pipelines, nil
+	}
+
+	fetched, err := listPipelinesGraphQL(projectID, missing)
+	if err != nil {
+		return nil, err
+	}
+	for i, pipeline := range fetched {
+		storePipelineDetails(pipeline)
+		pipelines[missingAt[i]] = pipeline
+	}
+	return pipelines, nil
+}
+
+func getPipelineDetails(projectID string, info *gitlab.PipelineInfo, options ...gitlab.RequestOptionFunc) (*gitlab.Pipeline, error) {
+	if cached, ok := cachedPipelineDetails(info); ok {
+		return cached, nil
+	}
+
+	key := strconv.Itoa(info.ID)
+	pipeline, err := shareFetch("pipeline/"+key, func() (*gitlab.Pipeline, error) {
+		pipeline := &gitlab.Pipeline{}
+		if readCache(pipelineCache, key, pipeline) && sameTime(pipeline.UpdatedAt, info.UpdatedAt) {
+			return pipeline, nil
+		}
+		pipeline, _, err := gitlabClient.Pipelines().GetPipeline(projectID, info.ID, options...)
+		if err != nil {
+			return nil, fmt.Errorf("fetching pipeline %d: %w", info.ID, err)
+		}
+		writeCache(pipelineCache, key, pipeline)
+		return pipeline, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	storePipelineDetails(pipeline)
+	return pipeline, nil
+}
+
+// cachedPipelineDetails returns the full pipeline of info if it is cached
+// and up to date.
+func cachedPipelineDetails(info *gitlab.PipelineInfo) (*gitlab.Pipeline, bool) {
+	pipelineDetailsCacheMu.Lock()
+	defer pipelineDetailsCacheMu.Unlock()
+
+	cached, ok := pipelineDetailsCache[info.ID]
+	if !ok || !sameTime(cached.UpdatedAt, info.UpdatedAt) {
+		return nil, false
+	}
+	return cached, true
+}
+
+func storePipelineDetails(pipeline *gitlab.Pipeline) {
+	pipelineDetailsCacheMu.Lock()
+	defer pipelineDetailsCacheMu.Unlock()
+
+	if _, ok := pipelineDetailsCache[pipeline.ID]; !ok {
+		pipelineDetailsOrder = append(pipelineDetailsOrder, pipeline.ID)
+	}
+	pipelineDetailsCache[pipeline.ID] = pipeline
+
+	for len(pipelineDetailsOrder) > maxPipelineDetails {
+		delete(pipelineDetailsCache, pipelineDetailsOrder[0])
+		pipelineDetailsOrder = pipelineDetailsOrder[1:]
+	}
+}
+
+// pipelineRows returns the pipelines of infos to show in the table: the full
+// pipeline where its details are cached, or what the list endpoint gave.
+func pipelineRows(infos []*gitlab.PipelineInfo) []*gitlab.Pipeline {
+	rows := make([]*gitlab.Pipeline, len(infos))
+	for i, info := range infos {
+		if cached, ok := cachedPipelineDetails(info); ok {
+			rows[i] = cached
+			continue
+		}
+		rows[i] = &gitlab.Pipeline{
+			ID:        info.ID,
+			IID:       info.IID,
+			ProjectID: info.ProjectID,
+			Status:    info.Status,
+			Source:    info.Source,
+			Ref:       info.Ref,
+			SHA:       info.SHA,
+			WebURL:    info.WebURL,
+			UpdatedAt: info.UpdatedAt,
+			CreatedAt: info.CreatedAt,
+		}
+	}
+	return rows
+}
+
+// visiblePipelinesWithoutDetails returns those of infos that are on the
+// rows of table currently on screen and have no up-to-date details.
+func visiblePipelinesWithoutDetails(table *tview.Table, infos []*gitlab.PipelineInfo) []*gitlab.PipelineInfo {
+	byID := make(map[int]*gitlab.PipelineInfo, len(infos))
+	for _, info := range infos {
+		byID[info.ID] = info
+	}
+
+	offset, _ := table.GetOffset()
+	_, _, _, height := table.GetInnerRect()
+	if height <= 0 {
+		// Not drawn yet, assume a screenful.
+		height = 50
+	}
+	// The offset only follows the selection once the table is drawn.
+	if selected, _ := table.GetSelection(); selected-1 < offset {
+		offset = selected - 1
+	} else if selected > offset+height {
+		offset = selected - height
+	}
+	var missing []*gitlab.PipelineInfo
+	for row := offset + 1; row <= offset+height && row < table.GetRowCount(); row++ {
+		pipeline, _ := table.GetCell(row, 0).GetReference().(*gitlab.Pipeline)
+		if pipeline == nil {
+			continue
+		}
+		if info, ok := byID[pipeline.ID]; ok {
+			if _, cached := cachedPipelineDetails(info); !cached {
+				missing = append(missing, info)
+			}
+		}
+	}
+	return missing
+}
+
+func pipelineUser(pipeline *gitlab.Pipeline) string {
+	if pipeline.User == nil {
+		return ""
+	}
+	return pipeline.User.Username
+}
+
+// pipelineDuration is how long pipeline has run so far, in seconds. GitLab
+// only reports the duration once a pipeline has finished.
+func pipelineDuration(pipeline *gitlab.Pipeline) int {
+	if pipeline.Duration == 0 && pipeline.FinishedAt == nil && pipeline.StartedAt != nil {
+		return int(time.Since(*pipeline.StartedAt).Seconds())
+	}
+	return pipeline.Duration
+}
+
+func formatDuration(seconds int) string {
+	if seconds == 0 {
+		return "-"
+	}
+	return (time.Duration(seconds) * time.Second).String()
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+	return t.Local().Format("2006-01-02 15:04:05")
+}
+
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+// pipelineSortKey returns the column index for one of the number keys shown
+// in the table header.
+func pipelineSortKey(event *tcell.EventKey) (int, bool) {
+	if event.Key() != tcell.KeyRune {
+		return 0, false
+	}
+	column := strings.IndexRune("12345678", event.Rune())
+	return column, column >= 0 && column < len(pipelineColumns)
+}
@@ -0,0 +1,74 @@
+// playjob.go
+package ui
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/xanzy/go-gitlab"
+)
+
+func playJob(projectID string, jobID int, variables []*gitlab.JobVariableOptions) error {
+	opts := &gitlab.PlayJobOptions{}
+	if len(variables) > 0 {
+		opts.JobVariablesAttributes = &variables
+	}
+
+	_, _, err := gitlabClient.Jobs().PlayJob(projectID, jobID, opts)
+	if err != nil {
+		return fmt.Errorf("playing job %d: %w", jobID, err)
+	}
+	return nil
+}
+
+// showPlayJobForm starts a manual job, with whatever variables the user adds
+// first. done is called once the job has started, back on Esc or Cancel.
+func showPlayJobForm(app *tview.Application, projectID string, job *gitlab.Job, done, back func()) {
+	cancelPendingLoads()
+
+	statusBar := newStatusBar()
+	form := tview.NewForm()
+	form.SetBorder(true).
+		SetTitle(fmt.Sprintf(" Play %s ", tview.Escape(job.Name)))
+
+	variables := &variableRows{app: app, form: form}
+
+	form.AddButton("Play", func() {
+		jobVariables, err := variables.jobVariables()
+		if err != nil {
+			showError(app, err, nil)
+			return
+		}
+
+		fetchInBackground(app, statusBar, "Starting job...", func() (func(), error) {
+			if err := playJob(projectID, job.ID, jobVariables); err != nil {
+				return nil, err
+			}
+			return func() {
+				showInfo(app, fmt.Sprintf("Job %s started", job.Name))
+				done()
+			}, nil
+		})
+	}).
+		AddButton("Add variable", variables.add).
+		AddButton("Cancel", back).
+		SetCancelFunc(back)
+
+	form.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyCtrlA {
+			variables.add()
+			return nil
+		}
+		return event
+	})
+
+	flex := tview.NewFlex().
+		SetDirection(tview.FlexRow).
+		AddItem(form, 0, 1, true).
+		AddItem(statusBar, 1, 0, false).
+		AddItem(tview.NewButton("Ctrl-A - Add variable   ESC - Back").SetSelectedFunc(back), 1, 0, false)
+
+	routes.show("play-job/"+projectID+"/"+strconv.Itoa(job.ID), flex, form)
+}
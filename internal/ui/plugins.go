@@ -0,0 +1,149 @@
+// plugins.go
+package ui
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/rivo/tview"
+)
+
+// pluginPrefix starts the name of every plugin executable on the PATH.
+const pluginPrefix = "gpv-"
+
+// plugin is an executable named gpv-<name> on the PATH that adds <name> as
+// an action on jobs and pipelines. It is run with "job" or "pipeline" as its
+// argument and the selected job or pipeline described in the same
+// environment variables as hooks. What it prints is shown as a toast.
+type plugin struct {
+	name string
+	path string
+}
+
+// plugins are the plugins found on the PATH when gpv started.
+var plugins []plugin
+
+// discoverPlugins looks for plugin executables on the PATH. If two have the
+// same name, the one found first wins, like the shell does.
+func discoverPlugins() []plugin {
+	seen := map[string]bool{}
+	var found []plugin
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			name, ok := pluginName(entry)
+			if !ok || seen[name] {
+				continue
+			}
+			seen[name] = true
+			found = append(found, plugin{name: name, path: filepath.Join(dir, entry.Name())})
+		}
+	}
+	sort.Slice(found, func(i, j int) bool {
+		return found[i].name < found[j].name
+	})
+	return found
+}
+
+// pluginName returns the name of the plugin entry is, if it is one.
+func pluginName(entry os.DirEntry) (string, bool) {
+	if !strings.HasPrefix(entry.Name(), pluginPrefix) || entry.IsDir() {
+		return "", false
+	}
+	name := strings.TrimPrefix(entry.Name(), pluginPrefix)
+
+	if runtime.GOOS == "windows" {
+		extension := strings.ToLower(filepath.Ext(name))
+		if extension != ".exe" && extension != ".bat" && extension != ".cmd" {
+			return "", false
+		}
+		name = strings.TrimSuffix(name, filepath.Ext(name))
+	} else {
+		info, err := entry.Info()
+		if err != nil || info.Mode()&0o111 == 0 {
+			return "", false
+		}
+	}
+	return name, name != ""
+}
+
+func findPlugin(name string) (plugin, bool) {
+	for _, p := range plugins {
+		if p.name == name {
+			return p, true
+		}
+	}
+	return plugin{}, false
+}
+
+// runPlugin runs p on entity, "job" or "pipeline", described by env, and
+// returns what it printed.
+func runPlugin(p plugin, entity string, env []string) (string, error) {
+	cmd := exec.Command(p.path, entity)
+	cmd.Env = append(cmd.Environ(), env...)
+
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		message := strings.TrimSpace(stderr.String())
+		if message == "" {
+			message = strings.TrimSpace(string(output))
+		}
+		return "", fmt.Errorf("plugin %s: %w: %s", p.name, err, message)
+	}
+	logInfo("plugin ran", "plugin", p.name, "entity", entity)
+	return strings.TrimSpace(string(output)), nil
+}
+
+// runPluginInBackground runs p while statusBar shows it is running, then
+// shows what it printed as a toast.
+func runPluginInBackground(app *tview.Application, statusBar *tview.TextView, p plugin, entity string, env []string) {
+	fetchInBackground(app, statusBar, "Running "+p.name+"...", func() (func(), error) {
+		output, err := runPlugin(p, entity, env)
+		if err != nil {
+			return nil, err
+		}
+		return func() {
+			if output == "" {
+				output = p.name + " done"
+			}
+			showInfo(app, output)
+		}, nil
+	})
+}
+
+// showPluginMenu asks which plugin to run on entity in a modal, then goes
+// back to the current view.
+func showPluginMenu(app *tview.Application, statusBar *tview.TextView, entity string, env []string) {
+	if len(plugins) == 0 {
+		showInfo(app, "No plugins installed, put gpv-<name> executables on your PATH")
+		return
+	}
+
+	labels := make([]string, 0, len(plugins)+1)
+	for _, p := range plugins {
+		labels = append(labels, p.name)
+	}
+	labels = append(labels, "Close")
+
+	modal := tview.NewModal().
+		SetText("Run plugin on " + entity).
+		AddButtons(labels).
+		SetDoneFunc(func(buttonIndex int, buttonLabel string) {
+			routes.dismiss()
+			if buttonIndex >= 0 && buttonIndex < len(plugins) {
+				runPluginInBackground(app, statusBar, plugins[buttonIndex], entity, env)
+			}
+		})
+
+	routes.overlay(modal)
+}
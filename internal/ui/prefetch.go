@@ -0,0 +1,136 @@
+// prefetch.go
+package ui
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+// prefetchTTL is how long prefetched data is used by the view it was
+// fetched for. Views refresh it in the background once shown.
+const prefetchTTL = 30 * time.Second
+
+// prefetchDepth is how many of the latest pipelines of the default branch are
+// prefetched with the branches of a highlighted project, 0 turns prefetching
+// off. prefetchWorkers is how many projects are prefetched at once.
+var (
+	prefetchDepth   = 10
+	prefetchWorkers = 2
+)
+
+// prefetchSlots holds a value per project being prefetched. Highlights that
+// find it full are skipped, so scrolling through the tree never queues work.
+var prefetchSlots chan struct{}
+
+type prefetchEntry struct {
+	value     interface{}
+	fetchedAt time.Time
+}
+
+var (
+	prefetchMu sync.Mutex
+	prefetches = map[string]prefetchEntry{}
+	// prefetching holds the projects being prefetched, so highlighting a
+	// project again doesn't start over.
+	prefetching = map[int]bool{}
+)
+
+// setupPrefetch sets how many pipelines and projects are prefetched.
+func setupPrefetch(depth, workers int) {
+	prefetchDepth, prefetchWorkers = depth, workers
+	prefetchSlots = make(chan struct{}, prefetchWorkers)
+}
+
+// prefetched returns the value prefetched under key if it is recent enough.
+func prefetched[T any](key string) (T, bool) {
+	prefetchMu.Lock()
+	defer prefetchMu.Unlock()
+
+	entry, ok := prefetches[key]
+	if !ok || time.Since(entry.fetchedAt) > prefetchTTL {
+		var zero T
+		return zero, false
+	}
+	value, ok := entry.value.(T)
+	return value, ok
+}
+
+func storePrefetched(key string, value interface{}) {
+	prefetchMu.Lock()
+	prefetches[key] = prefetchEntry{value: value, fetchedAt: time.Now()}
+	prefetchMu.Unlock()
+}
+
+func branchesPrefetchKey(projectID string) string {
+	return "branches/" + projectID
+}
+
+func pipelinesPrefetchKey(projectID, ref string) string {
+	return "pipelines/" + projectID + "/" + ref
+}
+
+// prefetchProject fetches the branches of project and the latest pipelines of
+// its default branch in the background, so opening it right after is
+// instant.
+func prefetchProject(project *gitlab.Project) {
+	if prefetchDepth == 0 {
+		return
+	}
+	projectID := strconv.Itoa(project.ID)
+	if _, ok := prefetched[[]*gitlab.Branch](branchesPrefetchKey(projectID)); ok {
+		return
+	}
+
+	prefetchMu.Lock()
+	if prefetching[project.ID] {
+		prefetchMu.Unlock()
+		return
+	}
+	select {
+	case prefetchSlots <- struct{}{}:
+	default:
+		prefetchMu.Unlock()
+		return
+	}
+	prefetching[project.ID] = true
+	prefetchMu.Unlock()
+
+	go func() {
+		defer func() {
+			prefetchMu.Lock()
+			delete(prefetching, project.ID)
+			prefetchMu.Unlock()
+			<-prefetchSlots
+		}()
+
+		logDebug("prefetching project", "project", project.PathWithNamespace)
+		branches, err := listAllBranches(projectID, detached)
+		if err != nil {
+			logDebug("prefetching branches failed", "project", project.PathWithNamespace, "error", err)
+			return
+		}
+		storePrefetched(branchesPrefetchKey(projectID), branches)
+
+		ref := project.DefaultBranch
+		if ref == "" {
+			return
+		}
+		infos, _, err := gitlabClient.Pipelines().ListProjectPipelines(projectID, &gitlab.ListProjectPipelinesOptions{
+			ListOptions: gitlab.ListOptions{PerPage: prefetchDepth},
+			Ref:         gitlab.String(ref),
+		}, detached)
+		if err != nil {
+			logDebug("prefetching pipelines failed", "project", project.PathWithNamespace, "error", err)
+			return
+		}
+		pipelines, err := listPipelineDetails(projectID, infos, detached)
+		if err != nil {
+			logDebug("prefetching pipelines failed", "project", project.PathWithNamespace, "error", err)
+			return
+		}
+		storePrefetched(pipelinesPrefetchKey(projectID, ref), pipelines)
+	}()
+}
@@ -0,0 +1,232 @@
+// refresh.go
+package ui
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/xanzy/go-gitlab"
+
+	api "main.go/internal/gitlab"
+)
+
+// refreshInterval is how often the visible pipeline or job list is re-fetched
+// in the background. Zero disables auto-refresh.
+var refreshInterval time.Duration
+
+// stopAutoRefresh stops the background refresh of the last view that started
+// one. Only one view refreshes at a time.
+var stopAutoRefresh = func() {}
+
+// startAutoRefresh calls fetch every refreshInterval, and whenever a webhook
+// event arrives, while view has focus and runs the function it returns on
+// the UI goroutine to update the view in place. Starting a new auto-refresh
+// stops the previous one.
+func startAutoRefresh(app *tview.Application, view tview.Primitive, fetch func() (func(), error)) {
+	stopAutoRefresh()
+	stopAutoRefresh = func() {}
+
+	if refreshInterval <= 0 && !webhookListening {
+		return
+	}
+
+	var ticks <-chan time.Time
+	stopTicker := func() {}
+	if refreshInterval > 0 {
+		ticker := time.NewTicker(refreshInterval)
+		ticks, stopTicker = ticker.C, ticker.Stop
+	}
+	var webhooks <-chan struct{}
+	unsubscribe := func() {}
+	if webhookListening {
+		webhooks, unsubscribe = subscribeWebhooks()
+	}
+	done := make(chan struct{})
+	stopAutoRefresh = func() {
+		stopTicker()
+		unsubscribe()
+		close(done)
+	}
+
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticks:
+			case <-webhooks:
+			}
+
+			focused := make(chan bool, 1)
+			app.QueueUpdate(func() {
+				focused <- app.GetFocus() == view
+			})
+			if !<-focused {
+				continue
+			}
+
+			update, err := fetch()
+			if err != nil {
+				queueError(app, fmt.Errorf("refreshing view: %w", err), nil)
+				continue
+			}
+
+			select {
+			case <-done:
+				return
+			default:
+				app.QueueUpdateDraw(update)
+			}
+		}
+	}()
+}
+
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// isRefreshKey reports whether event is one of the manual refresh keys.
+func isRefreshKey(event *tcell.EventKey) bool {
+	return event.Key() == tcell.KeyF5 || event.Key() == tcell.KeyRune && event.Rune() == 'r'
+}
+
+// newStatusBar returns the line views show their loading state in. Its right
+// end shows how many notifications are unread.
+func newStatusBar() *tview.TextView {
+	statusBar := tview.NewTextView().
+		SetDynamicColors(true).
+		SetTextColor(tcell.ColorGray)
+	statusBar.SetDrawFunc(drawUnreadBadge)
+	return statusBar
+}
+
+// loadGeneration is bumped by cancelPendingLoads whenever the user opens a
+// new view. Background fetches started under an older generation are dropped
+// when they complete instead of updating a view that is no longer shown.
+var loadGeneration int
+
+// loadCtx is the context the API reads of the current view are sent in.
+// cancelPendingLoads cancels it, so reads still in flight for the view being
+// left, like the trace of a huge job, stop downloading.
+var (
+	loadCtxMu              sync.Mutex
+	loadCtx, cancelLoadCtx = context.WithCancel(context.Background())
+)
+
+// detached is the request option of background work that outlives the view
+// it was started from, like prefetches and pipeline watches.
+var detached = gitlab.WithContext(api.Detached(context.Background()))
+
+// cancelPendingLoads leaves the current view: it cancels the API reads still
+// in flight for it, discards the results of its background fetches and drops
+// its key binding context. It must be called from the UI goroutine.
+func cancelPendingLoads() {
+	loadGeneration++
+	keybindContext = nil
+
+	loadCtxMu.Lock()
+	cancelLoadCtx()
+	loadCtx, cancelLoadCtx = context.WithCancel(context.Background())
+	loadCtxMu.Unlock()
+}
+
+// loadContext returns the context of the reads of the current view.
+func loadContext() context.Context {
+	loadCtxMu.Lock()
+	defer loadCtxMu.Unlock()
+	return loadCtx
+}
+
+// fetchInBackground runs fetch in the background while a spinner and message
+// animate in statusBar, then runs the function fetch returned on the UI
+// goroutine. It must be called from the UI goroutine.
+func fetchInBackground(app *tview.Application, statusBar *tview.TextView, message string, fetch func() (func(), error)) {
+	fetchWithProgress(app, statusBar, message, func(progress func(string)) (func(), error) {
+		return fetch()
+	})
+}
+
+// fetchWithProgress is fetchInBackground for fetches that can tell how far
+// along they are. Whatever fetch passes to progress is shown after message.
+func fetchWithProgress(app *tview.Application, statusBar *tview.TextView, message string, fetch func(progress func(string)) (func(), error)) {
+	type fetchResult struct {
+		update func()
+		err    error
+	}
+
+	generation := loadGeneration
+	statusBar.SetText(spinnerFrames[0] + " " + message)
+
+	var (
+		progressMu   sync.Mutex
+		progressText string
+	)
+	progress := func(text string) {
+		progressMu.Lock()
+		progressText = text
+		progressMu.Unlock()
+	}
+
+	result := make(chan fetchResult, 1)
+	go func() {
+		update, err := fetch(progress)
+		result <- fetchResult{update, err}
+	}()
+
+	go func() {
+		ticker := time.NewTicker(100 * time.Millisecond)
+		defer ticker.Stop()
+
+		for frame := 1; ; frame++ {
+			select {
+			case r := <-result:
+				app.QueueUpdateDraw(func() {
+					statusBar.SetText("")
+					if generation != loadGeneration {
+						return
+					}
+					if r.err != nil {
+						showError(app, r.err, func() {
+							fetchWithProgress(app, statusBar, message, fetch)
+						})
+						return
+					}
+					r.update()
+					routes.restore()
+				})
+				return
+			case <-ticker.C:
+				progressMu.Lock()
+				text := spinnerFrames[frame%len(spinnerFrames)] + " " + message
+				if progressText != "" {
+					text += " " + progressText
+				}
+				progressMu.Unlock()
+
+				app.QueueUpdateDraw(func() {
+					statusBar.SetText(text)
+				})
+			}
+		}
+	}()
+}
+
+// runAction runs action in the background like fetchInBackground, then
+// reloads the view with reload and reports done as a toast.
+func runAction(app *tview.Application, statusBar *tview.TextView, message, done string, action func() error, reload func() (func(), error)) {
+	fetchInBackground(app, statusBar, message, func() (func(), error) {
+		if err := action(); err != nil {
+			return nil, err
+		}
+		update, err := reload()
+		if err != nil {
+			return nil, err
+		}
+		return func() {
+			update()
+			showInfo(app, done)
+		}, nil
+	})
+}
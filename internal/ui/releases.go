@@ -0,0 +1,320 @@
+// releases.go
+package ui
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/xanzy/go-gitlab"
+)
+
+// releaseAsset is a file attached to a release, either a link or one of the
+// source archives GitLab generates for its tag.
+type releaseAsset struct {
+	name string
+	url  string
+	kind string
+}
+
+func listReleases(projectID int) ([]*gitlab.Release, error) {
+	releases, err := listAllPages(func(listOptions gitlab.ListOptions) ([]*gitlab.Release, *gitlab.Response, error) {
+		return gitlabClient.Releases().ListReleases(projectID, &gitlab.ListReleasesOptions{ListOptions: listOptions})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("fetching releases: %w", err)
+	}
+	return releases, nil
+}
+
+// tagPipeline returns the latest pipeline for tag, or nil if there is none.
+func tagPipeline(projectID int, tag string) (*gitlab.PipelineInfo, error) {
+	pipelines, _, err := gitlabClient.Pipelines().ListProjectPipelines(projectID, &gitlab.ListProjectPipelinesOptions{
+		ListOptions: gitlab.ListOptions{PerPage: 1},
+		Ref:         gitlab.String(tag),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("fetching pipelines for tag %s: %w", tag, err)
+	}
+	if len(pipelines) == 0 {
+		return nil, nil
+	}
+	return pipelines[0], nil
+}
+
+func releaseAssets(release *gitlab.Release) []releaseAsset {
+	var assets []releaseAsset
+	for _, link := range release.Assets.Links {
+		assetURL := link.DirectAssetURL
+		if assetURL == "" {
+			assetURL = link.URL
+		}
+		kind := string(link.LinkType)
+		if kind == "" {
+			kind = "other"
+		}
+		assets = append(assets, releaseAsset{name: link.Name, url: assetURL, kind: kind})
+	}
+	for _, source := range release.Assets.Sources {
+		assets = append(assets, releaseAsset{name: "Source code (" + source.Format + ")", url: source.URL, kind: "source"})
+	}
+	return assets
+}
+
+// downloadReleaseAsset downloads asset, reporting progress. The token is only
+// sent along to the GitLab instance itself, as links can point anywhere.
+func downloadReleaseAsset(asset releaseAsset, progress func(string)) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, asset.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if strings.HasPrefix(asset.url, gitlabURL+"/") {
+		req.Header.Set("PRIVATE-TOKEN", token)
+	}
+
+	resp, err := gitlabClient.HTTPClient().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("downloading %s: %w", asset.name, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("downloading %s: GET %s: %s", asset.name, req.URL.Path, resp.Status)
+	}
+
+	writer := &progressWriter{total: resp.ContentLength, progress: progress}
+	if _, err := io.Copy(writer, resp.Body); err != nil {
+		return nil, fmt.Errorf("downloading %s: %w", asset.name, err)
+	}
+	return writer.buf.Bytes(), nil
+}
+
+// assetFilename is the name a downloaded asset is saved under.
+func assetFilename(asset releaseAsset) string {
+	if parsed, err := url.Parse(asset.url); err == nil {
+		if name := path.Base(parsed.Path); name != "/" && name != "." {
+			return name
+		}
+	}
+	return strings.ReplaceAll(asset.name, "/", "_")
+}
+
+// showReleases lists the releases of project next to the details of the
+// selected one, including the pipeline of its tag. Enter opens that
+// pipeline, Tab moves to the assets, where Enter downloads one. back is
+// called on Esc.
+func showReleases(app *tview.Application, project *gitlab.Project, back func()) {
+	cancelPendingLoads()
+
+	projectID := strconv.Itoa(project.ID)
+
+	table := tview.NewTable().
+		SetSelectable(true, false).
+		SetFixed(1, 0)
+	table.SetBorder(true).
+		SetTitle(fmt.Sprintf(" Releases of %s ", tview.Escape(project.PathWithNamespace)))
+	detail := tview.NewTextView().
+		SetDynamicColors(true).
+		SetScrollable(true).
+		SetWordWrap(true)
+	detail.SetBorder(true).
+		SetTitle(" Release ")
+	assetList := tview.NewList().ShowSecondaryText(false)
+	assetList.SetBorder(true).
+		SetTitle(" Assets ")
+	statusBar := newStatusBar()
+
+	var releases []*gitlab.Release
+
+	// pipelines holds the pipeline of each tag looked up so far. A nil entry
+	// means the tag has no pipeline or its lookup is still running.
+	pipelines := map[string]*gitlab.PipelineInfo{}
+
+	selected := func() *gitlab.Release {
+		row, _ := table.GetSelection()
+		if row < 1 || row > len(releases) {
+			return nil
+		}
+		return releases[row-1]
+	}
+
+	showRelease := func(release *gitlab.Release) {
+		detail.SetText(formatRelease(release, pipelines[release.TagName])).ScrollToBeginning()
+
+		assetList.Clear()
+		for _, asset := range releaseAssets(release) {
+			asset := asset
+			assetList.AddItem(fmt.Sprintf("%-8s %s", asset.kind, tview.Escape(asset.name)), "", 0, func() {
+				fetchWithProgress(app, statusBar, "Downloading "+asset.name+"...", func(progress func(string)) (func(), error) {
+					data, err := downloadReleaseAsset(asset, progress)
+					if err != nil {
+						return nil, err
+					}
+					return func() {
+						path, err := saveDownload(assetFilename(asset), data)
+						if err != nil {
+							showError(app, fmt.Errorf("saving %s: %w", asset.name, err), nil)
+							return
+						}
+						showInfo(app, "Saved "+path)
+					}, nil
+				})
+			})
+		}
+
+		if _, ok := pipelines[release.TagName]; ok {
+			return
+		}
+		pipelines[release.TagName] = nil
+		fetchInBackground(app, statusBar, "Loading pipeline...", func() (func(), error) {
+			pipeline, err := tagPipeline(project.ID, release.TagName)
+			if err != nil {
+				return nil, err
+			}
+			return func() {
+				pipelines[release.TagName] = pipeline
+				if selected() == release {
+					detail.SetText(formatRelease(release, pipeline))
+				}
+			}, nil
+		})
+	}
+
+	fillTable := func() {
+		row, _ := table.GetSelection()
+		table.Clear()
+
+		for column, title := range []string{"Tag", "Title", "Released", "Author", "Assets"} {
+			table.SetCell(0, column, tview.NewTableCell(title).
+				SetTextColor(tcell.ColorYellow).
+				SetAttributes(tcell.AttrBold).
+				SetSelectable(false))
+		}
+
+		for i, release := range releases {
+			table.SetCell(i+1, 0, tview.NewTableCell(tview.Escape(release.TagName)))
+			table.SetCell(i+1, 1, tview.NewTableCell(tview.Escape(release.Name)).SetExpansion(1))
+			table.SetCell(i+1, 2, tview.NewTableCell(formatTime(release.ReleasedAt)))
+			table.SetCell(i+1, 3, tview.NewTableCell(tview.Escape(release.Author.Username)))
+			table.SetCell(i+1, 4, tview.NewTableCell(strconv.Itoa(release.Assets.Count)).SetAlign(tview.AlignRight))
+		}
+
+		if len(releases) == 0 {
+			table.SetCell(1, 0, tview.NewTableCell("This project has no releases").
+				SetTextColor(tcell.ColorGray).
+				SetSelectable(false))
+			detail.SetText("")
+			assetList.Clear()
+			return
+		}
+		if row < 1 || row > len(releases) {
+			row = 1
+		}
+		table.Select(row, 0)
+		showRelease(releases[row-1])
+	}
+
+	reloadReleases := func() (func(), error) {
+		loaded, err := listReleases(project.ID)
+		if err != nil {
+			return nil, err
+		}
+		return func() {
+			releases = loaded
+			for tag := range pipelines {
+				delete(pipelines, tag)
+			}
+			fillTable()
+		}, nil
+	}
+
+	table.SetSelectionChangedFunc(func(row, column int) {
+		if release := selected(); release != nil {
+			showRelease(release)
+		}
+	})
+
+	table.SetSelectedFunc(func(row, column int) {
+		release := selected()
+		if release == nil {
+			return
+		}
+		pipeline := pipelines[release.TagName]
+		if pipeline == nil {
+			showError(app, fmt.Errorf("no pipeline found for tag %s", release.TagName), nil)
+			return
+		}
+		pipelineTrail = nil
+		showPipelineDetail(app, projectID, strconv.Itoa(pipeline.ID), release.TagName)
+	})
+
+	table.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch {
+		case event.Key() == tcell.KeyEsc:
+			back()
+			return nil
+		case isRefreshKey(event):
+			fetchInBackground(app, statusBar, "Refreshing...", reloadReleases)
+			return nil
+		case event.Key() == tcell.KeyTab:
+			app.SetFocus(assetList)
+			return nil
+		}
+		return event
+	})
+
+	assetList.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyEsc || event.Key() == tcell.KeyTab {
+			app.SetFocus(table)
+			return nil
+		}
+		return event
+	})
+
+	flex := tview.NewFlex().
+		SetDirection(tview.FlexRow).
+		AddItem(tview.NewFlex().
+			AddItem(table, 0, 1, true).
+			AddItem(tview.NewFlex().
+				SetDirection(tview.FlexRow).
+				AddItem(detail, 0, 2, false).
+				AddItem(assetList, 0, 1, false), 0, 1, false), 0, 1, true).
+		AddItem(statusBar, 1, 0, false).
+		AddItem(tview.NewButton("ENTER - Pipeline   TAB - Assets   ENTER on an asset - Download   ESC - Back").SetSelectedFunc(back), 1, 0, false)
+
+	routes.show("releases/"+strconv.Itoa(project.ID), flex, table)
+
+	fetchInBackground(app, statusBar, "Loading releases...", reloadReleases)
+}
+
+func formatRelease(release *gitlab.Release, pipeline *gitlab.PipelineInfo) string {
+	var text strings.Builder
+	field := func(name, value string) {
+		fmt.Fprintf(&text, "[yellow]%-9s[-] %s\n", name, value)
+	}
+
+	field("Tag", tview.Escape(release.TagName))
+	field("Title", tview.Escape(release.Name))
+	field("Released", formatTime(release.ReleasedAt))
+	if release.UpcomingRelease {
+		field("", "[orange]upcoming release[-]")
+	}
+	field("Author", tview.Escape(release.Author.Name))
+	field("Commit", shortSHA(release.Commit.ID)+" "+tview.Escape(release.Commit.Title))
+	if pipeline != nil {
+		field("Pipeline", fmt.Sprintf("#%d %s", pipeline.ID, statusLabel(pipeline.Status)))
+	} else {
+		field("Pipeline", "-")
+	}
+
+	if release.Description != "" {
+		text.WriteString("\n" + tview.Escape(release.Description) + "\n")
+	}
+	return text.String()
+}
@@ -0,0 +1,57 @@
+// reports.go
+package ui
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+// reportArtifact is a report a job uploaded, such as a code quality or SAST
+// report.
+type reportArtifact struct {
+	job      *gitlab.Job
+	fileType string
+	filename string
+}
+
+// findReports returns the reports of jobs with one of fileTypes.
+func findReports(jobs []*gitlab.Job, fileTypes ...string) []reportArtifact {
+	var reports []reportArtifact
+	for _, job := range jobs {
+		for _, artifact := range job.Artifacts {
+			for _, fileType := range fileTypes {
+				if artifact.FileType == fileType {
+					reports = append(reports, reportArtifact{
+						job:      job,
+						fileType: artifact.FileType,
+						filename: strings.TrimSuffix(artifact.Filename, ".gz"),
+					})
+				}
+			}
+		}
+	}
+	return reports
+}
+
+// downloadReport fetches report from its job's artifacts archive. The API
+// only serves files out of the archive, so the report has to be listed under
+// artifacts:paths as well as artifacts:reports.
+func downloadReport(projectID string, report reportArtifact) ([]byte, error) {
+	reader, resp, err := gitlabClient.Jobs().DownloadSingleArtifactsFile(projectID, report.job.ID, report.filename)
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return nil, fmt.Errorf("%s of job %s isn't in its artifacts archive, add it to artifacts:paths to view it", report.filename, report.job.Name)
+		}
+		return nil, fmt.Errorf("downloading %s of job %s: %w", report.filename, report.job.Name, err)
+	}
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s of job %s: %w", report.filename, report.job.Name, err)
+	}
+	return data, nil
+}
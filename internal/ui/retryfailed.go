@@ -0,0 +1,113 @@
+// retryfailed.go
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/xanzy/go-gitlab"
+)
+
+// failedJobs returns the jobs of jobs that failed.
+func failedJobs(jobs []*gitlab.Job) []*gitlab.Job {
+	var failed []*gitlab.Job
+	for _, job := range jobs {
+		if job.Status == "failed" {
+			failed = append(failed, job)
+		}
+	}
+	return failed
+}
+
+// confirmRetryFailedJobs asks before retrying every failed job of jobs and
+// then shows the progress of each retry. done is called when the user leaves
+// the progress view.
+func confirmRetryFailedJobs(app *tview.Application, projectID string, jobs []*gitlab.Job, done func()) {
+	failed := failedJobs(jobs)
+	if len(failed) == 0 {
+		showError(app, fmt.Errorf("there are no failed jobs to retry"), nil)
+		return
+	}
+
+	confirmAction(app, fmt.Sprintf("Retry %d failed jobs?", len(failed)), "Retry", func() {
+		showRetryProgress(app, projectID, failed, done)
+	})
+}
+
+// showRetryProgress retries jobs one after another, with a line per job that
+// shows whether its retry went through.
+func showRetryProgress(app *tview.Application, projectID string, jobs []*gitlab.Job, done func()) {
+	cancelPendingLoads()
+
+	results := make([]string, len(jobs))
+	for i := range results {
+		results[i] = "[gray]waiting[-]"
+	}
+	footer := ""
+
+	view := tview.NewTextView().
+		SetDynamicColors(true)
+	view.SetBorder(true).
+		SetTitle(" Retrying failed jobs ")
+
+	render := func() {
+		var text strings.Builder
+		for i, job := range jobs {
+			fmt.Fprintf(&text, "%-40s %s\n", tview.Escape(job.Name), results[i])
+		}
+		text.WriteString("\n" + footer)
+		view.SetText(text.String())
+	}
+	render()
+
+	view.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyEsc || event.Key() == tcell.KeyEnter {
+			done()
+			return nil
+		}
+		return event
+	})
+
+	flex := tview.NewFlex().
+		SetDirection(tview.FlexRow).
+		AddItem(view, 0, 1, true).
+		AddItem(tview.NewButton("ESC - Back").SetSelectedFunc(done), 1, 0, false)
+
+	routes.show("retry-failed/"+projectID, flex, view)
+
+	go func() {
+		retried := 0
+		for i, job := range jobs {
+			i := i
+			app.QueueUpdateDraw(func() {
+				results[i] = "[yellow]retrying...[-]"
+				render()
+			})
+
+			retry, _, err := gitlabClient.Jobs().RetryJob(projectID, job.ID)
+			if err == nil {
+				go runEventHook("on_job_retried", jobHookEnv(projectID, retry, job.ID))
+			}
+
+			app.QueueUpdateDraw(func() {
+				if err != nil {
+					results[i] = "[red]" + tview.Escape(err.Error()) + "[-]"
+				} else {
+					results[i] = statusIcon("success") + " retried"
+				}
+				render()
+			})
+			if err == nil {
+				retried++
+			}
+		}
+
+		app.QueueUpdateDraw(func() {
+			footer = fmt.Sprintf("%d of %d jobs retried. Press Esc to go back.", retried, len(jobs))
+			render()
+			logInfo("retried failed jobs", "project", projectID, "retried", retried, "failed", len(jobs)-retried)
+		})
+	}()
+}
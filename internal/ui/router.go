@@ -0,0 +1,192 @@
+// router.go
+package ui
+
+import (
+	"strings"
+
+	"github.com/rivo/tview"
+)
+
+// route is a view on the router's stack. key names what the view shows,
+// like "jobs/12/345", so showing the same view again finds its place in the
+// stack. focus is the primitive the view is driven from, whose selection
+// and scroll position are kept in state when the view is left, and
+// lastFocus what had focus then, like the filter field of a list.
+type route struct {
+	key       string
+	root      tview.Primitive
+	focus     tview.Primitive
+	lastFocus tview.Primitive
+	modal     bool
+	state     viewState
+}
+
+// viewState is what the user had narrowed a view down to, selected and
+// scrolled to, put back when the view is shown again.
+type viewState struct {
+	filter string
+
+	saved        bool
+	restored     bool
+	row, column  int
+	rowOffset    int
+	columnOffset int
+}
+
+// router owns what the application shows. Views are shown through it
+// instead of with SetRoot, so it knows how the user got where they are and
+// can put back the state of the views they return to.
+type router struct {
+	app   *tview.Application
+	stack []*route
+}
+
+var routes = &router{}
+
+// show makes the view under key the current one, focusing focus, or root
+// if focus is nil. A view already on the stack is replaced along with
+// everything above it, which is what going back to a parent does, and
+// keeps its state; any other view is opened on top of the current one.
+func (r *router) show(key string, root, focus tview.Primitive) {
+	r.dropModals()
+	if focus == nil {
+		focus = root
+	}
+	next := &route{key: key, root: root, focus: focus, lastFocus: focus}
+
+	if current := r.current(); current != nil {
+		current.lastFocus = r.app.GetFocus()
+		current.save()
+	}
+	for i, existing := range r.stack {
+		if existing.key == key {
+			next.state = existing.state
+			next.state.restored = false
+			r.stack = r.stack[:i]
+			break
+		}
+	}
+	r.stack = append(r.stack, next)
+	logDebug("showing view", "path", r.path())
+
+	r.app.SetRoot(root, true).SetFocus(focus)
+	r.restore()
+}
+
+// overlay shows modal over the current view until dismiss is called.
+func (r *router) overlay(modal tview.Primitive) {
+	key := "modal"
+	if current := r.current(); current != nil {
+		current.lastFocus = r.app.GetFocus()
+		key = current.key + "#modal"
+	}
+	r.stack = append(r.stack, &route{key: key, root: modal, focus: modal, modal: true})
+	r.app.SetRoot(modal, false).SetFocus(modal)
+}
+
+// dismiss closes the modals over the current view and gives the view back
+// its focus.
+func (r *router) dismiss() {
+	if !r.dropModals() {
+		return
+	}
+	if current := r.current(); current != nil {
+		r.app.SetRoot(current.root, true).SetFocus(current.lastFocus)
+	}
+}
+
+func (r *router) dropModals() bool {
+	dropped := false
+	for len(r.stack) > 0 && r.stack[len(r.stack)-1].modal {
+		r.stack = r.stack[:len(r.stack)-1]
+		dropped = true
+	}
+	return dropped
+}
+
+// current returns the view on top of the stack, modals aside.
+func (r *router) current() *route {
+	for i := len(r.stack) - 1; i >= 0; i-- {
+		if !r.stack[i].modal {
+			return r.stack[i]
+		}
+	}
+	return nil
+}
+
+// setFilter remembers what the current view is filtered by.
+func (r *router) setFilter(filter string) {
+	if current := r.current(); current != nil {
+		current.state.filter = filter
+	}
+}
+
+// filter returns what the view under key was last filtered by, if it is on
+// the stack.
+func (r *router) filter(key string) string {
+	for _, existing := range r.stack {
+		if existing.key == key {
+			return existing.state.filter
+		}
+	}
+	return ""
+}
+
+// path is the keys of the views on the stack, outermost first, for the log.
+func (r *router) path() string {
+	keys := make([]string, 0, len(r.stack))
+	for _, existing := range r.stack {
+		keys = append(keys, existing.key)
+	}
+	return strings.Join(keys, " > ")
+}
+
+// restore puts back the selection and scroll position of the current view
+// once it has content to select in. Views call it again through
+// fetchInBackground, as most are filled after being shown.
+func (r *router) restore() {
+	current := r.current()
+	if current == nil || !current.state.saved || current.state.restored {
+		return
+	}
+	state := &current.state
+
+	switch focus := current.focus.(type) {
+	case *tview.Table:
+		if state.row >= focus.GetRowCount() {
+			return
+		}
+		focus.Select(state.row, state.column)
+		focus.SetOffset(state.rowOffset, state.columnOffset)
+	case *tview.List:
+		if state.row >= focus.GetItemCount() {
+			return
+		}
+		focus.SetCurrentItem(state.row)
+		focus.SetOffset(state.rowOffset, state.columnOffset)
+	case *tview.TextView:
+		if focus.GetText(false) == "" {
+			return
+		}
+		focus.ScrollTo(state.rowOffset, state.columnOffset)
+	}
+	state.restored = true
+}
+
+// save records the selection and scroll position of the view.
+func (rt *route) save() {
+	state := &rt.state
+	switch focus := rt.focus.(type) {
+	case *tview.Table:
+		state.row, state.column = focus.GetSelection()
+		state.rowOffset, state.columnOffset = focus.GetOffset()
+	case *tview.List:
+		state.row = focus.GetCurrentItem()
+		state.rowOffset, state.columnOffset = focus.GetOffset()
+	case *tview.TextView:
+		state.rowOffset, state.columnOffset = focus.GetScrollOffset()
+	default:
+		return
+	}
+	state.saved = true
+}
@@ -0,0 +1,229 @@
+// runnerqueue.go
+package ui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/xanzy/go-gitlab"
+)
+
+// queuedJob is a pending job with the runners whose tags allow them to pick
+// it up, and those of them that currently can.
+type queuedJob struct {
+	job       *gitlab.Job
+	matching  []*runner
+	available []*runner
+}
+
+func listPendingJobs(projectID int) ([]*gitlab.Job, error) {
+	jobs, err := listAllPages(func(listOptions gitlab.ListOptions) ([]*gitlab.Job, *gitlab.Response, error) {
+		return gitlabClient.Jobs().ListProjectJobs(projectID, &gitlab.ListJobsOptions{
+			ListOptions: listOptions,
+			Scope:       &[]gitlab.BuildStateValue{gitlab.Pending},
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("fetching pending jobs: %w", err)
+	}
+	return jobs, nil
+}
+
+// runnerMatchesJob reports whether the tags of r allow it to run job: it
+// needs every tag of the job, and untagged jobs only go to runners that run
+// untagged jobs.
+func runnerMatchesJob(r *runner, job *gitlab.Job) bool {
+	if len(job.TagList) == 0 {
+		return r.RunUntagged
+	}
+	tags := map[string]bool{}
+	for _, tag := range r.TagList {
+		tags[tag] = true
+	}
+	for _, tag := range job.TagList {
+		if !tags[tag] {
+			return false
+		}
+	}
+	return true
+}
+
+// matchQueue pairs every pending job with the runners that could pick it up.
+func matchQueue(jobs []*gitlab.Job, runners []*runner) []*queuedJob {
+	queue := make([]*queuedJob, len(jobs))
+	for i, job := range jobs {
+		queued := &queuedJob{job: job}
+		for _, r := range runners {
+			if !runnerMatchesJob(r, job) {
+				continue
+			}
+			queued.matching = append(queued.matching, r)
+			if r.Status == "online" && !r.Paused {
+				queued.available = append(queued.available, r)
+			}
+		}
+		queue[i] = queued
+	}
+	return queue
+}
+
+// queueVerdict sums up why a pending job is waiting, or whether runners are
+// there to pick it up.
+func queueVerdict(queued *queuedJob) string {
+	switch {
+	case len(queued.available) > 0:
+		return fmt.Sprintf("[green]%d online[-]", len(queued.available))
+	case len(queued.matching) > 0:
+		return fmt.Sprintf("[orange]%d matching, none online[-]", len(queued.matching))
+	case len(queued.job.TagList) == 0:
+		return "[red]no runner runs untagged jobs[-]"
+	default:
+		return "[red]no runner with these tags[-]"
+	}
+}
+
+// showRunnerQueue lists the pending jobs of project next to the runners of
+// scope that could pick them up, to explain why a pipeline is stuck. The
+// panel below shows the matching runners of the selected job. Enter opens
+// the pipeline of the job. back is called on Esc.
+func showRunnerQueue(app *tview.Application, project *gitlab.Project, scope runnerScope, back func()) {
+	cancelPendingLoads()
+
+	table := tview.NewTable().
+		SetSelectable(true, false).
+		SetFixed(1, 0)
+	table.SetBorder(true).
+		SetTitle(fmt.Sprintf(" Pending jobs of %s ", tview.Escape(project.PathWithNamespace)))
+	detail := tview.NewTextView().
+		SetDynamicColors(true).
+		SetScrollable(true).
+		SetWordWrap(true)
+	detail.SetBorder(true).
+		SetTitle(" Matching runners ")
+	statusBar := newStatusBar()
+
+	var queue []*queuedJob
+
+	fillTable := func() {
+		row, _ := table.GetSelection()
+		table.Clear()
+
+		for column, title := range []string{"Job", "Pipeline", "Ref", "Name", "Stage", "Tags", "Waiting", "Runners"} {
+			table.SetCell(0, column, tview.NewTableCell(title).
+				SetTextColor(tcell.ColorYellow).
+				SetAttributes(tcell.AttrBold).
+				SetSelectable(false))
+		}
+
+		for i, queued := range queue {
+			job := queued.job
+			tags := "[gray]untagged[-]"
+			if len(job.TagList) > 0 {
+				tags = tview.Escape(strings.Join(job.TagList, ", "))
+			}
+			waiting := "-"
+			if job.CreatedAt != nil {
+				waiting = formatDuration(int(time.Since(*job.CreatedAt).Seconds()))
+			}
+
+			table.SetCell(i+1, 0, tview.NewTableCell(strconv.Itoa(job.ID)).SetAlign(tview.AlignRight))
+			table.SetCell(i+1, 1, tview.NewTableCell(strconv.Itoa(job.Pipeline.ID)).SetAlign(tview.AlignRight))
+			table.SetCell(i+1, 2, tview.NewTableCell(tview.Escape(job.Ref)))
+			table.SetCell(i+1, 3, tview.NewTableCell(tview.Escape(job.Name)).SetExpansion(1))
+			table.SetCell(i+1, 4, tview.NewTableCell(tview.Escape(job.Stage)))
+			table.SetCell(i+1, 5, tview.NewTableCell(tags).SetMaxWidth(40))
+			table.SetCell(i+1, 6, tview.NewTableCell(waiting).SetAlign(tview.AlignRight))
+			table.SetCell(i+1, 7, tview.NewTableCell(queueVerdict(queued)))
+		}
+
+		if len(queue) == 0 {
+			table.SetCell(1, 0, tview.NewTableCell("No jobs are waiting for a runner").
+				SetTextColor(tcell.ColorGray).
+				SetSelectable(false))
+			detail.SetText("")
+			return
+		}
+		if row < 1 || row > len(queue) {
+			row = 1
+		}
+		table.Select(row, 0)
+		detail.SetText(formatQueuedJob(queue[row-1]))
+	}
+
+	reloadQueue := func() (func(), error) {
+		jobs, err := listPendingJobs(project.ID)
+		if err != nil {
+			return nil, err
+		}
+		runners, err := listRunners(scope)
+		if err != nil {
+			return nil, err
+		}
+		return func() {
+			queue = matchQueue(jobs, runners)
+			fillTable()
+		}, nil
+	}
+
+	table.SetSelectionChangedFunc(func(row, column int) {
+		if row >= 1 && row <= len(queue) {
+			detail.SetText(formatQueuedJob(queue[row-1])).ScrollToBeginning()
+		}
+	})
+
+	table.SetSelectedFunc(func(row, column int) {
+		if row < 1 || row > len(queue) {
+			return
+		}
+		job := queue[row-1].job
+		pipelineTrail = nil
+		showPipelineDetail(app, strconv.Itoa(project.ID), strconv.Itoa(job.Pipeline.ID), job.Ref)
+	})
+
+	table.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch {
+		case event.Key() == tcell.KeyEsc:
+			back()
+			return nil
+		case isRefreshKey(event):
+			fetchInBackground(app, statusBar, "Refreshing...", reloadQueue)
+			return nil
+		}
+		return event
+	})
+
+	flex := tview.NewFlex().
+		SetDirection(tview.FlexRow).
+		AddItem(table, 0, 2, true).
+		AddItem(detail, 0, 1, false).
+		AddItem(statusBar, 1, 0, false).
+		AddItem(tview.NewButton("ENTER - Pipeline   ESC - Back").SetSelectedFunc(back), 1, 0, false)
+
+	routes.show("runner-queue/"+strconv.Itoa(project.ID), flex, table)
+
+	startAutoRefresh(app, table, reloadQueue)
+	fetchInBackground(app, statusBar, "Loading pending jobs...", reloadQueue)
+}
+
+func formatQueuedJob(queued *queuedJob) string {
+	if len(queued.matching) == 0 {
+		if len(queued.job.TagList) == 0 {
+			return "[red]None of the runners of this project runs untagged jobs.[-]\nAdd tags to the job or allow a runner to run untagged jobs."
+		}
+		return fmt.Sprintf("[red]No runner of this project has all of the tags %s.[-]", tview.Escape(strings.Join(queued.job.TagList, ", ")))
+	}
+
+	var text strings.Builder
+	for _, r := range queued.matching {
+		fmt.Fprintf(&text, "#%-6d %s  %s  last contact %s\n", r.ID, statusLabel(runnerStatus(r)),
+			tview.Escape(runnerName(r)), formatTime(r.ContactedAt))
+	}
+	if len(queued.available) > 0 {
+		text.WriteString("\n[gray]Runners are online for this job, it should be picked up once one is free.[-]")
+	}
+	return text.String()
+}
@@ -0,0 +1,485 @@
+// runners.go
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/xanzy/go-gitlab"
+)
+
+// runnerJobHistorySize is how many of its latest jobs are shown for a
+// runner, newest first.
+const runnerJobHistorySize = 50
+
+// runnerScope is whose runners the runners screen lists. project is set for
+// the runners of a project, whose pending jobs can be matched against them.
+type runnerScope struct {
+	title   string
+	project *gitlab.Project
+	list    func() ([]*gitlab.Runner, error)
+}
+
+// runner is a runner with its details and the number of jobs it is running,
+// or -1 if the token isn't allowed to see its jobs.
+type runner struct {
+	*gitlab.RunnerDetails
+	runningJobs int
+}
+
+// instanceReference is the reference of the instance node in the tree.
+type instanceReference struct{}
+
+func projectRunnerScope(project *gitlab.Project) runnerScope {
+	return runnerScope{
+		title:   project.PathWithNamespace,
+		project: project,
+		list: func() ([]*gitlab.Runner, error) {
+			runners, err := listAllPages(func(listOptions gitlab.ListOptions) ([]*gitlab.Runner, *gitlab.Response, error) {
+				return gitlabClient.Runners().ListProjectRunners(project.ID, &gitlab.ListProjectRunnersOptions{ListOptions: listOptions})
+			})
+			if err != nil {
+				return nil, fmt.Errorf("fetching runners of project %s: %w", project.PathWithNamespace, err)
+			}
+			return runners, nil
+		},
+	}
+}
+
+func groupRunnerScope(group *gitlab.Group) runnerScope {
+	return runnerScope{
+		title: group.FullPath,
+		list: func() ([]*gitlab.Runner, error) {
+			runners, err := listAllPages(func(listOptions gitlab.ListOptions) ([]*gitlab.Runner, *gitlab.Response, error) {
+				return gitlabClient.Runners().ListGroupsRunners(group.ID, &gitlab.ListGroupsRunnersOptions{ListOptions: listOptions})
+			})
+			if err != nil {
+				return nil, fmt.Errorf("fetching runners of group %s: %w", group.FullPath, err)
+			}
+			return runners, nil
+		},
+	}
+}
+
+// instanceRunnerScope lists every runner of the instance, which takes an
+// administrator. For anyone else it lists the runners they own instead.
+func instanceRunnerScope() runnerScope {
+	return runnerScope{
+		title: gitlabURL,
+		list: func() ([]*gitlab.Runner, error) {
+			runners, err := listAllPages(func(listOptions gitlab.ListOptions) ([]*gitlab.Runner, *gitlab.Response, error) {
+				return gitlabClient.Runners().ListAllRunners(&gitlab.ListRunnersOptions{ListOptions: listOptions})
+			})
+			if isForbidden(err) {
+				runners, err = listAllPages(func(listOptions gitlab.ListOptions) ([]*gitlab.Runner, *gitlab.Response, error) {
+					return gitlabClient.Runners().ListRunners(&gitlab.ListRunnersOptions{ListOptions: listOptions})
+				})
+			}
+			if err != nil {
+				return nil, fmt.Errorf("fetching runners: %w", err)
+			}
+			return runners, nil
+		},
+	}
+}
+
+// isForbidden reports whether err is GitLab refusing the request for lack of
+// permissions.
+func isForbidden(err error) bool {
+	var errorResponse *gitlab.ErrorResponse
+	return errors.As(err, &errorResponse) && errorResponse.Response != nil &&
+		errorResponse.Response.StatusCode == http.StatusForbidden
+}
+
+// listRunners fetches the runners of scope with their details, which carry
+// the tags and last contact the list leaves out, and how many jobs each one
+// is running.
+func listRunners(scope runnerScope) ([]*runner, error) {
+	listed, err := scope.list()
+	if err != nil {
+		return nil, err
+	}
+
+	runners := make([]*runner, len(listed))
+	errs := make([]error, len(listed))
+
+	forEachParallel(len(listed), pipelineDetailWorkers, func(i int) {
+		runners[i], errs[i] = getRunner(listed[i].ID)
+	})
+
+	for i, err := range errs {
+		if err != nil {
+			return nil, fmt.Errorf("fetching runner #%d: %w", listed[i].ID, err)
+		}
+	}
+	return runners, nil
+}
+
+func getRunner(runnerID int) (*runner, error) {
+	details, _, err := gitlabClient.Runners().GetRunnerDetails(runnerID)
+	if err != nil {
+		return nil, err
+	}
+
+	running, err := listAllPages(func(listOptions gitlab.ListOptions) ([]*gitlab.Job, *gitlab.Response, error) {
+		return gitlabClient.Runners().ListRunnerJobs(runnerID, &gitlab.ListRunnerJobsOptions{
+			ListOptions: listOptions,
+			Status:      gitlab.String("running"),
+		})
+	})
+	if isForbidden(err) {
+		return &runner{RunnerDetails: details, runningJobs: -1}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &runner{RunnerDetails: details, runningJobs: len(running)}, nil
+}
+
+// setRunnerPaused pauses the runner, so it stops picking up new jobs, or
+// resumes it.
+func setRunnerPaused(runnerID int, paused bool) error {
+	_, _, err := gitlabClient.Runners().UpdateRunnerDetails(runnerID, &gitlab.UpdateRunnerDetailsOptions{
+		Paused: gitlab.Bool(paused),
+	})
+	if err != nil {
+		if paused {
+			return fmt.Errorf("pausing runner #%d: %w", runnerID, err)
+		}
+		return fmt.Errorf("resuming runner #%d: %w", runnerID, err)
+	}
+	return nil
+}
+
+func updateRunner(runnerID int, description string, tags []string, runUntagged bool) error {
+	_, _, err := gitlabClient.Runners().UpdateRunnerDetails(runnerID, &gitlab.UpdateRunnerDetailsOptions{
+		Description: gitlab.String(description),
+		TagList:     &tags,
+		RunUntagged: gitlab.Bool(runUntagged),
+	})
+	if err != nil {
+		return fmt.Errorf("updating runner #%d: %w", runnerID, err)
+	}
+	return nil
+}
+
+func listRunnerJobs(runnerID int) ([]*gitlab.Job, error) {
+	jobs, _, err := gitlabClient.Runners().ListRunnerJobs(runnerID, &gitlab.ListRunnerJobsOptions{
+		ListOptions: gitlab.ListOptions{PerPage: runnerJobHistorySize},
+		OrderBy:     gitlab.String("id"),
+		Sort:        gitlab.String("desc"),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("fetching jobs of runner #%d: %w", runnerID, err)
+	}
+	return jobs, nil
+}
+
+// parseTags splits a comma separated list of tags, dropping empty ones.
+func parseTags(text string) []string {
+	tags := []string{}
+	for _, tag := range strings.Split(text, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// runnerStatus is the status shown for r, which is paused rather than online
+// while it doesn't pick up jobs.
+func runnerStatus(r *runner) string {
+	if r.Paused {
+		return "paused"
+	}
+	return r.Status
+}
+
+// runnerName is the description of r, or its name if it has none.
+func runnerName(r *runner) string {
+	if r.Description != "" {
+		return r.Description
+	}
+	return r.Name
+}
+
+// showRunners lists the runners of scope with their status, tags, last
+// contact and how many jobs they are running. Enter shows the latest jobs of
+// the selected runner, p pauses or resumes it and e edits its description
+// and tags. For a project, q shows which runners its pending jobs wait for.
+// back is called on Esc.
+func showRunners(app *tview.Application, scope runnerScope, back func()) {
+	cancelPendingLoads()
+
+	table := tview.NewTable().
+		SetSelectable(true, false).
+		SetFixed(1, 0)
+	table.SetBorder(true).
+		SetTitle(fmt.Sprintf(" Runners of %s ", tview.Escape(scope.title)))
+	statusBar := newStatusBar()
+
+	var runners []*runner
+
+	fillTable := func() {
+		row, _ := table.GetSelection()
+		table.Clear()
+
+		for column, title := range []string{"ID", "Description", "Type", "Status", "Tags", "Version", "Last contact", "Jobs"} {
+			table.SetCell(0, column, tview.NewTableCell(title).
+				SetTextColor(tcell.ColorYellow).
+				SetAttributes(tcell.AttrBold).
+				SetSelectable(false))
+		}
+
+		for i, r := range runners {
+			tags := strings.Join(r.TagList, ", ")
+			if tags == "" {
+				tags = "[gray]untagged[-]"
+			} else {
+				tags = tview.Escape(tags)
+			}
+			jobs := "-"
+			if r.runningJobs >= 0 {
+				jobs = strconv.Itoa(r.runningJobs)
+			}
+
+			table.SetCell(i+1, 0, tview.NewTableCell(strconv.Itoa(r.ID)).SetAlign(tview.AlignRight))
+			table.SetCell(i+1, 1, tview.NewTableCell(tview.Escape(runnerName(r))).SetMaxWidth(40).SetExpansion(1))
+			table.SetCell(i+1, 2, tview.NewTableCell(strings.TrimSuffix(r.RunnerType, "_type")))
+			table.SetCell(i+1, 3, tview.NewTableCell(statusLabel(runnerStatus(r))))
+			table.SetCell(i+1, 4, tview.NewTableCell(tags).SetMaxWidth(40))
+			table.SetCell(i+1, 5, tview.NewTableCell(tview.Escape(r.Version)))
+			table.SetCell(i+1, 6, tview.NewTableCell(formatTime(r.ContactedAt)))
+			table.SetCell(i+1, 7, tview.NewTableCell(jobs).SetAlign(tview.AlignRight))
+		}
+
+		if len(runners) == 0 {
+			table.SetCell(1, 0, tview.NewTableCell("No runners available").
+				SetTextColor(tcell.ColorGray).
+				SetSelectable(false))
+			return
+		}
+		if row < 1 || row > len(runners) {
+			row = 1
+		}
+		table.Select(row, 0)
+	}
+
+	reloadRunners := func() (func(), error) {
+		loaded, err := listRunners(scope)
+		if err != nil {
+			return nil, err
+		}
+		return func() {
+			runners = loaded
+			fillTable()
+		}, nil
+	}
+
+	selected := func() *runner {
+		row, _ := table.GetSelection()
+		if row < 1 || row > len(runners) {
+			return nil
+		}
+		return runners[row-1]
+	}
+
+	var flex *tview.Flex
+
+	returnToRunners := func() {
+		routes.show("runners/"+scope.title, flex, table)
+	}
+
+	table.SetSelectedFunc(func(row, column int) {
+		if r := selected(); r != nil {
+			showRunnerJobs(app, r, func() {
+				showRunners(app, scope, back)
+			})
+		}
+	})
+
+	table.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch {
+		case event.Key() == tcell.KeyEsc:
+			back()
+			return nil
+		case isRefreshKey(event):
+			fetchInBackground(app, statusBar, "Refreshing...", reloadRunners)
+			return nil
+		case event.Rune() == 'p':
+			r := selected()
+			if r == nil {
+				return nil
+			}
+			message, done := "Pausing runner...", fmt.Sprintf("Paused runner #%d", r.ID)
+			if r.Paused {
+				message, done = "Resuming runner...", fmt.Sprintf("Resumed runner #%d", r.ID)
+			}
+			paused := !r.Paused
+			runAction(app, statusBar, message, done, func() error { return setRunnerPaused(r.ID, paused) }, reloadRunners)
+			return nil
+		case event.Rune() == 'q':
+			if scope.project == nil {
+				showError(app, fmt.Errorf("the job queue is only shown for the runners of a project"), nil)
+				return nil
+			}
+			showRunnerQueue(app, scope.project, scope, func() {
+				showRunners(app, scope, back)
+			})
+			return nil
+		case event.Rune() == 'e':
+			if r := selected(); r != nil {
+				showRunnerForm(app, r, func(message string, save func() error) {
+					returnToRunners()
+					runAction(app, statusBar, "Saving runner...", message, save, reloadRunners)
+				}, returnToRunners)
+			}
+			return nil
+		}
+		return event
+	})
+
+	flex = tview.NewFlex().
+		SetDirection(tview.FlexRow).
+		AddItem(table, 0, 1, true).
+		AddItem(statusBar, 1, 0, false).
+		AddItem(tview.NewButton("ENTER - Jobs   p - Pause/Resume   e - Edit   q - Job queue   ESC - Back").SetSelectedFunc(back), 1, 0, false)
+
+	routes.show("runners/"+scope.title, flex, table)
+
+	startAutoRefresh(app, table, reloadRunners)
+	fetchInBackground(app, statusBar, "Loading runners...", reloadRunners)
+}
+
+// showRunnerForm edits the description and tags of r. On Save it passes the
+// message to show on success and the request that saves the runner to save.
+// cancel is called on Esc or Cancel.
+func showRunnerForm(app *tview.Application, r *runner, save func(message string, request func() error), cancel func()) {
+	form := tview.NewForm()
+	form.SetBorder(true).
+		SetTitle(fmt.Sprintf(" Edit runner #%d ", r.ID))
+
+	form.AddInputField("Description", r.Description, 40, nil, nil).
+		AddInputField("Tags", strings.Join(r.TagList, ", "), 60, nil, nil).
+		AddCheckbox("Run untagged jobs", r.RunUntagged, nil).
+		AddButton("Save", func() {
+			description := strings.TrimSpace(form.GetFormItemByLabel("Description").(*tview.InputField).GetText())
+			tags := parseTags(form.GetFormItemByLabel("Tags").(*tview.InputField).GetText())
+			runUntagged := form.GetFormItemByLabel("Run untagged jobs").(*tview.Checkbox).IsChecked()
+
+			if len(tags) == 0 && !runUntagged {
+				showError(app, fmt.Errorf("a runner without tags has to run untagged jobs"), nil)
+				return
+			}
+
+			save(fmt.Sprintf("Runner #%d updated", r.ID), func() error {
+				return updateRunner(r.ID, description, tags, runUntagged)
+			})
+		}).
+		AddButton("Cancel", cancel).
+		SetCancelFunc(cancel)
+
+	routes.show("runner-form/"+strconv.Itoa(r.ID), form, form)
+}
+
+// showRunnerJobs shows the latest jobs r ran, newest first. Enter opens the
+// pipeline of the selected job. back is called on Esc.
+func showRunnerJobs(app *tview.Application, r *runner, back func()) {
+	cancelPendingLoads()
+
+	table := tview.NewTable().
+		SetSelectable(true, false).
+		SetFixed(1, 0)
+	table.SetBorder(true).
+		SetTitle(fmt.Sprintf(" Jobs of runner #%d %s ", r.ID, tview.Escape(runnerName(r))))
+	statusBar := newStatusBar()
+
+	var jobs []*gitlab.Job
+
+	fillTable := func() {
+		row, _ := table.GetSelection()
+		table.Clear()
+
+		for column, title := range []string{"Job", "Project", "Ref", "Name", "Stage", "Status", "Started", "Duration"} {
+			table.SetCell(0, column, tview.NewTableCell(title).
+				SetTextColor(tcell.ColorYellow).
+				SetAttributes(tcell.AttrBold).
+				SetSelectable(false))
+		}
+
+		for i, job := range jobs {
+			project := "-"
+			if job.Project != nil {
+				project = job.Project.PathWithNamespace
+			}
+
+			table.SetCell(i+1, 0, tview.NewTableCell(strconv.Itoa(job.ID)).SetAlign(tview.AlignRight))
+			table.SetCell(i+1, 1, tview.NewTableCell(tview.Escape(project)).SetMaxWidth(40).SetExpansion(1))
+			table.SetCell(i+1, 2, tview.NewTableCell(tview.Escape(job.Ref)))
+			table.SetCell(i+1, 3, tview.NewTableCell(tview.Escape(job.Name)))
+			table.SetCell(i+1, 4, tview.NewTableCell(tview.Escape(job.Stage)))
+			table.SetCell(i+1, 5, tview.NewTableCell(statusLabel(job.Status)))
+			table.SetCell(i+1, 6, tview.NewTableCell(formatTime(job.StartedAt)))
+			table.SetCell(i+1, 7, tview.NewTableCell(formatDuration(int(job.Duration))).SetAlign(tview.AlignRight))
+		}
+
+		if len(jobs) == 0 {
+			table.SetCell(1, 0, tview.NewTableCell("This runner hasn't run any jobs").
+				SetTextColor(tcell.ColorGray).
+				SetSelectable(false))
+			return
+		}
+		if row < 1 || row > len(jobs) {
+			row = 1
+		}
+		table.Select(row, 0)
+	}
+
+	reloadJobs := func() (func(), error) {
+		loaded, err := listRunnerJobs(r.ID)
+		if err != nil {
+			return nil, err
+		}
+		return func() {
+			jobs = loaded
+			fillTable()
+		}, nil
+	}
+
+	table.SetSelectedFunc(func(row, column int) {
+		if row < 1 || row > len(jobs) {
+			return
+		}
+		job := jobs[row-1]
+		pipelineTrail = nil
+		showPipelineDetail(app, strconv.Itoa(job.Pipeline.ProjectID), strconv.Itoa(job.Pipeline.ID), job.Pipeline.Ref)
+	})
+
+	table.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch {
+		case event.Key() == tcell.KeyEsc:
+			back()
+			return nil
+		case isRefreshKey(event):
+			fetchInBackground(app, statusBar, "Refreshing...", reloadJobs)
+			return nil
+		}
+		return event
+	})
+
+	flex := tview.NewFlex().
+		SetDirection(tview.FlexRow).
+		AddItem(table, 0, 1, true).
+		AddItem(statusBar, 1, 0, false).
+		AddItem(tview.NewButton("ENTER - Pipeline   ESC - Back").SetSelectedFunc(back), 1, 0, false)
+
+	routes.show("runner-jobs/"+strconv.Itoa(r.ID), flex, table)
+
+	startAutoRefresh(app, table, reloadJobs)
+	fetchInBackground(app, statusBar, "Loading jobs...", reloadJobs)
+}
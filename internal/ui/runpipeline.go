@@ -0,0 +1,194 @@
+// runpipeline.go
+package ui
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/xanzy/go-gitlab"
+)
+
+// variableTypes are the options of the type drop-down of a variable row.
+var variableTypes = []string{string(gitlab.EnvVariableType), string(gitlab.FileVariableType)}
+
+// variableRow is one key/value pair in a form that collects CI variables.
+type variableRow struct {
+	key          *tview.InputField
+	value        *tview.InputField
+	variableType *tview.DropDown
+}
+
+// variableRows adds key/value/type rows to a form and collects them again.
+type variableRows struct {
+	app  *tview.Application
+	form *tview.Form
+	rows []*variableRow
+}
+
+// add appends an empty row to the form and focuses its key.
+func (v *variableRows) add() {
+	n := len(v.rows) + 1
+	row := &variableRow{
+		key:   tview.NewInputField().SetLabel(fmt.Sprintf("Variable %d key", n)).SetFieldWidth(40),
+		value: tview.NewInputField().SetLabel(fmt.Sprintf("Variable %d value", n)).SetFieldWidth(40),
+	}
+
+	row.variableType = tview.NewDropDown().
+		SetLabel(fmt.Sprintf("Variable %d type", n)).
+		SetOptions(variableTypes, nil).
+		SetCurrentOption(0)
+
+	v.form.AddFormItem(row.key).AddFormItem(row.value).AddFormItem(row.variableType)
+
+	v.rows = append(v.rows, row)
+	v.form.SetFocus(v.form.GetFormItemIndex(row.key.GetLabel()))
+	v.app.SetFocus(v.form)
+}
+
+// pipelineVariables returns the filled-in rows. The value of a file variable
+// starting with @ names a local file whose contents are sent instead.
+func (v *variableRows) pipelineVariables() ([]*gitlab.PipelineVariableOptions, error) {
+	var variables []*gitlab.PipelineVariableOptions
+
+	for _, row := range v.rows {
+		key := strings.TrimSpace(row.key.GetText())
+		if key == "" {
+			continue
+		}
+
+		value := row.value.GetText()
+		_, variableType := row.variableType.GetCurrentOption()
+
+		if variableType == string(gitlab.FileVariableType) && strings.HasPrefix(value, "@") {
+			contents, err := os.ReadFile(strings.TrimPrefix(value, "@"))
+			if err != nil {
+				return nil, fmt.Errorf("reading file variable %s: %w", key, err)
+			}
+			value = string(contents)
+		}
+
+		variables = append(variables, &gitlab.PipelineVariableOptions{
+			Key:          gitlab.String(key),
+			Value:        gitlab.String(value),
+			VariableType: gitlab.String(variableType),
+		})
+	}
+
+	return variables, nil
+}
+
+// jobVariables is pipelineVariables in the form PlayJob takes.
+func (v *variableRows) jobVariables() ([]*gitlab.JobVariableOptions, error) {
+	pipelineVariables, err := v.pipelineVariables()
+	if err != nil {
+		return nil, err
+	}
+
+	variables := make([]*gitlab.JobVariableOptions, len(pipelineVariables))
+	for i, variable := range pipelineVariables {
+		variables[i] = &gitlab.JobVariableOptions{
+			Key:          variable.Key,
+			Value:        variable.Value,
+			VariableType: variable.VariableType,
+		}
+	}
+	return variables, nil
+}
+
+// runPipelineFunc creates a pipeline for ref with variables.
+type runPipelineFunc func(ref string, variables []*gitlab.PipelineVariableOptions) (*gitlab.Pipeline, error)
+
+// showRunPipelineForm asks for a ref and any number of variables, creates a
+// pipeline from them with run and opens its job list. back is called on Esc
+// or Cancel.
+func showRunPipelineForm(app *tview.Application, title, projectID, defaultRef string, run runPipelineFunc, back func()) {
+	cancelPendingLoads()
+
+	statusBar := newStatusBar()
+	form := tview.NewForm()
+	form.SetBorder(true).
+		SetTitle(" " + title + " ")
+
+	var branchNames []string
+
+	refField := tview.NewInputField().
+		SetLabel("Ref").
+		SetText(defaultRef).
+		SetFieldWidth(40)
+	refField.SetAutocompleteFunc(func(currentText string) []string {
+		var matches []string
+		for _, name := range branchNames {
+			if name == currentText {
+				return nil
+			}
+			if strings.Contains(strings.ToLower(name), strings.ToLower(currentText)) {
+				matches = append(matches, name)
+			}
+		}
+		return matches
+	})
+
+	variables := &variableRows{app: app, form: form}
+
+	form.AddFormItem(refField).
+		AddButton("Run", func() {
+			ref := strings.TrimSpace(refField.GetText())
+			if ref == "" {
+				showError(app, fmt.Errorf("a ref is required to run a pipeline"), nil)
+				return
+			}
+			pipelineVariables, err := variables.pipelineVariables()
+			if err != nil {
+				showError(app, err, nil)
+				return
+			}
+
+			fetchInBackground(app, statusBar, "Creating pipeline...", func() (func(), error) {
+				pipeline, err := run(ref, pipelineVariables)
+				if err != nil {
+					return nil, err
+				}
+				return func() {
+					showInfo(app, fmt.Sprintf("Pipeline #%d created", pipeline.ID))
+					pipelineTrail = nil
+					showJobList(app, nil, nil, projectID, strconv.Itoa(pipeline.ID), ref)
+				}, nil
+			})
+		}).
+		AddButton("Add variable", variables.add).
+		AddButton("Cancel", back).
+		SetCancelFunc(back)
+
+	form.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyCtrlA {
+			variables.add()
+			return nil
+		}
+		return event
+	})
+
+	flex := tview.NewFlex().
+		SetDirection(tview.FlexRow).
+		AddItem(form, 0, 1, true).
+		AddItem(statusBar, 1, 0, false).
+		AddItem(tview.NewButton("Ctrl-A - Add variable   ESC - Back").SetSelectedFunc(back), 1, 0, false)
+
+	routes.show("run-pipeline/"+projectID, flex, form)
+
+	fetchInBackground(app, statusBar, "Loading branches...", func() (func(), error) {
+		branches, err := listAllBranches(projectID)
+		if err != nil {
+			return nil, fmt.Errorf("fetching branches for project %s: %w", projectID, err)
+		}
+		return func() {
+			branchNames = branchNames[:0]
+			for _, branch := range branches {
+				branchNames = append(branchNames, branch.Name)
+			}
+		}, nil
+	})
+}
@@ -0,0 +1,328 @@
+// schedules.go
+package ui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/xanzy/go-gitlab"
+)
+
+func listSchedules(projectID int) ([]*gitlab.PipelineSchedule, error) {
+	schedules, err := listAllPages(func(listOptions gitlab.ListOptions) ([]*gitlab.PipelineSchedule, *gitlab.Response, error) {
+		return gitlabClient.PipelineSchedules().ListPipelineSchedules(projectID, (*gitlab.ListPipelineSchedulesOptions)(&listOptions))
+	})
+	if err != nil {
+		return nil, fmt.Errorf("fetching pipeline schedules: %w", err)
+	}
+	return schedules, nil
+}
+
+func setScheduleActive(projectID, scheduleID int, active bool) error {
+	_, _, err := gitlabClient.PipelineSchedules().EditPipelineSchedule(projectID, scheduleID, &gitlab.EditPipelineScheduleOptions{
+		Active: gitlab.Bool(active),
+	})
+	if err != nil {
+		return fmt.Errorf("updating schedule %d: %w", scheduleID, err)
+	}
+	return nil
+}
+
+func runSchedule(projectID, scheduleID int) error {
+	_, err := gitlabClient.PipelineSchedules().RunPipelineSchedule(projectID, scheduleID)
+	if err != nil {
+		return fmt.Errorf("running schedule %d: %w", scheduleID, err)
+	}
+	return nil
+}
+
+func deleteSchedule(projectID, scheduleID int) error {
+	_, err := gitlabClient.PipelineSchedules().DeletePipelineSchedule(projectID, scheduleID)
+	if err != nil {
+		return fmt.Errorf("deleting schedule %d: %w", scheduleID, err)
+	}
+	return nil
+}
+
+// scheduleVariableScope manages the variables of a pipeline schedule on the
+// variables screen.
+func scheduleVariableScope(projectID int, schedule *gitlab.PipelineSchedule) variableScope {
+	return variableScope{
+		title: "schedule " + schedule.Description,
+		plain: true,
+		list: func() ([]ciVariable, error) {
+			// Only a single schedule comes with its variables.
+			full, _, err := gitlabClient.PipelineSchedules().GetPipelineSchedule(projectID, schedule.ID)
+			if err != nil {
+				return nil, fmt.Errorf("fetching schedule %d: %w", schedule.ID, err)
+			}
+
+			variables := make([]ciVariable, len(full.Variables))
+			for i, v := range full.Variables {
+				variables[i] = ciVariable{key: v.Key, value: v.Value, variableType: v.VariableType}
+			}
+			return variables, nil
+		},
+		create: func(variable ciVariable) error {
+			_, _, err := gitlabClient.PipelineSchedules().CreatePipelineScheduleVariable(projectID, schedule.ID, &gitlab.CreatePipelineScheduleVariableOptions{
+				Key:          gitlab.String(variable.key),
+				Value:        gitlab.String(variable.value),
+				VariableType: gitlab.String(variable.variableType),
+			})
+			if err != nil {
+				return fmt.Errorf("creating variable %s: %w", variable.key, err)
+			}
+			return nil
+		},
+		update: func(old, variable ciVariable) error {
+			_, _, err := gitlabClient.PipelineSchedules().EditPipelineScheduleVariable(projectID, schedule.ID, old.key, &gitlab.EditPipelineScheduleVariableOptions{
+				Value:        gitlab.String(variable.value),
+				VariableType: gitlab.String(variable.variableType),
+			})
+			if err != nil {
+				return fmt.Errorf("updating variable %s: %w", old.key, err)
+			}
+			return nil
+		},
+		remove: func(variable ciVariable) error {
+			_, _, err := gitlabClient.PipelineSchedules().DeletePipelineScheduleVariable(projectID, schedule.ID, variable.key)
+			if err != nil {
+				return fmt.Errorf("deleting variable %s: %w", variable.key, err)
+			}
+			return nil
+		},
+	}
+}
+
+// showSchedules lists the pipeline schedules of project. n adds a schedule,
+// Enter edits the selected one, a toggles whether it is active, p runs it
+// now, v manages its variables and D deletes it. back is called on Esc.
+func showSchedules(app *tview.Application, project *gitlab.Project, back func()) {
+	cancelPendingLoads()
+
+	projectID := project.ID
+
+	table := tview.NewTable().
+		SetSelectable(true, false).
+		SetFixed(1, 0)
+	table.SetBorder(true).
+		SetTitle(fmt.Sprintf(" Pipeline schedules of %s ", tview.Escape(project.PathWithNamespace)))
+	statusBar := newStatusBar()
+
+	var schedules []*gitlab.PipelineSchedule
+
+	fillTable := func() {
+		row, _ := table.GetSelection()
+		table.Clear()
+
+		for column, title := range []string{"Description", "Ref", "Cron", "Next run", "Owner", "Last pipeline", "Active"} {
+			table.SetCell(0, column, tview.NewTableCell(title).
+				SetTextColor(tcell.ColorYellow).
+				SetAttributes(tcell.AttrBold).
+				SetSelectable(false))
+		}
+
+		for i, schedule := range schedules {
+			owner := "-"
+			if schedule.Owner != nil {
+				owner = schedule.Owner.Username
+			}
+			lastPipeline := "-"
+			if schedule.LastPipeline != nil {
+				lastPipeline = fmt.Sprintf("#%d %s", schedule.LastPipeline.ID, statusLabel(schedule.LastPipeline.Status))
+			}
+			nextRun := formatTime(schedule.NextRunAt)
+			if !schedule.Active {
+				nextRun = "-"
+			}
+
+			table.SetCell(i+1, 0, tview.NewTableCell(tview.Escape(schedule.Description)).SetExpansion(1))
+			table.SetCell(i+1, 1, tview.NewTableCell(tview.Escape(schedule.Ref)))
+			table.SetCell(i+1, 2, tview.NewTableCell(tview.Escape(schedule.Cron+" "+schedule.CronTimezone)))
+			table.SetCell(i+1, 3, tview.NewTableCell(nextRun))
+			table.SetCell(i+1, 4, tview.NewTableCell(tview.Escape(owner)))
+			table.SetCell(i+1, 5, tview.NewTableCell(lastPipeline))
+			table.SetCell(i+1, 6, tview.NewTableCell(yesNo(schedule.Active)))
+		}
+
+		if len(schedules) == 0 {
+			table.SetCell(1, 0, tview.NewTableCell("No schedules yet, press n to add one").
+				SetTextColor(tcell.ColorGray).
+				SetSelectable(false))
+			return
+		}
+		if row < 1 || row > len(schedules) {
+			row = 1
+		}
+		table.Select(row, 0)
+	}
+
+	reloadSchedules := func() (func(), error) {
+		loaded, err := listSchedules(projectID)
+		if err != nil {
+			return nil, err
+		}
+		return func() {
+			schedules = loaded
+			fillTable()
+		}, nil
+	}
+
+	selected := func() *gitlab.PipelineSchedule {
+		row, _ := table.GetSelection()
+		if row < 1 || row > len(schedules) {
+			return nil
+		}
+		return schedules[row-1]
+	}
+
+	var flex *tview.Flex
+
+	returnToSchedules := func() {
+		routes.show("schedules/"+strconv.Itoa(project.ID), flex, table)
+	}
+
+	showForm := func(schedule *gitlab.PipelineSchedule) {
+		showScheduleForm(app, project, schedule, func(message string, save func() error) {
+			returnToSchedules()
+			runAction(app, statusBar, "Saving schedule...", message, save, reloadSchedules)
+		}, returnToSchedules)
+	}
+
+	table.SetSelectedFunc(func(row, column int) {
+		if schedule := selected(); schedule != nil {
+			showForm(schedule)
+		}
+	})
+
+	table.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch {
+		case event.Key() == tcell.KeyEsc:
+			back()
+			return nil
+		case isRefreshKey(event):
+			fetchInBackground(app, statusBar, "Refreshing...", reloadSchedules)
+			return nil
+		case event.Rune() == 'n':
+			showForm(nil)
+			return nil
+		}
+
+		schedule := selected()
+		if schedule == nil {
+			return event
+		}
+
+		switch event.Rune() {
+		case 'a':
+			message := fmt.Sprintf("Schedule %s activated", schedule.Description)
+			if schedule.Active {
+				message = fmt.Sprintf("Schedule %s deactivated", schedule.Description)
+			}
+			runAction(app, statusBar, "Updating schedule...", message,
+				func() error { return setScheduleActive(projectID, schedule.ID, !schedule.Active) }, reloadSchedules)
+			return nil
+		case 'p':
+			confirmAction(app, fmt.Sprintf("Run schedule %s now?", schedule.Description), "Run", func() {
+				runAction(app, statusBar, "Running schedule...", fmt.Sprintf("Schedule %s started a pipeline", schedule.Description),
+					func() error { return runSchedule(projectID, schedule.ID) }, reloadSchedules)
+			})
+			return nil
+		case 'v':
+			showVariables(app, scheduleVariableScope(projectID, schedule), func() {
+				showSchedules(app, project, back)
+			})
+			return nil
+		case 'D':
+			confirmAction(app, fmt.Sprintf("Delete schedule %s?", schedule.Description), "Delete", func() {
+				runAction(app, statusBar, "Deleting schedule...", fmt.Sprintf("Schedule %s deleted", schedule.Description),
+					func() error { return deleteSchedule(projectID, schedule.ID) }, reloadSchedules)
+			})
+			return nil
+		}
+		return event
+	})
+
+	flex = tview.NewFlex().
+		SetDirection(tview.FlexRow).
+		AddItem(table, 0, 1, true).
+		AddItem(statusBar, 1, 0, false).
+		AddItem(tview.NewButton("ENTER - Edit   n - New   a - Activate/Deactivate   p - Run now   v - Variables   D - Delete   ESC - Back").SetSelectedFunc(back), 1, 0, false)
+
+	routes.show("schedules/"+strconv.Itoa(project.ID), flex, table)
+
+	fetchInBackground(app, statusBar, "Loading schedules...", reloadSchedules)
+}
+
+// showScheduleForm edits schedule, or a new one if schedule is nil. On Save it
+// passes the message to show on success and the request that saves the
+// schedule to save. cancel is called on Esc or Cancel.
+func showScheduleForm(app *tview.Application, project *gitlab.Project, schedule *gitlab.PipelineSchedule, save func(message string, request func() error), cancel func()) {
+	title := " New schedule "
+	edited := &gitlab.PipelineSchedule{Ref: project.DefaultBranch, CronTimezone: "UTC", Active: true}
+	if schedule != nil {
+		title = " Edit schedule "
+		edited = schedule
+	}
+
+	form := tview.NewForm()
+	form.SetBorder(true).
+		SetTitle(title)
+
+	form.AddInputField("Description", edited.Description, 40, nil, nil).
+		AddInputField("Ref", edited.Ref, 40, nil, nil).
+		AddInputField("Cron", edited.Cron, 20, nil, nil).
+		AddInputField("Timezone", edited.CronTimezone, 30, nil, nil).
+		AddCheckbox("Active", edited.Active, nil).
+		AddButton("Save", func() {
+			field := func(label string) *string {
+				text := strings.TrimSpace(form.GetFormItemByLabel(label).(*tview.InputField).GetText())
+				return &text
+			}
+			description, ref, cron, timezone := field("Description"), field("Ref"), field("Cron"), field("Timezone")
+			active := form.GetFormItemByLabel("Active").(*tview.Checkbox).IsChecked()
+
+			if *description == "" || *ref == "" || *cron == "" {
+				showError(app, fmt.Errorf("a schedule needs a description, a ref and a cron expression"), nil)
+				return
+			}
+
+			if schedule == nil {
+				save(fmt.Sprintf("Schedule %s created", *description), func() error {
+					_, _, err := gitlabClient.PipelineSchedules().CreatePipelineSchedule(project.ID, &gitlab.CreatePipelineScheduleOptions{
+						Description:  description,
+						Ref:          ref,
+						Cron:         cron,
+						CronTimezone: timezone,
+						Active:       &active,
+					})
+					if err != nil {
+						return fmt.Errorf("creating schedule %s: %w", *description, err)
+					}
+					return nil
+				})
+				return
+			}
+
+			save(fmt.Sprintf("Schedule %s updated", *description), func() error {
+				_, _, err := gitlabClient.PipelineSchedules().EditPipelineSchedule(project.ID, schedule.ID, &gitlab.EditPipelineScheduleOptions{
+					Description:  description,
+					Ref:          ref,
+					Cron:         cron,
+					CronTimezone: timezone,
+					Active:       &active,
+				})
+				if err != nil {
+					return fmt.Errorf("updating schedule %d: %w", schedule.ID, err)
+				}
+				return nil
+			})
+		}).
+		AddButton("Cancel", cancel).
+		SetCancelFunc(cancel)
+
+	routes.show("schedule-form/"+strconv.Itoa(project.ID), form, form)
+}
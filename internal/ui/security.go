@@ -0,0 +1,292 @@
+// security.go
+package ui
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// securityReportTypes are the artifact types of the security scanners whose
+// reports are shown.
+var securityReportTypes = []string{
+	"sast",
+	"dependency_scanning",
+	"secret_detection",
+	"container_scanning",
+	"dast",
+	"api_fuzzing",
+	"coverage_fuzzing",
+}
+
+// securitySeverities are the severities of vulnerabilities, most severe
+// first.
+var securitySeverities = []string{"Critical", "High", "Medium", "Low", "Info", "Unknown"}
+
+var securitySeverityColors = map[string]string{
+	"Critical": "fuchsia",
+	"High":     "red",
+	"Medium":   "yellow",
+	"Low":      "blue",
+	"Info":     "gray",
+	"Unknown":  "gray",
+}
+
+// securityReport is the part of GitLab's security report format that is
+// shown. All scanners share it.
+type securityReport struct {
+	Vulnerabilities []vulnerability `json:"vulnerabilities"`
+}
+
+type vulnerability struct {
+	Name        string `json:"name"`
+	Message     string `json:"message"`
+	Description string `json:"description"`
+	Severity    string `json:"severity"`
+	Solution    string `json:"solution"`
+	Identifiers []struct {
+		Name string `json:"name"`
+		URL  string `json:"url"`
+	} `json:"identifiers"`
+	Location struct {
+		File       string `json:"file"`
+		StartLine  int    `json:"start_line"`
+		Image      string `json:"image"`
+		Hostname   string `json:"hostname"`
+		Path       string `json:"path"`
+		Dependency struct {
+			Package struct {
+				Name string `json:"name"`
+			} `json:"package"`
+			Version string `json:"version"`
+		} `json:"dependency"`
+	} `json:"location"`
+
+	// reportType is the scanner that found the vulnerability.
+	reportType string
+}
+
+// title is the vulnerability's name, which older reports put in message.
+func (v vulnerability) title() string {
+	if v.Name != "" {
+		return v.Name
+	}
+	return v.Message
+}
+
+func (v vulnerability) location() string {
+	location := v.Location
+	switch {
+	case location.Dependency.Package.Name != "":
+		dependency := location.Dependency.Package.Name + " " + location.Dependency.Version
+		if location.Image != "" {
+			return location.Image + ": " + dependency
+		}
+		if location.File != "" {
+			return location.File + ": " + dependency
+		}
+		return dependency
+	case location.File != "" && location.StartLine > 0:
+		return fmt.Sprintf("%s:%d", location.File, location.StartLine)
+	case location.File != "":
+		return location.File
+	case location.Hostname != "":
+		return location.Hostname + location.Path
+	}
+	return location.Image
+}
+
+func securitySeverityRank(severity string) int {
+	for i, s := range securitySeverities {
+		if s == severity {
+			return i
+		}
+	}
+	return len(securitySeverities)
+}
+
+// listVulnerabilities downloads and merges the security reports of the jobs
+// of a pipeline, most severe first.
+func listVulnerabilities(projectID, pipelineID string) ([]vulnerability, int, error) {
+	jobs, err := listPipelineJobs(projectID, pipelineID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("fetching jobs for pipeline %s: %w", pipelineID, err)
+	}
+
+	reports := findReports(jobs, securityReportTypes...)
+	var vulnerabilities []vulnerability
+	for _, report := range reports {
+		data, err := downloadReport(projectID, report)
+		if err != nil {
+			return nil, 0, err
+		}
+		var parsed securityReport
+		if err := json.Unmarshal(data, &parsed); err != nil {
+			return nil, 0, fmt.Errorf("parsing %s of job %s: %w", report.filename, report.job.Name, err)
+		}
+		for _, v := range parsed.Vulnerabilities {
+			v.reportType = report.fileType
+			vulnerabilities = append(vulnerabilities, v)
+		}
+	}
+
+	sort.SliceStable(vulnerabilities, func(i, j int) bool {
+		return securitySeverityRank(vulnerabilities[i].Severity) < securitySeverityRank(vulnerabilities[j].Severity)
+	})
+	return vulnerabilities, len(reports), nil
+}
+
+// showSecurityReport lists the vulnerabilities the security scanners of a
+// pipeline found, most severe first, with the details of the highlighted one
+// below. back is called on Esc.
+func showSecurityReport(app *tview.Application, projectID, pipelineID string, back func()) {
+	cancelPendingLoads()
+
+	table := tview.NewTable().
+		SetSelectable(true, false).
+		SetFixed(1, 0)
+	table.SetBorder(true).
+		SetTitle(fmt.Sprintf(" Security of pipeline #%s ", pipelineID))
+	detail := tview.NewTextView().
+		SetDynamicColors(true).
+		SetScrollable(true).
+		SetWordWrap(true)
+	detail.SetBorder(true)
+	statusBar := newStatusBar()
+
+	var vulnerabilities []vulnerability
+
+	table.SetSelectionChangedFunc(func(row, column int) {
+		if row < 1 || row > len(vulnerabilities) {
+			detail.SetText("")
+			return
+		}
+		detail.SetText(formatVulnerability(vulnerabilities[row-1])).ScrollToBeginning()
+	})
+
+	reloadReport := func() (func(), error) {
+		loaded, reports, err := listVulnerabilities(projectID, pipelineID)
+		if err != nil {
+			return nil, err
+		}
+		return func() {
+			vulnerabilities = loaded
+
+			counts := map[string]int{}
+			for _, v := range loaded {
+				counts[v.Severity]++
+			}
+			var summary []string
+			for _, severity := range securitySeverities {
+				if counts[severity] > 0 {
+					summary = append(summary, fmt.Sprintf("[%s]%d %s[-]", securitySeverityColors[severity], counts[severity], strings.ToLower(severity)))
+				}
+			}
+			title := fmt.Sprintf(" Security of pipeline #%s ", pipelineID)
+			if len(summary) > 0 {
+				title = fmt.Sprintf(" Security of pipeline #%s: %s ", pipelineID, strings.Join(summary, ", "))
+			}
+			table.SetTitle(title)
+
+			table.Clear()
+			for column, title := range []string{"Severity", "Scanner", "Name", "Location"} {
+				table.SetCell(0, column, tview.NewTableCell(title).
+					SetTextColor(tcell.ColorYellow).
+					SetAttributes(tcell.AttrBold).
+					SetSelectable(false))
+			}
+			for i, v := range loaded {
+				row := i + 1
+				table.SetCell(row, 0, tview.NewTableCell("["+securitySeverityColors[v.Severity]+"]"+tview.Escape(v.Severity)+"[-]"))
+				table.SetCell(row, 1, tview.NewTableCell(v.reportType))
+				table.SetCell(row, 2, tview.NewTableCell(tview.Escape(v.title())).SetExpansion(1))
+				table.SetCell(row, 3, tview.NewTableCell(tview.Escape(v.location())))
+			}
+
+			if len(loaded) == 0 {
+				message := "No vulnerabilities found"
+				if reports == 0 {
+					message = "No job of this pipeline uploaded a security report"
+				}
+				table.SetCell(1, 0, tview.NewTableCell(message).
+					SetTextColor(tcell.ColorGray).
+					SetSelectable(false))
+				detail.SetText("")
+				return
+			}
+			table.Select(1, 0)
+		}, nil
+	}
+
+	table.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch {
+		case event.Key() == tcell.KeyEsc:
+			back()
+			return nil
+		case isRefreshKey(event):
+			fetchInBackground(app, statusBar, "Refreshing...", reloadReport)
+			return nil
+		case event.Key() == tcell.KeyTab:
+			app.SetFocus(detail)
+			return nil
+		}
+		return event
+	})
+
+	detail.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyEsc || event.Key() == tcell.KeyTab {
+			app.SetFocus(table)
+			return nil
+		}
+		return event
+	})
+
+	flex := tview.NewFlex().
+		SetDirection(tview.FlexRow).
+		AddItem(table, 0, 3, true).
+		AddItem(detail, 0, 2, false).
+		AddItem(statusBar, 1, 0, false).
+		AddItem(tview.NewButton("TAB - Scroll details   ESC - Back").SetSelectedFunc(back), 1, 0, false)
+
+	routes.show("security/"+projectID+"/"+pipelineID, flex, table)
+
+	fetchInBackground(app, statusBar, "Loading security reports...", reloadReport)
+}
+
+func formatVulnerability(v vulnerability) string {
+	var text strings.Builder
+	field := func(name, value string) {
+		fmt.Fprintf(&text, "[yellow]%-10s[-] %s\n", name, value)
+	}
+
+	field("Name", tview.Escape(v.title()))
+	field("Severity", "["+securitySeverityColors[v.Severity]+"]"+tview.Escape(v.Severity)+"[-]")
+	field("Scanner", v.reportType)
+	field("Location", tview.Escape(v.location()))
+
+	var identifiers []string
+	for _, identifier := range v.Identifiers {
+		identifiers = append(identifiers, identifier.Name)
+	}
+	if len(identifiers) > 0 {
+		field("IDs", tview.Escape(strings.Join(identifiers, ", ")))
+	}
+
+	if v.Description != "" {
+		text.WriteString("\n[yellow]Description[-]\n" + tview.Escape(v.Description) + "\n")
+	}
+	if v.Solution != "" {
+		text.WriteString("\n[yellow]Solution[-]\n" + tview.Escape(v.Solution) + "\n")
+	}
+	for _, identifier := range v.Identifiers {
+		if identifier.URL != "" {
+			text.WriteString("\n" + tview.Escape(identifier.URL))
+		}
+	}
+
+	return text.String()
+}
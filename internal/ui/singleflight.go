@@ -0,0 +1,56 @@
+// singleflight.go
+package ui
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// flight is a fetch in progress that callers asking for the same data wait
+// for instead of sending the same requests again.
+type flight struct {
+	done  chan struct{}
+	value interface{}
+	err   error
+}
+
+var (
+	flightsMu sync.Mutex
+	flights   = map[string]*flight{}
+)
+
+// shareFetch runs fetch, unless a fetch under the same key is already running,
+// in which case it waits for that one and returns its result. This keeps rapid
+// navigation, prefetching and repeated refreshes from sending identical
+// requests at once. Callers get the same value and must not modify it. A
+// fetch canceled with the view that started it is run again for the callers
+// waiting for it from the view shown now.
+func shareFetch[T any](key string, fetch func() (T, error)) (T, error) {
+	ctx := loadContext()
+	flightsMu.Lock()
+	if f, ok := flights[key]; ok {
+		flightsMu.Unlock()
+		<-f.done
+		if errors.Is(f.err, context.Canceled) && ctx.Err() == nil {
+			return shareFetch(key, fetch)
+		}
+		logDebug("shared fetch", "key", key)
+		value, _ := f.value.(T)
+		return value, f.err
+	}
+	f := &flight{done: make(chan struct{})}
+	flights[key] = f
+	flightsMu.Unlock()
+
+	defer func() {
+		flightsMu.Lock()
+		delete(flights, key)
+		flightsMu.Unlock()
+		close(f.done)
+	}()
+
+	value, err := fetch()
+	f.value, f.err = value, err
+	return value, err
+}
@@ -0,0 +1,296 @@
+// slowjobs.go
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/xanzy/go-gitlab"
+)
+
+// trendThreshold is the change in percent below which the durations of a job
+// are considered stable.
+const trendThreshold = 5
+
+// sparklineRuns is how many of the latest runs the sparkline of a job shows.
+const sparklineRuns = 30
+
+// jobDurations are the durations of a job or stage in seconds, oldest first.
+type jobDurations struct {
+	name      string
+	stage     string
+	durations []int
+}
+
+func (d jobDurations) average() int {
+	return averageDuration(d.durations)
+}
+
+func (d jobDurations) slowest() int {
+	slowest := 0
+	for _, duration := range d.durations {
+		if duration > slowest {
+			slowest = duration
+		}
+	}
+	return slowest
+}
+
+// trend compares the average of the newer half of the durations with that
+// of the older half, in percent. It reports false if there are too few runs
+// to tell.
+func (d jobDurations) trend() (float64, bool) {
+	if len(d.durations) < 4 {
+		return 0, false
+	}
+	half := len(d.durations) / 2
+	older, newer := averageDuration(d.durations[:half]), averageDuration(d.durations[half:])
+	if older == 0 {
+		return 0, false
+	}
+	return float64(newer-older) * 100 / float64(older), true
+}
+
+func averageDuration(durations []int) int {
+	if len(durations) == 0 {
+		return 0
+	}
+	total := 0
+	for _, duration := range durations {
+		total += duration
+	}
+	return total / len(durations)
+}
+
+// aggregateJobDurations collects the durations of every job that ran,
+// keyed by job name, slowest on average first.
+func aggregateJobDurations(jobs []*gitlab.Job) []jobDurations {
+	jobs = jobsOldestFirst(jobs)
+
+	byName := map[string]*jobDurations{}
+	var order []string
+	for _, job := range jobs {
+		if job.Duration <= 0 {
+			continue
+		}
+		aggregate, ok := byName[job.Name]
+		if !ok {
+			aggregate = &jobDurations{name: job.Name, stage: job.Stage}
+			byName[job.Name] = aggregate
+			order = append(order, job.Name)
+		}
+		aggregate.durations = append(aggregate.durations, int(job.Duration))
+	}
+
+	aggregates := make([]jobDurations, len(order))
+	for i, name := range order {
+		aggregates[i] = *byName[name]
+	}
+	sortSlowestFirst(aggregates)
+	return aggregates
+}
+
+// aggregateStageDurations collects how long each stage took per pipeline,
+// from the start of its first job to the end of its last, keyed by stage,
+// slowest on average first.
+func aggregateStageDurations(jobs []*gitlab.Job) []jobDurations {
+	jobs = jobsOldestFirst(jobs)
+
+	type span struct {
+		pipeline int
+		stage    string
+	}
+	type window struct {
+		started  *gitlab.Job
+		finished *gitlab.Job
+	}
+
+	windows := map[span]*window{}
+	var spans []span
+	for _, job := range jobs {
+		if job.StartedAt == nil || job.FinishedAt == nil {
+			continue
+		}
+		key := span{job.Pipeline.ID, job.Stage}
+		w, ok := windows[key]
+		if !ok {
+			w = &window{started: job, finished: job}
+			windows[key] = w
+			spans = append(spans, key)
+		}
+		if job.StartedAt.Before(*w.started.StartedAt) {
+			w.started = job
+		}
+		if job.FinishedAt.After(*w.finished.FinishedAt) {
+			w.finished = job
+		}
+	}
+
+	byStage := map[string]*jobDurations{}
+	var order []string
+	for _, key := range spans {
+		w := windows[key]
+		aggregate, ok := byStage[key.stage]
+		if !ok {
+			aggregate = &jobDurations{name: key.stage, stage: key.stage}
+			byStage[key.stage] = aggregate
+			order = append(order, key.stage)
+		}
+		aggregate.durations = append(aggregate.durations, int(w.finished.FinishedAt.Sub(*w.started.StartedAt).Seconds()))
+	}
+
+	aggregates := make([]jobDurations, len(order))
+	for i, stage := range order {
+		aggregates[i] = *byStage[stage]
+	}
+	sortSlowestFirst(aggregates)
+	return aggregates
+}
+
+// jobsOldestFirst returns a copy of jobs sorted by ID, which follows the
+// order they were created in.
+func jobsOldestFirst(jobs []*gitlab.Job) []*gitlab.Job {
+	sorted := make([]*gitlab.Job, len(jobs))
+	copy(sorted, jobs)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].ID < sorted[j].ID
+	})
+	return sorted
+}
+
+func sortSlowestFirst(aggregates []jobDurations) {
+	sort.SliceStable(aggregates, func(i, j int) bool {
+		return aggregates[i].average() > aggregates[j].average()
+	})
+}
+
+func formatTrend(d jobDurations) string {
+	change, ok := d.trend()
+	switch {
+	case !ok:
+		return "[gray]-[-]"
+	case change > trendThreshold:
+		return fmt.Sprintf("[red]▲ %+.0f%%[-]", change)
+	case change < -trendThreshold:
+		return fmt.Sprintf("[green]▼ %+.0f%%[-]", change)
+	default:
+		return "≈"
+	}
+}
+
+// showSlowestJobs ranks the jobs of the latest pipelines of project by their
+// average duration, with the slowest run, the trend and a sparkline of the
+// latest runs. s switches between jobs and stages. back is called on Esc.
+func showSlowestJobs(app *tview.Application, project *gitlab.Project, back func()) {
+	cancelPendingLoads()
+
+	projectID := strconv.Itoa(project.ID)
+
+	table := tview.NewTable().
+		SetSelectable(true, false).
+		SetFixed(1, 0)
+	table.SetBorder(true)
+	statusBar := newStatusBar()
+
+	var jobs []*gitlab.Job
+	byStage := false
+
+	setTitle := func() {
+		kind := "jobs"
+		if byStage {
+			kind = "stages"
+		}
+		table.SetTitle(fmt.Sprintf(" Slowest %s of %s (last %d pipelines) ", kind, tview.Escape(project.PathWithNamespace), jobAnalysisPipelines))
+	}
+	setTitle()
+
+	fillTable := func() {
+		row, _ := table.GetSelection()
+		table.Clear()
+
+		aggregates := aggregateJobDurations(jobs)
+		first, second := "Job", "Stage"
+		if byStage {
+			aggregates = aggregateStageDurations(jobs)
+			first, second = "Stage", ""
+		}
+
+		for column, title := range []string{first, second, "Runs", "Average", "Slowest", "Trend", "Latest runs"} {
+			table.SetCell(0, column, tview.NewTableCell(title).
+				SetTextColor(tcell.ColorYellow).
+				SetAttributes(tcell.AttrBold).
+				SetSelectable(false))
+		}
+
+		for i, aggregate := range aggregates {
+			stage := aggregate.stage
+			if byStage {
+				stage = ""
+			}
+			table.SetCell(i+1, 0, tview.NewTableCell(tview.Escape(aggregate.name)).SetExpansion(1))
+			table.SetCell(i+1, 1, tview.NewTableCell(tview.Escape(stage)))
+			table.SetCell(i+1, 2, tview.NewTableCell(strconv.Itoa(len(aggregate.durations))).SetAlign(tview.AlignRight))
+			table.SetCell(i+1, 3, tview.NewTableCell(formatDuration(aggregate.average())).SetAlign(tview.AlignRight))
+			table.SetCell(i+1, 4, tview.NewTableCell(formatDuration(aggregate.slowest())).SetAlign(tview.AlignRight))
+			table.SetCell(i+1, 5, tview.NewTableCell(formatTrend(aggregate)))
+			latest := aggregate.durations
+			if len(latest) > sparklineRuns {
+				latest = latest[len(latest)-sparklineRuns:]
+			}
+			table.SetCell(i+1, 6, tview.NewTableCell(sparkline(latest)))
+		}
+
+		if len(aggregates) == 0 {
+			table.SetCell(1, 0, tview.NewTableCell("No job of these pipelines has finished").
+				SetTextColor(tcell.ColorGray).
+				SetSelectable(false))
+			return
+		}
+		if row < 1 || row > len(aggregates) {
+			row = 1
+		}
+		table.Select(row, 0)
+	}
+
+	reloadJobs := func() (func(), error) {
+		loaded, err := listRecentJobs(projectID, jobAnalysisPipelines)
+		if err != nil {
+			return nil, err
+		}
+		return func() {
+			jobs = loaded
+			fillTable()
+		}, nil
+	}
+
+	table.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch {
+		case event.Key() == tcell.KeyEsc:
+			back()
+			return nil
+		case isRefreshKey(event):
+			fetchInBackground(app, statusBar, "Refreshing...", reloadJobs)
+			return nil
+		case event.Rune() == 's':
+			byStage = !byStage
+			setTitle()
+			table.Select(1, 0)
+			fillTable()
+			return nil
+		}
+		return event
+	})
+
+	flex := tview.NewFlex().
+		SetDirection(tview.FlexRow).
+		AddItem(table, 0, 1, true).
+		AddItem(statusBar, 1, 0, false).
+		AddItem(tview.NewButton("s - Jobs/Stages   ESC - Back").SetSelectedFunc(back), 1, 0, false)
+
+	routes.show("slow-jobs/"+strconv.Itoa(project.ID), flex, table)
+
+	fetchInBackground(app, statusBar, "Analyzing jobs...", reloadJobs)
+}
@@ -0,0 +1,151 @@
+// state.go
+package ui
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"main.go/internal/config"
+)
+
+// maxRecentProjects is how many recently opened projects are remembered.
+const maxRecentProjects = 10
+
+// appState is persisted between sessions in the XDG state directory.
+type appState struct {
+	Recent    []stateProject `json:"recent"`
+	Favorites []stateProject `json:"favorites"`
+}
+
+// stateProject is the minimal project information needed to reopen a project
+// without going through the group tree.
+type stateProject struct {
+	ID   int    `json:"id"`
+	Path string `json:"path"`
+}
+
+// state is the persisted state, read by loadState when gpv starts.
+var state = &appState{}
+
+// stateUnsaved is set when a state file that could not be parsed could not
+// be moved aside either, so saving doesn't overwrite what is in it.
+var stateUnsaved bool
+
+func stateFilePath() (string, error) {
+	dir, err := config.StateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "state.json"), nil
+}
+
+// loadState reads the state persisted by an earlier session. A missing state
+// file is not an error; on any other failure gpv starts without the recent
+// and favorite projects. A state file that can't be parsed is moved aside to
+// state.json.invalid, so it can be fixed by hand.
+func loadState() {
+	state = &appState{}
+	if err := readState(state); err != nil {
+		logWarn("loading state failed", "error", err)
+	}
+}
+
+func readState(s *appState) error {
+	path, err := stateFilePath()
+	if err != nil {
+		return fmt.Errorf("locating state file: %w", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("reading state file: %w", err)
+	}
+
+	if err := json.Unmarshal(data, s); err != nil {
+		invalid := path + ".invalid"
+		if renameErr := os.Rename(path, invalid); renameErr != nil {
+			stateUnsaved = true
+			return fmt.Errorf("parsing state file %s, not saving it this session: %w", path, err)
+		}
+		return fmt.Errorf("parsing state file %s, moved it to %s: %w", path, invalid, err)
+	}
+	return nil
+}
+
+func saveState() error {
+	// Recent and favorite projects still change for this session, they
+	// just aren't saved.
+	if stateUnsaved {
+		logDebug("not saving state, the state file could not be parsed")
+		return nil
+	}
+	path, err := stateFilePath()
+	if err != nil {
+		return fmt.Errorf("locating state file: %w", err)
+	}
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding state: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("creating state directory: %w", err)
+	}
+
+	// A temporary file renamed over the old one, so the state file is never
+	// left half written.
+	temp, err := os.CreateTemp(filepath.Dir(path), ".state-*")
+	if err != nil {
+		return fmt.Errorf("writing state file: %w", err)
+	}
+	if _, err := temp.Write(data); err != nil {
+		temp.Close()
+		os.Remove(temp.Name())
+		return fmt.Errorf("writing state file: %w", err)
+	}
+	if err := temp.Close(); err != nil {
+		os.Remove(temp.Name())
+		return fmt.Errorf("writing state file: %w", err)
+	}
+	if err := os.Rename(temp.Name(), path); err != nil {
+		os.Remove(temp.Name())
+		return fmt.Errorf("writing state file: %w", err)
+	}
+	return nil
+}
+
+// recordRecentProject moves the project to the front of the recent list,
+// trims the list to maxRecentProjects and persists it.
+func recordRecentProject(id int, path string) error {
+	recent := []stateProject{{ID: id, Path: path}}
+	for _, project := range state.Recent {
+		if project.ID != id && len(recent) < maxRecentProjects {
+			recent = append(recent, project)
+		}
+	}
+
+	state.Recent = recent
+	return saveState()
+}
+
+// toggleFavoriteProject adds the project to the favorites, or removes it if it
+// is already there, and persists the change.
+func toggleFavoriteProject(id int, path string) error {
+	for i, project := range state.Favorites {
+		if project.ID == id {
+			state.Favorites = append(state.Favorites[:i], state.Favorites[i+1:]...)
+			return saveState()
+		}
+	}
+
+	state.Favorites = append(state.Favorites, stateProject{ID: id, Path: path})
+	return saveState()
+}
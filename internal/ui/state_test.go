@@ -0,0 +1,42 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestInvalidStateFileKept(t *testing.T) {
+	savedState, savedUnsaved := state, stateUnsaved
+	defer func() { state, stateUnsaved = savedState, savedUnsaved }()
+
+	dir := t.TempDir()
+	t.Setenv("XDG_STATE_HOME", dir)
+	path, err := stateFilePath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	invalid := `{"favorites": [{"id": 1, "path": "g/p"}],`
+	if err := os.WriteFile(path, []byte(invalid), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	loadState()
+	if err := recordRecentProject(2, "g/q"); err != nil {
+		t.Fatal(err)
+	}
+
+	if data, err := os.ReadFile(path + ".invalid"); err != nil || string(data) != invalid {
+		t.Errorf("moved aside state file = %q, %v, want %q", data, err, invalid)
+	}
+	saved := &appState{}
+	if err := readState(saved); err != nil {
+		t.Fatal(err)
+	}
+	if len(saved.Recent) != 1 || saved.Recent[0].Path != "g/q" {
+		t.Errorf("saved recent projects = %v, want g/q", saved.Recent)
+	}
+}
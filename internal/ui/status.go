@@ -0,0 +1,117 @@
+// status.go
+package ui
+
+import (
+	"time"
+
+	"github.com/rivo/tview"
+)
+
+// asciiIcons replaces the status glyphs with plain ASCII for terminals or
+// fonts that can't render them. Set with GPV_ASCII_ICONS.
+var asciiIcons bool
+
+type statusStyle struct {
+	icon  string
+	ascii string
+	color string
+}
+
+var statusStyles = map[string]statusStyle{
+	"success":              {"✔", "+", "green"},
+	"failed":               {"✖", "x", "red"},
+	"running":              {"●", "*", "blue"},
+	"pending":              {"◔", "~", "yellow"},
+	"waiting_for_resource": {"◔", "~", "yellow"},
+	"preparing":            {"◔", "~", "yellow"},
+	"created":              {"○", "o", "gray"},
+	"scheduled":            {"◷", "@", "purple"},
+	"manual":               {"⏸", "=", "gray"},
+	"canceled":             {"⊘", "-", "gray"},
+	"skipped":              {"»", ">", "gray"},
+	"blocked":              {"⏸", "=", "orange"},
+	"error":                {"!", "!", "red"},
+	"online":               {"●", "*", "green"},
+	"offline":              {"○", "o", "red"},
+	"stale":                {"○", "o", "gray"},
+	"never_contacted":      {"○", "o", "gray"},
+	"paused":               {"⏸", "=", "orange"},
+}
+
+var asciiSpinnerFrames = []string{"|", "/", "-", "\\"}
+
+// spinnerTick advances while the status animation runs, and selects the
+// spinner frame used for running pipelines and jobs.
+var spinnerTick int
+
+// animatedView is redrawn on every spinner tick while it has focus, so the
+// spinners of running items move.
+var animatedView struct {
+	view   tview.Primitive
+	redraw func()
+}
+
+// statusLabel renders a pipeline or job status as a colored icon followed by
+// the status name.
+func statusLabel(status string) string {
+	style := styleForStatus(status)
+	return "[" + style.color + "]" + statusGlyph(status) + " " + status + "[-]"
+}
+
+// statusIcon is statusLabel without the status name, for tight spaces.
+func statusIcon(status string) string {
+	return "[" + styleForStatus(status).color + "]" + statusGlyph(status) + "[-]"
+}
+
+func styleForStatus(status string) statusStyle {
+	style, ok := statusStyles[status]
+	if !ok {
+		return statusStyle{"?", "?", "white"}
+	}
+	return style
+}
+
+// statusGlyph picks the icon for status. Running statuses get a spinner
+// instead of a fixed icon.
+func statusGlyph(status string) string {
+	style := styleForStatus(status)
+
+	icon := style.icon
+	if asciiIcons {
+		icon = style.ascii
+	}
+
+	if status == "running" {
+		if asciiIcons {
+			icon = asciiSpinnerFrames[spinnerTick%len(asciiSpinnerFrames)]
+		} else {
+			icon = spinnerFrames[spinnerTick%len(spinnerFrames)]
+		}
+	}
+
+	return tview.Escape(icon)
+}
+
+// animateWhileFocused makes redraw run on every spinner tick while view has
+// focus. Only the last registered view is animated.
+func animateWhileFocused(view tview.Primitive, redraw func()) {
+	animatedView.view = view
+	animatedView.redraw = redraw
+}
+
+// startStatusAnimation advances the spinner used for running statuses.
+func startStatusAnimation(app *tview.Application) {
+	go func() {
+		ticker := time.NewTicker(250 * time.Millisecond)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			app.QueueUpdateDraw(func() {
+				spinnerTick++
+				if animatedView.view != nil && animatedView.view.HasFocus() {
+					animatedView.redraw()
+				}
+			})
+		}
+	}()
+}
@@ -0,0 +1,100 @@
+// statusfile.go
+package ui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+// statusFilePath is the file the status of the watched pipelines is written
+// to for tmux status bars and shell prompts, empty if it isn't.
+var statusFilePath string
+
+// watchedStatuses holds the latest "<project>#<id>:<status>" of every
+// pipeline watched this session, keyed by watchKey. Finished pipelines stay
+// until gpv exits, so prompts can show how they ended.
+var (
+	watchedStatusesMu sync.Mutex
+	watchedStatuses   = map[string]string{}
+)
+
+// loadStatusFilePath reads GPV_STATUS_FILE.
+func loadStatusFilePath() string {
+	return os.Getenv("GPV_STATUS_FILE")
+}
+
+// recordWatchedStatus writes the status of pipeline to the status file. It
+// may be called from any goroutine.
+func recordWatchedStatus(projectID string, pipeline *gitlab.Pipeline) {
+	if statusFilePath == "" {
+		return
+	}
+
+	watchedStatusesMu.Lock()
+	defer watchedStatusesMu.Unlock()
+
+	watchedStatuses[watchKey(projectID, pipeline.ID)] = fmt.Sprintf("%s#%d:%s", pipelineProjectPath(pipeline), pipeline.ID, pipeline.Status)
+	if err := writeStatusFile(); err != nil {
+		logWarn("writing status file failed", "path", statusFilePath, "error", err)
+	}
+}
+
+// forgetWatchedStatus removes a pipeline that is no longer watched from the
+// status file.
+func forgetWatchedStatus(projectID string, pipelineID int) {
+	if statusFilePath == "" {
+		return
+	}
+
+	watchedStatusesMu.Lock()
+	defer watchedStatusesMu.Unlock()
+
+	delete(watchedStatuses, watchKey(projectID, pipelineID))
+	if err := writeStatusFile(); err != nil {
+		logWarn("writing status file failed", "path", statusFilePath, "error", err)
+	}
+}
+
+// writeStatusFile replaces the status file with a single line of the
+// watched statuses, sorted and separated by spaces. The file is renamed into
+// place so readers never see it half written. watchedStatusesMu must be
+// held.
+func writeStatusFile() error {
+	statuses := make([]string, 0, len(watchedStatuses))
+	for _, status := range watchedStatuses {
+		statuses = append(statuses, status)
+	}
+	sort.Strings(statuses)
+
+	temp, err := os.CreateTemp(filepath.Dir(statusFilePath), ".gpv-status-*")
+	if err != nil {
+		return err
+	}
+	if _, err := temp.WriteString(strings.Join(statuses, " ") + "\n"); err != nil {
+		temp.Close()
+		os.Remove(temp.Name())
+		return err
+	}
+	if err := temp.Close(); err != nil {
+		os.Remove(temp.Name())
+		return err
+	}
+	return os.Rename(temp.Name(), statusFilePath)
+}
+
+// removeStatusFile deletes the status file when gpv exits, so nothing shows
+// statuses that are no longer kept up to date.
+func removeStatusFile() {
+	if statusFilePath == "" {
+		return
+	}
+	if err := os.Remove(statusFilePath); err != nil && !os.IsNotExist(err) {
+		logWarn("removing status file failed", "path", statusFilePath, "error", err)
+	}
+}
@@ -0,0 +1,317 @@
+// successrate.go
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/xanzy/go-gitlab"
+)
+
+// successRateWindows are the numbers of days the success rate can be
+// computed over, cycled with w.
+var successRateWindows = []int{7, 30}
+
+// successRateBarWidth is the width of the bar at a 100% success rate.
+const successRateBarWidth = 20
+
+// pipelineTally counts pipelines by outcome. Pipelines that were canceled,
+// skipped or haven't finished count as other and don't affect the rate.
+type pipelineTally struct {
+	success int
+	failed  int
+	other   int
+}
+
+func (t *pipelineTally) add(status string) {
+	switch status {
+	case "success":
+		t.success++
+	case "failed":
+		t.failed++
+	default:
+		t.other++
+	}
+}
+
+func (t pipelineTally) total() int {
+	return t.success + t.failed + t.other
+}
+
+// rate is the share of finished pipelines that succeeded, in percent. It
+// reports false if no pipeline succeeded or failed.
+func (t pipelineTally) rate() (float64, bool) {
+	if t.success+t.failed == 0 {
+		return 0, false
+	}
+	return float64(t.success) * 100 / float64(t.success+t.failed), true
+}
+
+// healthRow is a line of the success rate dashboard: a project or a branch
+// with the tally of its pipelines.
+type healthRow struct {
+	name    string
+	tally   pipelineTally
+	project *gitlab.Project
+}
+
+// listPipelinesSince fetches every pipeline of a project updated in the last
+// days, only those of branches if branchesOnly is set.
+func listPipelinesSince(projectID int, days int, branchesOnly bool) ([]*gitlab.PipelineInfo, error) {
+	since := time.Now().AddDate(0, 0, -days)
+	pipelines, err := listAllPages(func(listOptions gitlab.ListOptions) ([]*gitlab.PipelineInfo, *gitlab.Response, error) {
+		options := &gitlab.ListProjectPipelinesOptions{
+			ListOptions:  listOptions,
+			UpdatedAfter: &since,
+		}
+		if branchesOnly {
+			options.Scope = gitlab.String("branches")
+		}
+		return gitlabClient.Pipelines().ListProjectPipelines(projectID, options)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("fetching pipelines for project %d: %w", projectID, err)
+	}
+	return pipelines, nil
+}
+
+// projectHealth tallies the pipelines of every project of group and its
+// subgroups over the last days. Projects without pipelines are left out.
+func projectHealth(group *gitlab.Group, days int) ([]healthRow, error) {
+	projects, err := listAllPages(func(listOptions gitlab.ListOptions) ([]*gitlab.Project, *gitlab.Response, error) {
+		options := filters.groupProjectsOptions(listOptions)
+		options.IncludeSubGroups = gitlab.Bool(true)
+		return gitlabClient.Groups().ListGroupProjects(group.ID, options)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("fetching projects of group %s: %w", group.FullPath, err)
+	}
+
+	rows := make([]healthRow, len(projects))
+	errs := make([]error, len(projects))
+
+	forEachParallel(len(projects), pipelineDetailWorkers, func(i int) {
+		rows[i] = healthRow{name: projects[i].PathWithNamespace, project: projects[i]}
+		var pipelines []*gitlab.PipelineInfo
+		pipelines, errs[i] = listPipelinesSince(projects[i].ID, days, false)
+		for _, pipeline := range pipelines {
+			rows[i].tally.add(pipeline.Status)
+		}
+	})
+
+	active := rows[:0]
+	for i, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+		if rows[i].tally.total() > 0 {
+			active = append(active, rows[i])
+		}
+	}
+	sortHealthRows(active)
+	return active, nil
+}
+
+// branchHealth tallies the pipelines of every branch of project over the
+// last days. Tag pipelines are left out.
+func branchHealth(project *gitlab.Project, days int) ([]healthRow, error) {
+	pipelines, err := listPipelinesSince(project.ID, days, true)
+	if err != nil {
+		return nil, err
+	}
+
+	tallies := map[string]*pipelineTally{}
+	for _, pipeline := range pipelines {
+		tally, ok := tallies[pipeline.Ref]
+		if !ok {
+			tally = &pipelineTally{}
+			tallies[pipeline.Ref] = tally
+		}
+		tally.add(pipeline.Status)
+	}
+
+	rows := make([]healthRow, 0, len(tallies))
+	for ref, tally := range tallies {
+		rows = append(rows, healthRow{name: ref, tally: *tally})
+	}
+	sortHealthRows(rows)
+	return rows, nil
+}
+
+// sortHealthRows puts the lowest success rate first, and rows without a rate
+// last.
+func sortHealthRows(rows []healthRow) {
+	sort.SliceStable(rows, func(i, j int) bool {
+		rateI, okI := rows[i].tally.rate()
+		rateJ, okJ := rows[j].tally.rate()
+		if okI != okJ {
+			return okI
+		}
+		if rateI != rateJ {
+			return rateI < rateJ
+		}
+		return rows[i].name < rows[j].name
+	})
+}
+
+func formatSuccessRate(tally pipelineTally) string {
+	rate, ok := tally.rate()
+	if !ok {
+		return "[gray]-[-]"
+	}
+
+	color := "green"
+	switch {
+	case rate < 75:
+		color = "red"
+	case rate < 90:
+		color = "yellow"
+	}
+	bar := "█"
+	if asciiIcons {
+		bar = "#"
+	}
+	width := int(rate / 100 * successRateBarWidth)
+	return fmt.Sprintf("[%s]%-*s %5.1f%%[-]", color, successRateBarWidth, strings.Repeat(bar, width), rate)
+}
+
+// showSuccessRate shows the success rate of pipelines over the last days,
+// per project of group or, if group is nil, per branch of project. Rows are
+// sorted with the lowest rate first. w switches between the windows and
+// Enter on a project shows its branches. back is called on Esc.
+func showSuccessRate(app *tview.Application, group *gitlab.Group, project *gitlab.Project, days int, back func()) {
+	cancelPendingLoads()
+
+	table := tview.NewTable().
+		SetSelectable(true, false).
+		SetFixed(1, 0)
+	table.SetBorder(true)
+	statusBar := newStatusBar()
+
+	name, column := "", "Branch"
+	if group != nil {
+		name, column = group.FullPath, "Project"
+	} else {
+		name = project.PathWithNamespace
+	}
+	setTitle := func() {
+		table.SetTitle(fmt.Sprintf(" Pipeline success rate of %s (last %d days) ", tview.Escape(name), days))
+	}
+	setTitle()
+
+	var rows []healthRow
+
+	fillTable := func() {
+		row, _ := table.GetSelection()
+		table.Clear()
+
+		for c, title := range []string{column, "Pipelines", "Succeeded", "Failed", "Other", "Success rate"} {
+			table.SetCell(0, c, tview.NewTableCell(title).
+				SetTextColor(tcell.ColorYellow).
+				SetAttributes(tcell.AttrBold).
+				SetSelectable(false))
+		}
+
+		var overall pipelineTally
+		for i, r := range rows {
+			overall.success += r.tally.success
+			overall.failed += r.tally.failed
+			overall.other += r.tally.other
+
+			table.SetCell(i+1, 0, tview.NewTableCell(tview.Escape(r.name)).SetExpansion(1))
+			table.SetCell(i+1, 1, tview.NewTableCell(strconv.Itoa(r.tally.total())).SetAlign(tview.AlignRight))
+			table.SetCell(i+1, 2, tview.NewTableCell(strconv.Itoa(r.tally.success)).SetAlign(tview.AlignRight))
+			table.SetCell(i+1, 3, tview.NewTableCell(strconv.Itoa(r.tally.failed)).SetAlign(tview.AlignRight))
+			table.SetCell(i+1, 4, tview.NewTableCell(strconv.Itoa(r.tally.other)).SetAlign(tview.AlignRight))
+			table.SetCell(i+1, 5, tview.NewTableCell(formatSuccessRate(r.tally)))
+		}
+
+		if len(rows) == 0 {
+			table.SetCell(1, 0, tview.NewTableCell(fmt.Sprintf("No pipelines in the last %d days", days)).
+				SetTextColor(tcell.ColorGray).
+				SetSelectable(false))
+			return
+		}
+
+		total := len(rows) + 1
+		table.SetCell(total, 0, tview.NewTableCell("Overall").SetAttributes(tcell.AttrBold).SetSelectable(false))
+		table.SetCell(total, 1, tview.NewTableCell(strconv.Itoa(overall.total())).SetAlign(tview.AlignRight).SetSelectable(false))
+		table.SetCell(total, 2, tview.NewTableCell(strconv.Itoa(overall.success)).SetAlign(tview.AlignRight).SetSelectable(false))
+		table.SetCell(total, 3, tview.NewTableCell(strconv.Itoa(overall.failed)).SetAlign(tview.AlignRight).SetSelectable(false))
+		table.SetCell(total, 4, tview.NewTableCell(strconv.Itoa(overall.other)).SetAlign(tview.AlignRight).SetSelectable(false))
+		table.SetCell(total, 5, tview.NewTableCell(formatSuccessRate(overall)).SetSelectable(false))
+
+		if row < 1 || row > len(rows) {
+			row = 1
+		}
+		table.Select(row, 0)
+	}
+
+	reloadRates := func() (func(), error) {
+		var loaded []healthRow
+		var err error
+		if group != nil {
+			loaded, err = projectHealth(group, days)
+		} else {
+			loaded, err = branchHealth(project, days)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return func() {
+			rows = loaded
+			fillTable()
+		}, nil
+	}
+
+	table.SetSelectedFunc(func(row, column int) {
+		if group == nil || row < 1 || row > len(rows) {
+			return
+		}
+		showSuccessRate(app, nil, rows[row-1].project, days, func() {
+			showSuccessRate(app, group, nil, days, back)
+		})
+	})
+
+	table.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch {
+		case event.Key() == tcell.KeyEsc:
+			back()
+			return nil
+		case isRefreshKey(event):
+			fetchInBackground(app, statusBar, "Refreshing...", reloadRates)
+			return nil
+		case event.Rune() == 'w':
+			for i, window := range successRateWindows {
+				if window == days {
+					days = successRateWindows[(i+1)%len(successRateWindows)]
+					break
+				}
+			}
+			setTitle()
+			fetchInBackground(app, statusBar, "Loading pipelines...", reloadRates)
+			return nil
+		}
+		return event
+	})
+
+	hint := "w - 7/30 days   ESC - Back"
+	if group != nil {
+		hint = "ENTER - Branches   " + hint
+	}
+
+	flex := tview.NewFlex().
+		SetDirection(tview.FlexRow).
+		AddItem(table, 0, 1, true).
+		AddItem(statusBar, 1, 0, false).
+		AddItem(tview.NewButton(hint).SetSelectedFunc(back), 1, 0, false)
+
+	routes.show("success-rate/"+name, flex, table)
+
+	fetchInBackground(app, statusBar, "Loading pipelines...", reloadRates)
+}
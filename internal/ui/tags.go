@@ -0,0 +1,56 @@
+// tags.go
+package ui
+
+import (
+	"fmt"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+// tagPipelinesWindow is how many of the latest tag pipelines are looked at to
+// find the status of each tag. Tags without a pipeline among them show none.
+const tagPipelinesWindow = 100
+
+// listAllTags pages through every tag of the project, most recently updated
+// first.
+func listAllTags(projectID string) ([]*gitlab.Tag, error) {
+	return listAllPages(func(listOptions gitlab.ListOptions) ([]*gitlab.Tag, *gitlab.Response, error) {
+		return gitlabClient.Tags().ListTags(projectID, &gitlab.ListTagsOptions{
+			ListOptions: listOptions,
+			OrderBy:     gitlab.String("updated"),
+		})
+	})
+}
+
+// listTagPipelines returns the latest pipeline of each tag that had one
+// recently, keyed by tag name.
+func listTagPipelines(projectID string) (map[string]*gitlab.PipelineInfo, error) {
+	pipelines, _, err := gitlabClient.Pipelines().ListProjectPipelines(projectID, &gitlab.ListProjectPipelinesOptions{
+		ListOptions: gitlab.ListOptions{PerPage: tagPipelinesWindow},
+		Scope:       gitlab.String("tags"),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("fetching tag pipelines for project %s: %w", projectID, err)
+	}
+
+	latest := map[string]*gitlab.PipelineInfo{}
+	for _, pipeline := range pipelines {
+		if _, ok := latest[pipeline.Ref]; !ok {
+			latest[pipeline.Ref] = pipeline
+		}
+	}
+	return latest, nil
+}
+
+func formatTag(tag *gitlab.Tag, pipeline *gitlab.PipelineInfo) string {
+	name := tag.Name
+	if pipeline != nil {
+		name = statusIcon(pipeline.Status) + " " + name
+	} else {
+		name = "  " + name
+	}
+	if tag.Commit != nil && tag.Commit.CommittedDate != nil {
+		name += "  -  last commit " + tag.Commit.CommittedDate.Format("2006-01-02 15:04:05")
+	}
+	return name
+}
@@ -0,0 +1,128 @@
+// tail.go
+package ui
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+// jobLookupLimit is how many of the latest jobs of a project are searched
+// for a job given by name.
+const jobLookupLimit = 100
+
+// setupTail sets up the tail command, which prints the log of a job and
+// keeps printing what it adds until it finishes. gpv then exits with 0 if the
+// job succeeded and 1 otherwise.
+func setupTail(flags *flag.FlagSet) func(positional []string) error {
+	project := flags.String("p", "", "project path or ID, e.g. group/project")
+	jobSpec := flags.String("job", "", "job ID, or name of the latest job with that name")
+	ref := flags.String("ref", "", "with a job name, only look at jobs on this branch or tag")
+	return func(positional []string) error {
+		if len(positional) == 1 && *project == "" {
+			*project = positional[0]
+		} else if len(positional) > 0 {
+			flags.Usage()
+			return errUsage
+		}
+		if err := requireFlag(flags, "p", *project); err != nil {
+			return err
+		}
+		if err := requireFlag(flags, "job", *jobSpec); err != nil {
+			return err
+		}
+
+		job, err := resolveJob(*project, *jobSpec, *ref)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Job %d %s (%s) on %s: %s\n", job.ID, job.Name, job.Stage, job.Ref, job.Status)
+
+		job, err = followJobLog(os.Stdout, *project, job)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Job %d finished: %s\n", job.ID, job.Status)
+		if job.Status != "success" {
+			return exitCode(1)
+		}
+		return nil
+	}
+}
+
+// resolveJob finds the job spec refers to: a job ID, or the name of a job of
+// which the latest one, on ref if it isn't empty, is taken.
+func resolveJob(projectID, spec, ref string) (*gitlab.Job, error) {
+	if id, err := strconv.Atoi(spec); err == nil {
+		job, _, err := gitlabClient.Jobs().GetJob(projectID, id)
+		if err != nil {
+			return nil, fmt.Errorf("fetching job %d: %w", id, err)
+		}
+		return job, nil
+	}
+
+	jobs, _, err := gitlabClient.Jobs().ListProjectJobs(projectID, &gitlab.ListJobsOptions{
+		ListOptions: gitlab.ListOptions{PerPage: jobLookupLimit},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("fetching jobs of project %s: %w", projectID, err)
+	}
+	for _, job := range jobs {
+		if job.Name == spec && (ref == "" || job.Ref == ref) {
+			return job, nil
+		}
+	}
+	return nil, fmt.Errorf("no job named %q among the latest %d jobs of %s", spec, jobLookupLimit, projectID)
+}
+
+// followJobLog writes the log of job to out as it grows, polling until the
+// job finishes, and returns the finished job.
+func followJobLog(out io.Writer, projectID string, job *gitlab.Job) (*gitlab.Job, error) {
+	wait := func() bool {
+		time.Sleep(logTailInterval)
+		return true
+	}
+	return pollJobLog(projectID, job, 0, wait, func(output string) error {
+		if stripLogColors {
+			output = ansiSequence.ReplaceAllString(output, "")
+		}
+		_, err := io.WriteString(out, output)
+		return err
+	})
+}
+
+// pollJobLog passes what the log of job adds past offset to emit until the
+// job finishes, and returns the last state of the job read. wait is called
+// between polls and stops them when it returns false. The job is read before
+// its log, so the read made after it finished has all of its output.
+func pollJobLog(projectID string, job *gitlab.Job, offset int, wait func() bool, emit func(output string) error) (*gitlab.Job, error) {
+	jobID := strconv.Itoa(job.ID)
+	for {
+		finished := !cancelableStatuses[job.Status]
+
+		output, err := fetchJobTraceFrom(projectID, jobID, offset)
+		if err != nil {
+			return job, fmt.Errorf("fetching logs for job %d: %w", job.ID, err)
+		}
+		offset += len(output)
+		if output != "" {
+			if err := emit(output); err != nil {
+				return job, err
+			}
+		}
+
+		if finished || !wait() {
+			return job, nil
+		}
+		latest, _, err := gitlabClient.Jobs().GetJob(projectID, job.ID)
+		if err != nil {
+			return job, fmt.Errorf("fetching job %s: %w", jobID, err)
+		}
+		job = latest
+	}
+}
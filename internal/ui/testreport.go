@@ -0,0 +1,172 @@
+// testreport.go
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/xanzy/go-gitlab"
+)
+
+// showTestReport shows the unit test report of a pipeline as a tree of
+// suites and their cases. Suites with failures start expanded. The panel on
+// the right shows the failure of the highlighted case. back is called on Esc.
+func showTestReport(app *tview.Application, projectID, pipelineID string, back func()) {
+	cancelPendingLoads()
+
+	summary := tview.NewTextView().
+		SetDynamicColors(true)
+	root := tview.NewTreeNode(fmt.Sprintf("Pipeline #%s", pipelineID)).
+		SetColor(tcell.ColorYellow).
+		SetSelectable(false)
+	tree := tview.NewTreeView().
+		SetRoot(root).
+		SetCurrentNode(root).
+		SetTopLevel(1).
+		SetGraphicsColor(tcell.ColorOrange)
+	tree.SetBorder(true).
+		SetTitle(" Test report ")
+	caseView := tview.NewTextView().
+		SetDynamicColors(true).
+		SetScrollable(true).
+		SetWordWrap(true)
+	caseView.SetBorder(true).
+		SetTitle(" Test case ")
+	statusBar := newStatusBar()
+
+	tree.SetChangedFunc(func(node *tview.TreeNode) {
+		switch reference := node.GetReference().(type) {
+		case *gitlab.PipelineTestCases:
+			caseView.SetText(formatTestCase(reference)).ScrollToBeginning()
+		case *gitlab.PipelineTestSuites:
+			caseView.SetText(testCounts(reference.SuccessCount, reference.FailedCount, reference.ErrorCount, reference.SkippedCount) +
+				"\n\n" + formatSeconds(reference.TotalTime))
+		}
+	})
+
+	tree.SetSelectedFunc(func(node *tview.TreeNode) {
+		node.SetExpanded(!node.IsExpanded())
+	})
+
+	reloadReport := func() (func(), error) {
+		report, _, err := gitlabClient.Pipelines().GetPipelineTestReport(projectID, toInt(pipelineID))
+		if err != nil {
+			return nil, fmt.Errorf("fetching test report of pipeline %s: %w", pipelineID, err)
+		}
+		return func() {
+			summary.SetText(fmt.Sprintf("%d tests   %s   [yellow]Time[-] %s",
+				report.TotalCount,
+				testCounts(report.SuccessCount, report.FailedCount, report.ErrorCount, report.SkippedCount),
+				formatSeconds(report.TotalTime)))
+
+			root.ClearChildren()
+			for _, suite := range report.TestSuites {
+				suiteNode := tview.NewTreeNode(fmt.Sprintf("%s  %s  [gray]%s[-]",
+					tview.Escape(suite.Name),
+					testCounts(suite.SuccessCount, suite.FailedCount, suite.ErrorCount, suite.SkippedCount),
+					formatSeconds(suite.TotalTime))).
+					SetReference(suite).
+					SetExpanded(suite.FailedCount+suite.ErrorCount > 0)
+
+				for _, testCase := range suite.TestCases {
+					suiteNode.AddChild(tview.NewTreeNode(fmt.Sprintf("%s %s  [gray]%s[-]",
+						statusIcon(testCase.Status), tview.Escape(testCase.Name), formatSeconds(testCase.ExecutionTime))).
+						SetReference(testCase))
+				}
+				root.AddChild(suiteNode)
+			}
+
+			if len(report.TestSuites) == 0 {
+				root.AddChild(tview.NewTreeNode("No test reports were uploaded for this pipeline").
+					SetColor(tcell.ColorGray).
+					SetSelectable(false))
+				caseView.SetText("")
+				return
+			}
+			if tree.GetCurrentNode() == root {
+				tree.SetCurrentNode(root.GetChildren()[0])
+			}
+		}, nil
+	}
+
+	tree.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch {
+		case event.Key() == tcell.KeyEsc:
+			back()
+			return nil
+		case isRefreshKey(event):
+			fetchInBackground(app, statusBar, "Refreshing...", reloadReport)
+			return nil
+		case event.Key() == tcell.KeyTab:
+			app.SetFocus(caseView)
+			return nil
+		}
+		return event
+	})
+
+	caseView.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyEsc || event.Key() == tcell.KeyTab {
+			app.SetFocus(tree)
+			return nil
+		}
+		return event
+	})
+
+	flex := tview.NewFlex().
+		SetDirection(tview.FlexRow).
+		AddItem(summary, 1, 0, false).
+		AddItem(tview.NewFlex().
+			AddItem(tree, 0, 1, true).
+			AddItem(caseView, 0, 1, false), 0, 1, true).
+		AddItem(statusBar, 1, 0, false).
+		AddItem(tview.NewButton("ENTER - Expand/Collapse   TAB - Scroll test case   ESC - Back").SetSelectedFunc(back), 1, 0, false)
+
+	routes.show("test-report/"+projectID+"/"+pipelineID, flex, tree)
+
+	fetchInBackground(app, statusBar, "Loading test report...", reloadReport)
+}
+
+// testCounts renders the number of passed, failed, errored and skipped tests.
+func testCounts(success, failed, errored, skipped int) string {
+	counts := fmt.Sprintf("%s %d  %s %d", statusIcon("success"), success, statusIcon("failed"), failed)
+	if errored > 0 {
+		counts += fmt.Sprintf("  %s %d", statusIcon("error"), errored)
+	}
+	return counts + fmt.Sprintf("  %s %d", statusIcon("skipped"), skipped)
+}
+
+func formatTestCase(testCase *gitlab.PipelineTestCases) string {
+	var text strings.Builder
+	field := func(name, value string) {
+		fmt.Fprintf(&text, "[yellow]%-9s[-] %s\n", name, value)
+	}
+
+	field("Name", tview.Escape(testCase.Name))
+	field("Status", statusLabel(testCase.Status))
+	field("Class", tview.Escape(testCase.Classname))
+	if testCase.File != "" {
+		field("File", tview.Escape(testCase.File))
+	}
+	field("Time", formatSeconds(testCase.ExecutionTime))
+	if failures := testCase.RecentFailures; failures != nil && failures.Count > 0 {
+		field("Flaky", fmt.Sprintf("failed %d times on %s recently", failures.Count, tview.Escape(failures.BaseBranch)))
+	}
+
+	if testCase.StackTrace != "" {
+		text.WriteString("\n[yellow]Failure[-]\n" + tview.Escape(testCase.StackTrace) + "\n")
+	}
+	if output, ok := testCase.SystemOutput.(string); ok && output != "" {
+		text.WriteString("\n[yellow]Output[-]\n" + tview.Escape(output) + "\n")
+	}
+
+	return text.String()
+}
+
+// formatSeconds renders a test duration, which unlike job durations is
+// usually well under a second.
+func formatSeconds(seconds float64) string {
+	return time.Duration(seconds * float64(time.Second)).Round(time.Millisecond).String()
+}
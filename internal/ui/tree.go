@@ -0,0 +1,623 @@
+// tree.go
+package ui
+
+import (
+	"fmt"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/xanzy/go-gitlab"
+)
+
+var (
+	lastSearchTerm string
+
+	// groupChildrenCache holds the subgroups and projects of every group
+	// expanded so far, keyed by group ID, so rebuilding the tree doesn't
+	// refetch them.
+	groupChildrenCache = map[int]*groupChildren{}
+)
+
+type groupChildren struct {
+	subgroups []*gitlab.Group
+	projects  []*gitlab.Project
+}
+
+// projectSection is the reference of a top-level tree node listing projects
+// that don't come from the group hierarchy, such as starred projects.
+type projectSection struct {
+	title    string
+	options  *gitlab.ListProjectsOptions
+	projects []*gitlab.Project
+	loaded   bool
+}
+
+func showGroupSearchInput(app *tview.Application) {
+	inputField := tview.NewInputField().
+		SetLabel("Enter Group Name: ")
+
+	inputField.SetDoneFunc(func(key tcell.Key) {
+		if key == tcell.KeyEnter {
+			searchTerm := inputField.GetText()
+			lastSearchTerm = searchTerm
+			showTree(app, searchTerm)
+		}
+	})
+
+	flex := tview.NewFlex().
+		SetDirection(tview.FlexRow).
+		AddItem(inputField, 0, 1, true)
+
+	routes.show("group-search", flex, inputField)
+}
+
+// showTree shows the group tree, limited to the groups matching searchTerm.
+func showTree(app *tview.Application, searchTerm string) {
+	routes.show("tree", buildTree(app, searchTerm), nil)
+}
+
+func buildTree(app *tview.Application, searchTerm string) *tview.Flex {
+	cancelPendingLoads()
+
+	root := tview.NewTreeNode("GitLab Pipelines").
+		SetColor(tcell.ColorYellow).
+		SetSelectable(false)
+
+	tree := tview.NewTreeView().
+		SetRoot(root).
+		SetCurrentNode(root).
+		SetTopLevel(1).
+		SetGraphicsColor(tcell.ColorOrange)
+
+	setKeybindContext(func() []string {
+		switch reference := tree.GetCurrentNode().GetReference().(type) {
+		case *gitlab.Group:
+			return groupKeybindEnv(reference)
+		case *gitlab.Project:
+			return projectKeybindEnv(reference)
+		}
+		return nil
+	})
+
+	tree.SetChangedFunc(func(node *tview.TreeNode) {
+		if project, ok := node.GetReference().(*gitlab.Project); ok {
+			prefetchProject(project)
+		}
+		prefetchVisibleGroups(app, tree)
+	})
+
+	tree.SetSelectedFunc(func(node *tview.TreeNode) {
+		switch reference := node.GetReference().(type) {
+		case *gitlab.Group:
+			expandGroupNode(app, node, reference)
+		case *projectSection:
+			expandProjectSection(app, node, reference)
+		case *gitlab.Project:
+			showPipelines(app, reference)
+		case retryLoadReference:
+			reference()
+		case allProjectsReference:
+			showAllProjects(app, func() {
+				showTree(app, lastSearchTerm)
+			})
+		}
+		prefetchVisibleGroups(app, tree)
+	})
+
+	favoritesNode := tview.NewTreeNode(" Favorites").
+		SetColor(tcell.ColorOrangeRed)
+	fillFavoriteProjects(favoritesNode)
+
+	filterField := tview.NewInputField().
+		SetLabel("/").
+		SetFieldBackgroundColor(tcell.ColorDarkGray).
+		SetFieldTextColor(tcell.ColorOrangeRed)
+
+	statusBar := newStatusBar()
+
+	flex := tview.NewFlex().
+		SetDirection(tview.FlexRow).
+		AddItem(tree, 0, 1, true).
+		AddItem(filterField, 0, 0, false).
+		AddItem(statusBar, 1, 0, false)
+
+	setRootChildren := func(groupsNode *tview.TreeNode) {
+		root.ClearChildren()
+		root.AddChild(favoritesNode)
+		if len(state.Recent) > 0 {
+			root.AddChild(buildRecentProjects())
+		}
+		root.AddChild(groupsNode)
+	}
+
+	var filter *treeFilter
+
+	filterField.SetChangedFunc(func(text string) {
+		if filter != nil {
+			filter.apply(text)
+			tree.SetCurrentNode(root)
+		}
+	})
+
+	filterField.SetDoneFunc(func(key tcell.Key) {
+		if key == tcell.KeyEsc && filter != nil {
+			filter.restore()
+			filter = nil
+			filterField.SetText("")
+		}
+		flex.ResizeItem(filterField, 0, 0)
+		app.SetFocus(tree)
+	})
+
+	tree.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyCtrlP {
+			showProjectFinder(app)
+			return nil
+		}
+		if event.Rune() == '/' {
+			if filter != nil {
+				filter.restore()
+			}
+			filter = newTreeFilter(root)
+			filter.apply(filterField.GetText())
+			flex.ResizeItem(filterField, 1, 0)
+			app.SetFocus(filterField)
+			return nil
+		}
+		if event.Rune() == 's' {
+			if project, ok := tree.GetCurrentNode().GetReference().(*gitlab.Project); ok {
+				showSchedules(app, project, func() {
+					showTree(app, lastSearchTerm)
+				})
+			}
+			return nil
+		}
+		if event.Rune() == 'T' {
+			if project, ok := tree.GetCurrentNode().GetReference().(*gitlab.Project); ok {
+				showTriggers(app, project, func() {
+					showTree(app, lastSearchTerm)
+				})
+			}
+			return nil
+		}
+		if event.Rune() == 'L' {
+			if project, ok := tree.GetCurrentNode().GetReference().(*gitlab.Project); ok {
+				showCILintForm(app, project, func() {
+					showTree(app, lastSearchTerm)
+				})
+			}
+			return nil
+		}
+		if event.Rune() == 'E' {
+			if project, ok := tree.GetCurrentNode().GetReference().(*gitlab.Project); ok {
+				showEditCIConfig(app, project, func() {
+					showTree(app, lastSearchTerm)
+				})
+			}
+			return nil
+		}
+		if event.Rune() == 'd' {
+			if project, ok := tree.GetCurrentNode().GetReference().(*gitlab.Project); ok {
+				showEnvironments(app, project, func() {
+					showTree(app, lastSearchTerm)
+				})
+			}
+			return nil
+		}
+		if event.Rune() == 'l' {
+			if project, ok := tree.GetCurrentNode().GetReference().(*gitlab.Project); ok {
+				showReleases(app, project, func() {
+					showTree(app, lastSearchTerm)
+				})
+			}
+			return nil
+		}
+		if event.Rune() == 'M' {
+			if project, ok := tree.GetCurrentNode().GetReference().(*gitlab.Project); ok {
+				showMergeRequests(app, project, func() {
+					showTree(app, lastSearchTerm)
+				})
+			}
+			return nil
+		}
+		if event.Rune() == 'a' {
+			if project, ok := tree.GetCurrentNode().GetReference().(*gitlab.Project); ok {
+				showDurations(app, project, func() {
+					showTree(app, lastSearchTerm)
+				})
+			}
+			return nil
+		}
+		if event.Rune() == 'F' {
+			if project, ok := tree.GetCurrentNode().GetReference().(*gitlab.Project); ok {
+				showFlakyJobs(app, project, func() {
+					showTree(app, lastSearchTerm)
+				})
+			}
+			return nil
+		}
+		if event.Rune() == 'S' {
+			if project, ok := tree.GetCurrentNode().GetReference().(*gitlab.Project); ok {
+				showSlowestJobs(app, project, func() {
+					showTree(app, lastSearchTerm)
+				})
+			}
+			return nil
+		}
+		if event.Rune() == 'H' {
+			back := func() {
+				showTree(app, lastSearchTerm)
+			}
+			switch reference := tree.GetCurrentNode().GetReference().(type) {
+			case *gitlab.Project:
+				showSuccessRate(app, nil, reference, successRateWindows[0], back)
+			case *gitlab.Group:
+				showSuccessRate(app, reference, nil, successRateWindows[0], back)
+			}
+			return nil
+		}
+		if event.Rune() == 'v' {
+			back := func() {
+				showTree(app, lastSearchTerm)
+			}
+			switch reference := tree.GetCurrentNode().GetReference().(type) {
+			case *gitlab.Project:
+				showVariables(app, projectVariableScope(reference), back)
+			case *gitlab.Group:
+				showVariables(app, groupVariableScope(reference), back)
+			}
+			return nil
+		}
+		if event.Rune() == 'u' {
+			back := func() {
+				showTree(app, lastSearchTerm)
+			}
+			switch reference := tree.GetCurrentNode().GetReference().(type) {
+			case *gitlab.Project:
+				showRunners(app, projectRunnerScope(reference), back)
+			case *gitlab.Group:
+				showRunners(app, groupRunnerScope(reference), back)
+			case instanceReference:
+				showRunners(app, instanceRunnerScope(), back)
+			}
+			return nil
+		}
+		if event.Rune() == 'o' {
+			switch reference := tree.GetCurrentNode().GetReference().(type) {
+			case *gitlab.Project:
+				openWebURL(app, reference.WebURL)
+			case *gitlab.Group:
+				openWebURL(app, reference.WebURL)
+			}
+			return nil
+		}
+		if event.Rune() == 'f' {
+			if project, ok := tree.GetCurrentNode().GetReference().(*gitlab.Project); ok {
+				if err := toggleFavoriteProject(project.ID, project.PathWithNamespace); err != nil {
+					showError(app, err, nil)
+				}
+				fillFavoriteProjects(favoritesNode)
+			}
+			return nil
+		}
+		if isRefreshKey(event) {
+			if filter != nil {
+				filter.restore()
+				filter = nil
+				filterField.SetText("")
+			}
+			invalidateTreeCaches()
+			fetchInBackground(app, statusBar, "Refreshing...", func() (func(), error) {
+				allGroups, err := listGroups()
+				if err != nil {
+					return nil, fmt.Errorf("fetching groups: %w", err)
+				}
+				user, err := loadCurrentUser()
+				if err != nil {
+					return nil, err
+				}
+				return func() {
+					instanceNode := newInstanceNode()
+					addAdminNodes(instanceNode, user)
+					addGroupNodes(instanceNode, allGroups, searchTerm)
+					setRootChildren(instanceNode)
+					tree.SetCurrentNode(root)
+					prefetchVisibleGroups(app, tree)
+				}, nil
+			})
+			return nil
+		}
+		return event
+	})
+
+	setRootChildren(buildGroups(app, tree, searchTerm))
+
+	return flex
+}
+
+// invalidateTreeCaches drops everything cached for the tree, in memory and
+// on disk, so the next expansion of each node hits the API again.
+func invalidateTreeCaches() {
+	groupChildrenCache = map[int]*groupChildren{}
+	projectSections = map[string]*projectSection{}
+	dropCache(treeCache)
+}
+
+// fillFavoriteProjects replaces the children of the favorites node with the
+// current favorites, or a hint on how to add one.
+func fillFavoriteProjects(favoritesNode *tview.TreeNode) {
+	favoritesNode.ClearChildren()
+
+	if len(state.Favorites) == 0 {
+		favoritesNode.AddChild(newEmptyNode("Press f on a project to pin it here"))
+		return
+	}
+
+	for _, favorite := range state.Favorites {
+		favoritesNode.AddChild(newProjectNode(favorite.Path, &gitlab.Project{
+			ID:                favorite.ID,
+			PathWithNamespace: favorite.Path,
+		}))
+	}
+}
+
+func buildRecentProjects() *tview.TreeNode {
+	root := tview.NewTreeNode(" Recent").
+		SetColor(tcell.ColorOrangeRed)
+
+	for _, recent := range state.Recent {
+		root.AddChild(newProjectNode(recent.Path, &gitlab.Project{
+			ID:                recent.ID,
+			PathWithNamespace: recent.Path,
+		}))
+	}
+
+	return root
+}
+
+// buildGroups returns the instance node and loads its groups in the
+// background.
+func buildGroups(app *tview.Application, tree *tview.TreeView, searchTerm string) *tview.TreeNode {
+	root := newInstanceNode()
+
+	loadChildren(app, root, func() (func(), error) {
+		allGroups, err := listGroups()
+		if err != nil {
+			return nil, fmt.Errorf("fetching groups: %w", err)
+		}
+		user, err := loadCurrentUser()
+		if err != nil {
+			return nil, err
+		}
+		return func() {
+			addAdminNodes(root, user)
+			addGroupNodes(root, allGroups, searchTerm)
+			prefetchVisibleGroups(app, tree)
+		}, nil
+	})
+
+	return root
+}
+
+func newInstanceNode() *tview.TreeNode {
+	root := tview.NewTreeNode("󰮠 Instance: " + gitlabURL).
+		SetColor(tcell.ColorOrangeRed).
+		SetReference(instanceReference{})
+
+	root.AddChild(newProjectSectionNode(" My projects", &gitlab.ListProjectsOptions{
+		Membership: gitlab.Bool(true),
+	}))
+	root.AddChild(newProjectSectionNode("★ Starred", &gitlab.ListProjectsOptions{
+		Starred: gitlab.Bool(true),
+	}))
+
+	return root
+}
+
+func listGroups() ([]*gitlab.Group, error) {
+	return shareFetch("groups", func() ([]*gitlab.Group, error) {
+		return cachedFetch(treeCache, "groups", func() ([]*gitlab.Group, error) {
+			return listAllPages(func(listOptions gitlab.ListOptions) ([]*gitlab.Group, *gitlab.Response, error) {
+				return gitlabClient.Groups().ListGroups(&gitlab.ListGroupsOptions{ListOptions: listOptions})
+			})
+		})
+	})
+}
+
+func addGroupNodes(root *tview.TreeNode, allGroups []*gitlab.Group, searchTerm string) {
+	for _, group := range topLevelGroups(allGroups, searchTerm) {
+		root.AddChild(newGroupNode(group))
+	}
+}
+
+// projectSections is kept across tree rebuilds so sections that were already
+// loaded don't hit the API again.
+var projectSections = map[string]*projectSection{}
+
+func newProjectSectionNode(title string, options *gitlab.ListProjectsOptions) *tview.TreeNode {
+	section, ok := projectSections[title]
+	if !ok {
+		section = &projectSection{title: title, options: options}
+		projectSections[title] = section
+	}
+
+	return tview.NewTreeNode(title).
+		SetColor(tcell.ColorWhiteSmoke).
+		SetReference(section).
+		SetExpanded(false)
+}
+
+func newGroupNode(group *gitlab.Group) *tview.TreeNode {
+	return tview.NewTreeNode(" Group: " + group.Name).
+		SetColor(tcell.ColorWhiteSmoke).
+		SetReference(group).
+		SetExpanded(false)
+}
+
+// expandGroupNode toggles a group node, fetching its subgroups and projects
+// in the background the first time it is opened. Results are cached per group.
+func expandGroupNode(app *tview.Application, groupNode *tview.TreeNode, group *gitlab.Group) {
+	if len(groupNode.GetChildren()) > 0 {
+		groupNode.SetExpanded(!groupNode.IsExpanded())
+		return
+	}
+
+	if children, ok := groupChildrenCache[group.ID]; ok {
+		addGroupChildren(groupNode, children)
+		groupNode.SetExpanded(true)
+		return
+	}
+
+	loadChildren(app, groupNode, func() (func(), error) {
+		children, err := fetchGroupChildren(group)
+		if err != nil {
+			return nil, fmt.Errorf("fetching children for group %s: %w", group.Name, err)
+		}
+
+		return func() {
+			groupChildrenCache[group.ID] = children
+			addGroupChildren(groupNode, children)
+		}, nil
+	})
+}
+
+// expandProjectSection toggles a project section node, listing its projects
+// the first time it is opened.
+func expandProjectSection(app *tview.Application, sectionNode *tview.TreeNode, section *projectSection) {
+	if len(sectionNode.GetChildren()) > 0 {
+		sectionNode.SetExpanded(!sectionNode.IsExpanded())
+		return
+	}
+
+	if section.loaded {
+		addProjectNodes(sectionNode, section.projects)
+		sectionNode.SetExpanded(true)
+		return
+	}
+
+	loadChildren(app, sectionNode, func() (func(), error) {
+		projects, err := cachedFetch(treeCache, "sections/"+section.title+" "+filters.cacheKey(), func() ([]*gitlab.Project, error) {
+			return listAllPages(func(listOptions gitlab.ListOptions) ([]*gitlab.Project, *gitlab.Response, error) {
+				options := *section.options
+				options.ListOptions = listOptions
+				filters.applyToProjectsOptions(&options)
+				return gitlabClient.Projects().ListProjects(&options)
+			})
+		})
+		if err != nil {
+			return nil, fmt.Errorf("fetching %s: %w", section.title, err)
+		}
+
+		return func() {
+			section.projects = projects
+			section.loaded = true
+			addProjectNodes(sectionNode, projects)
+		}, nil
+	})
+}
+
+// retryLoadReference is the reference of the node left in place of children
+// whose load was canceled. Selecting it loads them again.
+type retryLoadReference func()
+
+// loadChildren shows a loading placeholder under node and runs fetch in the
+// background. The function returned by fetch adds the real children and is
+// run on the UI goroutine once the placeholder has been removed.
+func loadChildren(app *tview.Application, node *tview.TreeNode, fetch func() (func(), error)) {
+	loadingNode := tview.NewTreeNode("Loading...").
+		SetColor(tcell.ColorGray).
+		SetSelectable(false)
+	node.AddChild(loadingNode).SetExpanded(true)
+
+	generation := loadGeneration
+	go func() {
+		addChildren, err := fetch()
+
+		app.QueueUpdateDraw(func() {
+			node.RemoveChild(loadingNode)
+			if err != nil && generation != loadGeneration {
+				// The reads were canceled when the user left, they
+				// are made again when the user asks for them.
+				var retryNode *tview.TreeNode
+				retryNode = tview.NewTreeNode("Not loaded, press Enter to load").
+					SetColor(tcell.ColorGray).
+					SetReference(retryLoadReference(func() {
+						node.RemoveChild(retryNode)
+						loadChildren(app, node, fetch)
+					}))
+				node.AddChild(retryNode)
+				return
+			}
+			if err != nil {
+				showError(app, err, func() {
+					loadChildren(app, node, fetch)
+				})
+				return
+			}
+			addChildren()
+		})
+	}()
+}
+
+func fetchGroupChildren(group *gitlab.Group, options ...gitlab.RequestOptionFunc) (*groupChildren, error) {
+	return shareFetch(fmt.Sprintf("groups/%d/children", group.ID), func() (*groupChildren, error) {
+		subgroups, err := cachedFetch(treeCache, fmt.Sprintf("groups/%d/subgroups", group.ID), func() ([]*gitlab.Group, error) {
+			return listAllPages(func(listOptions gitlab.ListOptions) ([]*gitlab.Group, *gitlab.Response, error) {
+				return gitlabClient.Groups().ListSubGroups(group.ID, &gitlab.ListSubGroupsOptions{ListOptions: listOptions}, options...)
+			})
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		projects, err := cachedFetch(treeCache, fmt.Sprintf("groups/%d/projects %s", group.ID, filters.cacheKey()), func() ([]*gitlab.Project, error) {
+			return listAllPages(func(listOptions gitlab.ListOptions) ([]*gitlab.Project, *gitlab.Response, error) {
+				return gitlabClient.Groups().ListGroupProjects(group.ID, filters.groupProjectsOptions(listOptions), options...)
+			})
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		return &groupChildren{subgroups: subgroups, projects: projects}, nil
+	})
+}
+
+func addGroupChildren(groupNode *tview.TreeNode, children *groupChildren) {
+	for _, subgroup := range children.subgroups {
+		groupNode.AddChild(newGroupNode(subgroup))
+	}
+
+	for _, project := range children.projects {
+		groupNode.AddChild(newProjectNode(project.Name, project))
+	}
+
+	if len(children.subgroups) == 0 && len(children.projects) == 0 {
+		groupNode.AddChild(newEmptyNode("No projects or subgroups in this group"))
+	}
+}
+
+// addProjectNodes adds projects from outside the group hierarchy, labelled
+// with their full path since their namespace isn't visible in the tree.
+func addProjectNodes(node *tview.TreeNode, projects []*gitlab.Project) {
+	for _, project := range projects {
+		node.AddChild(newProjectNode(project.PathWithNamespace, project))
+	}
+
+	if len(projects) == 0 {
+		node.AddChild(newEmptyNode("No projects here yet"))
+	}
+}
+
+// newEmptyNode returns a greyed-out placeholder for a node without children.
+func newEmptyNode(text string) *tview.TreeNode {
+	return tview.NewTreeNode(text).
+		SetColor(tcell.ColorGray).
+		SetSelectable(false)
+}
+
+func newProjectNode(label string, project *gitlab.Project) *tview.TreeNode {
+	return tview.NewTreeNode("Project: " + label).
+		SetColor(tcell.ColorDarkGrey).
+		SetReference(project)
+}
@@ -0,0 +1,110 @@
+// treefilter.go
+package ui
+
+import (
+	"strings"
+
+	"github.com/rivo/tview"
+)
+
+// treeFilter narrows a tree down to the nodes matching a search term. The
+// original text, children and expansion of every node are remembered when the
+// filter is created so the full tree can be restored afterwards.
+type treeFilter struct {
+	root     *tview.TreeNode
+	original map[*tview.TreeNode]treeNodeState
+}
+
+type treeNodeState struct {
+	text     string
+	children []*tview.TreeNode
+	expanded bool
+}
+
+func newTreeFilter(root *tview.TreeNode) *treeFilter {
+	f := &treeFilter{
+		root:     root,
+		original: map[*tview.TreeNode]treeNodeState{},
+	}
+
+	root.Walk(func(node, parent *tview.TreeNode) bool {
+		f.original[node] = treeNodeState{
+			text:     node.GetText(),
+			children: node.GetChildren(),
+			expanded: node.IsExpanded(),
+		}
+		return true
+	})
+
+	return f
+}
+
+// apply restores the full tree and then keeps only the nodes whose text
+// contains term, along with their ancestors. Matching nodes keep all of their
+// children and have the matched text highlighted.
+func (f *treeFilter) apply(term string) {
+	f.restore()
+
+	term = strings.TrimSpace(term)
+	if term == "" {
+		return
+	}
+
+	var kept []*tview.TreeNode
+	for _, child := range f.original[f.root].children {
+		if f.filterNode(child, term) {
+			kept = append(kept, child)
+		}
+	}
+	f.root.SetChildren(kept)
+}
+
+func (f *treeFilter) filterNode(node *tview.TreeNode, term string) bool {
+	state := f.original[node]
+
+	matched := containsFold(state.text, term)
+	if matched {
+		node.SetText(highlightMatch(state.text, term))
+	}
+
+	var kept []*tview.TreeNode
+	for _, child := range state.children {
+		if f.filterNode(child, term) {
+			kept = append(kept, child)
+		}
+	}
+
+	if len(kept) > 0 {
+		node.SetChildren(kept).SetExpanded(true)
+	}
+
+	return matched || len(kept) > 0
+}
+
+// restore puts every node back the way it was when the filter was created.
+func (f *treeFilter) restore() {
+	for node, state := range f.original {
+		node.SetText(state.text).
+			SetChildren(state.children).
+			SetExpanded(state.expanded)
+	}
+}
+
+func containsFold(text, term string) bool {
+	return strings.Contains(strings.ToLower(text), strings.ToLower(term))
+}
+
+// highlightMatch wraps the first case-insensitive occurrence of term in text
+// in a color tag, escaping the rest of the text so it isn't parsed as tags.
+func highlightMatch(text, term string) string {
+	lowerText := strings.ToLower(text)
+	index := strings.Index(lowerText, strings.ToLower(term))
+	if index < 0 || len(lowerText) != len(text) {
+		return tview.Escape(text)
+	}
+
+	end := index + len(term)
+	return tview.Escape(text[:index]) +
+		"[yellow::b]" + tview.Escape(text[index:end]) + "[-::-]" +
+		tview.Escape(text[end:])
+}
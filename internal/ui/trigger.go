@@ -0,0 +1,151 @@
+// trigger.go
+package ui
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+// variableFlag collects the KEY=VALUE pipeline variables of a repeated flag.
+type variableFlag struct {
+	variableType gitlab.VariableTypeValue
+	variables    *[]*gitlab.PipelineVariableOptions
+}
+
+func (f variableFlag) String() string {
+	return ""
+}
+
+// Set adds a variable. Like in the run pipeline form, the value of a file
+// variable starting with @ names a local file whose contents are sent.
+func (f variableFlag) Set(value string) error {
+	key, value, ok := strings.Cut(value, "=")
+	if !ok || key == "" {
+		return fmt.Errorf("expected KEY=VALUE")
+	}
+	if f.variableType == gitlab.FileVariableType && strings.HasPrefix(value, "@") {
+		contents, err := os.ReadFile(strings.TrimPrefix(value, "@"))
+		if err != nil {
+			return fmt.Errorf("reading file variable %s: %w", key, err)
+		}
+		value = string(contents)
+	}
+	*f.variables = append(*f.variables, &gitlab.PipelineVariableOptions{
+		Key:          gitlab.String(key),
+		Value:        gitlab.String(value),
+		VariableType: gitlab.String(string(f.variableType)),
+	})
+	return nil
+}
+
+// setupTrigger sets up the trigger command, which creates a pipeline and,
+// with --wait or --tail, follows it until it finishes, exiting like the wait
+// command.
+func setupTrigger(flags *flag.FlagSet) func(args []string) error {
+	var variables []*gitlab.PipelineVariableOptions
+
+	project := flags.String("p", "", "project path or ID, e.g. group/project")
+	ref := flags.String("ref", "", "branch or tag to run the pipeline on")
+	flags.Var(variableFlag{gitlab.EnvVariableType, &variables}, "v", "pipeline variable `KEY=VALUE`, may be repeated")
+	flags.Var(variableFlag{gitlab.FileVariableType, &variables}, "file", "file variable `KEY=VALUE`, or KEY=@path to send a local file, may be repeated")
+	wait := flags.Bool("wait", false, "wait for the pipeline to finish")
+	tail := flags.Bool("tail", false, "print the logs of its jobs as they run until the pipeline finishes")
+	output := addOutputFlag(flags)
+	return func(args []string) error {
+		if err := requireFlag(flags, "p", *project); err != nil {
+			return err
+		}
+		if err := requireFlag(flags, "ref", *ref); err != nil {
+			return err
+		}
+		if err := validateOutputFormat(flags, *output); err != nil {
+			return err
+		}
+
+		pipeline, err := createPipeline(*project, *ref, variables)
+		if err != nil {
+			return err
+		}
+		logInfo("pipeline created", "project", *project, "ref", *ref, "pipeline", pipeline.ID)
+
+		if !*wait && !*tail {
+			return writeOutput(os.Stdout, *output, newPipelineRecord(pipeline), func(w io.Writer) error {
+				_, err := fmt.Fprintf(w, "Pipeline #%d created on %s: %s\n", pipeline.ID, pipeline.Ref, pipeline.WebURL)
+				return err
+			})
+		}
+
+		fmt.Fprintf(os.Stderr, "Pipeline #%d created on %s: %s\n", pipeline.ID, pipeline.Ref, pipeline.WebURL)
+		progress := io.Writer(os.Stdout)
+		if *output != "table" {
+			progress = os.Stderr
+		}
+		if *tail {
+			pipeline, err = followPipelineLogs(progress, *project, pipeline)
+		} else {
+			pipeline, err = waitForPipeline(progress, *project, pipeline)
+		}
+		if err != nil {
+			return err
+		}
+		if *tail {
+			fmt.Fprintf(os.Stderr, "Pipeline #%d finished: %s\n", pipeline.ID, pipeline.Status)
+		}
+
+		if *output != "table" {
+			if err := writeOutput(os.Stdout, *output, newPipelineRecord(pipeline), nil); err != nil {
+				return err
+			}
+		}
+		return pipelineExitCode(pipeline)
+	}
+}
+
+// followPipelineLogs writes the log of every job of pipeline to out as it
+// runs, one job after the other in the order they were created, and returns
+// the pipeline once it finished. Jobs that run in parallel are written once
+// the one before them finished.
+func followPipelineLogs(out io.Writer, projectID string, pipeline *gitlab.Pipeline) (*gitlab.Pipeline, error) {
+	followed := map[int]bool{}
+	for {
+		jobs, err := listPipelineJobs(projectID, strconv.Itoa(pipeline.ID))
+		if err != nil {
+			return nil, fmt.Errorf("fetching jobs of pipeline #%d: %w", pipeline.ID, err)
+		}
+
+		var next *gitlab.Job
+		for _, job := range jobsOldestFirst(jobs) {
+			if !followed[job.ID] && job.StartedAt != nil {
+				next = job
+				break
+			}
+		}
+		if next != nil {
+			followed[next.ID] = true
+			fmt.Fprintf(os.Stderr, "==> Job %d %s (%s) <==\n", next.ID, next.Name, next.Stage)
+			job, err := followJobLog(out, projectID, next)
+			if err != nil {
+				return nil, err
+			}
+			fmt.Fprintf(os.Stderr, "==> Job %d finished: %s <==\n", job.ID, job.Status)
+			continue
+		}
+
+		current, _, err := gitlabClient.Pipelines().GetPipeline(projectID, pipeline.ID)
+		if err != nil {
+			return nil, fmt.Errorf("fetching pipeline #%d: %w", pipeline.ID, err)
+		}
+		if !cancelableStatuses[current.Status] {
+			return current, nil
+		}
+		pipeline = current
+		time.Sleep(cliPollInterval)
+	}
+}
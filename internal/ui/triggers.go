@@ -0,0 +1,230 @@
+// triggers.go
+package ui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/xanzy/go-gitlab"
+)
+
+func listTriggers(projectID int) ([]*gitlab.PipelineTrigger, error) {
+	triggers, err := listAllPages(func(listOptions gitlab.ListOptions) ([]*gitlab.PipelineTrigger, *gitlab.Response, error) {
+		return gitlabClient.PipelineTriggers().ListPipelineTriggers(projectID, (*gitlab.ListPipelineTriggersOptions)(&listOptions))
+	})
+	if err != nil {
+		return nil, fmt.Errorf("fetching trigger tokens: %w", err)
+	}
+	return triggers, nil
+}
+
+func createTrigger(projectID int, description string) (*gitlab.PipelineTrigger, error) {
+	trigger, _, err := gitlabClient.PipelineTriggers().AddPipelineTrigger(projectID, &gitlab.AddPipelineTriggerOptions{
+		Description: gitlab.String(description),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("creating trigger token %s: %w", description, err)
+	}
+	return trigger, nil
+}
+
+func revokeTrigger(projectID, triggerID int) error {
+	_, err := gitlabClient.PipelineTriggers().DeletePipelineTrigger(projectID, triggerID)
+	if err != nil {
+		return fmt.Errorf("revoking trigger token %d: %w", triggerID, err)
+	}
+	return nil
+}
+
+// triggerPipeline runs a pipeline for ref the way an API trigger would, with
+// token instead of the user's own credentials. The trigger API only takes
+// plain variables, so file variables are passed by value.
+func triggerPipeline(projectID int, token, ref string, variables []*gitlab.PipelineVariableOptions) (*gitlab.Pipeline, error) {
+	options := &gitlab.RunPipelineTriggerOptions{
+		Ref:   gitlab.String(ref),
+		Token: gitlab.String(token),
+	}
+	if len(variables) > 0 {
+		options.Variables = make(map[string]string, len(variables))
+		for _, variable := range variables {
+			options.Variables[*variable.Key] = *variable.Value
+		}
+	}
+
+	pipeline, _, err := gitlabClient.PipelineTriggers().RunPipelineTrigger(projectID, options)
+	if err != nil {
+		return nil, fmt.Errorf("triggering pipeline for ref %s: %w", ref, err)
+	}
+	return pipeline, nil
+}
+
+// showTriggers lists the pipeline trigger tokens of project. n creates a
+// token, D revokes the selected one, t runs a pipeline with it and v reveals
+// the tokens. back is called on Esc.
+func showTriggers(app *tview.Application, project *gitlab.Project, back func()) {
+	cancelPendingLoads()
+
+	projectID := project.ID
+
+	table := tview.NewTable().
+		SetSelectable(true, false).
+		SetFixed(1, 0)
+	table.SetBorder(true).
+		SetTitle(fmt.Sprintf(" Trigger tokens of %s ", tview.Escape(project.PathWithNamespace)))
+	statusBar := newStatusBar()
+
+	var triggers []*gitlab.PipelineTrigger
+	reveal := false
+
+	fillTable := func() {
+		row, _ := table.GetSelection()
+		table.Clear()
+
+		for column, title := range []string{"Description", "Token", "Owner", "Last used", "Created"} {
+			table.SetCell(0, column, tview.NewTableCell(title).
+				SetTextColor(tcell.ColorYellow).
+				SetAttributes(tcell.AttrBold).
+				SetSelectable(false))
+		}
+
+		for i, trigger := range triggers {
+			token := strings.Repeat("*", 8)
+			if reveal {
+				token = tview.Escape(trigger.Token)
+			}
+			owner := "-"
+			if trigger.Owner != nil {
+				owner = trigger.Owner.Username
+			}
+
+			table.SetCell(i+1, 0, tview.NewTableCell(tview.Escape(trigger.Description)).SetExpansion(1))
+			table.SetCell(i+1, 1, tview.NewTableCell(token))
+			table.SetCell(i+1, 2, tview.NewTableCell(tview.Escape(owner)))
+			table.SetCell(i+1, 3, tview.NewTableCell(formatTime(trigger.LastUsed)))
+			table.SetCell(i+1, 4, tview.NewTableCell(formatTime(trigger.CreatedAt)))
+		}
+
+		if len(triggers) == 0 {
+			table.SetCell(1, 0, tview.NewTableCell("No trigger tokens yet, press n to create one").
+				SetTextColor(tcell.ColorGray).
+				SetSelectable(false))
+			return
+		}
+		if row < 1 || row > len(triggers) {
+			row = 1
+		}
+		table.Select(row, 0)
+	}
+
+	reloadTriggers := func() (func(), error) {
+		loaded, err := listTriggers(projectID)
+		if err != nil {
+			return nil, err
+		}
+		return func() {
+			triggers = loaded
+			fillTable()
+		}, nil
+	}
+
+	selected := func() *gitlab.PipelineTrigger {
+		row, _ := table.GetSelection()
+		if row < 1 || row > len(triggers) {
+			return nil
+		}
+		return triggers[row-1]
+	}
+
+	var flex *tview.Flex
+
+	returnToTriggers := func() {
+		routes.show("triggers/"+strconv.Itoa(project.ID), flex, table)
+	}
+
+	showCreateForm := func() {
+		form := tview.NewForm()
+		form.SetBorder(true).
+			SetTitle(" New trigger token ")
+		form.AddInputField("Description", "", 40, nil, nil).
+			AddButton("Create", func() {
+				description := strings.TrimSpace(form.GetFormItemByLabel("Description").(*tview.InputField).GetText())
+				if description == "" {
+					showError(app, fmt.Errorf("a trigger token needs a description"), nil)
+					return
+				}
+				returnToTriggers()
+				fetchInBackground(app, statusBar, "Creating trigger token...", func() (func(), error) {
+					trigger, err := createTrigger(projectID, description)
+					if err != nil {
+						return nil, err
+					}
+					loaded, err := listTriggers(projectID)
+					if err != nil {
+						return nil, err
+					}
+					return func() {
+						triggers = loaded
+						fillTable()
+						showInfo(app, fmt.Sprintf("Trigger token %s created: %s", description, trigger.Token))
+					}, nil
+				})
+			}).
+			AddButton("Cancel", returnToTriggers).
+			SetCancelFunc(returnToTriggers)
+		routes.show("trigger-form/"+strconv.Itoa(project.ID), form, form)
+	}
+
+	table.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch {
+		case event.Key() == tcell.KeyEsc:
+			back()
+			return nil
+		case isRefreshKey(event):
+			fetchInBackground(app, statusBar, "Refreshing...", reloadTriggers)
+			return nil
+		case event.Rune() == 'n':
+			showCreateForm()
+			return nil
+		case event.Rune() == 'v':
+			reveal = !reveal
+			fillTable()
+			return nil
+		}
+
+		trigger := selected()
+		if trigger == nil {
+			return event
+		}
+
+		switch event.Rune() {
+		case 't':
+			run := func(ref string, variables []*gitlab.PipelineVariableOptions) (*gitlab.Pipeline, error) {
+				return triggerPipeline(projectID, trigger.Token, ref, variables)
+			}
+			showRunPipelineForm(app, "Trigger pipeline with "+trigger.Description, strconv.Itoa(projectID), project.DefaultBranch, run, func() {
+				showTriggers(app, project, back)
+			})
+			return nil
+		case 'D':
+			confirmAction(app, fmt.Sprintf("Revoke trigger token %s?\nPipelines can no longer be triggered with it.", trigger.Description), "Revoke", func() {
+				runAction(app, statusBar, "Revoking trigger token...", fmt.Sprintf("Trigger token %s revoked", trigger.Description),
+					func() error { return revokeTrigger(projectID, trigger.ID) }, reloadTriggers)
+			})
+			return nil
+		}
+		return event
+	})
+
+	flex = tview.NewFlex().
+		SetDirection(tview.FlexRow).
+		AddItem(table, 0, 1, true).
+		AddItem(statusBar, 1, 0, false).
+		AddItem(tview.NewButton("n - New   t - Trigger pipeline   v - Show tokens   D - Revoke   ESC - Back").SetSelectedFunc(back), 1, 0, false)
+
+	routes.show("triggers/"+strconv.Itoa(project.ID), flex, table)
+
+	fetchInBackground(app, statusBar, "Loading trigger tokens...", reloadTriggers)
+}
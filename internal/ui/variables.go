@@ -0,0 +1,413 @@
+// variables.go
+package ui
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/xanzy/go-gitlab"
+)
+
+// ciVariable is a CI/CD variable as the variables screen edits it.
+type ciVariable struct {
+	key              string
+	value            string
+	variableType     string
+	protected        bool
+	masked           bool
+	raw              bool
+	environmentScope string
+}
+
+// variableScope is where the variables on the variables screen live. update
+// and remove find the variable by the key and environment scope of old. The
+// variables of plain scopes, like those of a pipeline schedule, only have a
+// key, value and type.
+type variableScope struct {
+	title  string
+	plain  bool
+	list   func() ([]ciVariable, error)
+	create func(variable ciVariable) error
+	update func(old, variable ciVariable) error
+	remove func(variable ciVariable) error
+}
+
+func projectVariableScope(project *gitlab.Project) variableScope {
+	projectID := project.ID
+
+	return variableScope{
+		title: project.PathWithNamespace,
+		list: func() ([]ciVariable, error) {
+			projectVariables, err := listAllPages(func(listOptions gitlab.ListOptions) ([]*gitlab.ProjectVariable, *gitlab.Response, error) {
+				return gitlabClient.ProjectVariables().ListVariables(projectID, (*gitlab.ListProjectVariablesOptions)(&listOptions))
+			})
+			if err != nil {
+				return nil, fmt.Errorf("fetching variables of project %s: %w", project.PathWithNamespace, err)
+			}
+
+			variables := make([]ciVariable, len(projectVariables))
+			for i, v := range projectVariables {
+				variables[i] = ciVariable{
+					key:              v.Key,
+					value:            v.Value,
+					variableType:     string(v.VariableType),
+					protected:        v.Protected,
+					masked:           v.Masked,
+					raw:              v.Raw,
+					environmentScope: v.EnvironmentScope,
+				}
+			}
+			return variables, nil
+		},
+		create: func(variable ciVariable) error {
+			variableType := gitlab.VariableTypeValue(variable.variableType)
+			_, _, err := gitlabClient.ProjectVariables().CreateVariable(projectID, &gitlab.CreateProjectVariableOptions{
+				Key:              gitlab.String(variable.key),
+				Value:            gitlab.String(variable.value),
+				VariableType:     &variableType,
+				Protected:        gitlab.Bool(variable.protected),
+				Masked:           gitlab.Bool(variable.masked),
+				Raw:              gitlab.Bool(variable.raw),
+				EnvironmentScope: gitlab.String(variable.environmentScope),
+			})
+			if err != nil {
+				return fmt.Errorf("creating variable %s: %w", variable.key, err)
+			}
+			return nil
+		},
+		update: func(old, variable ciVariable) error {
+			variableType := gitlab.VariableTypeValue(variable.variableType)
+			_, _, err := gitlabClient.ProjectVariables().UpdateVariable(projectID, old.key, &gitlab.UpdateProjectVariableOptions{
+				Value:            gitlab.String(variable.value),
+				VariableType:     &variableType,
+				Protected:        gitlab.Bool(variable.protected),
+				Masked:           gitlab.Bool(variable.masked),
+				Raw:              gitlab.Bool(variable.raw),
+				EnvironmentScope: gitlab.String(variable.environmentScope),
+				Filter:           &gitlab.VariableFilter{EnvironmentScope: old.environmentScope},
+			})
+			if err != nil {
+				return fmt.Errorf("updating variable %s: %w", old.key, err)
+			}
+			return nil
+		},
+		remove: func(variable ciVariable) error {
+			_, err := gitlabClient.ProjectVariables().RemoveVariable(projectID, variable.key, &gitlab.RemoveProjectVariableOptions{
+				Filter: &gitlab.VariableFilter{EnvironmentScope: variable.environmentScope},
+			})
+			if err != nil {
+				return fmt.Errorf("deleting variable %s: %w", variable.key, err)
+			}
+			return nil
+		},
+	}
+}
+
+// groupVariableFilter picks a group variable by environment scope when the
+// same key is defined for several. go-gitlab only supports it for project
+// variables, so group requests are built by hand to add it.
+type groupVariableFilter struct {
+	gitlab.UpdateGroupVariableOptions
+	Filter *gitlab.VariableFilter `url:"filter,omitempty" json:"filter,omitempty"`
+}
+
+func groupVariableScope(group *gitlab.Group) variableScope {
+	groupID := group.ID
+
+	// send makes a request for the variable key of the group with options,
+	// which for DELETE are sent in the query string.
+	send := func(method, key string, options interface{}) error {
+		path := fmt.Sprintf("groups/%d/variables/%s", groupID, url.PathEscape(key))
+		_, err := gitlabClient.Send(method, path, options, nil)
+		return err
+	}
+
+	return variableScope{
+		title: group.FullPath,
+		list: func() ([]ciVariable, error) {
+			groupVariables, err := listAllPages(func(listOptions gitlab.ListOptions) ([]*gitlab.GroupVariable, *gitlab.Response, error) {
+				return gitlabClient.GroupVariables().ListVariables(groupID, (*gitlab.ListGroupVariablesOptions)(&listOptions))
+			})
+			if err != nil {
+				return nil, fmt.Errorf("fetching variables of group %s: %w", group.FullPath, err)
+			}
+
+			variables := make([]ciVariable, len(groupVariables))
+			for i, v := range groupVariables {
+				variables[i] = ciVariable{
+					key:              v.Key,
+					value:            v.Value,
+					variableType:     string(v.VariableType),
+					protected:        v.Protected,
+					masked:           v.Masked,
+					raw:              v.Raw,
+					environmentScope: v.EnvironmentScope,
+				}
+			}
+			return variables, nil
+		},
+		create: func(variable ciVariable) error {
+			variableType := gitlab.VariableTypeValue(variable.variableType)
+			_, _, err := gitlabClient.GroupVariables().CreateVariable(groupID, &gitlab.CreateGroupVariableOptions{
+				Key:              gitlab.String(variable.key),
+				Value:            gitlab.String(variable.value),
+				VariableType:     &variableType,
+				Protected:        gitlab.Bool(variable.protected),
+				Masked:           gitlab.Bool(variable.masked),
+				Raw:              gitlab.Bool(variable.raw),
+				EnvironmentScope: gitlab.String(variable.environmentScope),
+			})
+			if err != nil {
+				return fmt.Errorf("creating variable %s: %w", variable.key, err)
+			}
+			return nil
+		},
+		update: func(old, variable ciVariable) error {
+			variableType := gitlab.VariableTypeValue(variable.variableType)
+			err := send(http.MethodPut, old.key, &groupVariableFilter{
+				UpdateGroupVariableOptions: gitlab.UpdateGroupVariableOptions{
+					Value:            gitlab.String(variable.value),
+					VariableType:     &variableType,
+					Protected:        gitlab.Bool(variable.protected),
+					Masked:           gitlab.Bool(variable.masked),
+					Raw:              gitlab.Bool(variable.raw),
+					EnvironmentScope: gitlab.String(variable.environmentScope),
+				},
+				Filter: &gitlab.VariableFilter{EnvironmentScope: old.environmentScope},
+			})
+			if err != nil {
+				return fmt.Errorf("updating variable %s: %w", old.key, err)
+			}
+			return nil
+		},
+		remove: func(variable ciVariable) error {
+			err := send(http.MethodDelete, variable.key, &groupVariableFilter{
+				Filter: &gitlab.VariableFilter{EnvironmentScope: variable.environmentScope},
+			})
+			if err != nil {
+				return fmt.Errorf("deleting variable %s: %w", variable.key, err)
+			}
+			return nil
+		},
+	}
+}
+
+// showVariables lists the CI/CD variables of scope with their values hidden.
+// n adds a variable, Enter edits the selected one, D deletes it and v shows
+// or hides the values. back is called on Esc.
+func showVariables(app *tview.Application, scope variableScope, back func()) {
+	cancelPendingLoads()
+
+	table := tview.NewTable().
+		SetSelectable(true, false).
+		SetFixed(1, 0)
+	table.SetBorder(true).
+		SetTitle(fmt.Sprintf(" CI/CD variables of %s ", tview.Escape(scope.title)))
+	statusBar := newStatusBar()
+
+	var variables []ciVariable
+	reveal := false
+
+	fillTable := func() {
+		row, _ := table.GetSelection()
+		table.Clear()
+
+		columns := []string{"Key", "Value", "Type", "Protected", "Masked", "Environments"}
+		if scope.plain {
+			columns = columns[:3]
+		}
+		for column, title := range columns {
+			table.SetCell(0, column, tview.NewTableCell(title).
+				SetTextColor(tcell.ColorYellow).
+				SetAttributes(tcell.AttrBold).
+				SetSelectable(false))
+		}
+
+		for i, variable := range variables {
+			value := strings.Repeat("*", 8)
+			if reveal {
+				value = tview.Escape(strings.ReplaceAll(variable.value, "\n", " "))
+			}
+			table.SetCell(i+1, 0, tview.NewTableCell(tview.Escape(variable.key)))
+			table.SetCell(i+1, 1, tview.NewTableCell(value).SetMaxWidth(40).SetExpansion(1))
+			table.SetCell(i+1, 2, tview.NewTableCell(variable.variableType))
+			if scope.plain {
+				continue
+			}
+			table.SetCell(i+1, 3, tview.NewTableCell(yesNo(variable.protected)))
+			table.SetCell(i+1, 4, tview.NewTableCell(yesNo(variable.masked)))
+			table.SetCell(i+1, 5, tview.NewTableCell(tview.Escape(variable.environmentScope)))
+		}
+
+		if len(variables) == 0 {
+			table.SetCell(1, 0, tview.NewTableCell("No variables yet, press n to add one").
+				SetTextColor(tcell.ColorGray).
+				SetSelectable(false))
+			return
+		}
+		if row < 1 || row > len(variables) {
+			row = 1
+		}
+		table.Select(row, 0)
+	}
+
+	reloadVariables := func() (func(), error) {
+		loaded, err := scope.list()
+		if err != nil {
+			return nil, err
+		}
+		return func() {
+			variables = loaded
+			fillTable()
+		}, nil
+	}
+
+	selected := func() (ciVariable, bool) {
+		row, _ := table.GetSelection()
+		if row < 1 || row > len(variables) {
+			return ciVariable{}, false
+		}
+		return variables[row-1], true
+	}
+
+	var flex *tview.Flex
+
+	showForm := func(old *ciVariable) {
+		showVariableForm(app, old, scope.plain, func(variable ciVariable) {
+			routes.show("variables/"+scope.title, flex, table)
+			if old == nil {
+				runAction(app, statusBar, "Creating variable...", fmt.Sprintf("Variable %s created", variable.key),
+					func() error { return scope.create(variable) }, reloadVariables)
+				return
+			}
+			runAction(app, statusBar, "Updating variable...", fmt.Sprintf("Variable %s updated", variable.key),
+				func() error { return scope.update(*old, variable) }, reloadVariables)
+		}, func() {
+			routes.show("variables/"+scope.title, flex, table)
+		})
+	}
+
+	table.SetSelectedFunc(func(row, column int) {
+		if variable, ok := selected(); ok {
+			showForm(&variable)
+		}
+	})
+
+	table.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch {
+		case event.Key() == tcell.KeyEsc:
+			back()
+			return nil
+		case isRefreshKey(event):
+			fetchInBackground(app, statusBar, "Refreshing...", reloadVariables)
+			return nil
+		case event.Rune() == 'n':
+			showForm(nil)
+			return nil
+		case event.Rune() == 'v':
+			reveal = !reveal
+			fillTable()
+			return nil
+		case event.Rune() == 'D':
+			variable, ok := selected()
+			if !ok {
+				return nil
+			}
+			message := fmt.Sprintf("Delete variable %s (%s)?", variable.key, variable.environmentScope)
+			if scope.plain {
+				message = fmt.Sprintf("Delete variable %s?", variable.key)
+			}
+			confirmAction(app, message, "Delete", func() {
+				runAction(app, statusBar, "Deleting variable...", fmt.Sprintf("Variable %s deleted", variable.key),
+					func() error { return scope.remove(variable) }, reloadVariables)
+			})
+			return nil
+		}
+		return event
+	})
+
+	flex = tview.NewFlex().
+		SetDirection(tview.FlexRow).
+		AddItem(table, 0, 1, true).
+		AddItem(statusBar, 1, 0, false).
+		AddItem(tview.NewButton("ENTER - Edit   n - New   D - Delete   v - Show values   ESC - Back").SetSelectedFunc(back), 1, 0, false)
+
+	routes.show("variables/"+scope.title, flex, table)
+
+	fetchInBackground(app, statusBar, "Loading variables...", reloadVariables)
+}
+
+// showVariableForm edits old, or a new variable if old is nil, and passes the
+// result to save. The key of an existing variable can't be changed. A plain
+// variable only has a key, value and type. cancel is called on Esc or Cancel.
+func showVariableForm(app *tview.Application, old *ciVariable, plain bool, save func(variable ciVariable), cancel func()) {
+	variable := ciVariable{variableType: string(gitlab.EnvVariableType), environmentScope: "*"}
+	title := " New variable "
+	if old != nil {
+		variable = *old
+		title = " Edit variable "
+	}
+
+	typeIndex := 0
+	for i, variableType := range variableTypes {
+		if variableType == variable.variableType {
+			typeIndex = i
+		}
+	}
+
+	form := tview.NewForm()
+	form.SetBorder(true).
+		SetTitle(title)
+
+	keyField := tview.NewInputField().
+		SetLabel("Key").
+		SetText(variable.key).
+		SetFieldWidth(40)
+	keyField.SetDisabled(old != nil)
+
+	form.AddFormItem(keyField).
+		AddTextArea("Value", variable.value, 60, 5, 0, nil).
+		AddDropDown("Type", variableTypes, typeIndex, nil)
+	if !plain {
+		form.AddInputField("Environments", variable.environmentScope, 40, nil, nil).
+			AddCheckbox("Protected", variable.protected, nil).
+			AddCheckbox("Masked", variable.masked, nil).
+			AddCheckbox("Expand variables", !variable.raw, nil)
+	}
+
+	form.AddButton("Save", func() {
+		variable.key = strings.TrimSpace(keyField.GetText())
+		if variable.key == "" {
+			showError(app, fmt.Errorf("a variable needs a key"), nil)
+			return
+		}
+		variable.value = form.GetFormItemByLabel("Value").(*tview.TextArea).GetText()
+		_, variable.variableType = form.GetFormItemByLabel("Type").(*tview.DropDown).GetCurrentOption()
+		if !plain {
+			variable.environmentScope = form.GetFormItemByLabel("Environments").(*tview.InputField).GetText()
+			variable.protected = form.GetFormItemByLabel("Protected").(*tview.Checkbox).IsChecked()
+			variable.masked = form.GetFormItemByLabel("Masked").(*tview.Checkbox).IsChecked()
+			variable.raw = !form.GetFormItemByLabel("Expand variables").(*tview.Checkbox).IsChecked()
+		}
+		save(variable)
+	}).
+		AddButton("Cancel", cancel).
+		SetCancelFunc(cancel)
+
+	if old != nil {
+		form.SetFocus(1)
+	}
+
+	routes.show("variable-form", form, form)
+}
+
+func yesNo(value bool) string {
+	if value {
+		return "yes"
+	}
+	return "no"
+}
@@ -0,0 +1,124 @@
+// wait.go
+package ui
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+// cliPollInterval is how often commands that wait for a pipeline check it.
+const cliPollInterval = 5 * time.Second
+
+// manualExitCode is what gpv exits with when a pipeline it waited for stopped
+// at a manual job, which is neither a success nor a failure.
+const manualExitCode = 3
+
+// setupWait sets up the wait command, which waits for a pipeline to finish,
+// printing every status it goes through. gpv then exits with 0 if the
+// pipeline succeeded, 3 if it waits for a manual job and 1 otherwise.
+func setupWait(flags *flag.FlagSet) func(args []string) error {
+	project := flags.String("p", "", "project path or ID, e.g. group/project")
+	pipelineID := flags.Int("pipeline", 0, "pipeline ID")
+	ref := flags.String("ref", "", "wait for the latest pipeline of this branch or tag")
+	latest := flags.Bool("latest", false, "wait for the latest pipeline of --ref, or of the default branch")
+	output := addOutputFlag(flags)
+	return func(args []string) error {
+		if err := requireFlag(flags, "p", *project); err != nil {
+			return err
+		}
+		if *pipelineID == 0 && !*latest && *ref == "" {
+			fmt.Fprintln(flags.Output(), "either -pipeline or -latest is required")
+			flags.Usage()
+			return errUsage
+		}
+		if err := validateOutputFormat(flags, *output); err != nil {
+			return err
+		}
+
+		pipeline, err := findPipeline(*project, *pipelineID, *ref)
+		if err != nil {
+			return err
+		}
+
+		// With -o json or yaml only the finished pipeline goes to stdout.
+		progress := io.Writer(os.Stdout)
+		if *output != "table" {
+			progress = os.Stderr
+		}
+		pipeline, err = waitForPipeline(progress, *project, pipeline)
+		if err != nil {
+			return err
+		}
+
+		if *output != "table" {
+			if err := writeOutput(os.Stdout, *output, newPipelineRecord(pipeline), nil); err != nil {
+				return err
+			}
+		}
+		return pipelineExitCode(pipeline)
+	}
+}
+
+// pipelineExitCode is the exit code for the finished pipeline, nil if it
+// succeeded.
+func pipelineExitCode(pipeline *gitlab.Pipeline) error {
+	switch pipeline.Status {
+	case "success":
+		return nil
+	case "manual":
+		return exitCode(manualExitCode)
+	default:
+		return exitCode(1)
+	}
+}
+
+// findPipeline fetches the pipeline with id, or the latest pipeline of ref
+// if id is 0, or of the default branch if ref is empty too.
+func findPipeline(projectID string, id int, ref string) (*gitlab.Pipeline, error) {
+	if id != 0 {
+		pipeline, _, err := gitlabClient.Pipelines().GetPipeline(projectID, id)
+		if err != nil {
+			return nil, fmt.Errorf("fetching pipeline #%d: %w", id, err)
+		}
+		return pipeline, nil
+	}
+
+	opts := &gitlab.GetLatestPipelineOptions{}
+	if ref != "" {
+		opts.Ref = gitlab.String(ref)
+	}
+	pipeline, _, err := gitlabClient.Pipelines().GetLatestPipeline(projectID, opts)
+	if err != nil {
+		return nil, fmt.Errorf("fetching the latest pipeline of %s: %w", projectID, err)
+	}
+	return pipeline, nil
+}
+
+// waitForPipeline polls pipeline until it finishes, writing a line to out
+// whenever its status changes, and returns the finished pipeline.
+func waitForPipeline(out io.Writer, projectID string, pipeline *gitlab.Pipeline) (*gitlab.Pipeline, error) {
+	printStatus := func() {
+		fmt.Fprintf(out, "%s  #%d  %s  %s\n", time.Now().Format("15:04:05"), pipeline.ID, pipeline.Ref, pipeline.Status)
+	}
+	printStatus()
+
+	for cancelableStatuses[pipeline.Status] {
+		time.Sleep(cliPollInterval)
+
+		current, _, err := gitlabClient.Pipelines().GetPipeline(projectID, pipeline.ID)
+		if err != nil {
+			return nil, fmt.Errorf("fetching pipeline #%d: %w", pipeline.ID, err)
+		}
+		changed := current.Status != pipeline.Status
+		pipeline = current
+		if changed {
+			printStatus()
+		}
+	}
+	return pipeline, nil
+}
@@ -0,0 +1,25 @@
+package ui
+
+import (
+	"testing"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+func TestPipelineExitCode(t *testing.T) {
+	tests := []struct {
+		status string
+		want   error
+	}{
+		{"success", nil},
+		{"manual", exitCode(manualExitCode)},
+		{"failed", exitCode(1)},
+		{"canceled", exitCode(1)},
+		{"skipped", exitCode(1)},
+	}
+	for _, tt := range tests {
+		if got := pipelineExitCode(&gitlab.Pipeline{Status: tt.status}); got != tt.want {
+			t.Errorf("pipelineExitCode(%s) = %v, want %v", tt.status, got, tt.want)
+		}
+	}
+}
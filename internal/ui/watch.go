@@ -0,0 +1,187 @@
+// watch.go
+package ui
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/xanzy/go-gitlab"
+)
+
+// watchPollInterval is how often a watched pipeline is checked.
+const watchPollInterval = 15 * time.Second
+
+// watchedPipelines holds a stop function for every pipeline being watched,
+// keyed by watchKey. It is only touched from the UI goroutine.
+var watchedPipelines = map[string]func(){}
+
+func watchKey(projectID string, pipelineID int) string {
+	return projectID + "#" + strconv.Itoa(pipelineID)
+}
+
+// toggleWatch starts watching pipeline in the background until it finishes,
+// or stops watching it if it already is. It must be called from the UI
+// goroutine.
+func toggleWatch(app *tview.Application, projectID string, pipeline *gitlab.Pipeline) {
+	key := watchKey(projectID, pipeline.ID)
+	if stop, ok := watchedPipelines[key]; ok {
+		stop()
+		delete(watchedPipelines, key)
+		forgetWatchedStatus(projectID, pipeline.ID)
+		showInfo(app, fmt.Sprintf("Stopped watching pipeline #%d", pipeline.ID))
+		return
+	}
+	if pipeline.Status == "manual" {
+		showInfo(app, fmt.Sprintf("Pipeline #%d waits for a manual job", pipeline.ID))
+		return
+	}
+	if !cancelableStatuses[pipeline.Status] {
+		showInfo(app, fmt.Sprintf("Pipeline #%d already finished: %s", pipeline.ID, pipeline.Status))
+		return
+	}
+
+	done := make(chan struct{})
+	watchedPipelines[key] = func() { close(done) }
+	showInfo(app, fmt.Sprintf("Watching pipeline #%d, you'll be notified when it finishes", pipeline.ID))
+	recordWatchedStatus(projectID, pipeline)
+
+	go func() {
+		ticker := time.NewTicker(watchPollInterval)
+		defer ticker.Stop()
+		var webhooks <-chan struct{}
+		if webhookListening {
+			var unsubscribe func()
+			webhooks, unsubscribe = subscribeWebhooks()
+			defer unsubscribe()
+		}
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			case <-webhooks:
+			}
+
+			current, _, err := gitlabClient.Pipelines().GetPipeline(projectID, pipeline.ID, detached)
+			if err != nil {
+				logWarn("checking watched pipeline failed", "pipeline", pipeline.ID, "error", err)
+				continue
+			}
+			recordWatchedStatus(projectID, current)
+			if cancelableStatuses[current.Status] {
+				continue
+			}
+
+			app.QueueUpdateDraw(func() {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				delete(watchedPipelines, key)
+				pipelineFinished(app, current)
+			})
+			return
+		}
+	}()
+}
+
+// pipelineFinished reports that a watched pipeline finished, on screen, as a
+// desktop notification and to the notification webhook if there is one, and
+// runs its hooks. A pipeline stopped at a manual job counts as finished, as
+// it won't go on without the user, and is reported as waiting for them. It
+// must be called from the UI goroutine.
+func pipelineFinished(app *tview.Application, pipeline *gitlab.Pipeline) {
+	title := fmt.Sprintf("Pipeline #%d %s", pipeline.ID, pipeline.Status)
+	message := fmt.Sprintf("%s on %s after %s", pipelineProjectPath(pipeline), pipeline.Ref, formatDuration(pipeline.Duration))
+
+	color := tcell.ColorDarkGreen
+	switch pipeline.Status {
+	case "success":
+	case "manual":
+		title = fmt.Sprintf("Pipeline #%d waits for a manual job", pipeline.ID)
+		color = tcell.ColorDarkOrange
+	default:
+		color = tcell.ColorDarkRed
+	}
+	logInfo(title, "project", pipelineProjectPath(pipeline), "ref", pipeline.Ref)
+	recordNotification(title+": "+message, color)
+	showToast(app, statusGlyph(pipeline.Status)+" "+title+": "+message, color)
+
+	go func() {
+		if err := sendDesktopNotification(title, message); err != nil {
+			logWarn("desktop notification failed", "error", err)
+		}
+	}()
+	if notifyWebhookURL != "" {
+		go func() {
+			if err := postPipelineNotification(pipeline); err != nil {
+				logWarn("webhook notification failed", "error", err)
+			}
+		}()
+	}
+
+	env := pipelineHookEnv(pipeline)
+	go func() {
+		runEventHook("on_watch_complete", env)
+		if pipeline.Status == "failed" {
+			runPipelineFailedHook(env, pipeline.ID)
+		}
+	}()
+}
+
+// pipelineProjectPath is the path of the project of pipeline, taken from its
+// web URL as the pipeline itself only carries the project ID.
+func pipelineProjectPath(pipeline *gitlab.Pipeline) string {
+	return webURLProjectPath(pipeline.WebURL, strconv.Itoa(pipeline.ProjectID))
+}
+
+// webURLProjectPath is the path of the project a web URL on the instance
+// belongs to, or fallback if it can't be told from it.
+func webURLProjectPath(webURL, fallback string) string {
+	path := strings.TrimPrefix(webURL, gitlabURL+"/")
+	if i := strings.Index(path, "/-/"); i >= 0 {
+		return path[:i]
+	}
+	return fallback
+}
+
+// sendDesktopNotification shows a notification with the desktop's own
+// mechanism: notify-send on Linux and the BSDs, osascript on macOS and a
+// toast on Windows.
+func sendDesktopNotification(title, message string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		script := fmt.Sprintf("display notification %s with title %s", appleScriptString(message), appleScriptString(title))
+		cmd = exec.Command("osascript", "-e", script)
+	case "windows":
+		script := `[Windows.UI.Notifications.ToastNotificationManager, Windows.UI.Notifications, ContentType = WindowsRuntime] | Out-Null
+$template = [Windows.UI.Notifications.ToastNotificationManager]::GetTemplateContent([Windows.UI.Notifications.ToastTemplateType]::ToastText02)
+$text = $template.GetElementsByTagName("text")
+$text.Item(0).AppendChild($template.CreateTextNode($env:GPV_TITLE)) | Out-Null
+$text.Item(1).AppendChild($template.CreateTextNode($env:GPV_MESSAGE)) | Out-Null
+[Windows.UI.Notifications.ToastNotificationManager]::CreateToastNotifier("gpv").Show([Windows.UI.Notifications.ToastNotification]::new($template))`
+		cmd = exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", script)
+		cmd.Env = append(cmd.Environ(), "GPV_TITLE="+title, "GPV_MESSAGE="+message)
+	default:
+		cmd = exec.Command("notify-send", "--app-name=gpv", title, message)
+	}
+
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%s: %w: %s", cmd.Path, err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// appleScriptString quotes s as an AppleScript string literal.
+func appleScriptString(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
@@ -0,0 +1,116 @@
+// watchcli.go
+package ui
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+// setupWatchCommand sets up the watch command, which prints the latest
+// pipelines of a project and then a line for every pipeline that starts or
+// changes status, until it is interrupted, like kubectl get -w.
+func setupWatchCommand(flags *flag.FlagSet) func(args []string) error {
+	project := flags.String("p", "", "project path or ID, e.g. group/project")
+	ref := flags.String("ref", "", "only watch pipelines of this branch or tag")
+	count := flags.Int("n", 10, "number of latest pipelines to watch, at most 100")
+	output := addOutputFlag(flags)
+	return func(args []string) error {
+		if err := requireFlag(flags, "p", *project); err != nil {
+			return err
+		}
+		if err := validateOutputFormat(flags, *output); err != nil {
+			return err
+		}
+		if err := validateCount(flags, *count); err != nil {
+			return err
+		}
+
+		opts := &gitlab.ListProjectPipelinesOptions{ListOptions: gitlab.ListOptions{PerPage: *count}}
+		if *ref != "" {
+			opts.Ref = gitlab.String(*ref)
+		}
+		write := pipelineChangeWriter(os.Stdout, *output)
+
+		seen := map[int]string{}
+		for first := true; ; first = false {
+			infos, _, err := gitlabClient.Pipelines().ListProjectPipelines(*project, opts)
+			if err != nil {
+				err = fmt.Errorf("fetching pipelines for project %s: %w", *project, err)
+				if first {
+					return err
+				}
+				// A watch runs for hours, one failed poll shouldn't end it.
+				logWarn("watch poll failed", "error", err)
+				fmt.Fprintln(os.Stderr, "Error:", err)
+				time.Sleep(cliPollInterval)
+				continue
+			}
+
+			// Pipelines no longer among the latest ones are forgotten, so a
+			// watch running for days doesn't keep every pipeline it printed.
+			listed := make(map[int]bool, len(infos))
+			for _, info := range infos {
+				listed[info.ID] = true
+			}
+			for id := range seen {
+				if !listed[id] {
+					delete(seen, id)
+				}
+			}
+
+			// The list is newest first, changes are printed in the order
+			// the pipelines were created.
+			for i := len(infos) - 1; i >= 0; i-- {
+				info := infos[i]
+				if status, ok := seen[info.ID]; ok && status == info.Status {
+					continue
+				}
+				pipeline, _, err := gitlabClient.Pipelines().GetPipeline(*project, info.ID)
+				if err != nil {
+					logWarn("fetching changed pipeline failed", "pipeline", info.ID, "error", err)
+					continue
+				}
+				seen[info.ID] = pipeline.Status
+				if err := write(pipeline); err != nil {
+					return err
+				}
+			}
+			time.Sleep(cliPollInterval)
+		}
+	}
+}
+
+// pipelineChangeWriter returns a function writing a pipeline to out as one
+// line of text, one JSON object per line, or one YAML document, so each
+// change can be read as soon as it is printed.
+func pipelineChangeWriter(out io.Writer, format string) func(pipeline *gitlab.Pipeline) error {
+	switch format {
+	case "json":
+		encoder := json.NewEncoder(out)
+		return func(pipeline *gitlab.Pipeline) error {
+			return encoder.Encode(newPipelineRecord(pipeline))
+		}
+	case "yaml":
+		return func(pipeline *gitlab.Pipeline) error {
+			var document strings.Builder
+			document.WriteString("---\n")
+			writeYAML(&document, reflect.ValueOf(newPipelineRecord(pipeline)), 0)
+			_, err := io.WriteString(out, document.String())
+			return err
+		}
+	default:
+		return func(pipeline *gitlab.Pipeline) error {
+			_, err := fmt.Fprintf(out, "%s  #%d  %s  %s  %s  %s\n", time.Now().Format("15:04:05"), pipeline.ID,
+				pipeline.Ref, shortSHA(pipeline.SHA), pipeline.Status, formatDuration(pipeline.Duration))
+			return err
+		}
+	}
+}
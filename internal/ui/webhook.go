@@ -0,0 +1,156 @@
+// webhook.go
+package ui
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+// maxWebhookPayload is the largest webhook request body that is read.
+const maxWebhookPayload = 1 << 20
+
+// webhookListening reports whether gpv listens for GitLab webhooks. Open
+// views then refresh as soon as a pipeline or job event arrives.
+var webhookListening bool
+
+var (
+	webhookMu          sync.Mutex
+	webhookSubscribers = map[chan struct{}]bool{}
+)
+
+// startWebhookListener listens on GPV_WEBHOOK_LISTEN, e.g. ":8090", for the
+// pipeline and job events of GitLab project webhooks. Only requests carrying
+// GPV_WEBHOOK_SECRET as their secret token are accepted, and it may only be
+// left unset when listening on a loopback address.
+func startWebhookListener() error {
+	address := os.Getenv("GPV_WEBHOOK_LISTEN")
+	if address == "" {
+		return nil
+	}
+	secret := os.Getenv("GPV_WEBHOOK_SECRET")
+
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return fmt.Errorf("listening for webhooks on %s: %w", address, err)
+	}
+	if err := checkWebhookSecret(listener.Addr(), secret); err != nil {
+		listener.Close()
+		return fmt.Errorf("listening for webhooks on %s: %w", address, err)
+	}
+	webhookListening = true
+	logInfo("listening for webhooks", "address", listener.Addr().String())
+
+	handler := &webhookHandler{secret: secret}
+	go func() {
+		if err := http.Serve(listener, handler); err != nil {
+			logError("webhook listener stopped", "error", err)
+		}
+	}()
+	return nil
+}
+
+// checkWebhookSecret refuses to accept webhooks on addr without a secret
+// unless addr is a loopback address, which only local processes can reach.
+func checkWebhookSecret(addr net.Addr, secret string) error {
+	if tcpAddr, ok := addr.(*net.TCPAddr); secret == "" && (!ok || !tcpAddr.IP.IsLoopback()) {
+		return errors.New("GPV_WEBHOOK_SECRET must be set unless listening on a loopback address such as 127.0.0.1:8090")
+	}
+	return nil
+}
+
+type webhookHandler struct {
+	secret string
+}
+
+func (h *webhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if h.secret != "" && subtle.ConstantTimeCompare([]byte(r.Header.Get("X-Gitlab-Token")), []byte(h.secret)) != 1 {
+		logWarn("webhook rejected, wrong secret token", "remote", r.RemoteAddr)
+		http.Error(w, "invalid token", http.StatusUnauthorized)
+		return
+	}
+
+	eventType := gitlab.HookEventType(r)
+	if eventType != gitlab.EventTypePipeline && eventType != gitlab.EventTypeJob {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	payload, err := io.ReadAll(io.LimitReader(r.Body, maxWebhookPayload))
+	if err != nil {
+		http.Error(w, "reading payload", http.StatusBadRequest)
+		return
+	}
+	event, err := gitlab.ParseWebhook(eventType, payload)
+	if err != nil {
+		logWarn("webhook rejected, invalid payload", "event", eventType, "error", err)
+		http.Error(w, "invalid payload", http.StatusBadRequest)
+		return
+	}
+
+	switch event := event.(type) {
+	case *gitlab.PipelineEvent:
+		logDebug("pipeline webhook", "project", event.Project.PathWithNamespace,
+			"pipeline", event.ObjectAttributes.ID, "status", event.ObjectAttributes.Status)
+		if event.ObjectAttributes.Status == "failed" {
+			go runPipelineFailedHook(pipelineEventHookEnv(event), event.ObjectAttributes.ID)
+		}
+	case *gitlab.JobEvent:
+		logDebug("job webhook", "project", event.ProjectID, "job", event.BuildID, "status", event.BuildStatus)
+	}
+	notifyWebhookSubscribers()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// pipelineEventHookEnv describes the pipeline of a webhook event to a hook,
+// like pipelineHookEnv.
+func pipelineEventHookEnv(event *gitlab.PipelineEvent) []string {
+	return []string{
+		"GPV_PROJECT=" + event.Project.PathWithNamespace,
+		"GPV_PROJECT_ID=" + strconv.Itoa(event.Project.ID),
+		"GPV_PIPELINE_ID=" + strconv.Itoa(event.ObjectAttributes.ID),
+		"GPV_PIPELINE_STATUS=" + event.ObjectAttributes.Status,
+		"GPV_PIPELINE_URL=" + event.ObjectAttributes.URL,
+		"GPV_REF=" + event.ObjectAttributes.Ref,
+		"GPV_SHA=" + event.ObjectAttributes.SHA,
+	}
+}
+
+// subscribeWebhooks returns a channel that receives a value whenever a
+// pipeline or job event arrives, and a function to stop receiving them.
+// Events that arrive while one is still pending are merged into it.
+func subscribeWebhooks() (<-chan struct{}, func()) {
+	events := make(chan struct{}, 1)
+	webhookMu.Lock()
+	webhookSubscribers[events] = true
+	webhookMu.Unlock()
+
+	return events, func() {
+		webhookMu.Lock()
+		delete(webhookSubscribers, events)
+		webhookMu.Unlock()
+	}
+}
+
+func notifyWebhookSubscribers() {
+	webhookMu.Lock()
+	defer webhookMu.Unlock()
+	for events := range webhookSubscribers {
+		select {
+		case events <- struct{}{}:
+		default:
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package ui
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCheckWebhookSecret(t *testing.T) {
+	tests := []struct {
+		addr    net.Addr
+		secret  string
+		wantErr bool
+	}{
+		{&net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 8090}, "", false},
+		{&net.TCPAddr{IP: net.IPv6loopback, Port: 8090}, "", false},
+		{&net.TCPAddr{IP: net.IPv6zero, Port: 8090}, "", true},
+		{&net.TCPAddr{IP: net.IPv4(192, 0, 2, 1), Port: 8090}, "", true},
+		{&net.TCPAddr{IP: net.IPv6zero, Port: 8090}, "s3cret", false},
+		{&net.UnixAddr{Name: "/tmp/gpv.sock", Net: "unix"}, "", true},
+	}
+	for _, tt := range tests {
+		err := checkWebhookSecret(tt.addr, tt.secret)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("checkWebhookSecret(%v, %q): error %v, want error %v", tt.addr, tt.secret, err, tt.wantErr)
+		}
+	}
+}
+
+func TestWebhookHandlerToken(t *testing.T) {
+	tests := []struct {
+		token string
+		want  int
+	}{
+		{"s3cret", http.StatusNoContent},
+		{"wrong", http.StatusUnauthorized},
+		{"", http.StatusUnauthorized},
+	}
+	handler := &webhookHandler{secret: "s3cret"}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		req.Header.Set("X-Gitlab-Token", tt.token)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != tt.want {
+			t.Errorf("token %q: status %d, want %d", tt.token, rec.Code, tt.want)
+		}
+	}
+}
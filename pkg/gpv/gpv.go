@@ -0,0 +1,48 @@
+// gpv.go
+
+// Package gpv runs the GitLab pipeline viewer, on its own or inside another
+// tview application.
+package gpv
+
+import (
+	"errors"
+	"flag"
+
+	"github.com/rivo/tview"
+
+	"main.go/internal/config"
+	"main.go/internal/ui"
+)
+
+// Config is how gpv is set up. Load fills it in from the environment.
+type Config = config.Config
+
+// Load returns the configuration in the environment, as gpv reads it when
+// started from the shell.
+func Load() (Config, error) {
+	c := Config{LogLevel: "info"}
+	err := c.Load()
+	return c, err
+}
+
+// Main parses args as the command line of gpv, runs the command it names
+// or the TUI, and returns the exit code.
+func Main(args []string) int {
+	var c Config
+	flags := flag.NewFlagSet("gpv", flag.ContinueOnError)
+	c.RegisterFlags(flags)
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+	c.Args = flags.Args()
+	return ui.Main(c)
+}
+
+// Attach shows gpv on app, which the caller runs and may take back with
+// SetRoot once the user is done. c usually comes from Load.
+func Attach(app *tview.Application, c Config) error {
+	return ui.Attach(app, c)
+}